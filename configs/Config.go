@@ -44,9 +44,22 @@ type RedisConfig struct {
 	WriteTimeout string `yaml:"writeTimeout"`
 }
 
+// RateLimitRule 限流规则，Path 和 Event 二选一
+type RateLimitRule struct {
+	Path   string `yaml:"path"`   // gin 路由模板，如 /api/v1/user/login，以 * 结尾表示前缀匹配
+	Method string `yaml:"method"` // 请求方法，空表示全部
+	Event  string `yaml:"event"`  // ws 事件类型，如 chat，* 表示全部事件
+	Limit  int    `yaml:"limit"`  // 窗口内最大请求数
+	Window string `yaml:"window"` // 窗口大小，默认 1s
+	By     string `yaml:"by"`     // 限流维度 user（未登录按 ip）/ ip / global，默认 user
+}
+
 type RateConfig struct {
-	UserLimit int `yaml:"userLimit"`
-	ApiLimit  int `yaml:"apiLimit"`
+	UserLimit int             `yaml:"userLimit"` // 每个用户（未登录按 ip）每秒最多请求数，0 不限制
+	ApiLimit  int             `yaml:"apiLimit"`  // 每个接口每秒最多请求数（所有用户合计），0 不限制
+	Backend   string          `yaml:"backend"`   // 存储后端 redis / memory，默认 memory，多实例部署请使用 redis
+	MaxKeys   int             `yaml:"maxKeys"`   // memory 后端最多保留的限流 key 数，默认 100000
+	Rules     []RateLimitRule `yaml:"rules"`     // 按路由或 ws 事件的限流规则
 }
 
 type RabbitmqConfig struct {
@@ -71,17 +84,143 @@ type MinioConfig struct {
 	BaseUrl   string `yaml:"baseUrl"`
 }
 
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Driver     string `yaml:"driver"`     // 存储驱动 minio / local / memory，默认 minio
+	LocalDir   string `yaml:"localDir"`   // local 驱动的存储目录，默认 uploads
+	BaseUrl    string `yaml:"baseUrl"`    // 文件对外访问地址，指向 /file/object 接口，默认 api.prefix + /file/object
+	SignSecret string `yaml:"signSecret"` // local / memory 驱动预签名密钥，默认使用 jwt.secretKey
+
+	PartSize      int64  `yaml:"partSize"`      // 分片上传的分片大小（字节），默认 8MB
+	UploadExpire  string `yaml:"uploadExpire"`  // 分片上传会话有效期，默认 24h
+	PresignExpire string `yaml:"presignExpire"` // 预签名地址有效期，默认 1h
+	GcGracePeriod string `yaml:"gcGracePeriod"` // 无引用文件的保留时间，超过后被垃圾回收，默认 24h
+
+	DownloadExpire   string `yaml:"downloadExpire"`   // 下载签名地址有效期，默认 10m
+	DownloadRedirect bool   `yaml:"downloadRedirect"` // 鉴权后重定向到存储的预签名地址，由存储直接提供下载，默认由服务转发
+}
+
+// MessageConfig 消息配置
+type MessageConfig struct {
+	VoiceMaxDuration   int `yaml:"voiceMaxDuration"`   // 语音消息最长时长（秒），默认 60
+	ReactionMaxPerUser int `yaml:"reactionMaxPerUser"` // 每人对同一条消息最多回应的表情数，默认 3
+	PinMaxCount        int `yaml:"pinMaxCount"`        // 每个会话最多置顶的消息数，默认 10
+	TtlMax             int `yaml:"ttlMax"`             // 限时消息最长的销毁时长（秒），默认 604800（7 天）
+	ScheduleMaxDays    int `yaml:"scheduleMaxDays"`    // 定时消息最多提前的天数，默认 30
+	RevokeWindow       int `yaml:"revokeWindow"`       // 发送者撤回自己消息的时限（秒），默认 120，群主和管理员撤回不受限制
+}
+
+// StickerConfig 表情包和群自定义表情配置
+type StickerConfig struct {
+	MaxSize          int64 `yaml:"maxSize"`          // 单个表情图片最大字节数，默认 1048576
+	PackMaxCount     int   `yaml:"packMaxCount"`     // 每个表情包最多的表情数，默认 120
+	GroupMaxCount    int   `yaml:"groupMaxCount"`    // 每个群最多的自定义表情数，默认 100
+	FavoriteMaxCount int   `yaml:"favoriteMaxCount"` // 每人最多收藏的表情数，默认 300
+}
+
+// RedPacketConfig 红包配置，金额单位为分
+type RedPacketConfig struct {
+	Backend    string `yaml:"backend"`    // 待领取金额的存储 redis / memory，默认 redis，memory 仅适用于单机部署
+	ExpireTime string `yaml:"expireTime"` // 过期时间，默认 24h，过期未领完的金额退回发送者
+	MaxAmount  int64  `yaml:"maxAmount"`  // 单个红包总金额上限，默认 20000
+	MaxCount   int    `yaml:"maxCount"`   // 单个红包最多个数，默认 100
+}
+
+// UploadConfig 上传校验策略，文件类型按内容识别，不信任扩展名
+type UploadConfig struct {
+	MaxSize      int64            `yaml:"maxSize"`      // 单个文件大小上限（字节），0 不限制
+	TypeMaxSize  map[string]int64 `yaml:"typeMaxSize"`  // 按类别的大小上限（image/audio/video/document/archive/code/file），未配置的类别使用 maxSize
+	AllowedMimes []string         `yaml:"allowedMimes"` // 允许的 MIME 类型，支持 image/* 通配，为空不限制
+	UserQuota    int64            `yaml:"userQuota"`    // 每个用户的存储配额（字节），0 不限制
+}
+
+// ImageConfig 图片处理配置，上传时自动摆正、去除元数据并生成缩略图
+type ImageConfig struct {
+	Disabled       bool  `yaml:"disabled"`       // 关闭图片处理，按原样保存
+	ThumbnailSizes []int `yaml:"thumbnailSizes"` // 缩略图最长边（像素），默认 160/480/1080
+	Quality        int   `yaml:"quality"`        // JPEG/WebP 编码质量 1-100，默认 85
+	WebP           bool  `yaml:"webp"`           // 缩略图使用 WebP 编码，并额外生成原尺寸 WebP
+	MaxPixels      int   `yaml:"maxPixels"`      // 允许处理的最大像素数，防止解压炸弹，默认 4000 万
+}
+
+// MediaConfig 音视频异步处理配置，任务默认通过 RabbitMQ 投递，连接不可用时使用进程内队列
+type MediaConfig struct {
+	Queue      string `yaml:"queue"`      // 任务队列 rabbitmq / memory，默认 rabbitmq
+	Exchange   string `yaml:"exchange"`   // rabbitmq 交换机，默认 media-exchange
+	QueueName  string `yaml:"queueName"`  // rabbitmq 队列，默认 media-queue
+	RoutingKey string `yaml:"routingKey"` // rabbitmq 路由键，默认 media
+	Workers    int    `yaml:"workers"`    // 进程内队列并发数，默认 2
+	QueueSize  int    `yaml:"queueSize"`  // 进程内队列长度，默认 1000
+
+	Ffmpeg          string `yaml:"ffmpeg"`          // ffmpeg 路径，默认从 PATH 查找
+	Ffprobe         string `yaml:"ffprobe"`         // ffprobe 路径，默认从 PATH 查找
+	Timeout         string `yaml:"timeout"`         // 单个任务超时时间，默认 2m
+	WaveformSamples int    `yaml:"waveformSamples"` // 音频波形采样点数，默认 100
+}
+
+// LinkPreviewConfig 链接预览配置，消息发送后异步抓取网页元数据
+type LinkPreviewConfig struct {
+	Disabled  bool   `yaml:"disabled"`  // 关闭链接预览
+	Timeout   string `yaml:"timeout"`   // 单个链接抓取超时，默认 5s
+	MaxBytes  int64  `yaml:"maxBytes"`  // 最多读取的网页字节数，默认 524288
+	CacheTtl  string `yaml:"cacheTtl"`  // 预览缓存时间，默认 24h，抓取失败的结果缓存 10m
+	MaxLinks  int    `yaml:"maxLinks"`  // 每条消息最多预览的链接数，默认 3
+	Workers   int    `yaml:"workers"`   // 同时抓取的消息数，默认 4
+	UserAgent string `yaml:"userAgent"` // 抓取时使用的 User-Agent
+}
+
+// OidcProviderConfig 单个 OIDC 身份提供方配置
+type OidcProviderConfig struct {
+	Name          string   `yaml:"name"`          // 提供方名称，用于路由 /user/oidc/:provider
+	Issuer        string   `yaml:"issuer"`        // Issuer 地址，用于服务发现
+	ClientId      string   `yaml:"clientId"`      // 客户端ID
+	ClientSecret  string   `yaml:"clientSecret"`  // 客户端密钥（公共客户端可留空）
+	RedirectUrl   string   `yaml:"redirectUrl"`   // 回调地址
+	Scopes        []string `yaml:"scopes"`        // 申请的 scope，默认 openid profile email
+	AutoProvision bool     `yaml:"autoProvision"` // 首次登录时是否自动创建用户
+	LinkByEmail   bool     `yaml:"linkByEmail"`   // 是否按已验证邮箱关联已有用户
+}
+
+type OidcConfig struct {
+	StateTTL  string               `yaml:"stateTTL"` // 登录状态有效期，如 10m
+	Providers []OidcProviderConfig `yaml:"providers"`
+}
+
+// ModerationConfig 消息内容审核配置，动作可选 mask/flag/reject
+type ModerationConfig struct {
+	Enabled            bool     `yaml:"enabled"`            // 是否开启内容审核
+	Words              []string `yaml:"words"`              // 敏感词
+	WordFiles          []string `yaml:"wordFiles"`          // 敏感词文件（每行一个词，# 开头为注释），修改后自动热加载
+	WordAction         string   `yaml:"wordAction"`         // 命中敏感词的动作，默认 mask
+	LinkBlocklist      []string `yaml:"linkBlocklist"`      // 禁止发送的域名（包含子域名）
+	LinkAction         string   `yaml:"linkAction"`         // 命中链接黑名单的动作，默认 reject
+	FloodWindow        string   `yaml:"floodWindow"`        // 刷屏检测时间窗口，默认 10s
+	FloodMaxMessages   int      `yaml:"floodMaxMessages"`   // 窗口内最多消息数，0 不限制
+	FloodMaxDuplicates int      `yaml:"floodMaxDuplicates"` // 窗口内相同内容最多条数，0 不限制
+	FloodAction        string   `yaml:"floodAction"`        // 刷屏动作，默认 reject
+}
+
 // Config 配置结构体 整个文件
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Api      ApiConfig      `yaml:"api"`
-	Jwt      JWTConfig      `yaml:"jwt"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Rate     RateConfig     `yaml:"rate"`
-	Rabbitmq RabbitmqConfig `yaml:"rabbitmq"`
-	Mq       []MqConfig     `yaml:"mq"`
-	Minio    MinioConfig    `yaml:"minio"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Api         ApiConfig         `yaml:"api"`
+	Jwt         JWTConfig         `yaml:"jwt"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Rate        RateConfig        `yaml:"rate"`
+	Rabbitmq    RabbitmqConfig    `yaml:"rabbitmq"`
+	Mq          []MqConfig        `yaml:"mq"`
+	Minio       MinioConfig       `yaml:"minio"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Message     MessageConfig     `yaml:"message"`
+	Sticker     StickerConfig     `yaml:"sticker"`
+	RedPacket   RedPacketConfig   `yaml:"redPacket"`
+	Upload      UploadConfig      `yaml:"upload"`
+	Image       ImageConfig       `yaml:"image"`
+	Media       MediaConfig       `yaml:"media"`
+	LinkPreview LinkPreviewConfig `yaml:"linkPreview"`
+	Oidc        OidcConfig        `yaml:"oidc"`
+	Moderation  ModerationConfig  `yaml:"moderation"`
 }
 
 var appConfigPath = "configs"
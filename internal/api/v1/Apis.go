@@ -12,10 +12,16 @@ func InitRouter(r *gin.Engine) {
 	RegisterMiddlewares(r)
 	//配置控制器的路由
 	UserApi(r)
+	OidcApi(r)
 	MessageApi(r)
 	GroupApi(r)
 	FriendApi(r)
 	FileApi(r)
+	RedPacketApi(r)
+	PollApi(r)
+	StickerApi(r)
+	ReportApi(r)
+	AdminApi(r)
 }
 
 func UserApi(r *gin.Engine) {
@@ -30,12 +36,40 @@ func UserApi(r *gin.Engine) {
 	}
 }
 
+func OidcApi(r *gin.Engine) {
+	oidcApi := r.Group(configs.AppConfig.Api.Prefix + "/user/oidc")
+	{
+		oidcApi.GET("/identities", middleware.AuthMiddleware(), controllers.OidcControllerInstance.Identities)
+		oidcApi.GET("/:provider/login", controllers.OidcControllerInstance.Login)
+		oidcApi.GET("/:provider/bind", middleware.AuthMiddleware(), controllers.OidcControllerInstance.Bind)
+		oidcApi.GET("/:provider/callback", controllers.OidcControllerInstance.Callback)
+	}
+}
+
 func MessageApi(r *gin.Engine) {
 	messageApi := r.Group(configs.AppConfig.Api.Prefix+"/message", middleware.AuthMiddleware())
 	{
 		messageApi.POST("/read", controllers.MessageControllerInstance.Read)
 		messageApi.POST("/query", controllers.MessageControllerInstance.Query)
 		messageApi.GET("/:id/revoke", controllers.MessageControllerInstance.Revoke)
+		messageApi.POST("/delete", controllers.MessageControllerInstance.Delete)                   //删除消息（仅自己或对所有人）
+		messageApi.POST("/clear", controllers.MessageControllerInstance.Clear)                     //清空聊天记录
+		messageApi.POST("/voice/played", controllers.MessageControllerInstance.PlayVoice)          //标记语音已播放
+		messageApi.POST("/forward", controllers.MessageControllerInstance.Forward)                 //转发消息
+		messageApi.GET("/:id/forward_record", controllers.MessageControllerInstance.ForwardRecord) //查看合并转发的聊天记录
+		messageApi.POST("/mention/list", controllers.MessageControllerInstance.MentionList)        //@我的消息
+		messageApi.POST("/mention/read", controllers.MessageControllerInstance.ReadMentions)       //标记 @ 提醒已读
+		messageApi.POST("/reaction/add", controllers.MessageControllerInstance.AddReaction)        //添加表情回应
+		messageApi.POST("/reaction/remove", controllers.MessageControllerInstance.RemoveReaction)  //取消表情回应
+		messageApi.POST("/thread/replies", controllers.MessageControllerInstance.ThreadReplies)    //话题回复列表
+		messageApi.POST("/thread/list", controllers.MessageControllerInstance.ThreadList)          //参与的话题
+		messageApi.POST("/thread/read", controllers.MessageControllerInstance.ReadThread)          //标记话题已读
+		messageApi.POST("/pin", controllers.MessageControllerInstance.Pin)                         //置顶消息
+		messageApi.POST("/unpin", controllers.MessageControllerInstance.Unpin)                     //取消置顶
+		messageApi.POST("/pin/list", controllers.MessageControllerInstance.PinnedList)             //会话的置顶消息
+		messageApi.POST("/schedule", controllers.MessageControllerInstance.Schedule)               //创建定时消息
+		messageApi.POST("/schedule/cancel", controllers.MessageControllerInstance.CancelSchedule)  //取消定时消息
+		messageApi.POST("/schedule/list", controllers.MessageControllerInstance.ScheduleList)      //我的定时消息
 	}
 }
 
@@ -87,5 +121,94 @@ func FileApi(r *gin.Engine) {
 	fileApi := r.Group(configs.AppConfig.Api.Prefix+"/file", middleware.AuthMiddleware())
 	{
 		fileApi.POST("/upload", controllers.FileControllerInstance.Upload)
+		fileApi.POST("/upload/init", controllers.FileControllerInstance.UploadInit)         //创建分片上传会话
+		fileApi.GET("/upload/session", controllers.FileControllerInstance.UploadSession)    //查询上传进度
+		fileApi.POST("/upload/complete", controllers.FileControllerInstance.UploadComplete) //完成分片上传
+		fileApi.POST("/upload/abort", controllers.FileControllerInstance.UploadAbort)       //取消分片上传
+		fileApi.POST("/sign", controllers.FileControllerInstance.Sign)                      //生成文件签名地址
+		fileApi.GET("/usage", controllers.FileControllerInstance.Usage)                     //存储用量
+	}
+	//文件读取需登录或签名；local / memory 存储驱动的分片上传依靠预签名校验
+	objectApi := r.Group(configs.AppConfig.Api.Prefix + "/file/object")
+	{
+		objectApi.GET("/*key", middleware.ObjectAccessMiddleware(), controllers.FileControllerInstance.GetObject)
+		objectApi.PUT("/*key", middleware.PresignMiddleware(), controllers.FileControllerInstance.PutObject)
+	}
+}
+
+func RedPacketApi(r *gin.Engine) {
+	redPacketApi := r.Group(configs.AppConfig.Api.Prefix+"/red_packet", middleware.AuthMiddleware())
+	{
+		redPacketApi.POST("/send", controllers.RedPacketControllerInstance.Send)   //发红包
+		redPacketApi.POST("/claim", controllers.RedPacketControllerInstance.Claim) //抢红包
+		redPacketApi.GET("/:id", controllers.RedPacketControllerInstance.Detail)   //红包详情和领取记录
+	}
+	walletApi := r.Group(configs.AppConfig.Api.Prefix+"/wallet", middleware.AuthMiddleware())
+	{
+		walletApi.GET("", controllers.RedPacketControllerInstance.Wallet)           //钱包余额
+		walletApi.POST("/logs", controllers.RedPacketControllerInstance.WalletLogs) //钱包流水
+	}
+}
+
+func PollApi(r *gin.Engine) {
+	pollApi := r.Group(configs.AppConfig.Api.Prefix+"/poll", middleware.AuthMiddleware())
+	{
+		pollApi.POST("/create", controllers.PollControllerInstance.Create) //发起投票
+		pollApi.POST("/vote", controllers.PollControllerInstance.Vote)     //投票或修改投票
+		pollApi.POST("/close", controllers.PollControllerInstance.Close)   //结束投票
+		pollApi.GET("/:id", controllers.PollControllerInstance.Detail)     //投票详情和结果
+	}
+}
+
+func StickerApi(r *gin.Engine) {
+	stickerApi := r.Group(configs.AppConfig.Api.Prefix+"/sticker", middleware.AuthMiddleware())
+	{
+		stickerApi.POST("/pack/create", controllers.StickerControllerInstance.CreatePack)         //创建表情包
+		stickerApi.POST("/pack/update", controllers.StickerControllerInstance.UpdatePack)         //修改表情包
+		stickerApi.POST("/pack/delete", controllers.StickerControllerInstance.DeletePack)         //删除表情包
+		stickerApi.POST("/pack/list", controllers.StickerControllerInstance.PackList)             //表情包列表
+		stickerApi.POST("/pack/search", controllers.StickerControllerInstance.SearchPacks)        //搜索表情包
+		stickerApi.GET("/pack/:id", controllers.StickerControllerInstance.PackDetail)             //表情包详情
+		stickerApi.POST("/add", controllers.StickerControllerInstance.Add)                        //上传表情到表情包或群
+		stickerApi.POST("/remove", controllers.StickerControllerInstance.Remove)                  //删除表情
+		stickerApi.GET("/group", controllers.StickerControllerInstance.GroupStickers)             //群自定义表情
+		stickerApi.POST("/favorite/add", controllers.StickerControllerInstance.AddFavorite)       //收藏表情
+		stickerApi.POST("/favorite/remove", controllers.StickerControllerInstance.RemoveFavorite) //取消收藏
+		stickerApi.GET("/favorite/list", controllers.StickerControllerInstance.Favorites)         //收藏的表情
+	}
+}
+
+func ReportApi(r *gin.Engine) {
+	reportApi := r.Group(configs.AppConfig.Api.Prefix+"/report", middleware.AuthMiddleware())
+	{
+		reportApi.POST("/create", controllers.ReportControllerInstance.Create)
+		reportApi.POST("/mine", controllers.ReportControllerInstance.Mine)
+	}
+}
+
+func AdminApi(r *gin.Engine) {
+	adminApi := r.Group(configs.AppConfig.Api.Prefix+"/admin", middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// 用户管理
+		adminApi.POST("/user/search", controllers.AdminControllerInstance.SearchUsers)
+		adminApi.POST("/user/ban", controllers.AdminControllerInstance.BanUser)
+		adminApi.POST("/user/unban", controllers.AdminControllerInstance.UnbanUser)
+		adminApi.POST("/user/force_logout", controllers.AdminControllerInstance.ForceLogout)
+		// 群组管理
+		adminApi.POST("/group/disable", controllers.AdminControllerInstance.DisableGroup)
+		adminApi.POST("/group/enable", controllers.AdminControllerInstance.EnableGroup)
+		// 消息管理
+		adminApi.POST("/message/delete", controllers.AdminControllerInstance.DeleteMessage)
+		// 钱包
+		adminApi.POST("/wallet/recharge", controllers.AdminControllerInstance.RechargeWallet)
+		// 审计日志
+		adminApi.POST("/audit_log/list", controllers.AdminControllerInstance.AuditLogs)
+		// 内容审核
+		adminApi.POST("/moderation/review/list", controllers.ModerationControllerInstance.Reviews)
+		adminApi.POST("/moderation/review/handle", controllers.ModerationControllerInstance.HandleReview)
+		adminApi.POST("/moderation/reload", controllers.ModerationControllerInstance.Reload)
+		// 举报处理
+		adminApi.POST("/report/list", controllers.ReportControllerInstance.List)
+		adminApi.POST("/report/handle", controllers.ReportControllerInstance.Handle)
 	}
 }
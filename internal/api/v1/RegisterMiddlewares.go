@@ -8,6 +8,6 @@ import (
 func RegisterMiddlewares(r *gin.Engine) {
 	// 添加中间件
 	// r.Use(middleware.Cors())
-	// 用户限流
-	r.Use(middleware.UserRateLimiterMiddleware())
+	// 限流（用户、接口、路由规则）
+	r.Use(middleware.RateLimitMiddleware())
 }
@@ -30,8 +30,8 @@ func Start() {
 	router := gin.Default()
 	// 配置swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	//配置Minio
-	manager.InitMinIO()
+	//配置文件存储
+	manager.InitStorage()
 	//配置rabbitmq
 	manager.InitRabbitMQ()
 	//配置WebSocket
@@ -2,11 +2,15 @@ package app
 
 import (
 	"github.com/sirupsen/logrus"
+	"go-chat/configs"
+	"go-chat/internal/consumer"
 	controllers "go-chat/internal/controller"
+	"go-chat/internal/db"
 	"go-chat/internal/manager"
 	"go-chat/internal/repository"
 	"go-chat/internal/service"
 	wsHandler "go-chat/internal/ws/handler"
+	"time"
 )
 
 func doWire() {
@@ -20,25 +24,89 @@ func doWire() {
 	repository.InitFriendGroupRepository()
 	repository.InitGroupAnnouncementRepository()
 	repository.InitFileRepository()
+	repository.InitUserIdentityRepository()
+	repository.InitAdminAuditLogRepository()
+	repository.InitModerationReviewRepository()
+	repository.InitReportRepository()
+	repository.InitUploadSessionRepository()
+	repository.InitWalletRepository()
+	repository.InitRedPacketRepository()
+	repository.InitMentionRepository()
+	repository.InitReactionRepository()
+	repository.InitThreadRepository()
+	repository.InitPinRepository()
+	repository.InitScheduledMessageRepository()
+	repository.InitPollRepository()
+	repository.InitStickerRepository()
+	repository.InitMessageDeletionRepository()
+	//manager
+	manager.InitSessionManager(db.Redis)
+	manager.InitModerationManager()
+	manager.InitRateLimitManager(db.Redis)
+	manager.InitMediaJobQueue()
 	//ws
-	wsHandler.InitWebSocketHandler(nil, nil, nil)
+	wsHandler.InitWebSocketHandler(nil, nil, nil, manager.RateLimitManagerInstance)
 	//service
-	service.InitUserService(wsHandler.WebSocketHandlerInstance, repository.UserRepositoryInstance)
+	service.InitFileService(repository.FileRepositoryInstance, repository.UploadSessionRepositoryInstance, manager.StorageInstance,
+		manager.MediaJobQueueInstance, manager.NewNoopVirusScanner())
+	service.InitMediaService(repository.FileRepositoryInstance, manager.StorageInstance, manager.NewFfmpegProcessor(),
+		wsHandler.WebSocketHandlerInstance)
+	// 超时配置无效时使用默认值
+	linkTimeout, _ := time.ParseDuration(configs.AppConfig.LinkPreview.Timeout)
+	service.InitLinkPreviewService(repository.MessageRepositoryInstance, repository.GroupMemberRepositoryInstance,
+		manager.NewHttpLinkFetcher(manager.LinkFetcherOptions{
+			Timeout:   linkTimeout,
+			MaxBytes:  configs.AppConfig.LinkPreview.MaxBytes,
+			UserAgent: configs.AppConfig.LinkPreview.UserAgent,
+		}), manager.NewRedisLinkPreviewCache(db.Redis), wsHandler.WebSocketHandlerInstance)
+	service.InitUserService(wsHandler.WebSocketHandlerInstance, repository.UserRepositoryInstance, service.FileServiceInstance)
 	service.InitMessageService(repository.MessageRepositoryInstance, repository.UserRepositoryInstance,
-		repository.GroupMemberRepositoryInstance)
+		repository.GroupRepositoryInstance, repository.GroupMemberRepositoryInstance,
+		repository.ModerationReviewRepositoryInstance, manager.ModerationManagerInstance, service.FileServiceInstance,
+		wsHandler.WebSocketHandlerInstance, repository.MentionRepositoryInstance, repository.ReactionRepositoryInstance,
+		repository.ThreadRepositoryInstance, repository.PinRepositoryInstance,
+		repository.ScheduledMessageRepositoryInstance, service.LinkPreviewServiceInstance,
+		repository.StickerRepositoryInstance, repository.MessageDeletionRepositoryInstance)
 	service.InitGroupService(repository.GroupRepositoryInstance, repository.MessageRepositoryInstance,
-		repository.UserRepositoryInstance, repository.GroupMemberRepositoryInstance, repository.GroupAnnouncementRepositoryInstance)
+		repository.UserRepositoryInstance, repository.GroupMemberRepositoryInstance, repository.GroupAnnouncementRepositoryInstance,
+		service.FileServiceInstance)
 	service.InitFriendService(repository.FriendRepositoryInstance, repository.FriendRequestRepositoryInstance,
 		repository.FriendGroupRepositoryInstance, repository.UserRepositoryInstance, wsHandler.WebSocketHandlerInstance)
-	service.InitFileService(repository.FileRepositoryInstance, manager.MinioManagerInstance)
+	service.InitOidcService(service.UserServiceInstance, repository.UserRepositoryInstance,
+		repository.UserIdentityRepositoryInstance, manager.NewRedisOidcStateStore(db.Redis))
+	service.InitAdminService(repository.UserRepositoryInstance, repository.GroupRepositoryInstance,
+		repository.MessageRepositoryInstance, repository.AdminAuditLogRepositoryInstance, repository.WalletRepositoryInstance,
+		manager.SessionManagerInstance, wsHandler.WebSocketHandlerInstance, service.FileServiceInstance)
+	service.InitModerationService(repository.ModerationReviewRepositoryInstance, service.AdminServiceInstance,
+		manager.ModerationManagerInstance)
+	service.InitReportService(repository.ReportRepositoryInstance, repository.MessageRepositoryInstance,
+		repository.UserRepositoryInstance, repository.GroupRepositoryInstance, repository.GroupMemberRepositoryInstance,
+		wsHandler.WebSocketHandlerInstance)
+	service.InitRedPacketService(repository.RedPacketRepositoryInstance, repository.WalletRepositoryInstance,
+		repository.UserRepositoryInstance, repository.GroupRepositoryInstance, repository.GroupMemberRepositoryInstance,
+		service.MessageServiceInstance, manager.NewRedPacketStore(db.Redis), wsHandler.WebSocketHandlerInstance)
+	service.InitPollService(repository.PollRepositoryInstance, repository.UserRepositoryInstance,
+		repository.GroupRepositoryInstance, repository.GroupMemberRepositoryInstance, service.MessageServiceInstance,
+		wsHandler.WebSocketHandlerInstance)
+	service.InitStickerService(repository.StickerRepositoryInstance, repository.GroupMemberRepositoryInstance,
+		service.FileServiceInstance)
 	//controller
 	controllers.InitUserController(service.UserServiceInstance)
 	controllers.InitMessageController(service.MessageServiceInstance)
 	controllers.InitGroupController(service.GroupServiceInstance)
 	controllers.InitFriendController(service.FriendServiceInstance)
 	controllers.InitFileController(service.FileServiceInstance)
+	controllers.InitOidcController(service.OidcServiceInstance)
+	controllers.InitAdminController(service.AdminServiceInstance)
+	controllers.InitModerationController(service.ModerationServiceInstance)
+	controllers.InitReportController(service.ReportServiceInstance)
+	controllers.InitRedPacketController(service.RedPacketServiceInstance)
+	controllers.InitPollController(service.PollServiceInstance)
+	controllers.InitStickerController(service.StickerServiceInstance)
 	//延迟注入
-	wsHandler.InitWebSocketHandler(service.UserServiceInstance, service.MessageServiceInstance, service.GroupServiceInstance)
+	wsHandler.InitWebSocketHandler(service.UserServiceInstance, service.MessageServiceInstance, service.GroupServiceInstance,
+		manager.RateLimitManagerInstance)
+	manager.MediaJobQueueInstance.Consume(consumer.HandleMediaJobConsumer)
 
 	logrus.Info("=======================依赖注入完成=====================")
 }
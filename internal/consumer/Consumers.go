@@ -3,6 +3,8 @@ package consumer
 import (
 	"encoding/json"
 	"github.com/sirupsen/logrus"
+	"go-chat/internal/model"
+	"go-chat/internal/service"
 )
 
 // 处理队列 "string" 的消息逻辑
@@ -21,3 +23,19 @@ func HandleJsonConsumer(msg []byte) {
 	}
 	logrus.Printf("处理 notifications 队列的通知: %+v", jsonMessage)
 }
+
+// 处理音视频任务队列的消息
+func HandleMediaJobConsumer(msg []byte) {
+	var job model.MediaJob
+	if err := json.Unmarshal(msg, &job); err != nil {
+		logrus.Printf("音视频任务反序列化失败: %s", err)
+		return
+	}
+	if service.MediaServiceInstance == nil {
+		logrus.Printf("音视频处理服务未初始化，丢弃任务: %+v", job)
+		return
+	}
+	if err := service.MediaServiceInstance.Process(job); err != nil {
+		logrus.Printf("音视频任务处理失败(%d): %s", job.FileId, err)
+	}
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+)
+
+// AdminController 平台管理后台控制器
+// @Tags Admin
+// @Description 平台管理员对用户、群组、消息的管理操作，所有写操作均记录审计日志
+type AdminController struct {
+	BaseController
+	adminService interfacesservice.AdminServiceInterface
+}
+
+var AdminControllerInstance *AdminController
+
+func InitAdminController(adminService interfacesservice.AdminServiceInterface) {
+	AdminControllerInstance = &AdminController{
+		adminService: adminService,
+	}
+}
+
+// SearchUsers 用户搜索
+// @Summary 用户搜索
+// @Description 按关键字、用户ID、状态分页查询用户
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminUserSearchRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.AdminUserVo]}
+// @Router /admin/user/search [post]
+func (con AdminController) SearchUsers(c *gin.Context) {
+	var req request.AdminUserSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.adminService.SearchUsers(req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// BanUser 封禁用户
+// @Summary 封禁用户
+// @Description 封禁用户并强制下线，duration 为封禁秒数，0 表示永久
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminBanRequest true "封禁参数"
+// @Success 200 {object} model.Response
+// @Router /admin/user/ban [post]
+func (con AdminController) BanUser(c *gin.Context) {
+	var req request.AdminBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.BanUser(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// UnbanUser 解封用户
+// @Summary 解封用户
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminUserOperateRequest true "解封参数"
+// @Success 200 {object} model.Response
+// @Router /admin/user/unban [post]
+func (con AdminController) UnbanUser(c *gin.Context) {
+	var req request.AdminUserOperateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.UnbanUser(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// ForceLogout 强制下线
+// @Summary 强制下线
+// @Description 使用户已签发的 token 失效并断开 ws 连接
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminUserOperateRequest true "下线参数"
+// @Success 200 {object} model.Response
+// @Router /admin/user/force_logout [post]
+func (con AdminController) ForceLogout(c *gin.Context) {
+	var req request.AdminUserOperateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.ForceLogout(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// DisableGroup 停用群组
+// @Summary 停用群组
+// @Description 停用后群内不能发送消息，也不能加入
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminGroupStatusRequest true "群组参数"
+// @Success 200 {object} model.Response
+// @Router /admin/group/disable [post]
+func (con AdminController) DisableGroup(c *gin.Context) {
+	var req request.AdminGroupStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.DisableGroup(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// EnableGroup 恢复群组
+// @Summary 恢复群组
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminGroupStatusRequest true "群组参数"
+// @Success 200 {object} model.Response
+// @Router /admin/group/enable [post]
+func (con AdminController) EnableGroup(c *gin.Context) {
+	var req request.AdminGroupStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.EnableGroup(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// DeleteMessage 删除违规消息
+// @Summary 删除消息
+// @Description 删除违规消息，消息内容快照记录在审计日志中
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminMessageDeleteRequest true "消息参数"
+// @Success 200 {object} model.Response
+// @Router /admin/message/delete [post]
+func (con AdminController) DeleteMessage(c *gin.Context) {
+	var req request.AdminMessageDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.DeleteMessage(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// RechargeWallet 钱包充值
+// @Summary 钱包充值
+// @Description 给用户钱包充值，金额单位为分
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminWalletRechargeRequest true "充值参数"
+// @Success 200 {object} model.Response
+// @Router /admin/wallet/recharge [post]
+func (con AdminController) RechargeWallet(c *gin.Context) {
+	var req request.AdminWalletRechargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.adminService.RechargeWallet(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// AuditLogs 审计日志
+// @Summary 审计日志查询
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.AdminAuditLogQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.AdminAuditLog]}
+// @Router /admin/audit_log/list [post]
+func (con AdminController) AuditLogs(c *gin.Context) {
+	var req request.AdminAuditLogQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.adminService.AuditLogs(req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
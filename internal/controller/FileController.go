@@ -1,8 +1,17 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	interfacemanager "go-chat/internal/interfaces/manager"
 	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
 )
 
 // FileController 文件相关控制器
@@ -21,6 +30,16 @@ func InitFileController(fileService interfacesservice.FileServiceInterface) {
 	}
 }
 
+// Upload 上传文件
+// @Summary 上传文件
+// @Description 上传文件到配置的存储驱动，返回文件访问地址
+// @Tags File
+// @Accept multipart/form-data
+// @Produce json
+// @security Bearer
+// @Param file formData file true "文件"
+// @Success 200 {object} model.Response{data=string}
+// @Router /file/upload [post]
 func (con FileController) Upload(c *gin.Context) {
 	id := c.GetUint("id")
 	// 获取上传的文件
@@ -31,11 +50,309 @@ func (con FileController) Upload(c *gin.Context) {
 	}
 	url, err := con.fileService.Upload(id, file)
 	if err != nil {
+		con.Error(c, err.Error(), uploadErrorCode(err))
+		return
+	}
+	con.Success(c, url)
+}
+
+// UploadInit 创建分片上传会话
+// @Summary 创建分片上传会话
+// @Description 返回每个分片的预签名上传地址，客户端用 PUT 上传分片后调用 complete 完成上传
+// @Tags File
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.UploadInitRequest true "文件信息"
+// @Success 200 {object} model.Response{data=model.UploadSessionVo}
+// @Router /file/upload/init [post]
+func (con FileController) UploadInit(c *gin.Context) {
+	var req request.UploadInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		con.Error(c, err.Error())
 		return
 	}
+	session, err := con.fileService.InitUpload(c.GetUint("id"), req)
 	if err != nil {
+		con.Error(c, err.Error(), uploadErrorCode(err))
 		return
 	}
-	con.Success(c, url)
+	con.Success(c, session)
+}
+
+// UploadSession 查询分片上传进度
+// @Summary 查询分片上传进度
+// @Description 断点续传时调用，返回已上传的分片和未上传分片的新地址
+// @Tags File
+// @Produce json
+// @security Bearer
+// @Param upload_id query string true "上传ID"
+// @Success 200 {object} model.Response{data=model.UploadSessionVo}
+// @Router /file/upload/session [get]
+func (con FileController) UploadSession(c *gin.Context) {
+	var req request.UploadSessionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	session, err := con.fileService.GetUploadSession(c.GetUint("id"), req.UploadId)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, session)
+}
+
+// UploadComplete 完成分片上传
+// @Summary 完成分片上传
+// @Description 校验分片、文件大小和 SHA-256 后合并为文件；秒传时提交校验区间的字节，无需上传分片
+// @Tags File
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.UploadCompleteRequest true "上传ID和秒传校验数据"
+// @Success 200 {object} model.Response{data=model.File}
+// @Router /file/upload/complete [post]
+func (con FileController) UploadComplete(c *gin.Context) {
+	var req request.UploadCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	file, err := con.fileService.CompleteUpload(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error(), uploadErrorCode(err))
+		return
+	}
+	con.Success(c, file)
+}
+
+// Usage 查询存储用量
+// @Summary 查询存储用量
+// @Description 返回当前用户上传的文件数、已用空间和配额，quota 为 0 表示不限制
+// @Tags File
+// @Produce json
+// @security Bearer
+// @Success 200 {object} model.Response{data=model.FileUsageVo}
+// @Router /file/usage [get]
+func (con FileController) Usage(c *gin.Context) {
+	usage, err := con.fileService.Usage(c.GetUint("id"))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, usage)
+}
+
+// uploadErrorCode 上传校验失败的错误码
+func uploadErrorCode(err error) int {
+	switch {
+	case errors.Is(err, interfacesservice.ErrFileTooLarge), errors.Is(err, interfacesservice.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, interfacesservice.ErrFileTypeNotAllowed):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, interfacesservice.ErrFileInfected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, interfacesservice.ErrUploadProof):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// UploadAbort 取消分片上传
+// @Summary 取消分片上传
+// @Tags File
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.UploadSessionRequest true "上传ID"
+// @Success 200 {object} model.Response
+// @Router /file/upload/abort [post]
+func (con FileController) UploadAbort(c *gin.Context) {
+	var req request.UploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.fileService.AbortUpload(c.GetUint("id"), req.UploadId); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// GetObject 读取对象
+// @Summary 读取对象
+// @Description 文件访问地址。需登录且能看到引用该文件的消息，或携带 /file/sign 生成的签名参数；支持 Range 断点下载。图片、音频、视频以外的文件均作为附件下载
+// @Tags File
+// @Produce octet-stream
+// @security Bearer
+// @Param key path string true "对象路径"
+// @Param Range header string false "字节范围，如 bytes=0-1023"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Router /file/object/{key} [get]
+func (con FileController) GetObject(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	c.Header("X-Content-Type-Options", "nosniff")
+	if !c.GetBool("presigned") {
+		if err := con.fileService.AuthorizeObject(c.GetUint("id"), key); err != nil {
+			if errors.Is(err, interfacesservice.ErrFileForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 由存储直接提供下载
+		redirect, err := con.fileService.DownloadUrl(key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if redirect != "" {
+			c.Redirect(http.StatusFound, redirect)
+			return
+		}
+	}
+	info, err := con.fileService.StatObject(key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if info == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+		return
+	}
+
+	// 只有图片、音频、视频在浏览器中直接打开，html 等其他类型作为附件下载，防止上传的页面在本站执行脚本
+	if !inlineContentType(info.ContentType) {
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	}
+
+	// 对象路径按内容哈希生成，内容不会变化，可以长期缓存
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	etag := ""
+	if info.ETag != "" {
+		etag = `"` + strings.Trim(info.ETag, `"`) + `"`
+		c.Header("ETag", etag)
+	}
+	if etag != "" && c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	offset, length, partial, ok := parseRange(c.GetHeader("Range"), info.Size)
+	if !ok {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.Status(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	reader, _, err := con.fileService.GetObjectRange(key, offset, length)
+	if err != nil {
+		if errors.Is(err, interfacemanager.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+	status := http.StatusOK
+	if partial {
+		status = http.StatusPartialContent
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, info.Size))
+	}
+	c.DataFromReader(status, length, info.ContentType, reader, nil)
+}
+
+// Sign 生成文件的短期签名地址
+// @Summary 生成文件签名地址
+// @Description 为能访问的文件生成短期有效的签名地址，可直接用于 img/video 标签；无权访问的地址不返回
+// @Tags File
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.FileSignRequest true "文件地址列表"
+// @Success 200 {object} model.Response{data=map[string]string}
+// @Router /file/sign [post]
+func (con FileController) Sign(c *gin.Context) {
+	var req request.FileSignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	signed, err := con.fileService.SignUrls(c.GetUint("id"), req.Urls)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, signed)
+}
+
+// inlineContentType 是否可以在浏览器中直接打开，svg 可以包含脚本，不在此列
+func inlineContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "image/svg+xml" {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/")
+}
+
+// parseRange 解析单个字节范围，header 为空或包含多个范围时返回整个文件；ok 为 false 表示范围无法满足
+func parseRange(header string, size int64) (offset, length int64, partial, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if header == "" || !found || strings.Contains(spec, ",") {
+		return 0, size, false, true
+	}
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, false
+	}
+	if startStr == "" {
+		// bytes=-n 表示最后 n 个字节
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, false, false
+		}
+		suffix = min(suffix, size)
+		return size - suffix, suffix, true, true
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false, false
+	}
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end - start + 1, true, true
+}
+
+// PutObject 通过预签名地址写入对象
+// @Summary 写入对象
+// @Description local / memory 存储驱动的预签名上传地址，需携带 method、expires、signature 参数
+// @Tags File
+// @Accept octet-stream
+// @Produce json
+// @Param key path string true "对象路径"
+// @Param method query string true "签名方法"
+// @Param expires query string true "过期时间戳"
+// @Param signature query string true "签名"
+// @Success 200 {object} model.Response{data=model.ObjectInfo}
+// @Router /file/object/{key} [put]
+func (con FileController) PutObject(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	info, err := con.fileService.PutObject(key, c.Request.Body, c.Request.ContentLength, c.ContentType())
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, info)
 }
@@ -70,7 +70,7 @@ func (con GroupController) Update(c *gin.Context) {
 		con.Error(c, err.Error())
 		return
 	}
-	if err := con.groupService.Update(req); err != nil {
+	if err := con.groupService.Update(c.GetUint("id"), req); err != nil {
 		con.Error(c, err.Error())
 		return
 	}
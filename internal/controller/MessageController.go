@@ -48,7 +48,8 @@ func (con MessageController) Read(c *gin.Context) {
 
 // Query godoc
 // @Summary 查询历史消息（分页，支持游标分页）
-// @Description 根据目标ID和目标类型查询聊天消息历史，支持分页、时间范围等过滤
+// @Description 根据目标ID和目标类型查询聊天消息历史；direction 为 before 时向前翻页，after 时向后翻页，around 时返回 cursor 指定的消息及其前后的消息（用于跳转）
+// @Description 支持按多个消息类型、发送者、是否包含附件、关键字和时间范围过滤，返回的消息都按 id 从新到旧排列
 // @Tags Message
 // @Accept application/json
 // @Produce application/json
@@ -74,9 +75,33 @@ func (con MessageController) Query(c *gin.Context) {
 	}
 }
 
+// PlayVoice 标记语音已播放
+// @Summary 标记语音已播放
+// @Description 接收者播放语音后调用，记录播放状态并视为已读
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PlayVoiceReq true "消息ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 500 {object} model.Response "标记失败"
+// @Router /message/voice/played [post]
+func (con MessageController) PlayVoice(c *gin.Context) {
+	var req request.PlayVoiceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, "参数错误")
+		return
+	}
+	if err := con.messageService.PlayVoice(c.GetUint("id"), req.MessageId); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
 // Revoke 撤回消息接口
 // @Summary 撤回消息
-// @Description 根据消息ID撤回指定消息，只有发送者或管理员才能撤回消息
+// @Description 根据消息ID撤回指定消息（对所有人删除），发送者只能撤回撤回时限内的消息，群主和管理员可以随时撤回群内的消息
 // @Tags Message
 // @Accept json
 // @Produce json
@@ -94,3 +119,403 @@ func (con MessageController) Revoke(c *gin.Context) {
 	}
 	con.Success(c)
 }
+
+// Delete 删除消息
+// @Summary 删除消息
+// @Description for_everyone 为 false 时仅自己删除，只对自己隐藏；为 true 时对所有人删除，规则与撤回相同；任一消息不满足条件时都不删除
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.MessageDeleteReq true "删除参数"
+// @Success 200 {object} model.Response
+// @Router /message/delete [post]
+func (con MessageController) Delete(c *gin.Context) {
+	var req request.MessageDeleteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.messageService.DeleteMessages(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// Clear 清空聊天记录
+// @Summary 清空聊天记录
+// @Description 清空会话中当前的全部消息，只对自己生效，之后的新消息照常显示
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ConversationClearReq true "会话"
+// @Success 200 {object} model.Response
+// @Router /message/clear [post]
+func (con MessageController) Clear(c *gin.Context) {
+	var req request.ConversationClearReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.messageService.ClearConversation(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// Forward 转发消息
+// @Summary 转发消息
+// @Description 逐条转发（最多 30 条）或合并为一条聊天记录（最多 100 条，须来自同一会话）转发到最多 9 个会话
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ForwardMessageReq true "转发参数"
+// @Success 200 {object} model.Response{data=[]model.MessageVo}
+// @Router /message/forward [post]
+func (con MessageController) Forward(c *gin.Context) {
+	var req request.ForwardMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	list, err := con.messageService.Forward(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, list)
+}
+
+// ForwardRecord 查看聊天记录
+// @Summary 查看合并转发的聊天记录
+// @Tags Message
+// @Produce json
+// @security Bearer
+// @Param id path int true "聊天记录消息ID"
+// @Success 200 {object} model.Response{data=model.ForwardRecord}
+// @Router /message/{id}/forward_record [get]
+func (con MessageController) ForwardRecord(c *gin.Context) {
+	messageId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		con.Error(c, "消息ID不合法")
+		return
+	}
+	record, err := con.messageService.ForwardRecord(c.GetUint("id"), uint(messageId))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, record)
+}
+
+// MentionList “@我的”消息
+// @Summary 查询@我的消息
+// @Description 分页查询群聊中 @ 到当前用户（含 @所有人）的消息，最新的在前
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.MentionQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.MentionVo]}
+// @Router /message/mention/list [post]
+func (con MessageController) MentionList(c *gin.Context) {
+	var req request.MentionQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	page, err := con.messageService.MentionList(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, page)
+}
+
+// ReadMentions 标记 @ 提醒已读
+// @Summary 标记@提醒已读
+// @Description 按 id 标记，或不传 id 时标记整个群（不传群时标记全部），返回标记的条数
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.MentionReadRequest true "标记参数"
+// @Success 200 {object} model.Response{data=int64}
+// @Router /message/mention/read [post]
+func (con MessageController) ReadMentions(c *gin.Context) {
+	var req request.MentionReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	count, err := con.messageService.ReadMentions(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, count)
+}
+
+// AddReaction 添加表情回应
+// @Summary 添加表情回应
+// @Description 对看得到的消息添加表情回应，重复添加同一表情不报错，每人对同一条消息的表情数有上限
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReactionReq true "消息ID和表情"
+// @Success 200 {object} model.Response{data=[]model.ReactionVo}
+// @Router /message/reaction/add [post]
+func (con MessageController) AddReaction(c *gin.Context) {
+	var req request.ReactionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	reactions, err := con.messageService.AddReaction(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, reactions)
+}
+
+// RemoveReaction 取消表情回应
+// @Summary 取消表情回应
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReactionReq true "消息ID和表情"
+// @Success 200 {object} model.Response{data=[]model.ReactionVo}
+// @Router /message/reaction/remove [post]
+func (con MessageController) RemoveReaction(c *gin.Context) {
+	var req request.ReactionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	reactions, err := con.messageService.RemoveReaction(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, reactions)
+}
+
+// ThreadReplies 话题回复列表
+// @Summary 查看话题回复
+// @Description 按时间顺序分页查看话题的回复，同时返回带话题摘要的根消息；传入回复的 id 时查看其所在的话题
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ThreadRepliesRequest true "查询参数"
+// @Success 200 {object} model.Response{data=model.ThreadRepliesResponse}
+// @Router /message/thread/replies [post]
+func (con MessageController) ThreadReplies(c *gin.Context) {
+	var req request.ThreadRepliesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	resp, err := con.messageService.ThreadReplies(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, resp)
+}
+
+// ThreadList 参与的话题
+// @Summary 查询参与的话题
+// @Description 分页查询当前用户参与（发起或回复过）的话题，最近有回复的在前，可只查有未读回复的话题
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ThreadQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.ThreadVo]}
+// @Router /message/thread/list [post]
+func (con MessageController) ThreadList(c *gin.Context) {
+	var req request.ThreadQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	page, err := con.messageService.ThreadList(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, page)
+}
+
+// ReadThread 标记话题已读
+// @Summary 标记话题已读
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ThreadReadRequest true "根消息ID"
+// @Success 200 {object} model.Response "成功"
+// @Router /message/thread/read [post]
+func (con MessageController) ReadThread(c *gin.Context) {
+	var req request.ThreadReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.messageService.ReadThread(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// Pin 置顶消息
+// @Summary 置顶消息
+// @Description 私聊双方都可以置顶，群聊仅群主和管理员；每个会话的置顶数有上限，置顶后在会话中发送一条系统消息
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PinMessageReq true "消息ID"
+// @Success 200 {object} model.Response{data=model.MessageVo} "生成的系统消息"
+// @Router /message/pin [post]
+func (con MessageController) Pin(c *gin.Context) {
+	var req request.PinMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.messageService.PinMessage(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Unpin 取消置顶
+// @Summary 取消置顶
+// @Description 权限与置顶相同，取消后在会话中发送一条系统消息
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PinMessageReq true "消息ID"
+// @Success 200 {object} model.Response{data=model.MessageVo} "生成的系统消息"
+// @Router /message/unpin [post]
+func (con MessageController) Unpin(c *gin.Context) {
+	var req request.PinMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.messageService.UnpinMessage(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// PinnedList 置顶消息列表
+// @Summary 查询会话的置顶消息
+// @Description 最近置顶的在前
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PinListReq true "会话"
+// @Success 200 {object} model.Response{data=[]model.PinVo}
+// @Router /message/pin/list [post]
+func (con MessageController) PinnedList(c *gin.Context) {
+	var req request.PinListReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	list, err := con.messageService.PinnedList(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, list)
+}
+
+// Schedule 创建定时消息
+// @Summary 创建定时消息
+// @Description 到达发送时间后按普通消息发送，最多提前 30 天（可配置）；可同时设置发出后的销毁时长和阅后即焚
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ScheduleMessageReq true "定时消息"
+// @Success 200 {object} model.Response{data=model.ScheduledMessage}
+// @Router /message/schedule [post]
+func (con MessageController) Schedule(c *gin.Context) {
+	var req request.ScheduleMessageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	scheduled, err := con.messageService.ScheduleMessage(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, scheduled)
+}
+
+// CancelSchedule 取消定时消息
+// @Summary 取消定时消息
+// @Description 只能取消自己尚未发送的定时消息
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ScheduleCancelReq true "定时消息ID"
+// @Success 200 {object} model.Response
+// @Router /message/schedule/cancel [post]
+func (con MessageController) CancelSchedule(c *gin.Context) {
+	var req request.ScheduleCancelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.messageService.CancelScheduled(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, nil)
+}
+
+// ScheduleList 我的定时消息
+// @Summary 查询我的定时消息
+// @Description 分页查询，可按状态过滤（0 待发送 1 发送中 2 已发送 3 已取消 4 发送失败），发送时间晚的在前
+// @Tags Message
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ScheduleQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.ScheduledMessage]}
+// @Router /message/schedule/list [post]
+func (con MessageController) ScheduleList(c *gin.Context) {
+	var req request.ScheduleQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	page, err := con.messageService.ScheduledList(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, page)
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+)
+
+// ModerationController 内容审核控制器
+// @Tags Moderation
+// @Description 内容审核队列与敏感词表管理，仅平台管理员可用
+type ModerationController struct {
+	BaseController
+	moderationService interfacesservice.ModerationServiceInterface
+}
+
+var ModerationControllerInstance *ModerationController
+
+func InitModerationController(moderationService interfacesservice.ModerationServiceInterface) {
+	ModerationControllerInstance = &ModerationController{
+		moderationService: moderationService,
+	}
+}
+
+// Reviews 审核队列
+// @Summary 审核队列查询
+// @Tags Moderation
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ModerationReviewQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.ModerationReview]}
+// @Router /admin/moderation/review/list [post]
+func (con ModerationController) Reviews(c *gin.Context) {
+	var req request.ModerationReviewQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.moderationService.Reviews(req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// HandleReview 处理审核
+// @Summary 处理审核
+// @Description approve=true 通过；approve=false 驳回并删除消息
+// @Tags Moderation
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ModerationReviewHandleRequest true "审核参数"
+// @Success 200 {object} model.Response
+// @Router /admin/moderation/review/handle [post]
+func (con ModerationController) HandleReview(c *gin.Context) {
+	var req request.ModerationReviewHandleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.moderationService.HandleReview(c.GetUint("id"), c.ClientIP(), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// Reload 重新加载敏感词表
+// @Summary 重新加载敏感词表
+// @Description 立即从配置和词表文件重建敏感词表（词表文件变化也会被定时任务自动加载）
+// @Tags Moderation
+// @Produce json
+// @security Bearer
+// @Success 200 {object} model.Response
+// @Router /admin/moderation/reload [post]
+func (con ModerationController) Reload(c *gin.Context) {
+	if err := con.moderationService.ReloadWords(true); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-chat/configs"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/utils/oidcUtil"
+	"net/http"
+)
+
+// oidcBrowserCookie 标识发起授权的浏览器，回调时必须带回，防止登录 CSRF
+const oidcBrowserCookie = "go_chat_oidc"
+
+// OidcController OIDC 单点登录控制器
+// @Tags Oidc
+// @Description 使用企业身份提供方（OIDC）登录
+type OidcController struct {
+	BaseController
+	oidcService interfacesservice.OidcServiceInterface
+}
+
+var OidcControllerInstance *OidcController
+
+func InitOidcController(oidcService interfacesservice.OidcServiceInterface) {
+	OidcControllerInstance = &OidcController{
+		oidcService: oidcService,
+	}
+}
+
+// Login 跳转到身份提供方登录
+// @Summary OIDC 登录
+// @Description 重定向到身份提供方的授权页面（授权码 + PKCE）
+// @Tags Oidc
+// @Param provider path string true "身份提供方名称"
+// @Success 302
+// @Router /user/oidc/{provider}/login [get]
+func (con OidcController) Login(c *gin.Context) {
+	browserKey, err := con.browserKey(c)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	authUrl, err := con.oidcService.AuthUrl(c.Param("provider"), 0, browserKey)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, authUrl)
+}
+
+// Bind 为当前用户绑定外部身份
+// @Summary 绑定外部身份
+// @Description 返回授权地址，完成授权后外部身份绑定到当前登录用户；须在同一浏览器中打开授权地址
+// @Tags Oidc
+// @Produce json
+// @security Bearer
+// @Param provider path string true "身份提供方名称"
+// @Success 200 {object} model.Response "授权地址"
+// @Router /user/oidc/{provider}/bind [get]
+func (con OidcController) Bind(c *gin.Context) {
+	userId := c.GetUint("id")
+	browserKey, err := con.browserKey(c)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	authUrl, err := con.oidcService.AuthUrl(c.Param("provider"), userId, browserKey)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, authUrl)
+}
+
+// Callback 身份提供方回调
+// @Summary OIDC 回调
+// @Description 校验授权结果，关联或自动创建用户，返回 jwt
+// @Tags Oidc
+// @Produce json
+// @Param provider path string true "身份提供方名称"
+// @Param code query string true "授权码"
+// @Param state query string true "state"
+// @Success 200 {object} model.Response "jwt"
+// @Router /user/oidc/{provider}/callback [get]
+func (con OidcController) Callback(c *gin.Context) {
+	if errMsg := c.Query("error"); errMsg != "" {
+		con.Error(c, "身份提供方返回错误: "+errMsg, http.StatusUnauthorized)
+		return
+	}
+	browserKey, _ := c.Cookie(oidcBrowserCookie)
+	token, err := con.oidcService.Callback(c.Param("provider"), c.Query("code"), c.Query("state"), browserKey)
+	if err != nil {
+		con.Error(c, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	con.Success(c, token)
+}
+
+// Identities 查询已绑定的外部身份
+// @Summary 外部身份列表
+// @Tags Oidc
+// @Produce json
+// @security Bearer
+// @Success 200 {object} model.Response{data=[]model.UserIdentity}
+// @Router /user/oidc/identities [get]
+func (con OidcController) Identities(c *gin.Context) {
+	userId := c.GetUint("id")
+	identities, err := con.oidcService.Identities(userId)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, identities)
+}
+
+// browserKey 读取浏览器标识 cookie，没有时生成一个；cookie 只在 OIDC 接口路径下发送
+func (con OidcController) browserKey(c *gin.Context) (string, error) {
+	if key, err := c.Cookie(oidcBrowserCookie); err == nil && key != "" {
+		return key, nil
+	}
+	key, err := oidcUtil.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	// 身份提供方回调是跨站的顶层 GET 跳转，SameSite=Lax 时仍会带上 cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBrowserCookie, key, 0, configs.AppConfig.Api.Prefix+"/user/oidc", "", c.Request.TLS != nil, true)
+	return key, nil
+}
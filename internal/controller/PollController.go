@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+	"strconv"
+)
+
+// PollController 群投票相关控制器
+// @Tags Poll
+// @Description 控制群投票相关的 API
+type PollController struct {
+	BaseController
+	pollService interfacesservice.PollServiceInterface
+}
+
+var PollControllerInstance *PollController
+
+func InitPollController(pollService interfacesservice.PollServiceInterface) {
+	PollControllerInstance = &PollController{
+		pollService: pollService,
+	}
+}
+
+// Create 发起投票
+// @Summary 发起投票
+// @Description 在群中发起单选或多选投票，可匿名、可设置截止时间，生成投票消息并推送给群成员
+// @Tags Poll
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PollCreateRequest true "投票参数"
+// @Success 200 {object} model.Response{data=model.MessageVo}
+// @Router /poll/create [post]
+func (con PollController) Create(c *gin.Context) {
+	var req request.PollCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.pollService.Create(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Vote 投票
+// @Summary 投票
+// @Description 每人一票，截止前可以修改，修改时以本次选择为准；投票后推送最新结果给在线的群成员
+// @Tags Poll
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PollVoteRequest true "投票ID和选中的选项"
+// @Success 200 {object} model.Response{data=model.PollVo}
+// @Router /poll/vote [post]
+func (con PollController) Vote(c *gin.Context) {
+	var req request.PollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.pollService.Vote(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Close 结束投票
+// @Summary 结束投票
+// @Description 发起人或群主、管理员可以提前结束投票
+// @Tags Poll
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.PollCloseRequest true "投票ID"
+// @Success 200 {object} model.Response{data=model.PollVo}
+// @Router /poll/close [post]
+func (con PollController) Close(c *gin.Context) {
+	var req request.PollCloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.pollService.Close(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Detail 投票详情
+// @Summary 投票详情
+// @Description 投票结果，实名投票附带每个选项的投票人
+// @Tags Poll
+// @Produce json
+// @security Bearer
+// @Param id path int true "投票ID"
+// @Success 200 {object} model.Response{data=model.PollVo}
+// @Router /poll/{id} [get]
+func (con PollController) Detail(c *gin.Context) {
+	pollId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		con.Error(c, "投票ID不合法")
+		return
+	}
+	vo, err := con.pollService.Detail(c.GetUint("id"), uint(pollId))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
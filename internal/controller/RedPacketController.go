@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+	"strconv"
+)
+
+// RedPacketController 红包和钱包相关控制器，金额单位均为分
+// @Tags RedPacket
+// @Description 控制红包、钱包相关的 API
+type RedPacketController struct {
+	BaseController
+	redPacketService interfacesservice.RedPacketServiceInterface
+}
+
+var RedPacketControllerInstance *RedPacketController
+
+func InitRedPacketController(redPacketService interfacesservice.RedPacketServiceInterface) {
+	RedPacketControllerInstance = &RedPacketController{
+		redPacketService: redPacketService,
+	}
+}
+
+// Send 发红包
+// @Summary 发红包
+// @Description 从钱包扣款后生成红包消息并推送给接收者；普通红包 amount 为单个金额，拼手气红包 amount 为总金额
+// @Tags RedPacket
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.RedPacketSendRequest true "红包参数"
+// @Success 200 {object} model.Response{data=model.MessageVo}
+// @Router /red_packet/send [post]
+func (con RedPacketController) Send(c *gin.Context) {
+	var req request.RedPacketSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.redPacketService.Send(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Claim 抢红包
+// @Summary 抢红包
+// @Description 每人只能领取一次，领取后返回红包详情
+// @Tags RedPacket
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.RedPacketClaimRequest true "红包ID"
+// @Success 200 {object} model.Response{data=model.RedPacketVo}
+// @Router /red_packet/claim [post]
+func (con RedPacketController) Claim(c *gin.Context) {
+	var req request.RedPacketClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	vo, err := con.redPacketService.Claim(c.GetUint("id"), req.RedPacketId)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Detail 红包详情
+// @Summary 红包详情
+// @Description 红包状态和领取记录
+// @Tags RedPacket
+// @Produce json
+// @security Bearer
+// @Param id path int true "红包ID"
+// @Success 200 {object} model.Response{data=model.RedPacketVo}
+// @Router /red_packet/{id} [get]
+func (con RedPacketController) Detail(c *gin.Context) {
+	packetId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		con.Error(c, "红包ID不合法")
+		return
+	}
+	vo, err := con.redPacketService.Detail(c.GetUint("id"), uint(packetId))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Wallet 钱包余额
+// @Summary 钱包余额
+// @Tags RedPacket
+// @Produce json
+// @security Bearer
+// @Success 200 {object} model.Response{data=model.WalletVo}
+// @Router /wallet [get]
+func (con RedPacketController) Wallet(c *gin.Context) {
+	vo, err := con.redPacketService.Wallet(c.GetUint("id"))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// WalletLogs 钱包流水
+// @Summary 钱包流水
+// @Tags RedPacket
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.WalletLogQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.WalletLog]}
+// @Router /wallet/logs [post]
+func (con RedPacketController) WalletLogs(c *gin.Context) {
+	var req request.WalletLogQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.redPacketService.WalletLogs(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+)
+
+// ReportController 举报控制器
+// @Tags Report
+// @Description 用户举报消息、用户、群组，管理员处理举报
+type ReportController struct {
+	BaseController
+	reportService interfacesservice.ReportServiceInterface
+}
+
+var ReportControllerInstance *ReportController
+
+func InitReportController(reportService interfacesservice.ReportServiceInterface) {
+	ReportControllerInstance = &ReportController{
+		reportService: reportService,
+	}
+}
+
+// Create 提交举报
+// @Summary 提交举报
+// @Description 举报消息/用户/群组，举报消息时会保存消息快照，撤回后仍可查证
+// @Tags Report
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReportCreateRequest true "举报参数"
+// @Success 200 {object} model.Response{data=model.Report}
+// @Router /report/create [post]
+func (con ReportController) Create(c *gin.Context) {
+	var req request.ReportCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	report, err := con.reportService.Create(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, report)
+}
+
+// Mine 我的举报
+// @Summary 我的举报
+// @Tags Report
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReportQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.Report]}
+// @Router /report/mine [post]
+func (con ReportController) Mine(c *gin.Context) {
+	var req request.ReportQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.reportService.MyReports(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// List 举报列表
+// @Summary 举报列表（管理员）
+// @Tags Report
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReportQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.Report]}
+// @Router /admin/report/list [post]
+func (con ReportController) List(c *gin.Context) {
+	var req request.ReportQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.reportService.List(req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// Handle 处理举报
+// @Summary 处理举报（管理员）
+// @Description status: 1 处理中 2 已处理 3 已驳回，结案后通知举报人
+// @Tags Report
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.ReportHandleRequest true "处理参数"
+// @Success 200 {object} model.Response
+// @Router /admin/report/handle [post]
+func (con ReportController) Handle(c *gin.Context) {
+	var req request.ReportHandleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.reportService.Handle(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
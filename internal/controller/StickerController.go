@@ -0,0 +1,307 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	interfacesservice "go-chat/internal/interfaces/service"
+	request "go-chat/internal/model/request"
+	"strconv"
+)
+
+// StickerController 表情包和群自定义表情相关控制器
+// @Tags Sticker
+// @Description 控制表情包、群自定义表情和表情收藏相关的 API
+type StickerController struct {
+	BaseController
+	stickerService interfacesservice.StickerServiceInterface
+}
+
+var StickerControllerInstance *StickerController
+
+func InitStickerController(stickerService interfacesservice.StickerServiceInterface) {
+	StickerControllerInstance = &StickerController{
+		stickerService: stickerService,
+	}
+}
+
+// CreatePack 创建表情包
+// @Summary 创建表情包
+// @Description 创建后通过 /sticker/add 上传表情，包内有表情后其他人才能搜索到
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerPackCreateRequest true "表情包信息"
+// @Success 200 {object} model.Response{data=model.StickerPack}
+// @Router /sticker/pack/create [post]
+func (con StickerController) CreatePack(c *gin.Context) {
+	var req request.StickerPackCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	pack, err := con.stickerService.CreatePack(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, pack)
+}
+
+// UpdatePack 修改表情包
+// @Summary 修改表情包
+// @Description 修改自己创建的表情包的名称、简介和封面，封面只能使用包内表情的图片地址
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerPackUpdateRequest true "修改的字段"
+// @Success 200 {object} model.Response{data=model.StickerPack}
+// @Router /sticker/pack/update [post]
+func (con StickerController) UpdatePack(c *gin.Context) {
+	var req request.StickerPackUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	pack, err := con.stickerService.UpdatePack(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, pack)
+}
+
+// DeletePack 删除表情包
+// @Summary 删除表情包
+// @Description 删除自己创建的表情包及包内表情，已发出的表情消息不受影响
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerPackIdRequest true "表情包ID"
+// @Success 200 {object} model.Response
+// @Router /sticker/pack/delete [post]
+func (con StickerController) DeletePack(c *gin.Context) {
+	var req request.StickerPackIdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.stickerService.DeletePack(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// PackList 表情包列表
+// @Summary 表情包列表
+// @Description 分页查询表情包，最新创建的在前；mine 为 true 时只查自己创建的（包括空表情包）
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerPackQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.StickerPack]}
+// @Router /sticker/pack/list [post]
+func (con StickerController) PackList(c *gin.Context) {
+	var req request.StickerPackQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.stickerService.PackList(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// SearchPacks 搜索表情包
+// @Summary 搜索表情包
+// @Description 按关键字搜索表情包，匹配表情包名称、简介以及包内表情的名称和关键字
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerPackQueryRequest true "查询参数"
+// @Success 200 {object} model.Response{data=pagination.PageResult[model.StickerPack]}
+// @Router /sticker/pack/search [post]
+func (con StickerController) SearchPacks(c *gin.Context) {
+	var req request.StickerPackQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	result, err := con.stickerService.SearchPacks(c.GetUint("id"), req)
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, result)
+}
+
+// PackDetail 表情包详情
+// @Summary 表情包详情
+// @Description 表情包信息和包内全部表情
+// @Tags Sticker
+// @Produce json
+// @security Bearer
+// @Param id path int true "表情包ID"
+// @Success 200 {object} model.Response{data=model.StickerPackVo}
+// @Router /sticker/pack/{id} [get]
+func (con StickerController) PackDetail(c *gin.Context) {
+	packId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		con.Error(c, "表情包ID格式错误")
+		return
+	}
+	vo, err := con.stickerService.PackDetail(c.GetUint("id"), uint(packId))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, vo)
+}
+
+// Add 上传表情
+// @Summary 上传表情
+// @Description 上传图片并添加到自己的表情包（pack_id），或添加为群自定义表情（group_id，仅群主和管理员，name 为群内唯一的短代码）
+// @Tags Sticker
+// @Accept multipart/form-data
+// @Produce json
+// @security Bearer
+// @Param pack_id formData int false "表情包ID"
+// @Param group_id formData int false "群ID"
+// @Param name formData string true "表情名称"
+// @Param keywords formData string false "搜索关键字，空格分隔"
+// @Param file formData file true "表情图片"
+// @Success 200 {object} model.Response{data=model.Sticker}
+// @Router /sticker/add [post]
+func (con StickerController) Add(c *gin.Context) {
+	var req request.StickerAddRequest
+	if err := c.ShouldBind(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		con.Error(c, "上传文件读取失败: "+err.Error())
+		return
+	}
+	sticker, err := con.stickerService.AddSticker(c.GetUint("id"), req, file)
+	if err != nil {
+		con.Error(c, err.Error(), uploadErrorCode(err))
+		return
+	}
+	con.Success(c, sticker)
+}
+
+// Remove 删除表情
+// @Summary 删除表情
+// @Description 表情包的创建者可以删除包内表情，群主和管理员可以删除群自定义表情，同时清理所有人的收藏
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerIdRequest true "表情ID"
+// @Success 200 {object} model.Response
+// @Router /sticker/remove [post]
+func (con StickerController) Remove(c *gin.Context) {
+	var req request.StickerIdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.stickerService.RemoveSticker(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// GroupStickers 群自定义表情
+// @Summary 群自定义表情
+// @Description 群成员查询群的自定义表情，按添加顺序
+// @Tags Sticker
+// @Produce json
+// @security Bearer
+// @Param group_id query int true "群ID"
+// @Success 200 {object} model.Response{data=[]model.Sticker}
+// @Router /sticker/group [get]
+func (con StickerController) GroupStickers(c *gin.Context) {
+	groupId, err := strconv.ParseUint(c.Query("group_id"), 10, 64)
+	if err != nil {
+		con.Error(c, "群ID格式错误")
+		return
+	}
+	stickers, err := con.stickerService.GroupStickers(c.GetUint("id"), uint(groupId))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, stickers)
+}
+
+// AddFavorite 收藏表情
+// @Summary 收藏表情
+// @Description 收藏表情包中的表情或所在群的自定义表情
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerIdRequest true "表情ID"
+// @Success 200 {object} model.Response
+// @Router /sticker/favorite/add [post]
+func (con StickerController) AddFavorite(c *gin.Context) {
+	var req request.StickerIdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.stickerService.AddFavorite(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// RemoveFavorite 取消收藏表情
+// @Summary 取消收藏表情
+// @Tags Sticker
+// @Accept json
+// @Produce json
+// @security Bearer
+// @Param req body model.StickerIdRequest true "表情ID"
+// @Success 200 {object} model.Response
+// @Router /sticker/favorite/remove [post]
+func (con StickerController) RemoveFavorite(c *gin.Context) {
+	var req request.StickerIdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	if err := con.stickerService.RemoveFavorite(c.GetUint("id"), req); err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c)
+}
+
+// Favorites 收藏的表情
+// @Summary 收藏的表情
+// @Description 最近收藏的在前
+// @Tags Sticker
+// @Produce json
+// @security Bearer
+// @Success 200 {object} model.Response{data=[]model.Sticker}
+// @Router /sticker/favorite/list [get]
+func (con StickerController) Favorites(c *gin.Context) {
+	stickers, err := con.stickerService.Favorites(c.GetUint("id"))
+	if err != nil {
+		con.Error(c, err.Error())
+		return
+	}
+	con.Success(c, stickers)
+}
@@ -156,6 +156,8 @@ func (con UserController) Update(c *gin.Context) {
 		con.Error(c, err.Error())
 		return
 	}
+	//只能修改自己的信息，头像校验也以登录用户为准
+	updateRequest.ID = c.GetUint("id")
 	if err := con.userService.UpdateUser(updateRequest); err != nil {
 		con.Error(c, err)
 		return
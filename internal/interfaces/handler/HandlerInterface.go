@@ -1,11 +1,27 @@
 // Package interfaces
 package interfaces
 
-import "go-chat/internal/model"
+import (
+	"go-chat/internal/model"
+	response "go-chat/internal/model/response"
+)
 
 // WsHandlerInterface  接口
 type WsHandlerInterface interface {
 	ChatHandler(sendId int64, data interface{})
 	HeartBeatHandler(sendId int64, data interface{})
 	OnlineStatusNotice(sendId int64, data model.OnlineStatusNotice)
+	ForceOffline(userId int64, reason string)
+	ReportNotice(userId int64, data model.ReportNotice)
+	MediaNotice(userId int64, data model.MediaNotice)
+	RedPacketNotice(userId int64, data model.RedPacketNotice)
+	MentionNotice(userId int64, data model.MentionNotice)
+	ReactionNotice(userIds []int64, data model.ReactionNotice)
+	ThreadNotice(userId int64, data model.ThreadNotice)
+	MessageExpiredNotice(userIds []int64, data model.MessageExpiredNotice)
+	MessageRevokedNotice(userIds []int64, data model.MessageRevokedNotice)
+	PollNotice(userIds []int64, data model.PollNotice)
+	LinkPreviewNotice(userIds []int64, data model.LinkPreviewNotice)
+	// DeliverMessage 推送服务端生成的消息（如红包）给接收者
+	DeliverMessage(sendId int64, vo *response.MessageVo)
 }
@@ -0,0 +1,9 @@
+package interfaces
+
+// JobQueue 异步任务队列，具体实现有 RabbitMQ 和进程内队列
+type JobQueue interface {
+	// Publish 投递任务，message 序列化为 JSON
+	Publish(message interface{}) error
+	// Consume 开始消费任务，应在依赖注入完成后调用
+	Consume(handler func([]byte))
+}
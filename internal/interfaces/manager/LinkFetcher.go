@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"go-chat/internal/model"
+	"time"
+)
+
+// LinkFetcher 抓取网页并解析链接预览，默认实现通过 HTTP 抓取，测试中可替换
+type LinkFetcher interface {
+	Fetch(ctx context.Context, url string) (*model.LinkPreview, error)
+}
+
+// LinkPreviewCache 链接预览缓存，抓取失败时缓存空预览，避免反复请求同一地址
+type LinkPreviewCache interface {
+	// Get 第二个返回值表示是否命中缓存
+	Get(url string) (*model.LinkPreview, bool, error)
+	Set(url string, preview *model.LinkPreview, ttl time.Duration) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"go-chat/internal/model"
+)
+
+// MediaProcessor 音视频处理，默认实现调用 ffprobe/ffmpeg，测试中可替换
+type MediaProcessor interface {
+	// Probe 探测时长和视频宽高
+	Probe(ctx context.Context, path string) (*model.MediaInfo, error)
+	// Poster 截取 at 秒处的一帧，返回 JPEG 数据
+	Poster(ctx context.Context, path string, at float64) ([]byte, error)
+	// Waveform 提取音频波形，返回 samples 个 0-100 的峰值
+	Waveform(ctx context.Context, path string, samples int) ([]int, error)
+}
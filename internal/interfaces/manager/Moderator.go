@@ -0,0 +1,21 @@
+package interfaces
+
+import "go-chat/internal/model"
+
+// ModerationChecker 内容审核检查器，未命中返回 nil；动作为打码时可直接修改消息内容
+type ModerationChecker interface {
+	Name() string
+	Check(msg *model.Message) *model.ModerationHit
+}
+
+// ReloadableChecker 支持热加载的检查器（如敏感词表）
+type ReloadableChecker interface {
+	// Reload force 为 false 时仅在配置或词表文件变化后重建
+	Reload(force bool) error
+}
+
+// Moderator 内容审核流水线
+type Moderator interface {
+	Moderate(msg *model.Message) *model.ModerationResult
+	Reload(force bool) error
+}
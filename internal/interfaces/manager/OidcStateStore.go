@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// OidcStateStore OIDC 登录状态存储，state 只能被取出一次
+type OidcStateStore interface {
+	Save(state string, data *model.OidcState, ttl time.Duration) error
+	Take(state string) (*model.OidcState, error)
+}
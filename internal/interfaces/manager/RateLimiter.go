@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// RateLimiter 滑动窗口限流存储后端
+type RateLimiter interface {
+	Allow(key string, limit int, window time.Duration) (*model.RateLimitResult, error)
+}
+
+// RateLimitManager 按配置规则对 http 路由和 ws 事件限流，没有命中任何规则时返回 nil
+type RateLimitManager interface {
+	AllowRoute(method, path, userKey, ip string) *model.RateLimitResult
+	AllowEvent(event string, userId int64) *model.RateLimitResult
+}
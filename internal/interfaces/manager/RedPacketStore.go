@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrRedPacketClaimed = errors.New("已经领取过该红包")
+	ErrRedPacketEmpty   = errors.New("红包已被领完")
+)
+
+// RedPacketStore 红包待领取金额的缓存，领取时原子地取出一份金额并记录领取人，保证每人只领一次、领完即止
+type RedPacketStore interface {
+	// Put 写入拆分好的金额
+	Put(packetId uint, amounts []int64, ttl time.Duration) error
+	// Take 为用户取出一份金额，领取过返回 ErrRedPacketClaimed，领完返回 ErrRedPacketEmpty
+	Take(packetId uint, userId uint) (int64, error)
+	// Restore 落库失败时归还金额并撤销领取记录
+	Restore(packetId uint, userId uint, amount int64) error
+	// Remove 红包领完或过期后清理
+	Remove(packetId uint) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "time"
+
+// SessionManager 登录会话管理，用于使已签发的 jwt 失效（强制下线、封禁）
+type SessionManager interface {
+	// RevokeUserTokens 使该用户当前时间之前签发的 token 全部失效
+	RevokeUserTokens(userId uint) error
+	// IsTokenRevoked 判断某个签发时间的 token 是否已失效
+	IsTokenRevoked(userId uint, issuedAt time.Time) bool
+}
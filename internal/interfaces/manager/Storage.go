@@ -0,0 +1,29 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"go-chat/internal/model"
+	"io"
+	"time"
+)
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("对象不存在")
+
+// Storage 对象存储，具体实现有 MinIO、本地磁盘和内存，由配置 storage.driver 选择
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error)
+	// Get 读取对象，对象不存在时返回 ErrObjectNotFound，调用方负责关闭 reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *model.ObjectInfo, error)
+	// GetRange 读取对象从 offset 开始的 length 字节，length 小于 0 表示读到末尾
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	// Stat 查询对象元信息，对象不存在时返回 nil, nil
+	Stat(ctx context.Context, key string) (*model.ObjectInfo, error)
+	// Presign 生成带有效期的访问地址，method 为 GET 或 PUT
+	Presign(ctx context.Context, method, key string, expires time.Duration) (string, error)
+	// URL 对象的访问地址，指向 /file/object 接口，需鉴权或签名后才能读取
+	URL(key string) string
+}
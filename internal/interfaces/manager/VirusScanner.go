@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// VirusScanner 病毒扫描钩子，新上传的文件保存前扫描，可接入 ClamAV 等扫描服务
+type VirusScanner interface {
+	// Scan 扫描文件内容，发现病毒时返回病毒名称，未发现返回空
+	Scan(ctx context.Context, reader io.Reader, name string) (virus string, err error)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type AdminAuditLogRepositoryInterface interface {
+	Create(log *model.AdminAuditLog, tx ...*gorm.DB) error
+	Page(req request.AdminAuditLogQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.AdminAuditLog], error)
+}
@@ -3,8 +3,29 @@ package interfaces
 import (
 	"go-chat/internal/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type FileRepositoryInterface interface {
 	Create(file *model.File, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.File, error)
+	// GetBySha256 按内容哈希查找文件，用于秒传
+	GetBySha256(sha256 string, tx ...*gorm.DB) (*model.File, error)
+	// GetBySha256AndUser 查找用户自己的该内容文件记录
+	GetBySha256AndUser(sha256 string, userId uint, tx ...*gorm.DB) (*model.File, error)
+	// IsOwnedBy 用户是否上传过该内容的文件
+	IsOwnedBy(sha256 string, userId uint, tx ...*gorm.DB) (bool, error)
+	// IsVisibleTo 文件的任一地址（原图或缩略图）是否被用户可见的消息引用，或被上传者本人用作头像、被群成员用作群头像
+	IsVisibleTo(urls []string, userId uint, tx ...*gorm.DB) (bool, error)
+	// Usage 用户上传的文件数和总大小，秒传复用他人内容的记录不计入
+	Usage(userId uint, tx ...*gorm.DB) (files int64, size int64, err error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	// UpdateBySha256 按内容哈希更新，用于音视频处理结果，秒传复用同一内容的记录一并更新
+	UpdateBySha256(sha256 string, updates map[string]interface{}, tx ...*gorm.DB) error
+	// AdjustRefs 按内容哈希调整引用数，引用数归零时记录孤立时间
+	AdjustRefs(sha256s []string, delta int, tx ...*gorm.DB) error
+	// ListOrphaned 查询在 before 之前就已无引用的文件
+	ListOrphaned(before time.Time, limit int, tx ...*gorm.DB) ([]model.File, error)
+	// DeleteOrphaned 仍无引用时删除文件记录，返回是否删除
+	DeleteOrphaned(id uint, before time.Time, tx ...*gorm.DB) (bool, error)
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type MentionRepositoryInterface interface {
+	CreateBatch(mentions []*model.Mention, tx ...*gorm.DB) error
+	Page(userId uint, req request.MentionQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Mention], error)
+	// MarkRead 标记已读，返回实际标记的条数
+	MarkRead(userId uint, req request.MentionReadRequest, tx ...*gorm.DB) (int64, error)
+	MarkReadByMessageId(userId uint, messageId uint, tx ...*gorm.DB) error
+	DeleteByMessageId(messageId uint, tx ...*gorm.DB) error
+}
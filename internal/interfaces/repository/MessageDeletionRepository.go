@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+)
+
+type MessageDeletionRepositoryInterface interface {
+	// Create 记录用户仅自己删除的消息，已删除的消息忽略
+	Create(userId uint, messageIds []uint, tx ...*gorm.DB) error
+	// SaveClear 记录清空聊天记录的位置，已清空过的会话更新位置
+	SaveClear(clear *model.ConversationClear, tx ...*gorm.DB) error
+}
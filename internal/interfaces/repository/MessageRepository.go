@@ -3,11 +3,22 @@ package interfaces
 import (
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"time"
 )
 
 type MessageRepositoryInterface interface {
 	Save(message *model.Message) (err error)
 	GetById(id uint) (message *model.Message, err error)
+	// GetByIdList 批量获取消息，不存在的 id 直接忽略
+	GetByIdList(ids []uint) ([]*model.Message, error)
 	UpdateFields(id uint, fields map[string]interface{}) (err error)
+	Delete(id uint, tx ...*gorm.DB) (err error)
+	// QueryHistoryMessages 按翻页方向查询会话中游标之前（id 降序）或之后（id 升序）的消息，多取一条用于判断是否还有更多
 	QueryHistoryMessages(userId uint, req *request.QueryMessagesRequest) ([]*model.Message, error)
+	// QueryThreadReplies 按 id 升序查询话题中 cursor 之后用户能看到的回复，多取一条用于判断是否还有更多
+	// targetType、targetId 为话题所在的会话（私聊为对方id），用于排除清空聊天记录之前的回复
+	QueryThreadReplies(userId uint, rootId uint, targetType model.TargetType, targetId uint, cursor uint, limit int) ([]*model.Message, error)
+	// ListExpired 已到销毁时间的限时消息
+	ListExpired(now time.Time, limit int) ([]*model.Message, error)
 }
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type ModerationReviewRepositoryInterface interface {
+	Create(review *model.ModerationReview, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.ModerationReview, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	Page(req request.ModerationReviewQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.ModerationReview], error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+)
+
+type PinRepositoryInterface interface {
+	// Create 置顶消息，已置顶时不重复添加，返回是否新增
+	Create(pin *model.Pin, tx ...*gorm.DB) (bool, error)
+	// Delete 取消置顶，返回是否删除了记录
+	Delete(messageId uint, tx ...*gorm.DB) (bool, error)
+	// CountByConversationForUpdate 加锁统计会话中的置顶数，同一会话的置顶在事务中串行执行
+	CountByConversationForUpdate(conversation string, tx *gorm.DB) (int64, error)
+	// ListByConversation 会话的置顶消息，最近置顶的在前
+	ListByConversation(conversation string, tx ...*gorm.DB) ([]model.Pin, error)
+	// PinnedMessageIds 返回给定消息中已置顶的消息
+	PinnedMessageIds(messageIds []uint, tx ...*gorm.DB) ([]uint, error)
+}
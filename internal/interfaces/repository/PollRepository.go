@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+)
+
+type PollRepositoryInterface interface {
+	Create(poll *model.Poll, options []*model.PollOption, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.Poll, error)
+	// GetByIdForUpdate 加行锁读取，同一投票的投票和结束在事务中串行执行
+	GetByIdForUpdate(id uint, tx *gorm.DB) (*model.Poll, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	GetOptions(pollId uint, tx ...*gorm.DB) ([]model.PollOption, error)
+	// AddVoteCount 选项票数加 delta
+	AddVoteCount(optionIds []uint, delta int, tx ...*gorm.DB) error
+	GetVote(pollId uint, userId uint, tx ...*gorm.DB) (*model.PollVote, error)
+	GetVotes(pollId uint, tx ...*gorm.DB) ([]model.PollVote, error)
+	// SaveVote 新建或更新成员的选票，(poll_id, user_id) 唯一
+	SaveVote(vote *model.PollVote, tx ...*gorm.DB) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+)
+
+type ReactionRepositoryInterface interface {
+	// Create 添加回应，已存在时不重复添加，返回是否新增
+	Create(reaction *model.Reaction, tx ...*gorm.DB) (bool, error)
+	// Delete 取消回应，返回是否删除了记录
+	Delete(messageId uint, userId uint, emoji string, tx ...*gorm.DB) (bool, error)
+	// ListByMessageIds 批量获取多条消息的回应，按回应时间排序
+	ListByMessageIds(messageIds []uint, tx ...*gorm.DB) ([]model.Reaction, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type RedPacketRepositoryInterface interface {
+	Create(packet *model.RedPacket, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.RedPacket, error)
+	// GetByIdForUpdate 加行锁读取，领取和过期退款在事务中串行执行
+	GetByIdForUpdate(id uint, tx *gorm.DB) (*model.RedPacket, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	ListExpired(now time.Time, limit int, tx ...*gorm.DB) ([]model.RedPacket, error)
+	CreateClaim(claim *model.RedPacketClaim, tx ...*gorm.DB) error
+	GetClaim(packetId uint, userId uint, tx ...*gorm.DB) (*model.RedPacketClaim, error)
+	GetClaims(packetId uint, tx ...*gorm.DB) ([]model.RedPacketClaim, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type ReportRepositoryInterface interface {
+	Create(report *model.Report, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.Report, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	// ExistsOpen 举报人对同一对象是否还有未结案的举报
+	ExistsOpen(reporterId uint, targetType model.ReportTargetType, targetId uint, tx ...*gorm.DB) bool
+	Page(req request.ReportQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Report], error)
+}
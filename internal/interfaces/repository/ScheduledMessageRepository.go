@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ScheduledMessageRepositoryInterface interface {
+	Create(scheduled *model.ScheduledMessage, tx ...*gorm.DB) error
+	// ListDue 到达发送时间的待发送消息，以及领取时间早于 staleBefore 的发送中消息（发送中断，如服务重启），按发送时间排序
+	ListDue(now time.Time, staleBefore time.Time, limit int, tx ...*gorm.DB) ([]model.ScheduledMessage, error)
+	// Claim 领取待发送或领取已超时的消息，改为发送中并记录领取时间，返回是否领取成功
+	Claim(id uint, now time.Time, staleBefore time.Time, tx ...*gorm.DB) (bool, error)
+	// Transit 仅当状态为 from 时改为 to，返回是否修改成功，用于取消
+	Transit(id uint, from model.ScheduleStatus, to model.ScheduleStatus, tx ...*gorm.DB) (bool, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	GetById(id uint, tx ...*gorm.DB) (*model.ScheduledMessage, error)
+	Page(senderId uint, req request.ScheduleQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.ScheduledMessage], error)
+}
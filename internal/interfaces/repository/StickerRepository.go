@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type StickerRepositoryInterface interface {
+	CreatePack(pack *model.StickerPack, tx ...*gorm.DB) error
+	GetPackById(id uint, tx ...*gorm.DB) (*model.StickerPack, error)
+	UpdatePack(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	// AddStickerCount 原子增减表情包的表情数量
+	AddStickerCount(packId uint, delta int, tx ...*gorm.DB) error
+	DeletePack(id uint, tx ...*gorm.DB) error
+	PagePacks(userId uint, req request.StickerPackQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.StickerPack], error)
+
+	// Create 群内短代码重复时返回 false
+	Create(sticker *model.Sticker, tx ...*gorm.DB) (bool, error)
+	GetById(id uint, tx ...*gorm.DB) (*model.Sticker, error)
+	GetByIds(ids []uint, tx ...*gorm.DB) ([]model.Sticker, error)
+	Delete(id uint, tx ...*gorm.DB) error
+	DeleteByPackId(packId uint, tx ...*gorm.DB) error
+	ListByPackId(packId uint, tx ...*gorm.DB) ([]model.Sticker, error)
+	ListByGroupId(groupId uint, tx ...*gorm.DB) ([]model.Sticker, error)
+	CountByGroupId(groupId uint, tx ...*gorm.DB) (int64, error)
+
+	// AddFavorite 已收藏时返回 false
+	AddFavorite(userId uint, stickerId uint, tx ...*gorm.DB) (bool, error)
+	RemoveFavorite(userId uint, stickerId uint, tx ...*gorm.DB) (bool, error)
+	// RemoveFavoritesBySticker 表情被删除时清理所有人的收藏
+	RemoveFavoritesBySticker(stickerIds []uint, tx ...*gorm.DB) error
+	CountFavorites(userId uint, tx ...*gorm.DB) (int64, error)
+	// ListFavorites 收藏的表情，最近收藏的在前
+	ListFavorites(userId uint, tx ...*gorm.DB) ([]model.Sticker, error)
+}
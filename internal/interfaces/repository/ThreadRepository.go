@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ThreadRepositoryInterface interface {
+	// AddReply 记录一条新回复，话题不存在时创建，返回更新后的话题
+	AddReply(rootId uint, replyId uint, replyAt time.Time, tx ...*gorm.DB) (*model.Thread, error)
+	// Join 加入话题，已参与时不变
+	Join(rootId uint, userId uint, tx ...*gorm.DB) error
+	// RecordReply 回复者加入话题，自己的回复计为已读；首次参与时之前的回复也视为已读
+	RecordReply(rootId uint, userId uint, replyCount int, tx ...*gorm.DB) error
+	// MarkRead 将话题的全部回复标记为已读
+	MarkRead(rootId uint, userId uint, tx ...*gorm.DB) error
+	GetByRootIds(rootIds []uint, tx ...*gorm.DB) ([]model.Thread, error)
+	// GetParticipants 批量获取话题参与者，按参与时间排序
+	GetParticipants(rootIds []uint, tx ...*gorm.DB) ([]model.ThreadParticipant, error)
+	// PageJoined 查询用户参与的话题，最近有回复的在前
+	PageJoined(userId uint, req request.ThreadQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Thread], error)
+}
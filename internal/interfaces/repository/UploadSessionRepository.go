@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UploadSessionRepositoryInterface interface {
+	Create(session *model.UploadSession, tx ...*gorm.DB) error
+	GetByUploadId(uploadId string, tx ...*gorm.DB) (*model.UploadSession, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+	// ListExpired 查询已过期但仍在上传中的会话
+	ListExpired(now time.Time, limit int, tx ...*gorm.DB) ([]model.UploadSession, error)
+}
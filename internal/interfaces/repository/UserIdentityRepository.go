@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepositoryInterface interface {
+	Create(identity *model.UserIdentity, tx ...*gorm.DB) error
+	GetByProviderAndSubject(provider, subject string, tx ...*gorm.DB) (*model.UserIdentity, error)
+	GetByUserId(userId uint, tx ...*gorm.DB) ([]model.UserIdentity, error)
+	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
+}
@@ -2,15 +2,19 @@
 package interfaces
 
 import (
+	"github.com/lty120712/gorm-pagination/pagination"
 	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
 	"gorm.io/gorm"
+	"time"
 )
 
 // UserRepositoryInterface 用户仓库接口
 type UserRepositoryInterface interface {
 	GetById(id uint, tx ...*gorm.DB) (user *model.User, err error)
 	GetByName(username *string, tx ...*gorm.DB) (user *model.User, err error)
+	ListByEmail(email string, tx ...*gorm.DB) (users []*model.User, err error)
 	Save(user *model.User, tx ...*gorm.DB) (err error)
 	UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error
 	GetNickNamesByIds(ids []uint, tx ...*gorm.DB) (map[uint]string, error)
@@ -20,4 +24,7 @@ type UserRepositoryInterface interface {
 	UpdateHeartbeatTime(userId int64, time int64, tx ...*gorm.DB) error
 
 	GetUsersWithHeartbeatBefore(cutoffTime int64, tx ...*gorm.DB) ([]model.User, error)
+
+	PageForAdmin(req request.AdminUserSearchRequest, tx ...*gorm.DB) (*pagination.PageResult[response.AdminUserVo], error)
+	UnbanExpired(now time.Time, tx ...*gorm.DB) (int64, error)
 }
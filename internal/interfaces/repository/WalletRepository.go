@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+)
+
+type WalletRepositoryInterface interface {
+	GetByUserId(userId uint, tx ...*gorm.DB) (*model.Wallet, error)
+	// Credit 增加余额，钱包不存在时创建
+	Credit(userId uint, amount int64, tx ...*gorm.DB) error
+	// Debit 扣减余额，余额不足时返回 false
+	Debit(userId uint, amount int64, tx ...*gorm.DB) (bool, error)
+	CreateLog(log *model.WalletLog, tx ...*gorm.DB) error
+	PageLogs(userId uint, req request.WalletLogQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.WalletLog], error)
+}
@@ -0,0 +1,29 @@
+package interfacesservice
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+)
+
+// AdminServiceInterface 平台管理后台
+type AdminServiceInterface interface {
+	// IsPlatformAdmin 用户是否为未被封禁的平台管理员
+	IsPlatformAdmin(userId uint) (bool, error)
+	SearchUsers(req request.AdminUserSearchRequest) (*pagination.PageResult[response.AdminUserVo], error)
+	BanUser(operatorId uint, ip string, req request.AdminBanRequest) error
+	UnbanUser(operatorId uint, ip string, req request.AdminUserOperateRequest) error
+	ForceLogout(operatorId uint, ip string, req request.AdminUserOperateRequest) error
+
+	DisableGroup(operatorId uint, ip string, req request.AdminGroupStatusRequest) error
+	EnableGroup(operatorId uint, ip string, req request.AdminGroupStatusRequest) error
+
+	DeleteMessage(operatorId uint, ip string, req request.AdminMessageDeleteRequest) error
+	// RechargeWallet 给用户钱包充值（金额单位为分）
+	RechargeWallet(operatorId uint, ip string, req request.AdminWalletRechargeRequest) error
+
+	AuditLogs(req request.AdminAuditLogQueryRequest) (*pagination.PageResult[model.AdminAuditLog], error)
+	// ReleaseExpiredBans 解除已到期的封禁（定时任务调用）
+	ReleaseExpiredBans() error
+}
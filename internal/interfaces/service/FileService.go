@@ -1,9 +1,57 @@
 package interfacesservice
 
 import (
+	"errors"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"io"
 	"mime/multipart"
 )
 
+var (
+	// ErrFileForbidden 无权访问文件
+	ErrFileForbidden = errors.New("无权访问该文件")
+	// ErrFileTooLarge 超过配置的大小上限
+	ErrFileTooLarge = errors.New("文件过大")
+	// ErrFileTypeNotAllowed 类型不在白名单中或扩展名与内容不符
+	ErrFileTypeNotAllowed = errors.New("不支持的文件类型")
+	// ErrQuotaExceeded 超过用户存储配额
+	ErrQuotaExceeded = errors.New("存储空间不足")
+	// ErrFileInfected 未通过病毒扫描
+	ErrFileInfected = errors.New("文件未通过安全扫描")
+	// ErrUploadProof 秒传校验失败，需要上传完整文件
+	ErrUploadProof = errors.New("秒传校验失败，请上传完整文件")
+)
+
+// FileRefServiceInterface 文件引用，消息、头像等引用或不再引用上传的文件时调用
+type FileRefServiceInterface interface {
+	AddRefs(urls ...string) error
+	ReleaseRefs(urls ...string) error
+	// FileByUrl 按访问地址查找文件，非本存储的地址返回 nil
+	FileByUrl(url string) (*model.File, error)
+	// CheckUsable 校验用户能读取地址中的本存储文件，用于消息等引用文件的场景，无权限时返回 ErrFileForbidden
+	CheckUsable(userId uint, urls ...string) error
+	// CheckOwned 校验地址中的本存储文件是用户自己上传的，用于头像等公开展示的场景，无权限时返回 ErrFileForbidden
+	CheckOwned(userId uint, urls ...string) error
+}
+
 type FileServiceInterface interface {
+	FileRefServiceInterface
 	Upload(id uint, file *multipart.FileHeader) (url string, err error)
+	// AuthorizeObject 校验用户能否读取对象，无权限时返回 ErrFileForbidden
+	AuthorizeObject(userId uint, key string) error
+	StatObject(key string) (*model.ObjectInfo, error)
+	GetObjectRange(key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error)
+	// DownloadUrl 开启下载重定向时返回存储的预签名地址，否则返回空
+	DownloadUrl(key string) (string, error)
+	// SignUrls 为用户有权访问的文件生成短期签名地址，无权访问的地址不返回
+	SignUrls(userId uint, urls []string) (map[string]string, error)
+	PutObject(key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error)
+	InitUpload(userId uint, req request.UploadInitRequest) (*response.UploadSessionVo, error)
+	GetUploadSession(userId uint, uploadId string) (*response.UploadSessionVo, error)
+	CompleteUpload(userId uint, req request.UploadCompleteRequest) (*model.File, error)
+	AbortUpload(userId uint, uploadId string) error
+	// Usage 用户已用存储空间和配额
+	Usage(userId uint) (*response.FileUsageVo, error)
 }
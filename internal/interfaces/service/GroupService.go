@@ -42,5 +42,6 @@ type GroupServiceInterface interface {
 	Dissolve(userId uint, groupId uint) error
 	TransferOwnership(userId uint, req request.GroupTransferRequest) error
 	Mute(userId uint, req request.GroupMuteRequest) error
-	Update(req *request.GroupUpdateRequest) error
+	// Update 更新群组信息，userId 为操作者，群头像只能使用操作者自己上传的文件
+	Update(userId uint, req *request.GroupUpdateRequest) error
 }
@@ -0,0 +1,9 @@
+package interfacesservice
+
+import "go-chat/internal/model"
+
+// LinkPreviewServiceInterface 链接预览
+type LinkPreviewServiceInterface interface {
+	// Unfurl 异步抓取消息中链接的预览，完成后写回消息并推送给会话成员
+	Unfurl(message *model.Message)
+}
@@ -0,0 +1,9 @@
+package interfacesservice
+
+import "go-chat/internal/model"
+
+// MediaServiceInterface 音视频异步处理
+type MediaServiceInterface interface {
+	// Process 处理一个任务：提取时长、生成视频封面或音频波形，更新文件并通知上传者
+	Process(job model.MediaJob) error
+}
@@ -1,6 +1,7 @@
 package interfacesservice
 
 import (
+	"github.com/lty120712/gorm-pagination/pagination"
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
@@ -16,5 +17,46 @@ type MessageServiceInterface interface {
 	ReadMessage(messageId uint, userId uint) error
 
 	QueryMessages(userId uint, req *request.QueryMessagesRequest) (*response.QueryMessagesResponse, error)
+	// Revoke 撤回消息（对所有人删除）
 	Revoke(userId uint, messageId uint) error
+	// DeleteMessages 仅自己删除或对所有人删除消息
+	DeleteMessages(userId uint, req request.MessageDeleteReq) error
+	// ClearConversation 清空会话的聊天记录，只对自己生效
+	ClearConversation(userId uint, req request.ConversationClearReq) error
+	// PlayVoice 标记语音消息已被用户播放
+	PlayVoice(userId uint, messageId uint) error
+	// Forward 转发消息，返回生成的新消息
+	Forward(userId uint, req request.ForwardMessageReq) ([]*response.MessageVo, error)
+	// ForwardRecord 查看合并转发的聊天记录
+	ForwardRecord(userId uint, messageId uint) (*model.ForwardRecord, error)
+	// MentionList 查询“@我的”消息
+	MentionList(userId uint, req request.MentionQueryRequest) (*pagination.PageResult[response.MentionVo], error)
+	// ReadMentions 标记 @ 提醒已读
+	ReadMentions(userId uint, req request.MentionReadRequest) (int64, error)
+	// AddReaction 添加表情回应，返回该消息最新的回应汇总
+	AddReaction(userId uint, req request.ReactionReq) ([]response.ReactionVo, error)
+	// RemoveReaction 取消表情回应，返回该消息最新的回应汇总
+	RemoveReaction(userId uint, req request.ReactionReq) ([]response.ReactionVo, error)
+	// ThreadReplies 分页查看话题的回复
+	ThreadReplies(userId uint, req request.ThreadRepliesRequest) (*response.ThreadRepliesResponse, error)
+	// ThreadList 查询参与的话题
+	ThreadList(userId uint, req request.ThreadQueryRequest) (*pagination.PageResult[response.ThreadVo], error)
+	// ReadThread 标记话题的回复已读
+	ReadThread(userId uint, req request.ThreadReadRequest) error
+	// PinMessage 置顶消息，返回生成的系统消息
+	PinMessage(userId uint, req request.PinMessageReq) (*response.MessageVo, error)
+	// UnpinMessage 取消置顶，返回生成的系统消息
+	UnpinMessage(userId uint, req request.PinMessageReq) (*response.MessageVo, error)
+	// PinnedList 会话的置顶消息
+	PinnedList(userId uint, req request.PinListReq) ([]response.PinVo, error)
+	// ScheduleMessage 创建定时消息
+	ScheduleMessage(userId uint, req request.ScheduleMessageReq) (*model.ScheduledMessage, error)
+	// CancelScheduled 取消尚未发送的定时消息
+	CancelScheduled(userId uint, req request.ScheduleCancelReq) error
+	// ScheduledList 自己的定时消息
+	ScheduledList(userId uint, req request.ScheduleQueryRequest) (*pagination.PageResult[model.ScheduledMessage], error)
+	// DispatchScheduled 发送到期的定时消息，返回处理的条数
+	DispatchScheduled() (int, error)
+	// ExpireMessages 删除到期的限时消息，返回删除的条数
+	ExpireMessages() (int, error)
 }
@@ -0,0 +1,14 @@
+package interfacesservice
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+)
+
+// ModerationServiceInterface 内容审核
+type ModerationServiceInterface interface {
+	Reviews(req request.ModerationReviewQueryRequest) (*pagination.PageResult[model.ModerationReview], error)
+	HandleReview(operatorId uint, ip string, req request.ModerationReviewHandleRequest) error
+	ReloadWords(force bool) error
+}
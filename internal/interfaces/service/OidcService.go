@@ -0,0 +1,12 @@
+package interfacesservice
+
+import "go-chat/internal/model"
+
+// OidcServiceInterface OIDC 单点登录
+type OidcServiceInterface interface {
+	// AuthUrl 生成授权地址，bindUserId 非0 表示绑定到已登录用户，browserKey 为发起授权的浏览器 cookie
+	AuthUrl(providerName string, bindUserId uint, browserKey string) (string, error)
+	// Callback 校验回调携带的浏览器 cookie 与发起授权时一致后返回 jwt
+	Callback(providerName, code, state, browserKey string) (token string, err error)
+	Identities(userId uint) ([]model.UserIdentity, error)
+}
@@ -0,0 +1,16 @@
+package interfacesservice
+
+import (
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+)
+
+type PollServiceInterface interface {
+	// Create 在群中发起投票，返回生成的投票消息
+	Create(userId uint, req request.PollCreateRequest) (*response.MessageVo, error)
+	// Vote 投票，已投过时改为本次选择
+	Vote(userId uint, req request.PollVoteRequest) (*response.PollVo, error)
+	// Close 提前结束投票
+	Close(userId uint, req request.PollCloseRequest) (*response.PollVo, error)
+	Detail(userId uint, pollId uint) (*response.PollVo, error)
+}
@@ -0,0 +1,21 @@
+package interfacesservice
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+)
+
+type RedPacketServiceInterface interface {
+	// Send 发红包：扣款后生成红包消息并推送给接收者
+	Send(userId uint, req request.RedPacketSendRequest) (*response.MessageVo, error)
+	// Claim 抢红包，每人只能领取一次
+	Claim(userId uint, packetId uint) (*response.RedPacketVo, error)
+	Detail(userId uint, packetId uint) (*response.RedPacketVo, error)
+	// RefundExpired 过期未领完的红包退款给发送者，返回处理的红包数
+	RefundExpired() (int, error)
+
+	Wallet(userId uint) (*response.WalletVo, error)
+	WalletLogs(userId uint, req request.WalletLogQueryRequest) (*pagination.PageResult[model.WalletLog], error)
+}
@@ -0,0 +1,16 @@
+package interfacesservice
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+)
+
+// ReportServiceInterface 举报
+type ReportServiceInterface interface {
+	Create(reporterId uint, req request.ReportCreateRequest) (*model.Report, error)
+	MyReports(reporterId uint, req request.ReportQueryRequest) (*pagination.PageResult[model.Report], error)
+
+	List(req request.ReportQueryRequest) (*pagination.PageResult[model.Report], error)
+	Handle(handlerId uint, req request.ReportHandleRequest) error
+}
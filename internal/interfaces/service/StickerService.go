@@ -0,0 +1,29 @@
+package interfacesservice
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"mime/multipart"
+)
+
+type StickerServiceInterface interface {
+	CreatePack(userId uint, req request.StickerPackCreateRequest) (*model.StickerPack, error)
+	// UpdatePack 修改自己的表情包
+	UpdatePack(userId uint, req request.StickerPackUpdateRequest) (*model.StickerPack, error)
+	// DeletePack 删除自己的表情包和包内表情，已发出的消息不受影响
+	DeletePack(userId uint, req request.StickerPackIdRequest) error
+	PackList(userId uint, req request.StickerPackQueryRequest) (*pagination.PageResult[model.StickerPack], error)
+	// SearchPacks 按关键字搜索表情包，匹配表情包名称、简介以及包内表情的名称和关键字
+	SearchPacks(userId uint, req request.StickerPackQueryRequest) (*pagination.PageResult[model.StickerPack], error)
+	PackDetail(userId uint, packId uint) (*response.StickerPackVo, error)
+	// AddSticker 上传图片并添加到自己的表情包，或添加为群自定义表情
+	AddSticker(userId uint, req request.StickerAddRequest, file *multipart.FileHeader) (*model.Sticker, error)
+	RemoveSticker(userId uint, req request.StickerIdRequest) error
+	// GroupStickers 群自定义表情，仅群成员可见
+	GroupStickers(userId uint, groupId uint) ([]model.Sticker, error)
+	AddFavorite(userId uint, req request.StickerIdRequest) error
+	RemoveFavorite(userId uint, req request.StickerIdRequest) error
+	Favorites(userId uint) ([]model.Sticker, error)
+}
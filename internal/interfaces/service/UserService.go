@@ -10,6 +10,7 @@ import (
 type UserServiceInterface interface {
 	Register(username, password, rePassword *string) (err error)
 	Login(username, password *string) (token string, err error)
+	LoginWithUser(user *model.User) (token string, err error)
 	Logout(id uint)
 
 	OnlineStatusChange(id uint, onlineStatus model.OnlineStatus) error
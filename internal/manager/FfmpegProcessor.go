@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-chat/configs"
+	"go-chat/internal/model"
+	"os/exec"
+	"strconv"
+)
+
+// waveformSampleRate 提取波形时的采样率，只需要包络，低采样率即可
+const waveformSampleRate = 1000
+
+// FfmpegProcessor 调用 ffprobe/ffmpeg 命令处理音视频
+type FfmpegProcessor struct {
+	ffmpeg  string
+	ffprobe string
+}
+
+func NewFfmpegProcessor() *FfmpegProcessor {
+	mediaConfig := configs.AppConfig.Media
+	return &FfmpegProcessor{
+		ffmpeg:  defaultString(mediaConfig.Ffmpeg, "ffmpeg"),
+		ffprobe: defaultString(mediaConfig.Ffprobe, "ffprobe"),
+	}
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		Width     uint   `json:"width"`
+		Height    uint   `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+func (p *FfmpegProcessor) Probe(ctx context.Context, path string) (*model.MediaInfo, error) {
+	out, err := runCommand(ctx, p.ffprobe,
+		"-hide_banner",
+		"-loglevel", "error",
+		"-show_entries", "format=duration:stream=codec_type,width,height",
+		"-of", "json",
+		path,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var probeOutput ffprobeOutput
+	if err := json.Unmarshal(out, &probeOutput); err != nil {
+		return nil, fmt.Errorf("ffprobe 输出解析失败: %w", err)
+	}
+	duration, err := strconv.ParseFloat(probeOutput.Format.Duration, 64)
+	if err != nil {
+		return nil, fmt.Errorf("时长转换失败: %w", err)
+	}
+	info := &model.MediaInfo{Duration: duration}
+	for _, stream := range probeOutput.Streams {
+		if stream.CodecType == "video" && stream.Width > 0 {
+			info.Width, info.Height = stream.Width, stream.Height
+			break
+		}
+	}
+	return info, nil
+}
+
+func (p *FfmpegProcessor) Poster(ctx context.Context, path string, at float64) ([]byte, error) {
+	out, err := runCommand(ctx, p.ffmpeg,
+		"-hide_banner",
+		"-loglevel", "error",
+		"-ss", strconv.FormatFloat(at, 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-f", "image2",
+		"-c:v", "mjpeg",
+		"pipe:1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, errors.New("未能截取视频帧")
+	}
+	return out, nil
+}
+
+func (p *FfmpegProcessor) Waveform(ctx context.Context, path string, samples int) ([]int, error) {
+	// 转为单声道 16 位 PCM 输出到标准输出
+	out, err := runCommand(ctx, p.ffmpeg,
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", path,
+		"-ac", "1",
+		"-ar", strconv.Itoa(waveformSampleRate),
+		"-f", "s16le",
+		"pipe:1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return waveformPeaks(out, samples), nil
+}
+
+// waveformPeaks 把 PCM 数据均分为 samples 段，取每段峰值并归一化到 0-100
+func waveformPeaks(pcm []byte, samples int) []int {
+	count := len(pcm) / 2
+	peaks := make([]int, samples)
+	if count == 0 || samples <= 0 {
+		return peaks
+	}
+	raw := make([]int, samples)
+	maxPeak := 0
+	for i := 0; i < count; i++ {
+		value := int(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+		if value < 0 {
+			value = -value
+		}
+		bucket := i * samples / count
+		if value > raw[bucket] {
+			raw[bucket] = value
+			maxPeak = max(maxPeak, value)
+		}
+	}
+	if maxPeak == 0 {
+		return peaks
+	}
+	for i, peak := range raw {
+		peaks[i] = peak * 100 / maxPeak
+	}
+	return peaks
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s 执行失败: %w, 输出: %s", name, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"crypto/sha1"
+	"go-chat/configs"
+	"go-chat/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// floodSweepEvery 每处理多少条消息清理一次不活跃用户的记录
+const floodSweepEvery = 1024
+
+type floodRecord struct {
+	at     time.Time
+	digest [sha1.Size]byte
+}
+
+// FloodChecker 按用户统计时间窗口内的消息数和重复内容数，检测刷屏
+type FloodChecker struct {
+	mu      sync.Mutex
+	records map[int64][]floodRecord
+	checks  int
+	now     func() time.Time
+}
+
+func NewFloodChecker() *FloodChecker {
+	return &FloodChecker{records: make(map[int64][]floodRecord), now: time.Now}
+}
+
+func (c *FloodChecker) Name() string {
+	return "flood"
+}
+
+func (c *FloodChecker) Check(msg *model.Message) *model.ModerationHit {
+	config := configs.AppConfig.Moderation
+	if config.FloodMaxMessages <= 0 && config.FloodMaxDuplicates <= 0 {
+		return nil
+	}
+	window := floodWindow(config)
+	now := c.now()
+	digest := messageDigest(msg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks++
+	if c.checks%floodSweepEvery == 0 {
+		c.sweep(now, window)
+	}
+
+	records := pruneFloodRecords(c.records[msg.SenderId], now.Add(-window))
+	duplicates := 0
+	for _, r := range records {
+		if r.digest == digest {
+			duplicates++
+		}
+	}
+	c.records[msg.SenderId] = append(records, floodRecord{at: now, digest: digest})
+
+	action := model.ParseModerationAction(config.FloodAction, model.ModerationReject)
+	if config.FloodMaxMessages > 0 && len(records) >= config.FloodMaxMessages {
+		return &model.ModerationHit{Action: action, Reason: "发送消息过于频繁"}
+	}
+	if config.FloodMaxDuplicates > 0 && duplicates >= config.FloodMaxDuplicates {
+		return &model.ModerationHit{Action: action, Reason: "重复发送相同内容"}
+	}
+	return nil
+}
+
+// sweep 清理窗口外的记录，避免不活跃用户一直占用内存
+func (c *FloodChecker) sweep(now time.Time, window time.Duration) {
+	for userId, records := range c.records {
+		records = pruneFloodRecords(records, now.Add(-window))
+		if len(records) == 0 {
+			delete(c.records, userId)
+		} else {
+			c.records[userId] = records
+		}
+	}
+}
+
+func pruneFloodRecords(records []floodRecord, since time.Time) []floodRecord {
+	i := 0
+	for i < len(records) && records[i].at.Before(since) {
+		i++
+	}
+	return records[i:]
+}
+
+func floodWindow(config configs.ModerationConfig) time.Duration {
+	window, err := time.ParseDuration(config.FloodWindow)
+	if err != nil || window <= 0 {
+		return 10 * time.Second
+	}
+	return window
+}
+
+func messageDigest(msg *model.Message) [sha1.Size]byte {
+	var sb strings.Builder
+	if msg.Content != nil {
+		for _, part := range *msg.Content {
+			if part == nil || part.Content == nil {
+				continue
+			}
+			sb.WriteString(string(part.Type))
+			sb.WriteByte(0)
+			sb.WriteString(*part.Content)
+			sb.WriteByte(0)
+		}
+	}
+	return sha1.Sum([]byte(sb.String()))
+}
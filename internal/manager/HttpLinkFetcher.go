@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-chat/internal/model"
+	"golang.org/x/net/html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultLinkFetchTimeout  = 5 * time.Second
+	defaultLinkFetchMaxBytes = 512 * 1024
+	defaultLinkUserAgent     = "go-chat-link-preview/1.0"
+	linkFetchMaxRedirects    = 3
+
+	linkTitleMaxLen       = 256
+	linkDescriptionMaxLen = 512
+)
+
+var errPrivateAddress = errors.New("禁止访问内网地址")
+
+// cgnatPrefix 运营商级 NAT 地址段，net.IP.IsPrivate 不包含
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// LinkFetcherOptions 抓取限制，零值使用默认值
+type LinkFetcherOptions struct {
+	Timeout   time.Duration // 单次抓取超时（含重定向），默认 5s
+	MaxBytes  int64         // 最多读取的响应字节数，超出部分丢弃，默认 512KB
+	UserAgent string
+	// AllowPrivate 允许访问回环和内网地址，仅用于测试
+	AllowPrivate bool
+}
+
+// HttpLinkFetcher 通过 HTTP 抓取网页并解析 Open Graph / Twitter Card 元数据
+// 连接建立时检查实际解析到的 IP，重定向和 DNS 重绑定也无法访问内网地址
+type HttpLinkFetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	userAgent string
+}
+
+func NewHttpLinkFetcher(opts LinkFetcherOptions) *HttpLinkFetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultLinkFetchTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultLinkFetchMaxBytes
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaultLinkUserAgent
+	}
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if blockedAddr(addrPort.Addr()) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		// 不走环境变量中的代理，否则连接检查的是代理地址
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &HttpLinkFetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > linkFetchMaxRedirects {
+					return errors.New("重定向次数过多")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return errors.New("不支持的链接协议")
+				}
+				return nil
+			},
+		},
+		maxBytes:  opts.MaxBytes,
+		userAgent: opts.UserAgent,
+	}
+}
+
+func (f *HttpLinkFetcher) Fetch(ctx context.Context, link string) (*model.LinkPreview, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("不支持的链接协议")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("响应状态码 %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("不支持的内容类型 %s", mediaType)
+	}
+	// 元数据在 <head> 中，超出大小限制的部分直接丢弃
+	preview := parseLinkPreview(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+	preview.Url = link
+	return preview, nil
+}
+
+// blockedAddr 回环、内网、链路本地、组播和未指定地址
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		addr.IsUnspecified() || cgnatPrefix.Contains(addr) || (addr.Is4() && addr.As4()[0] == 0)
+}
+
+// parseLinkPreview 解析 <head> 中的 meta 标签，og: 优先于 twitter:，最后使用 <title> 和 description
+func parseLinkPreview(r io.Reader, base *url.URL) *model.LinkPreview {
+	meta := make(map[string]string)
+	var title string
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return buildLinkPreview(meta, title, base)
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return buildLinkPreview(meta, title, base)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return buildLinkPreview(meta, title, base)
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = tokenizer.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(strings.TrimSpace(string(v)))
+					case "content":
+						content = strings.TrimSpace(string(v))
+					}
+				}
+				if key != "" && content != "" {
+					if _, ok := meta[key]; !ok {
+						meta[key] = content
+					}
+				}
+			}
+		}
+	}
+}
+
+func buildLinkPreview(meta map[string]string, title string, base *url.URL) *model.LinkPreview {
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if v := meta[key]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	preview := &model.LinkPreview{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		SiteName:    first("og:site_name"),
+	}
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	preview.Title = cleanLinkText(preview.Title, linkTitleMaxLen)
+	preview.Description = cleanLinkText(preview.Description, linkDescriptionMaxLen)
+	preview.SiteName = cleanLinkText(preview.SiteName, linkTitleMaxLen)
+	if image := first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if ref, err := url.Parse(image); err == nil {
+			abs := base.ResolveReference(ref)
+			if abs.Scheme == "http" || abs.Scheme == "https" {
+				preview.Image = abs.String()
+			}
+		}
+	}
+	return preview
+}
+
+// cleanLinkText 合并空白、去掉非法 UTF-8，并按字符数截断
+func cleanLinkText(s string, maxLen int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	return string([]rune(s)[:maxLen]) + "…"
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/utils/logUtil"
+	"strings"
+	"sync"
+)
+
+// MediaJobQueueInstance 音视频处理任务队列
+var MediaJobQueueInstance interfaces.JobQueue
+
+// InitMediaJobQueue 按配置选择任务队列，rabbitmq 未连接时退回进程内队列
+func InitMediaJobQueue() {
+	mediaConfig := configs.AppConfig.Media
+	if !strings.EqualFold(mediaConfig.Queue, "memory") {
+		if RabbitClient != nil && RabbitClient.conn != nil {
+			MediaJobQueueInstance = NewRabbitJobQueue(
+				defaultString(mediaConfig.Exchange, "media-exchange"),
+				defaultString(mediaConfig.RoutingKey, "media"),
+				defaultString(mediaConfig.QueueName, "media-queue"))
+			return
+		}
+		logUtil.Warnf("rabbitmq 不可用，音视频处理使用进程内队列")
+	}
+	MediaJobQueueInstance = NewMemoryJobQueue(mediaConfig.Workers, mediaConfig.QueueSize)
+}
+
+// RabbitJobQueue 基于 RabbitMQ 的任务队列，多实例部署时任务由任一实例消费
+type RabbitJobQueue struct {
+	exchange   string
+	routingKey string
+	queue      string
+}
+
+func NewRabbitJobQueue(exchange, routingKey, queue string) *RabbitJobQueue {
+	return &RabbitJobQueue{exchange: exchange, routingKey: routingKey, queue: queue}
+}
+
+func (q *RabbitJobQueue) Publish(message interface{}) error {
+	return RabbitClient.SendMessage(q.exchange, q.routingKey, message)
+}
+
+func (q *RabbitJobQueue) Consume(handler func([]byte)) {
+	go runConsumer(q.exchange, q.routingKey, q.queue, handler)
+}
+
+// MemoryJobQueue 进程内任务队列，服务重启时未处理的任务会丢失
+type MemoryJobQueue struct {
+	jobs    chan []byte
+	workers int
+	once    sync.Once
+}
+
+func NewMemoryJobQueue(workers, size int) *MemoryJobQueue {
+	if workers <= 0 {
+		workers = 2
+	}
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemoryJobQueue{jobs: make(chan []byte, size), workers: workers}
+}
+
+// Publish 队列已满时直接返回错误，不阻塞调用方
+func (q *MemoryJobQueue) Publish(message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	select {
+	case q.jobs <- body:
+		return nil
+	default:
+		return errors.New("任务队列已满")
+	}
+}
+
+// Consume 启动固定数量的 worker，重复调用无效
+func (q *MemoryJobQueue) Consume(handler func([]byte)) {
+	q.once.Do(func() {
+		for i := 0; i < q.workers; i++ {
+			go func() {
+				for body := range q.jobs {
+					runJob(handler, body)
+				}
+			}()
+		}
+	})
+}
+
+// runJob 单个任务 panic 不影响 worker 继续处理
+func runJob(handler func([]byte), body []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logUtil.Errorf("任务处理异常: %v", r)
+		}
+	}()
+	handler(body)
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"go-chat/configs"
+	"go-chat/internal/model"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPattern 匹配文本中的链接，支持省略协议的 www. 开头写法
+var urlPattern = regexp.MustCompile(`(?i)\b((?:https?://|www\.)[^\s<>"'，。）]+)`)
+
+// LinkChecker 链接黑名单检查器，按域名（含子域名）匹配
+type LinkChecker struct{}
+
+func NewLinkChecker() *LinkChecker {
+	return &LinkChecker{}
+}
+
+func (c *LinkChecker) Name() string {
+	return "link_blocklist"
+}
+
+func (c *LinkChecker) Check(msg *model.Message) *model.ModerationHit {
+	blocklist := configs.AppConfig.Moderation.LinkBlocklist
+	if len(blocklist) == 0 {
+		return nil
+	}
+	var blocked []string
+	for _, part := range messageTexts(msg) {
+		candidates := urlPattern.FindAllString(*part.Content, -1)
+		if part.Type == model.Link {
+			candidates = append(candidates, *part.Content)
+		}
+		for _, candidate := range candidates {
+			host := linkHost(candidate)
+			if host != "" && hostBlocked(host, blocklist) {
+				blocked = append(blocked, host)
+			}
+		}
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+	return &model.ModerationHit{
+		Action: model.ParseModerationAction(configs.AppConfig.Moderation.LinkAction, model.ModerationReject),
+		Reason: "包含被禁止的链接",
+		Words:  blocked,
+	}
+}
+
+func linkHost(link string) string {
+	link = strings.TrimSpace(link)
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// hostBlocked 域名本身或其任意上级域名在黑名单中即视为命中
+func hostBlocked(host string, blocklist []string) bool {
+	for _, domain := range blocklist {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
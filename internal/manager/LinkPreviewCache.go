@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go-chat/internal/model"
+	"sync"
+	"time"
+)
+
+const linkPreviewKeyPrefix = "link:preview:"
+
+// RedisLinkPreviewCache 基于 Redis 的链接预览缓存，多实例共享抓取结果
+type RedisLinkPreviewCache struct {
+	client *redis.Client
+}
+
+func NewRedisLinkPreviewCache(client *redis.Client) *RedisLinkPreviewCache {
+	return &RedisLinkPreviewCache{client: client}
+}
+
+// linkPreviewKey 链接可能很长，按摘要生成 key
+func linkPreviewKey(link string) string {
+	sum := sha1.Sum([]byte(link))
+	return linkPreviewKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+func (c *RedisLinkPreviewCache) Get(link string) (*model.LinkPreview, bool, error) {
+	bytes, err := c.client.Get(context.Background(), linkPreviewKey(link)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	preview := &model.LinkPreview{}
+	if err := json.Unmarshal(bytes, preview); err != nil {
+		return nil, false, err
+	}
+	return preview, true, nil
+}
+
+func (c *RedisLinkPreviewCache) Set(link string, preview *model.LinkPreview, ttl time.Duration) error {
+	bytes, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), linkPreviewKey(link), bytes, ttl).Err()
+}
+
+// MemoryLinkPreviewCache 进程内链接预览缓存，单机部署和测试使用
+type MemoryLinkPreviewCache struct {
+	mu       sync.Mutex
+	previews map[string]memoryLinkPreview
+}
+
+type memoryLinkPreview struct {
+	preview  *model.LinkPreview
+	expireAt time.Time
+}
+
+func NewMemoryLinkPreviewCache() *MemoryLinkPreviewCache {
+	return &MemoryLinkPreviewCache{previews: make(map[string]memoryLinkPreview)}
+}
+
+func (c *MemoryLinkPreviewCache) Get(link string) (*model.LinkPreview, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.previews[link]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(v.expireAt) {
+		delete(c.previews, link)
+		return nil, false, nil
+	}
+	preview := *v.preview
+	return &preview, true, nil
+}
+
+func (c *MemoryLinkPreviewCache) Set(link string, preview *model.LinkPreview, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// 顺便清理过期预览，避免无限增长
+	for k, v := range c.previews {
+		if now.After(v.expireAt) {
+			delete(c.previews, k)
+		}
+	}
+	copied := *preview
+	c.previews[link] = memoryLinkPreview{preview: &copied, expireAt: now.Add(ttl)}
+	return nil
+}
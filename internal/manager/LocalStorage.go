@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/signUtil"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localMetaSuffix 元信息文件后缀，与对象文件放在同一目录
+const localMetaSuffix = ".meta.json"
+
+type localObjectMeta struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
+// LocalStorage 本地磁盘存储，用于开发环境和单机部署
+type LocalStorage struct {
+	root    string
+	baseUrl string
+}
+
+func NewLocalStorage(root, baseUrl string) (*LocalStorage, error) {
+	if root == "" {
+		root = "uploads"
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: absRoot, baseUrl: strings.TrimSuffix(baseUrl, "/")}, nil
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// 先写临时文件再改名，避免读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("文件大小不一致: 期望 %d, 实际 %d", size, written)
+	}
+	meta := localObjectMeta{ContentType: contentType, ETag: hex.EncodeToString(hash.Sum(nil))}
+	metaBytes, _ := json.Marshal(meta)
+	if err := os.WriteFile(path+localMetaSuffix, metaBytes, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return s.Stat(context.Background(), key)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *model.ObjectInfo, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+func (s *LocalStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info == nil {
+		return nil, nil, interfaces.ErrObjectNotFound
+	}
+	path, _ := s.path(key)
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, interfaces.ErrObjectNotFound
+		}
+		return nil, nil, err
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+	if length >= 0 {
+		return limitReadCloser{Reader: io.LimitReader(file, length), Closer: file}, info, nil
+	}
+	return file, info, nil
+}
+
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(path + localMetaSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Stat(_ context.Context, key string) (*model.ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	info := &model.ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: "application/octet-stream",
+		ModTime:     stat.ModTime(),
+	}
+	if metaBytes, err := os.ReadFile(path + localMetaSuffix); err == nil {
+		var meta localObjectMeta
+		if json.Unmarshal(metaBytes, &meta) == nil {
+			if meta.ContentType != "" {
+				info.ContentType = meta.ContentType
+			}
+			info.ETag = meta.ETag
+		}
+	}
+	return info, nil
+}
+
+func (s *LocalStorage) Presign(_ context.Context, method, key string, expires time.Duration) (string, error) {
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	return signUtil.PresignURL(s.baseUrl, method, key, expires), nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseUrl + "/" + key
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(key, localMetaSuffix) {
+		return "", fmt.Errorf("非法的对象路径: %s", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"container/list"
+	"go-chat/internal/model"
+	"sync"
+	"time"
+)
+
+const defaultRateLimitMaxKeys = 100000
+
+type memoryWindow struct {
+	key      string
+	hits     []time.Time // 窗口内请求时间，按时间递增
+	window   time.Duration
+	lastSeen time.Time
+}
+
+// MemoryRateLimiter 单实例内存滑动窗口限流，按最近使用顺序淘汰，key 数量有上限
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	maxKeys int
+	lru     *list.List // 队首为最近使用
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+func NewMemoryRateLimiter(maxKeys int) *MemoryRateLimiter {
+	if maxKeys <= 0 {
+		maxKeys = defaultRateLimitMaxKeys
+	}
+	return &MemoryRateLimiter{
+		maxKeys: maxKeys,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(key string, limit int, window time.Duration) (*model.RateLimitResult, error) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.evictExpired(now)
+
+	var entry *memoryWindow
+	if element, ok := l.entries[key]; ok {
+		entry = element.Value.(*memoryWindow)
+		l.lru.MoveToFront(element)
+	} else {
+		entry = &memoryWindow{key: key}
+		l.entries[key] = l.lru.PushFront(entry)
+		for len(l.entries) > l.maxKeys {
+			l.removeElement(l.lru.Back())
+		}
+	}
+	entry.window = window
+	entry.lastSeen = now
+
+	since := now.Add(-window)
+	i := 0
+	for i < len(entry.hits) && !entry.hits[i].After(since) {
+		i++
+	}
+	entry.hits = entry.hits[i:]
+
+	result := &model.RateLimitResult{Limit: limit}
+	if len(entry.hits) < limit {
+		entry.hits = append(entry.hits, now)
+		result.Allowed = true
+	}
+	result.Remaining = limit - len(entry.hits)
+	if len(entry.hits) > 0 {
+		result.ResetAfter = entry.hits[0].Add(window).Sub(now)
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.ResetAfter
+	}
+	return result, nil
+}
+
+// Len 当前保留的 key 数量
+func (l *MemoryRateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// evictExpired 从最久未使用的一端淘汰窗口已过期的 key
+func (l *MemoryRateLimiter) evictExpired(now time.Time) {
+	for element := l.lru.Back(); element != nil; element = l.lru.Back() {
+		entry := element.Value.(*memoryWindow)
+		if now.Sub(entry.lastSeen) <= entry.window {
+			return
+		}
+		l.removeElement(element)
+	}
+}
+
+func (l *MemoryRateLimiter) removeElement(element *list.Element) {
+	entry := l.lru.Remove(element).(*memoryWindow)
+	delete(l.entries, entry.key)
+}
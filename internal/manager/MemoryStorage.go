@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/signUtil"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data []byte
+	info model.ObjectInfo
+}
+
+// MemoryStorage 内存存储，用于测试，进程退出后数据丢失
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+	baseUrl string
+}
+
+func NewMemoryStorage(baseUrl string) *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]*memoryObject), baseUrl: strings.TrimSuffix(baseUrl, "/")}
+}
+
+func (s *MemoryStorage) Put(_ context.Context, key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error) {
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return nil, fmt.Errorf("文件大小不一致: 期望 %d, 实际 %d", size, len(data))
+	}
+	sum := md5.Sum(data)
+	object := &memoryObject{
+		data: data,
+		info: model.ObjectInfo{
+			Key:         key,
+			Size:        int64(len(data)),
+			ContentType: contentType,
+			ETag:        hex.EncodeToString(sum[:]),
+			ModTime:     time.Now(),
+		},
+	}
+	s.mu.Lock()
+	s.objects[key] = object
+	s.mu.Unlock()
+	info := object.info
+	return &info, nil
+}
+
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, *model.ObjectInfo, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+func (s *MemoryStorage) GetRange(_ context.Context, key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error) {
+	s.mu.RLock()
+	object, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil, interfaces.ErrObjectNotFound
+	}
+	data := object.data[min(offset, int64(len(object.data))):]
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	info := object.info
+	return io.NopCloser(bytes.NewReader(data)), &info, nil
+}
+
+func (s *MemoryStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.objects, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) Stat(_ context.Context, key string) (*model.ObjectInfo, error) {
+	s.mu.RLock()
+	object, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	info := object.info
+	return &info, nil
+}
+
+func (s *MemoryStorage) Presign(_ context.Context, method, key string, expires time.Duration) (string, error) {
+	key, err := cleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	return signUtil.PresignURL(s.baseUrl, method, key, expires), nil
+}
+
+func (s *MemoryStorage) URL(key string) string {
+	return s.baseUrl + "/" + key
+}
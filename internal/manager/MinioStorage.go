@@ -0,0 +1,151 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/logUtil"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MinioStorage MinIO 对象存储
+type MinioStorage struct {
+	client  *minio.Client
+	bucket  string
+	baseUrl string
+}
+
+// NewMinioStorage 连接 MinIO，连接失败只记录日志，不影响服务启动
+// baseUrl 为服务对外的文件访问地址，存储桶不需要公开读
+func NewMinioStorage(minioConfig configs.MinioConfig, baseUrl string) *MinioStorage {
+	storage := &MinioStorage{
+		bucket:  minioConfig.Bucket,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+	}
+	client, err := minio.New(minioConfig.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(minioConfig.AccessKey, minioConfig.SecretKey, ""),
+		Secure: false,
+	})
+	if err != nil {
+		logUtil.Errorf("MinIO 初始化失败: %v", err)
+		return storage
+	}
+	_, err = client.ListBuckets(context.Background())
+	if err != nil {
+		logUtil.Errorf("MinIO 连接失败: %v", err)
+		return storage
+	}
+	storage.client = client
+	logUtil.Infof("MinIO 初始化成功")
+	return storage
+}
+
+func (s *MinioStorage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error) {
+	if s.client == nil {
+		return nil, errors.New("MinIO 未连接")
+	}
+	info, err := s.client.PutObject(ctx, s.bucket, key, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.ObjectInfo{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: contentType,
+		ETag:        info.ETag,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *MinioStorage) Get(ctx context.Context, key string) (io.ReadCloser, *model.ObjectInfo, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+func (s *MinioStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info == nil {
+		return nil, nil, interfaces.ErrObjectNotFound
+	}
+	opts := minio.GetObjectOptions{}
+	if offset > 0 || length >= 0 {
+		end := int64(0)
+		if length >= 0 {
+			end = offset + length - 1
+		}
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, nil, err
+		}
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, info, nil
+}
+
+func (s *MinioStorage) Delete(ctx context.Context, key string) error {
+	if s.client == nil {
+		return errors.New("MinIO 未连接")
+	}
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *MinioStorage) Stat(ctx context.Context, key string) (*model.ObjectInfo, error) {
+	if s.client == nil {
+		return nil, errors.New("MinIO 未连接")
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &model.ObjectInfo{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *MinioStorage) Presign(ctx context.Context, method, key string, expires time.Duration) (string, error) {
+	if s.client == nil {
+		return "", errors.New("MinIO 未连接")
+	}
+	var (
+		presigned *url.URL
+		err       error
+	)
+	switch method {
+	case http.MethodGet:
+		presigned, err = s.client.PresignedGetObject(ctx, s.bucket, key, expires, nil)
+	case http.MethodPut:
+		presigned, err = s.client.PresignedPutObject(ctx, s.bucket, key, expires)
+	default:
+		return "", fmt.Errorf("不支持的预签名方法: %s", method)
+	}
+	if err != nil {
+		return "", err
+	}
+	return presigned.String(), nil
+}
+
+func (s *MinioStorage) URL(key string) string {
+	return s.baseUrl + "/" + key
+}
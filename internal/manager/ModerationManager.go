@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"errors"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/logUtil"
+	"sync"
+)
+
+// ModerationManager 内容审核流水线：依次执行所有检查器，取最严格的动作作为最终结果
+type ModerationManager struct {
+	mu       sync.RWMutex
+	checkers []interfaces.ModerationChecker
+}
+
+var ModerationManagerInstance *ModerationManager
+
+// InitModerationManager 初始化内置检查器：敏感词、链接黑名单、刷屏检测
+func InitModerationManager() {
+	ModerationManagerInstance = NewModerationManager(
+		NewSensitiveWordChecker(),
+		NewLinkChecker(),
+		NewFloodChecker(),
+	)
+	if err := ModerationManagerInstance.Reload(true); err != nil {
+		logUtil.Errorf("内容审核词表加载失败: %v", err)
+	}
+}
+
+func NewModerationManager(checkers ...interfaces.ModerationChecker) *ModerationManager {
+	return &ModerationManager{checkers: checkers}
+}
+
+// RegisterChecker 注册自定义检查器
+func (m *ModerationManager) RegisterChecker(checker interfaces.ModerationChecker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkers = append(m.checkers, checker)
+}
+
+// Moderate 审核消息，打码动作会直接修改 msg 的内容
+func (m *ModerationManager) Moderate(msg *model.Message) *model.ModerationResult {
+	result := &model.ModerationResult{Action: model.ModerationPass}
+	if !configs.AppConfig.Moderation.Enabled || msg == nil || msg.Content == nil {
+		return result
+	}
+	result.OriginalContent = copyMessageParts(msg.Content)
+
+	m.mu.RLock()
+	checkers := m.checkers
+	m.mu.RUnlock()
+	for _, checker := range checkers {
+		hit := checker.Check(msg)
+		if hit == nil || hit.Action == model.ModerationPass {
+			continue
+		}
+		hit.Checker = checker.Name()
+		result.Hits = append(result.Hits, *hit)
+		if hit.Action > result.Action {
+			result.Action = hit.Action
+		}
+	}
+	return result
+}
+
+// Reload 重新加载支持热加载的检查器
+func (m *ModerationManager) Reload(force bool) error {
+	m.mu.RLock()
+	checkers := m.checkers
+	m.mu.RUnlock()
+	var errs []error
+	for _, checker := range checkers {
+		if reloadable, ok := checker.(interfaces.ReloadableChecker); ok {
+			if err := reloadable.Reload(force); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// messageTexts 返回消息中需要审核的文本片段（文本和链接）
+func messageTexts(msg *model.Message) []*model.MessagePart {
+	if msg.Content == nil {
+		return nil
+	}
+	parts := make([]*model.MessagePart, 0, len(*msg.Content))
+	for _, part := range *msg.Content {
+		if part == nil || part.Content == nil {
+			continue
+		}
+		if part.Type == model.Text || part.Type == model.Link {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func copyMessageParts(content *model.MessagePartList) *model.MessagePartList {
+	copied := make(model.MessagePartList, 0, len(*content))
+	for _, part := range *content {
+		if part == nil {
+			continue
+		}
+		p := &model.MessagePart{Type: part.Type, UserId: part.UserId}
+		if part.Content != nil {
+			text := *part.Content
+			p.Content = &text
+		}
+		copied = append(copied, p)
+	}
+	return &copied
+}
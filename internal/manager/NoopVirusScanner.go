@@ -0,0 +1,17 @@
+package manager
+
+import (
+	"context"
+	"io"
+)
+
+// NoopVirusScanner 不做扫描，未接入扫描服务时使用
+type NoopVirusScanner struct{}
+
+func NewNoopVirusScanner() *NoopVirusScanner {
+	return &NoopVirusScanner{}
+}
+
+func (s *NoopVirusScanner) Scan(context.Context, io.Reader, string) (string, error) {
+	return "", nil
+}
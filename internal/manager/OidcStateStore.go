@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go-chat/internal/model"
+	"sync"
+	"time"
+)
+
+const oidcStateKeyPrefix = "oidc:state:"
+
+// RedisOidcStateStore 基于 Redis 的 OIDC 状态存储，多实例部署时使用
+type RedisOidcStateStore struct {
+	client *redis.Client
+}
+
+func NewRedisOidcStateStore(client *redis.Client) *RedisOidcStateStore {
+	return &RedisOidcStateStore{client: client}
+}
+
+func (s *RedisOidcStateStore) Save(state string, data *model.OidcState, ttl time.Duration) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), oidcStateKeyPrefix+state, bytes, ttl).Err()
+}
+
+func (s *RedisOidcStateStore) Take(state string) (*model.OidcState, error) {
+	bytes, err := s.client.GetDel(context.Background(), oidcStateKeyPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := &model.OidcState{}
+	if err := json.Unmarshal(bytes, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MemoryOidcStateStore 进程内 OIDC 状态存储，单机部署和测试使用
+type MemoryOidcStateStore struct {
+	mu     sync.Mutex
+	states map[string]memoryOidcState
+}
+
+type memoryOidcState struct {
+	data     *model.OidcState
+	expireAt time.Time
+}
+
+func NewMemoryOidcStateStore() *MemoryOidcStateStore {
+	return &MemoryOidcStateStore{states: make(map[string]memoryOidcState)}
+}
+
+func (s *MemoryOidcStateStore) Save(state string, data *model.OidcState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// 顺便清理过期状态，避免无限增长
+	for k, v := range s.states {
+		if now.After(v.expireAt) {
+			delete(s.states, k)
+		}
+	}
+	s.states[state] = memoryOidcState{data: data, expireAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryOidcStateStore) Take(state string) (*model.OidcState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.states[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.states, state)
+	if time.Now().After(v.expireAt) {
+		return nil, nil
+	}
+	return v.data, nil
+}
@@ -0,0 +1,143 @@
+package manager
+
+import (
+	"github.com/redis/go-redis/v9"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/logUtil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitManager 根据配置规则判定 http 路由和 ws 事件是否限流，规则每次从配置读取，支持热更新
+type RateLimitManager struct {
+	limiter interfaces.RateLimiter
+}
+
+var RateLimitManagerInstance *RateLimitManager
+
+// InitRateLimitManager 按配置选择存储后端，redis 适用于多实例部署
+func InitRateLimitManager(client *redis.Client) {
+	rateConfig := configs.AppConfig.Rate
+	var limiter interfaces.RateLimiter
+	if strings.EqualFold(rateConfig.Backend, "redis") && client != nil {
+		limiter = NewRedisRateLimiter(client)
+	} else {
+		limiter = NewMemoryRateLimiter(rateConfig.MaxKeys)
+	}
+	RateLimitManagerInstance = NewRateLimitManager(limiter)
+}
+
+func NewRateLimitManager(limiter interfaces.RateLimiter) *RateLimitManager {
+	return &RateLimitManager{limiter: limiter}
+}
+
+// AllowRoute 依次检查用户总限流、接口总限流和匹配的路由规则，返回最严格的结果
+// path 为 gin 路由模板，userKey 为登录用户ID（未登录为空）
+func (m *RateLimitManager) AllowRoute(method, path, userKey, ip string) *model.RateLimitResult {
+	rateConfig := configs.AppConfig.Rate
+	identity := userKey
+	if identity == "" {
+		identity = "ip:" + ip
+	}
+	var final *model.RateLimitResult
+	if rateConfig.UserLimit > 0 {
+		final = mergeRateLimitResult(final, m.allow("user:"+identity, rateConfig.UserLimit, time.Second))
+	}
+	if rateConfig.ApiLimit > 0 && path != "" {
+		final = mergeRateLimitResult(final, m.allow("api:"+method+":"+path, rateConfig.ApiLimit, time.Second))
+	}
+	for i, rule := range rateConfig.Rules {
+		if rule.Path == "" || rule.Limit <= 0 || !matchRoute(rule, method, path) {
+			continue
+		}
+		key := "route:" + strconv.Itoa(i) + ":" + rule.Method + ":" + rule.Path + ":" + ruleDimension(rule, userKey, ip)
+		final = mergeRateLimitResult(final, m.allow(key, rule.Limit, ruleWindow(rule)))
+	}
+	return final
+}
+
+// AllowEvent 按 ws 事件规则限流，ws 连接一定是已登录用户，按用户计数
+func (m *RateLimitManager) AllowEvent(event string, userId int64) *model.RateLimitResult {
+	var final *model.RateLimitResult
+	userKey := strconv.FormatInt(userId, 10)
+	for i, rule := range configs.AppConfig.Rate.Rules {
+		if rule.Event == "" || rule.Limit <= 0 || (rule.Event != "*" && rule.Event != event) {
+			continue
+		}
+		key := "ws:" + strconv.Itoa(i) + ":" + rule.Event + ":" + ruleDimension(rule, userKey, "")
+		final = mergeRateLimitResult(final, m.allow(key, rule.Limit, ruleWindow(rule)))
+	}
+	return final
+}
+
+// allow 后端异常时放行，避免 Redis 故障导致全站不可用
+func (m *RateLimitManager) allow(key string, limit int, window time.Duration) *model.RateLimitResult {
+	result, err := m.limiter.Allow(key, limit, window)
+	if err != nil {
+		logUtil.Errorf("限流判定失败(%s): %v", key, err)
+		return nil
+	}
+	return result
+}
+
+// mergeRateLimitResult 多条规则同时生效时：有拒绝取等待最久的拒绝，否则取剩余次数最少的
+func mergeRateLimitResult(current, next *model.RateLimitResult) *model.RateLimitResult {
+	if current == nil {
+		return next
+	}
+	if next == nil {
+		return current
+	}
+	if current.Allowed != next.Allowed {
+		if !next.Allowed {
+			return next
+		}
+		return current
+	}
+	if !next.Allowed {
+		if next.RetryAfter > current.RetryAfter {
+			return next
+		}
+		return current
+	}
+	if next.Remaining < current.Remaining {
+		return next
+	}
+	return current
+}
+
+func matchRoute(rule configs.RateLimitRule, method, path string) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return rule.Path == path
+}
+
+func ruleDimension(rule configs.RateLimitRule, userKey, ip string) string {
+	switch strings.ToLower(rule.By) {
+	case "global":
+		return "global"
+	case "ip":
+		if ip != "" {
+			return "ip:" + ip
+		}
+	}
+	if userKey != "" {
+		return "user:" + userKey
+	}
+	return "ip:" + ip
+}
+
+func ruleWindow(rule configs.RateLimitRule) time.Duration {
+	window, err := time.ParseDuration(rule.Window)
+	if err != nil || window <= 0 {
+		return time.Second
+	}
+	return window
+}
@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go-chat/configs"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	"sync"
+	"time"
+)
+
+const redPacketKeyPrefix = "redpacket:"
+
+// takeRedPacketScript 检查是否领取过、弹出一份金额并记录领取人
+// 返回 -1 已领取过，-2 已领完，否则为领取金额
+var takeRedPacketScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 1 then
+	return -1
+end
+local ttl = redis.call('PTTL', KEYS[1])
+local amount = redis.call('LPOP', KEYS[1])
+if not amount then
+	return -2
+end
+redis.call('SADD', KEYS[2], ARGV[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return tonumber(amount)
+`)
+
+// restoreRedPacketScript 归还金额并撤销领取记录，红包已被清理时不再写回；金额列表领空后被删除时沿用领取记录的过期时间
+var restoreRedPacketScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[2])
+if redis.call('SREM', KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('LPUSH', KEYS[1], ARGV[2])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// RedisRedPacketStore 基于 Redis 的红包金额缓存，多实例部署时共享
+type RedisRedPacketStore struct {
+	client *redis.Client
+}
+
+func NewRedisRedPacketStore(client *redis.Client) *RedisRedPacketStore {
+	return &RedisRedPacketStore{client: client}
+}
+
+func redPacketKeys(packetId uint) []string {
+	return []string{
+		fmt.Sprintf("%s%d:amounts", redPacketKeyPrefix, packetId),
+		fmt.Sprintf("%s%d:claimed", redPacketKeyPrefix, packetId),
+	}
+}
+
+func (s *RedisRedPacketStore) Put(packetId uint, amounts []int64, ttl time.Duration) error {
+	keys := redPacketKeys(packetId)
+	values := make([]interface{}, len(amounts))
+	for i, amount := range amounts {
+		values[i] = amount
+	}
+	pipe := s.client.TxPipeline()
+	pipe.Del(context.Background(), keys...)
+	pipe.RPush(context.Background(), keys[0], values...)
+	pipe.Expire(context.Background(), keys[0], ttl)
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (s *RedisRedPacketStore) Take(packetId uint, userId uint) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	amount, err := takeRedPacketScript.Run(ctx, s.client, redPacketKeys(packetId), userId).Int64()
+	if err != nil {
+		return 0, err
+	}
+	switch amount {
+	case -1:
+		return 0, interfacemanager.ErrRedPacketClaimed
+	case -2:
+		return 0, interfacemanager.ErrRedPacketEmpty
+	}
+	return amount, nil
+}
+
+func (s *RedisRedPacketStore) Restore(packetId uint, userId uint, amount int64) error {
+	return restoreRedPacketScript.Run(context.Background(), s.client, redPacketKeys(packetId), userId, amount).Err()
+}
+
+func (s *RedisRedPacketStore) Remove(packetId uint) error {
+	return s.client.Del(context.Background(), redPacketKeys(packetId)...).Err()
+}
+
+// MemoryRedPacketStore 进程内红包金额缓存，单机部署和测试使用
+type MemoryRedPacketStore struct {
+	mu      sync.Mutex
+	packets map[uint]*memoryRedPacket
+}
+
+type memoryRedPacket struct {
+	amounts  []int64
+	claimed  map[uint]struct{}
+	expireAt time.Time
+}
+
+func NewMemoryRedPacketStore() *MemoryRedPacketStore {
+	return &MemoryRedPacketStore{packets: make(map[uint]*memoryRedPacket)}
+}
+
+func (s *MemoryRedPacketStore) Put(packetId uint, amounts []int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packets[packetId] = &memoryRedPacket{
+		amounts:  append([]int64{}, amounts...),
+		claimed:  make(map[uint]struct{}),
+		expireAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *MemoryRedPacketStore) Take(packetId uint, userId uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	packet := s.packets[packetId]
+	if packet != nil && time.Now().After(packet.expireAt) {
+		delete(s.packets, packetId)
+		packet = nil
+	}
+	if packet == nil {
+		return 0, interfacemanager.ErrRedPacketEmpty
+	}
+	if _, ok := packet.claimed[userId]; ok {
+		return 0, interfacemanager.ErrRedPacketClaimed
+	}
+	if len(packet.amounts) == 0 {
+		return 0, interfacemanager.ErrRedPacketEmpty
+	}
+	amount := packet.amounts[0]
+	packet.amounts = packet.amounts[1:]
+	packet.claimed[userId] = struct{}{}
+	return amount, nil
+}
+
+func (s *MemoryRedPacketStore) Restore(packetId uint, userId uint, amount int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if packet := s.packets[packetId]; packet != nil {
+		packet.amounts = append([]int64{amount}, packet.amounts...)
+		delete(packet.claimed, userId)
+	}
+	return nil
+}
+
+func (s *MemoryRedPacketStore) Remove(packetId uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.packets, packetId)
+	return nil
+}
+
+// NewRedPacketStore 按配置选择红包金额缓存
+func NewRedPacketStore(client *redis.Client) interfacemanager.RedPacketStore {
+	if configs.AppConfig.RedPacket.Backend == "memory" {
+		return NewMemoryRedPacketStore()
+	}
+	return NewRedisRedPacketStore(client)
+}
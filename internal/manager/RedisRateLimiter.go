@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/idUtil"
+	"time"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// slidingWindowScript 基于有序集合的滑动窗口：清理窗口外记录、计数、未超限时记录本次请求
+// 返回 {是否放行, 窗口内请求数, 窗口内最早请求的时间(ms)}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local oldestScore = now
+if oldest[2] then
+	oldestScore = tonumber(oldest[2])
+end
+return {allowed, count, oldestScore}
+`)
+
+// RedisRateLimiter 基于 Redis 的滑动窗口限流，多实例部署时共享计数
+type RedisRateLimiter struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client}
+}
+
+func (l *RedisRateLimiter) Allow(key string, limit int, window time.Duration) (*model.RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	now := time.Now().UnixMilli()
+	windowMs := window.Milliseconds()
+	if windowMs <= 0 {
+		windowMs = 1
+	}
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{rateLimitKeyPrefix + key},
+		now, windowMs, limit, fmt.Sprintf("%d-%s", now, idUtil.GenerateId())).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("限流脚本返回值异常: %v", values)
+	}
+	result := &model.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  limit - int(values[1]),
+		ResetAfter: time.Duration(values[2]+windowMs-now) * time.Millisecond,
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.ResetAfter
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"bufio"
+	"fmt"
+	"go-chat/configs"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/acUtil"
+	"go-chat/internal/utils/logUtil"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SensitiveWordChecker 基于 Aho-Corasick 的敏感词检查器，词表来自配置和词表文件，支持热加载
+type SensitiveWordChecker struct {
+	automaton atomic.Pointer[acUtil.Automaton]
+	mu        sync.Mutex
+	// signature 上次加载时的配置词表 + 文件修改时间，变化后才重建
+	signature string
+}
+
+func NewSensitiveWordChecker() *SensitiveWordChecker {
+	checker := &SensitiveWordChecker{}
+	checker.automaton.Store(acUtil.New(nil))
+	return checker
+}
+
+func (c *SensitiveWordChecker) Name() string {
+	return "sensitive_word"
+}
+
+func (c *SensitiveWordChecker) Check(msg *model.Message) *model.ModerationHit {
+	automaton := c.automaton.Load()
+	if automaton.Size() == 0 {
+		return nil
+	}
+	action := model.ParseModerationAction(configs.AppConfig.Moderation.WordAction, model.ModerationMask)
+	words := make(map[string]struct{})
+	for _, part := range messageTexts(msg) {
+		var matches []acUtil.Match
+		if action == model.ModerationMask {
+			var masked string
+			masked, matches = automaton.Mask(*part.Content, '*')
+			*part.Content = masked
+		} else {
+			matches = automaton.FindAll(*part.Content)
+		}
+		for _, m := range matches {
+			words[m.Word] = struct{}{}
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	hit := &model.ModerationHit{Action: action, Reason: "包含敏感词"}
+	for word := range words {
+		hit.Words = append(hit.Words, word)
+	}
+	return hit
+}
+
+// Reload 重新加载词表，force 为 false 时配置和文件均未变化则跳过
+func (c *SensitiveWordChecker) Reload(force bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	config := configs.AppConfig.Moderation
+	signature := wordListSignature(config)
+	if !force && signature == c.signature {
+		return nil
+	}
+
+	words := append([]string{}, config.Words...)
+	for _, path := range config.WordFiles {
+		fileWords, err := readWordFile(path)
+		if err != nil {
+			// 文件读取失败时保留旧词表，下次检查时重试
+			return fmt.Errorf("读取敏感词文件 %s 失败: %w", path, err)
+		}
+		words = append(words, fileWords...)
+	}
+	automaton := acUtil.New(words)
+	c.automaton.Store(automaton)
+	c.signature = signature
+	logUtil.Infof("敏感词表已加载，共 %d 个词", automaton.Size())
+	return nil
+}
+
+func wordListSignature(config configs.ModerationConfig) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Join(config.Words, "\n"))
+	for _, path := range config.WordFiles {
+		sb.WriteString("\n@" + path)
+		if info, err := os.Stat(path); err == nil {
+			sb.WriteString(fmt.Sprintf(":%d:%d", info.Size(), info.ModTime().UnixNano()))
+		}
+	}
+	return sb.String()
+}
+
+// readWordFile 读取词表文件，每行一个词，忽略空行和 # 开头的注释
+func readWordFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
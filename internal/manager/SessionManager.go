@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/utils/jwtUtil"
+	"go-chat/internal/utils/logUtil"
+	"strconv"
+	"time"
+)
+
+const (
+	tokenRevokeKeyPrefix = "auth:revoke:"
+	// legacyRevokeMilliBound 小于该值的失效时间是按秒记录的（毫秒时间戳早已超过 1e12）
+	legacyRevokeMilliBound = 1e12
+)
+
+// SessionManager 基于 Redis 记录每个用户的 token 失效时间点（毫秒），签发时间不晚于该时间点的 token 视为失效
+type SessionManager struct {
+	client *redis.Client
+}
+
+var SessionManagerInstance interfaces.SessionManager
+
+var (
+	ErrTokenInvalid = errors.New("登录凭证无效或已过期")
+	ErrTokenRevoked = errors.New("登录已失效，请重新登录")
+)
+
+func InitSessionManager(client *redis.Client) {
+	SessionManagerInstance = &SessionManager{client: client}
+}
+
+// AuthenticateToken 校验 jwt，并检查是否因强制下线或封禁被吊销，返回用户ID
+func AuthenticateToken(tokenStr string) (uint, error) {
+	claims, err := jwtUtil.ParseJWT(tokenStr)
+	if err != nil {
+		return 0, ErrTokenInvalid
+	}
+	if SessionManagerInstance != nil && SessionManagerInstance.IsTokenRevoked(claims.ID, claims.IssuedTime()) {
+		return 0, ErrTokenRevoked
+	}
+	return claims.ID, nil
+}
+
+func (m *SessionManager) RevokeUserTokens(userId uint) error {
+	// 失效记录只需保留到最晚签发的 token 过期为止
+	ttl, err := time.ParseDuration(configs.AppConfig.Jwt.ExpirationTime)
+	if err != nil {
+		ttl = 24 * time.Hour
+	}
+	key := fmt.Sprintf("%s%d", tokenRevokeKeyPrefix, userId)
+	return m.client.Set(context.Background(), key, time.Now().UnixMilli(), ttl).Err()
+}
+
+func (m *SessionManager) IsTokenRevoked(userId uint, issuedAt time.Time) bool {
+	key := fmt.Sprintf("%s%d", tokenRevokeKeyPrefix, userId)
+	value, err := m.client.Get(context.Background(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+	if err != nil {
+		// Redis 不可用时放行，避免所有请求被拒绝
+		logUtil.Warnf("查询 token 失效记录失败: %v", err)
+		return false
+	}
+	revokedAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	// 兼容按秒记录的旧失效时间，视为该秒内签发的 token 全部失效
+	if revokedAt < legacyRevokeMilliBound {
+		revokedAt = revokedAt*1000 + 999
+	}
+	return issuedAt.UnixMilli() <= revokedAt
+}
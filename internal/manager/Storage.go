@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"fmt"
+	"go-chat/configs"
+	interfaces "go-chat/internal/interfaces/manager"
+	"go-chat/internal/utils/logUtil"
+	"strings"
+)
+
+const (
+	StorageDriverMinio  = "minio"
+	StorageDriverLocal  = "local"
+	StorageDriverMemory = "memory"
+)
+
+var StorageInstance interfaces.Storage
+
+// InitStorage 按配置初始化存储驱动
+func InitStorage() {
+	storageConfig := configs.AppConfig.Storage
+	switch StorageDriver() {
+	case StorageDriverLocal:
+		storage, err := NewLocalStorage(storageConfig.LocalDir, StorageBaseUrl())
+		if err != nil {
+			logUtil.Errorf("本地存储初始化失败: %v", err)
+			return
+		}
+		StorageInstance = storage
+	case StorageDriverMemory:
+		StorageInstance = NewMemoryStorage(StorageBaseUrl())
+	default:
+		StorageInstance = NewMinioStorage(configs.AppConfig.Minio, StorageBaseUrl())
+	}
+	logUtil.Infof("文件存储驱动: %s", StorageDriver())
+}
+
+// StorageDriver 当前配置的存储驱动，默认 minio
+func StorageDriver() string {
+	driver := strings.ToLower(configs.AppConfig.Storage.Driver)
+	if driver == "" {
+		return StorageDriverMinio
+	}
+	return driver
+}
+
+// StorageBaseUrl 文件对外访问地址，统一经由 /file/object 接口鉴权后读取，存储桶无需公开
+func StorageBaseUrl() string {
+	if baseUrl := configs.AppConfig.Storage.BaseUrl; baseUrl != "" {
+		return strings.TrimSuffix(baseUrl, "/")
+	}
+	return configs.AppConfig.Api.Prefix + "/file/object"
+}
+
+// cleanObjectKey 规范化对象路径，拒绝跳出存储根目录的路径
+func cleanObjectKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", fmt.Errorf("对象路径不能为空")
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("非法的对象路径: %s", key)
+		}
+	}
+	return key, nil
+}
@@ -1,12 +1,15 @@
 package manager
 
 import (
+	"errors"
 	"github.com/gorilla/websocket"
+	"go-chat/internal/repository"
 	"go-chat/internal/utils/logUtil"
 	wsClient "go-chat/internal/ws/client"
 	"go-chat/internal/ws/handler"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // InitWebSocket 初始化 WebSocket
@@ -34,6 +37,12 @@ func InitWebSocket() {
 
 // WebSocket 连接处理
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// 握手时校验 token，被强制下线或封禁后不能用旧 token 重新连接
+	id, err := WebSocketUserId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 	conn, err := wsClient.WebSocketClient.Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		logUtil.Errorf("WebSocket 连接失败: %s", err)
@@ -41,10 +50,9 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	// 获取用户ID
-	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
-	if err != nil {
-		conn.WriteMessage(websocket.TextMessage, []byte("id is required"))
+	// 被封禁的用户不允许建立连接
+	if user, err := repository.UserRepositoryInstance.GetById(uint(id)); err == nil && user != nil && user.IsBanned() {
+		conn.WriteMessage(websocket.TextMessage, []byte("user is banned"))
 		return
 	}
 	// 存储连接
@@ -66,6 +74,28 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	onClose(conn, id)
 }
 
+// WebSocketUserId 从握手请求中取出 token 校验并返回用户ID
+// 浏览器建立 ws 连接时不能设置请求头，token 也可以通过 query 参数传递；传了 id 时必须与 token 一致
+func WebSocketUserId(r *http.Request) (int64, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return 0, errors.New("未认证")
+	}
+	userId, err := AuthenticateToken(token)
+	if err != nil {
+		return 0, err
+	}
+	if raw := r.URL.Query().Get("id"); raw != "" {
+		if id, err := strconv.ParseUint(raw, 10, 64); err != nil || uint(id) != userId {
+			return 0, errors.New("id 与登录用户不一致")
+		}
+	}
+	return int64(userId), nil
+}
+
 func onOpen(conn *websocket.Conn, id int64) {
 	logUtil.Infof("WebSocket 客户端(%v)已连接: %s", conn.RemoteAddr(), id)
 	//todo 更新心跳 时间
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-chat/internal/service"
+	"net/http"
+)
+
+// AdminMiddleware 校验平台管理员身份，需放在 AuthMiddleware 之后
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isAdmin, err := service.AdminServiceInstance.IsPlatformAdmin(c.GetUint("id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询用户失败"})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "需要平台管理员权限"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
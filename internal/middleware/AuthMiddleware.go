@@ -1,8 +1,9 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"go-chat/internal/utils/jwtUtil"
+	"go-chat/internal/manager"
 	"net/http"
 	"strings"
 )
@@ -26,14 +27,19 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenStr = tokenStr[len("Bearer "):]
 
-		claims, err := jwtUtil.ParseJWT(tokenStr)
+		// 被强制下线或封禁后，之前签发的 token 失效
+		id, err := manager.AuthenticateToken(tokenStr)
+		if errors.Is(err, manager.ErrTokenRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已失效，请重新登录"})
+			c.Abort()
+			return
+		}
 		if err != nil {
-
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
-		c.Set("id", claims.ID)
+		c.Set("id", id)
 		c.Next()
 	}
 }
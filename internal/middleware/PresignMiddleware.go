@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-chat/internal/utils/signUtil"
+	"net/http"
+	"strings"
+)
+
+// PresignMiddleware 校验 local / memory 存储驱动生成的预签名地址，对象路径取自路由参数 key
+func PresignMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		method := c.Query("method")
+		if !strings.EqualFold(method, c.Request.Method) ||
+			!signUtil.Verify(method, key, c.Query("expires"), c.Query("signature")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "签名无效或已过期"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ObjectAccessMiddleware 读取对象时允许签名地址或登录用户访问，签名通过时设置 presigned，跳过访问权限检查
+func ObjectAccessMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.Query("signature") == "" {
+			auth(c)
+			return
+		}
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		if !strings.EqualFold(c.Query("method"), http.MethodGet) ||
+			!signUtil.Verify(http.MethodGet, key, c.Query("expires"), c.Query("signature")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "签名无效或已过期"})
+			c.Abort()
+			return
+		}
+		c.Set("presigned", true)
+		c.Next()
+	}
+}
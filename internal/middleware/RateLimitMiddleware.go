@@ -2,83 +2,70 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
-	"go-chat/configs"
-	"golang.org/x/time/rate"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/jwtUtil"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 )
 
-var UserLimiter sync.Map
-var InterfaceLimiter sync.Map
-
-func UserRateLimiterMiddleware() gin.HandlerFunc {
+// RateLimitMiddleware 统一限流：用户总限流、接口总限流以及配置中的路由规则
+// 作为全局中间件时还未经过 AuthMiddleware，因此这里自行解析 token 获取用户ID，解析失败按 ip 限流
+func RateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取用户的标识，有id则用id，无id则用ip
-		id := c.GetString("id")
-		if id == "" { // 没有id时使用ip
-			id = c.ClientIP()
-		}
-
-		// 从 UserLimiter 中获取用户的限速器
-		limiter, exists := UserLimiter.Load(id)
-		if !exists {
-			// 如果没有，初始化一个限速器（每秒最多10次请求，突发最大10次）
-			limiter = rate.NewLimiter(rate.Every(1*time.Second), configs.AppConfig.Rate.UserLimit)
-			UserLimiter.Store(id, limiter)
+		if manager.RateLimitManagerInstance == nil {
+			c.Next()
+			return
 		}
-
-		// 转换类型
-		rateLimiter, ok := limiter.(*rate.Limiter)
-		if !ok {
-			// 错误处理，如果类型断言失败
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "内部错误，限速器转换失败。",
-			})
-			c.Abort() // 终止请求
+		result := manager.RateLimitManagerInstance.AllowRoute(c.Request.Method, c.FullPath(), rateLimitUserKey(c), c.ClientIP())
+		if result == nil {
+			c.Next()
 			return
 		}
-
-		// 判断是否允许请求
-		if !rateLimiter.Allow() {
+		SetRateLimitHeaders(c, result)
+		if !result.Allowed {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": "请求过于频繁，请稍后再试。",
 			})
-			c.Abort() // 终止请求
+			c.Abort()
 			return
 		}
-
-		// 如果没有超出限速，继续执行后续逻辑
 		c.Next()
 	}
 }
 
-func InterfaceRateLimiterMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		route := c.Request.URL.Path
-		method := c.Request.Method
-		key := route + ":" + method
-		limiter, exists := InterfaceLimiter.Load(key)
-		if !exists {
-			limiter = rate.NewLimiter(rate.Every(1*time.Second), configs.AppConfig.Rate.ApiLimit)
-			InterfaceLimiter.Store(key, limiter)
-		}
-		rateLimiter, ok := limiter.(*rate.Limiter)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "内部错误，限速器转换失败。",
-			})
-			c.Abort() // 终止请求
-			return
-		}
+// SetRateLimitHeaders 写入 X-RateLimit-* 和 Retry-After 响应头
+func SetRateLimitHeaders(c *gin.Context, result *model.RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.ResetAfter), 10))
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.FormatInt(ceilSeconds(result.RetryAfter), 10))
+	}
+}
 
-		if !rateLimiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "请求过于频繁，请稍后再试。",
-			})
-			c.Abort() // 终止请求
-			return
-		}
-		c.Next()
+// rateLimitUserKey 优先使用 AuthMiddleware 设置的用户ID，否则尝试解析 token
+func rateLimitUserKey(c *gin.Context) string {
+	if id := c.GetUint("id"); id != 0 {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	tokenStr, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || tokenStr == "" {
+		return ""
+	}
+	claims, err := jwtUtil.ParseJWT(tokenStr)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(claims.ID), 10)
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
 	}
+	return int64(math.Ceil(d.Seconds()))
 }
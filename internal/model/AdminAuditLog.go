@@ -0,0 +1,37 @@
+package model
+
+import "gorm.io/gorm"
+
+// AdminAuditLog 平台管理员操作审计日志
+type AdminAuditLog struct {
+	gorm.Model
+	OperatorId uint        `json:"operator_id"`                // 操作的管理员ID
+	Action     AdminAction `json:"action" gorm:"size:64"`      // 操作类型
+	TargetType string      `json:"target_type" gorm:"size:32"` // 操作对象类型 user/group/message
+	TargetId   uint        `json:"target_id"`                  // 操作对象ID
+	Reason     *string     `json:"reason"`                     // 操作原因
+	Detail     *string     `json:"detail" gorm:"type:text"`    // 操作详情（如封禁截止时间、被删消息内容）
+	Ip         string      `json:"ip" gorm:"size:45"`          // 操作者IP
+}
+
+func (m *AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+type AdminAction string
+
+const (
+	BanUserAction        AdminAction = "ban_user"
+	UnbanUserAction      AdminAction = "unban_user"
+	ForceLogoutAction    AdminAction = "force_logout"
+	DisableGroupAction   AdminAction = "disable_group"
+	EnableGroupAction    AdminAction = "enable_group"
+	DeleteMessageAction  AdminAction = "delete_message"
+	RechargeWalletAction AdminAction = "recharge_wallet"
+)
+
+const (
+	AuditTargetUser    = "user"
+	AuditTargetGroup   = "group"
+	AuditTargetMessage = "message"
+)
@@ -33,3 +33,11 @@ const (
 	Admin              // 1 管理员
 	Owner              // 2 群主
 )
+
+// UserRole 平台角色
+type UserRole int
+
+const (
+	NormalUser    UserRole = iota // 0 普通用户
+	PlatformAdmin                 // 1 平台管理员
+)
@@ -1,17 +1,74 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"database/sql/driver"
+	"go-chat/internal/utils/jsonUtil"
+	"gorm.io/gorm"
+	"time"
+)
 
 type File struct {
 	gorm.Model
-	UserID   uint     `json:"user_id"` // 上传者ID
-	Type     string   `gorm:"type:enum('image','audio','video','file');not null" json:"type"`
-	Name     string   `gorm:"size:255;not null" json:"name"` // 原始文件名
-	Ext      string   `gorm:"size:20" json:"ext"`            // 文件扩展名
-	Mime     string   `gorm:"size:100" json:"mime"`          // MIME 类型
-	Size     uint64   `json:"size"`                          // 文件大小（字节）
-	Url      string   `gorm:"type:text;not null" json:"url"` // 访问地址
-	Width    *uint    `json:"width,omitempty"`               // 图像/视频宽度
-	Height   *uint    `json:"height,omitempty"`              // 图像/视频高度
-	Duration *float64 `json:"duration,omitempty"`            // 音/视频时长（秒）
+	UserID      uint             `json:"user_id"` // 上传者ID
+	Type        string           `gorm:"type:enum('image','audio','video','document','archive','code','file');not null" json:"type"`
+	Name        string           `gorm:"size:255;not null" json:"name"`         // 原始文件名
+	Ext         string           `gorm:"size:20" json:"ext"`                    // 文件扩展名
+	Mime        string           `gorm:"size:100" json:"mime"`                  // MIME 类型
+	Size        uint64           `json:"size"`                                  // 文件大小（字节）
+	Url         string           `gorm:"type:text;not null" json:"url"`         // 访问地址
+	ObjectKey   string           `gorm:"size:255" json:"-"`                     // 存储中的对象路径，按内容哈希生成
+	Sha256      string           `gorm:"size:64;index" json:"sha256"`           // 文件内容 SHA-256，相同内容只存一份
+	RefCount    int              `json:"ref_count"`                             // 被消息、头像等引用的次数
+	OrphanedAt  *time.Time       `json:"-"`                                     // 引用数归零的时间，超过宽限期后由垃圾回收删除，为空表示不回收
+	Width       *uint            `json:"width,omitempty"`                       // 图像/视频宽度
+	Height      *uint            `json:"height,omitempty"`                      // 图像/视频高度
+	Duration    *float64         `json:"duration,omitempty"`                    // 音/视频时长（秒）
+	Variants    *FileVariantList `gorm:"type:json" json:"variants,omitempty"`   // 缩略图、WebP、视频封面等衍生文件
+	Waveform    *WaveformList    `gorm:"type:json" json:"waveform,omitempty"`   // 音频波形
+	MediaStatus MediaStatus      `gorm:"size:20" json:"media_status,omitempty"` // 音视频异步处理状态，其他类型为空
+}
+
+// FileVariant 图片、音视频处理生成的衍生文件，与原文件共用引用计数和访问权限
+type FileVariant struct {
+	Name      string `json:"name"` // thumb_160、webp、poster 等
+	Url       string `json:"url"`
+	ObjectKey string `json:"key"`
+	Mime      string `json:"mime"`
+	Size      uint64 `json:"size"`
+	Width     uint   `json:"width"`
+	Height    uint   `json:"height"`
+}
+
+type FileVariantList []*FileVariant
+
+func (variants *FileVariantList) Value() (driver.Value, error) {
+	return jsonUtil.MarshalValue(variants)
+}
+
+func (variants *FileVariantList) Scan(value interface{}) error {
+	return jsonUtil.UnmarshalValue(value, variants)
+}
+
+// Urls 原文件和全部衍生文件的访问地址
+func (f *File) Urls() []string {
+	urls := []string{f.Url}
+	if f.Variants != nil {
+		for _, variant := range *f.Variants {
+			urls = append(urls, variant.Url)
+		}
+	}
+	return urls
+}
+
+// Variant 按对象路径查找衍生文件
+func (f *File) Variant(key string) *FileVariant {
+	if f.Variants == nil {
+		return nil
+	}
+	for _, variant := range *f.Variants {
+		if variant.ObjectKey == key {
+			return variant
+		}
+	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// ForwardRecord 合并转发的聊天记录，保存在消息的 extra_data 中，内容为转发时的快照
+type ForwardRecord struct {
+	Title   string        `json:"title"`   // 标题，如「张三和李四的聊天记录」
+	Summary []string      `json:"summary"` // 前几条消息的摘要，列表展示用
+	Items   []ForwardItem `json:"items"`   // 被转发的消息
+}
+
+// ForwardItem 聊天记录中的一条消息
+type ForwardItem struct {
+	MessageId      uint             `json:"message_id"`
+	SenderId       int64            `json:"sender_id"`
+	SenderNickname string           `json:"sender_nickname"`
+	SenderAvatar   *string          `json:"sender_avatar"`
+	Type           MessageType      `json:"type"`
+	Content        *MessagePartList `json:"content"`
+	ExtraData      ExtraData        `json:"extra_data,omitempty"` // 嵌套转发的聊天记录
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// FileUrls 聊天记录中引用的文件地址，包括嵌套的聊天记录
+func (r *ForwardRecord) FileUrls() []string {
+	urls := make([]string, 0)
+	for _, item := range r.Items {
+		if item.Content != nil {
+			for _, part := range *item.Content {
+				if part != nil && part.Content != nil && !part.IsText() {
+					urls = append(urls, *part.Content)
+				}
+			}
+		}
+		var nested ForwardRecord
+		if item.Type == ForwardedCotent && item.ExtraData.Decode(&nested) == nil {
+			urls = append(urls, nested.FileUrls()...)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"database/sql/driver"
+	"go-chat/internal/utils/jsonUtil"
+)
+
+// LinkPreview 链接预览，来自网页的 Open Graph / Twitter Card 元数据
+type LinkPreview struct {
+	Url         string `json:"url"`                   // 消息中的原始链接
+	Title       string `json:"title"`                 // 标题，缺省时取 <title>
+	Description string `json:"description,omitempty"` // 摘要
+	Image       string `json:"image,omitempty"`       // 封面图地址（已转为绝对地址）
+	SiteName    string `json:"site_name,omitempty"`   // 站点名称
+}
+
+// Empty 没有任何可展示的信息，不推送给客户端
+func (p *LinkPreview) Empty() bool {
+	return p.Title == "" && p.Description == "" && p.Image == ""
+}
+
+// LinkPreviewList 消息中各链接的预览，按链接在消息中出现的顺序
+type LinkPreviewList []*LinkPreview
+
+func (l *LinkPreviewList) Value() (driver.Value, error) {
+	return jsonUtil.MarshalValue(l)
+}
+
+func (l *LinkPreviewList) Scan(value interface{}) error {
+	return jsonUtil.UnmarshalValue(value, l)
+}
+
+// LinkPreviewNotice 链接预览生成后推送给会话成员，客户端据此更新消息
+type LinkPreviewNotice struct {
+	MessageId  uint            `json:"message_id"`
+	TargetType TargetType      `json:"target_type"`
+	SenderId   int64           `json:"sender_id"`
+	ReceiverId *int64          `json:"receiver_id,omitempty"`
+	GroupId    *int64          `json:"group_id,omitempty"`
+	Previews   LinkPreviewList `json:"previews"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"database/sql/driver"
+	"go-chat/internal/utils/jsonUtil"
+)
+
+// MediaStatus 音视频异步处理状态
+type MediaStatus string
+
+const (
+	MediaProcessing MediaStatus = "processing" // 等待或正在处理
+	MediaReady      MediaStatus = "ready"      // 处理完成
+	MediaFailed     MediaStatus = "failed"     // 处理失败，时长等信息缺失
+)
+
+// MediaJob 音视频处理任务，通过任务队列投递
+type MediaJob struct {
+	FileId uint `json:"file_id"`
+	UserId uint `json:"user_id"` // 上传者，处理完成后通知
+}
+
+// MediaInfo 媒体探测结果
+type MediaInfo struct {
+	Duration float64 // 时长（秒）
+	Width    uint    // 视频宽度，音频为 0
+	Height   uint    // 视频高度，音频为 0
+}
+
+// MediaNotice 音视频处理完成通知
+type MediaNotice struct {
+	FileId uint        `json:"file_id"`
+	Status MediaStatus `json:"status"`
+	File   *File       `json:"file"`
+}
+
+// WaveformList 音频波形，每个元素为一段时间内的峰值，取值 0-100
+type WaveformList []int
+
+func (w *WaveformList) Value() (driver.Value, error) {
+	return jsonUtil.MarshalValue(w)
+}
+
+func (w *WaveformList) Scan(value interface{}) error {
+	return jsonUtil.UnmarshalValue(value, w)
+}
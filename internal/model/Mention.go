@@ -0,0 +1,32 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Mention 群消息中的 @ 提醒，@所有人 时为每个成员各写一条，作为被提醒者的“@我的”收件箱
+type Mention struct {
+	gorm.Model
+	UserId    uint       `json:"user_id" gorm:"index:idx_user_group,priority:1"`  // 被提醒的用户
+	GroupId   uint       `json:"group_id" gorm:"index:idx_user_group,priority:2"` // 群组ID
+	MessageId uint       `json:"message_id" gorm:"index"`                         // 消息ID
+	SenderId  uint       `json:"sender_id"`                                       // 发送者ID
+	IsAll     bool       `json:"is_all"`                                          // 是否为 @所有人
+	ReadAt    *time.Time `json:"read_at"`                                         // 查看时间（null 未读）
+}
+
+func (m *Mention) TableName() string {
+	return "mentions"
+}
+
+// MentionNotice 被 @ 时推送给被提醒者，与普通聊天消息分开推送，免打扰的会话也能高亮提醒
+type MentionNotice struct {
+	MentionId      uint   `json:"mention_id"`
+	MessageId      uint   `json:"message_id"`
+	GroupId        uint   `json:"group_id"`
+	SenderId       uint   `json:"sender_id"`
+	SenderNickname string `json:"sender_nickname"`
+	IsAll          bool   `json:"is_all"`  // 是否为 @所有人
+	Preview        string `json:"preview"` // 消息摘要
+}
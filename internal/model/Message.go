@@ -2,23 +2,40 @@ package model
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"go-chat/internal/utils/jsonUtil"
 	"gorm.io/gorm"
+	"time"
 )
 
 // 消息结构体
+// 历史消息按会话查询并按 id 翻页，群聊使用 idx_messages_group 按 id 顺序扫描；
+// 私聊的两个方向是 idx_messages_private 上的两个范围，扫描后需要再按 id 排序
+// InnoDB 二级索引末尾隐含主键 id，建表脚本中显式列出
 type Message struct {
 	gorm.Model
-	SenderId     int64            `json:"sender_id" gorm:"not null;comment:发送者ID"`          // 发送者ID（必填）
-	ReceiverId   *int64           `json:"receiver_id" gorm:"comment:接收者ID（私聊使用）"`           // 接收者ID（仅用于私聊）
-	GroupId      *int64           `json:"group_id" gorm:"comment:群组ID（群聊使用）"`               // 群组ID（仅用于群聊）
-	ReplyId      *int64           `json:"reply_id" gorm:"comment:回复的消息ID"`                  // 回复消息ID
-	ReaderIdList *ReaderIdList    `json:"reader_id_list" gorm:"type:json;comment:已读用户ID列表"` // 已读用户ID数组，JSON 存储
-	TargetType   *TargetType      `json:"target_type" gorm:"not null;comment:消息目标类型"`       // 消息目标类型（0=私聊，1=群聊）
-	Content      *MessagePartList `json:"content" gorm:"type:json;comment:富文本消息内容"`         // 消息内容片段数组（JSON）
-	Type         *MessageType     `json:"type" gorm:"not null;comment:消息类型"`                // 消息类型（文本、图片、红包等）
-	Status       *Status          `json:"status" gorm:"not null;comment:消息状态"`              // 消息状态（0=撤回，1=正常）
-	ExtraData    interface{}      `json:"extra_data" gorm:"type:json;comment:扩展字段"`         // 扩展字段（如红包、投票等结构）
+	SenderId     int64            `json:"sender_id" gorm:"not null;index:idx_messages_private,priority:2;comment:发送者ID"`                                        // 发送者ID（必填）
+	ReceiverId   *int64           `json:"receiver_id" gorm:"index:idx_messages_private,priority:3;comment:接收者ID（私聊使用）"`                                         // 接收者ID（仅用于私聊）
+	GroupId      *int64           `json:"group_id" gorm:"index:idx_messages_group,priority:2;comment:群组ID（群聊使用）"`                                               // 群组ID（仅用于群聊）
+	ReplyId      *int64           `json:"reply_id" gorm:"comment:回复的消息ID"`                                                                                      // 回复消息ID
+	RootId       *int64           `json:"root_id" gorm:"index;comment:话题根消息ID"`                                                                                 // 话题根消息ID，由服务端根据 ReplyId 填充
+	ReaderIdList *ReaderIdList    `json:"reader_id_list" gorm:"type:json;comment:已读用户ID列表"`                                                                     // 已读用户ID数组，JSON 存储
+	PlayedIdList *ReaderIdList    `json:"played_id_list" gorm:"type:json;comment:已播放语音的用户ID列表"`                                                                 // 已播放语音的用户ID数组（仅语音消息）
+	TargetType   *TargetType      `json:"target_type" gorm:"not null;index:idx_messages_group,priority:1;index:idx_messages_private,priority:1;comment:消息目标类型"` // 消息目标类型（0=私聊，1=群聊）
+	Content      *MessagePartList `json:"content" gorm:"type:json;comment:富文本消息内容"`                                                                             // 消息内容片段数组（JSON）
+	Type         *MessageType     `json:"type" gorm:"not null;comment:消息类型"`                                                                                    // 消息类型（文本、图片、红包等）
+	Status       *Status          `json:"status" gorm:"not null;comment:消息状态"`                                                                                  // 消息状态（0=撤回，1=正常）
+	ExtraData    ExtraData        `json:"extra_data" gorm:"type:json;comment:扩展字段"`                                                                             // 扩展字段（如红包、投票等结构）
+
+	Ttl           int        `json:"ttl" gorm:"not null;default:0;comment:销毁时长（秒）"`              // 大于 0 时消息到期后对所有人删除
+	BurnAfterRead bool       `json:"burn_after_read" gorm:"not null;default:false;comment:阅后即焚"` // 为 true 时接收者读取后才开始计时（仅私聊）
+	ExpireAt      *time.Time `json:"expire_at" gorm:"index;comment:销毁时间"`                        // 由服务端计算，阅后即焚的消息读取前为空
+
+	LinkPreviews *LinkPreviewList `json:"link_previews" gorm:"type:json;comment:链接预览"` // 发送后异步抓取，抓取完成前为空
+
+	RevokedBy *int64     `json:"revoked_by" gorm:"comment:撤回者ID"` // 撤回（对所有人删除）的操作者，群主或管理员撤回他人消息时与发送者不同
+	RevokedAt *time.Time `json:"revoked_at" gorm:"comment:撤回时间"`
 }
 
 func (m *Message) TableName() string {
@@ -52,6 +69,7 @@ const (
 
 	ForwardedCotent //转发
 	SystemContent   //系统消息
+	PollContent     //投票
 )
 
 type TargetType int
@@ -68,11 +86,32 @@ const (
 	Emoji ContentType = "emoji" // 表情
 	Image ContentType = "image" // 图片
 	Link  ContentType = "link"  // 链接
+	Voice ContentType = "voice" // 语音，content 为上传的音频文件地址
+
+	StickerPart ContentType = "sticker" // 表情包或群自定义表情，sticker_id 为表情ID，content 由服务端填充为图片地址
+
+	MentionUser ContentType = "mention"     // @某人，user_id 为被提醒的群成员，content 由服务端填充为 @群昵称
+	MentionAll  ContentType = "mention_all" // @所有人，仅群主和管理员可用
 )
 
 type MessagePart struct {
-	Type    ContentType `json:"type"`    // 内容类型（text, emoji, image, link）
+	Type    ContentType `json:"type"`    // 内容类型（text, emoji, image, link, voice, sticker, mention, mention_all）
 	Content *string     `json:"content"` // 内容（如文本、图片 URL、链接等）
+
+	UserId *uint `json:"user_id,omitempty"` // 被 @ 的用户（仅 mention）
+
+	StickerId *uint `json:"sticker_id,omitempty"` // 表情ID（仅 sticker）
+
+	Duration *float64      `json:"duration,omitempty"` // 语音时长（秒），由服务端根据音频文件填充
+	Waveform *WaveformList `json:"waveform,omitempty"` // 语音波形，由服务端根据音频文件填充
+}
+
+// AttachmentTypes 引用用户上传文件的片段类型，按附件过滤消息时使用
+var AttachmentTypes = []ContentType{Image, Voice}
+
+// IsText 文本和 @ 片段只有文字，不引用上传的文件
+func (p *MessagePart) IsText() bool {
+	return p.Type == Text || p.Type == MentionUser || p.Type == MentionAll
 }
 
 type MessagePartList []*MessagePart
@@ -93,3 +132,79 @@ func (ids *ReaderIdList) Value() (driver.Value, error) {
 func (ids *ReaderIdList) Scan(value interface{}) error {
 	return jsonUtil.UnmarshalValue(value, ids)
 }
+
+// ExtraData 消息扩展字段，按原始 JSON 保存，各类消息自行定义结构
+type ExtraData json.RawMessage
+
+// NewExtraData 把结构体编码为扩展字段
+func NewExtraData(v interface{}) (ExtraData, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Decode 把扩展字段解码到指定结构
+func (d ExtraData) Decode(v interface{}) error {
+	if len(d) == 0 {
+		return errors.New("扩展字段为空")
+	}
+	return json.Unmarshal(d, v)
+}
+
+func (d ExtraData) MarshalJSON() ([]byte, error) {
+	if len(d) == 0 {
+		return []byte("null"), nil
+	}
+	return d, nil
+}
+
+func (d *ExtraData) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = nil
+		return nil
+	}
+	*d = append((*d)[0:0], data...)
+	return nil
+}
+
+func (d ExtraData) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+func (d *ExtraData) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+	case []byte:
+		*d = append((*d)[0:0], v...)
+	case string:
+		*d = ExtraData(v)
+	default:
+		return errors.New("ExtraData: unsupported type")
+	}
+	return nil
+}
+
+// MessageRevokedNotice 消息被撤回（对所有人删除）时推送给会话成员
+type MessageRevokedNotice struct {
+	MessageId  uint       `json:"message_id"`
+	TargetType TargetType `json:"target_type"`
+	SenderId   int64      `json:"sender_id"`
+	ReceiverId *int64     `json:"receiver_id,omitempty"`
+	GroupId    *int64     `json:"group_id,omitempty"`
+	OperatorId uint       `json:"operator_id"` // 撤回者
+}
+
+// MessageExpiredNotice 限时消息到期删除时推送给会话成员
+type MessageExpiredNotice struct {
+	MessageId  uint       `json:"message_id"`
+	TargetType TargetType `json:"target_type"`
+	SenderId   int64      `json:"sender_id"`
+	ReceiverId *int64     `json:"receiver_id,omitempty"`
+	GroupId    *int64     `json:"group_id,omitempty"`
+}
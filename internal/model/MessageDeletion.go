@@ -0,0 +1,28 @@
+package model
+
+import "gorm.io/gorm"
+
+// MessageDeletion 用户“仅自己删除”的消息，只对该用户隐藏，消息本身和其他人不受影响
+type MessageDeletion struct {
+	gorm.Model
+	UserId    uint `json:"user_id" gorm:"uniqueIndex:uk_user_message,priority:1"`
+	MessageId uint `json:"message_id" gorm:"uniqueIndex:uk_user_message,priority:2;index"`
+}
+
+func (m *MessageDeletion) TableName() string {
+	return "message_deletions"
+}
+
+// ConversationClear 用户清空聊天记录的位置，该用户不再看到 id 不大于 ClearedId 的消息
+// 从用户自己的视角记录会话：私聊的 TargetId 为对方的用户ID，群聊为群ID
+type ConversationClear struct {
+	gorm.Model
+	UserId     uint       `json:"user_id" gorm:"uniqueIndex:uk_user_target,priority:1"`
+	TargetType TargetType `json:"target_type" gorm:"uniqueIndex:uk_user_target,priority:2"`
+	TargetId   uint       `json:"target_id" gorm:"uniqueIndex:uk_user_target,priority:3"`
+	ClearedId  uint       `json:"cleared_id"` // 清空时会话中最新的消息ID
+}
+
+func (m *ConversationClear) TableName() string {
+	return "conversation_clears"
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// ModerationAction 内容审核处置动作，数值越大越严格
+type ModerationAction int
+
+const (
+	ModerationPass   ModerationAction = iota // 0 放行
+	ModerationMask                           // 1 打码后放行
+	ModerationFlag                           // 2 放行并进入人工审核队列
+	ModerationReject                         // 3 拒绝发送
+)
+
+// ParseModerationAction 解析配置中的处置动作（mask/flag/reject），无法识别时返回默认值
+func ParseModerationAction(action string, defaultAction ModerationAction) ModerationAction {
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "pass":
+		return ModerationPass
+	case "mask":
+		return ModerationMask
+	case "flag", "review":
+		return ModerationFlag
+	case "reject":
+		return ModerationReject
+	default:
+		return defaultAction
+	}
+}
+
+// ModerationHit 单个检查器的命中结果
+type ModerationHit struct {
+	Checker string           `json:"checker"` // 检查器名称
+	Action  ModerationAction `json:"action"`  // 建议的处置动作
+	Reason  string           `json:"reason"`  // 命中原因
+	Words   []string         `json:"words"`   // 命中的敏感词/域名等
+}
+
+// ModerationResult 一条消息的审核结果，Action 取所有命中中最严格的动作
+type ModerationResult struct {
+	Action          ModerationAction
+	Hits            []ModerationHit
+	OriginalContent *MessagePartList // 审核前的原始内容，打码时用于留档
+}
+
+// Reason 拼接所有命中原因
+func (r *ModerationResult) Reason() string {
+	reasons := make([]string, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		reasons = append(reasons, hit.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// ModerationReview 内容审核队列
+type ModerationReview struct {
+	gorm.Model
+	MessageId  uint       `json:"message_id"`               // 被标记的消息ID
+	SenderId   uint       `json:"sender_id"`                // 发送者ID
+	Hits       *string    `json:"hits" gorm:"type:text"`    // 命中详情（JSON）
+	Content    *string    `json:"content" gorm:"type:text"` // 审核前的原始内容（JSON）
+	Status     Status     `json:"status"`                   // 0 待审核 1 通过 2 驳回（消息已删除）
+	ReviewerId *uint      `json:"reviewer_id"`              // 审核人
+	ReviewedAt *time.Time `json:"reviewed_at"`              // 审核时间
+	Remark     *string    `json:"remark" gorm:"size:255"`   // 审核备注
+}
+
+func (m *ModerationReview) TableName() string {
+	return "moderation_reviews"
+}
@@ -0,0 +1,10 @@
+package model
+
+// OidcState OIDC 登录过程中暂存的状态，以 state 参数为键
+type OidcState struct {
+	Provider     string `json:"provider"`      // 身份提供方名称
+	Nonce        string `json:"nonce"`         // 防重放 nonce
+	CodeVerifier string `json:"code_verifier"` // PKCE code_verifier
+	BindUserId   uint   `json:"bind_user_id"`  // 非0表示已登录用户绑定外部身份
+	BrowserKey   string `json:"browser_key"`   // 发起授权的浏览器 cookie 的 SHA-256，回调时必须携带同一 cookie
+}
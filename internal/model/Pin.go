@@ -0,0 +1,29 @@
+package model
+
+import "gorm.io/gorm"
+
+// Pin 会话中被置顶的消息，取消置顶时直接删除记录
+type Pin struct {
+	gorm.Model
+	Conversation string `json:"conversation" gorm:"size:64;index"` // 会话标识，私聊为 p{小id}-{大id}，群聊为 g{群id}
+	MessageId    uint   `json:"message_id" gorm:"uniqueIndex"`     // 被置顶的消息
+	PinnedBy     uint   `json:"pinned_by"`                         // 置顶的用户
+}
+
+func (m *Pin) TableName() string {
+	return "pins"
+}
+
+type PinAction string
+
+const (
+	PinAdd    PinAction = "pin"
+	PinRemove PinAction = "unpin"
+)
+
+// PinExtra 置顶/取消置顶时生成的系统消息的扩展字段
+type PinExtra struct {
+	Action     PinAction `json:"action"`
+	MessageId  uint      `json:"message_id"`
+	OperatorId uint      `json:"operator_id"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"database/sql/driver"
+	"go-chat/internal/utils/jsonUtil"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Poll 群投票；投票时锁定投票行后更新选项票数，每个成员只有一张选票，截止前可以修改
+type Poll struct {
+	gorm.Model
+	CreatorId  uint       `json:"creator_id" gorm:"index"` // 发起人
+	GroupId    uint       `json:"group_id" gorm:"index"`   // 群组ID
+	MessageId  *uint      `json:"message_id"`              // 对应的聊天消息
+	Question   string     `json:"question" gorm:"size:256"`
+	Multiple   bool       `json:"multiple"`    // 是否多选
+	MaxChoices int        `json:"max_choices"` // 最多可选的项数，单选为 1
+	Anonymous  bool       `json:"anonymous"`   // 匿名投票不公开投票人
+	Deadline   *time.Time `json:"deadline"`    // 截止时间，为空时直到手动结束
+	Status     PollStatus `json:"status"`      // 状态
+	VoterCount int        `json:"voter_count"` // 投票人数
+}
+
+func (m *Poll) TableName() string {
+	return "polls"
+}
+
+// Closed 手动结束或已过截止时间
+func (m *Poll) Closed(now time.Time) bool {
+	return m.Status == PollClosed || (m.Deadline != nil && !now.Before(*m.Deadline))
+}
+
+type PollStatus int
+
+const (
+	PollOpen   PollStatus = iota // 0 进行中
+	PollClosed                   // 1 已结束
+)
+
+// PollOption 投票选项，票数随投票增减
+type PollOption struct {
+	gorm.Model
+	PollId    uint   `json:"poll_id" gorm:"index"`
+	Content   string `json:"content" gorm:"size:64"`
+	VoteCount int    `json:"vote_count"`
+}
+
+func (m *PollOption) TableName() string {
+	return "poll_options"
+}
+
+// PollVote 成员的选票，同一投票每人只有一条记录，修改投票时更新选中的选项
+type PollVote struct {
+	gorm.Model
+	PollId    uint            `json:"poll_id" gorm:"uniqueIndex:uk_poll_user"`
+	UserId    uint            `json:"user_id" gorm:"uniqueIndex:uk_poll_user"`
+	OptionIds *PollChoiceList `json:"option_ids" gorm:"type:json"` // 选中的选项
+}
+
+func (m *PollVote) TableName() string {
+	return "poll_votes"
+}
+
+type PollChoiceList []uint
+
+func (ids *PollChoiceList) Value() (driver.Value, error) {
+	return jsonUtil.MarshalValue(ids)
+}
+
+func (ids *PollChoiceList) Scan(value interface{}) error {
+	return jsonUtil.UnmarshalValue(value, ids)
+}
+
+// PollExtra 投票消息的 extra_data
+type PollExtra struct {
+	PollId    uint       `json:"poll_id"`
+	Question  string     `json:"question"`
+	Multiple  bool       `json:"multiple"`
+	Anonymous bool       `json:"anonymous"`
+	Deadline  *time.Time `json:"deadline"`
+}
+
+// PollNotice 投票结果变化时推送给在线的群成员
+type PollNotice struct {
+	PollId     uint              `json:"poll_id"`
+	MessageId  *uint             `json:"message_id"`
+	GroupId    uint              `json:"group_id"`
+	VoterCount int               `json:"voter_count"`
+	Options    []PollOptionCount `json:"options"`
+	Closed     bool              `json:"closed"`
+}
+
+type PollOptionCount struct {
+	OptionId  uint `json:"option_id"`
+	VoteCount int  `json:"vote_count"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// RateLimitResult 一次限流判定的结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 窗口内允许的请求数
+	Remaining  int           // 窗口内剩余可用次数
+	ResetAfter time.Duration // 距离窗口内最早一次请求过期的时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
@@ -0,0 +1,33 @@
+package model
+
+import "gorm.io/gorm"
+
+// Reaction 消息的表情回应，同一用户对同一条消息的同一表情只记一次
+// 取消回应时直接删除记录，避免软删除的记录占用唯一索引
+type Reaction struct {
+	gorm.Model
+	MessageId uint   `json:"message_id" gorm:"uniqueIndex:uk_message_user_emoji,priority:1"` // 消息ID
+	UserId    uint   `json:"user_id" gorm:"uniqueIndex:uk_message_user_emoji,priority:2"`    // 回应的用户
+	Emoji     string `json:"emoji" gorm:"size:32;uniqueIndex:uk_message_user_emoji,priority:3"`
+}
+
+func (m *Reaction) TableName() string {
+	return "reactions"
+}
+
+type ReactionAction string
+
+const (
+	ReactionAdd    ReactionAction = "add"
+	ReactionRemove ReactionAction = "remove"
+)
+
+// ReactionNotice 回应变化时推送给会话中的其他人，count 为该表情变化后的回应人数
+type ReactionNotice struct {
+	MessageId uint           `json:"message_id"`
+	UserId    uint           `json:"user_id"`
+	Nickname  string         `json:"nickname"`
+	Emoji     string         `json:"emoji"`
+	Action    ReactionAction `json:"action"`
+	Count     int            `json:"count"`
+}
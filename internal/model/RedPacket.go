@@ -0,0 +1,73 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// RedPacket 红包，金额单位为分；发出时从发送者钱包扣款，过期未领完的部分退回
+type RedPacket struct {
+	gorm.Model
+	SenderId     uint            `json:"sender_id" gorm:"index"`                                 // 发送者ID
+	TargetType   TargetType      `json:"target_type"`                                            // 私聊/群聊
+	ReceiverId   *uint           `json:"receiver_id"`                                            // 私聊接收者
+	GroupId      *uint           `json:"group_id"`                                               // 群组ID
+	MessageId    *uint           `json:"message_id"`                                             // 对应的聊天消息
+	Mode         RedPacketMode   `json:"mode" gorm:"size:16"`                                    // 分配方式
+	TotalAmount  int64           `json:"total_amount"`                                           // 总金额（分）
+	Count        int             `json:"count"`                                                  // 红包个数
+	RemainAmount int64           `json:"remain_amount"`                                          // 剩余金额（分）
+	RemainCount  int             `json:"remain_count"`                                           // 剩余个数
+	Greeting     string          `json:"greeting" gorm:"size:64"`                                // 祝福语
+	Status       RedPacketStatus `json:"status" gorm:"index:idx_status_expire_at,priority:1"`    // 状态
+	ExpireAt     time.Time       `json:"expire_at" gorm:"index:idx_status_expire_at,priority:2"` // 过期时间
+}
+
+func (m *RedPacket) TableName() string {
+	return "red_packets"
+}
+
+// RedPacketClaim 领取记录，同一红包每人只能领取一次
+type RedPacketClaim struct {
+	gorm.Model
+	RedPacketId uint  `json:"red_packet_id" gorm:"uniqueIndex:uk_packet_user"` // 红包ID
+	UserId      uint  `json:"user_id" gorm:"uniqueIndex:uk_packet_user"`       // 领取者ID
+	Amount      int64 `json:"amount"`                                          // 领取金额（分）
+}
+
+func (m *RedPacketClaim) TableName() string {
+	return "red_packet_claims"
+}
+
+type RedPacketMode string
+
+const (
+	RedPacketFixed  RedPacketMode = "fixed"  // 普通红包，每个金额相同
+	RedPacketRandom RedPacketMode = "random" // 拼手气红包，金额随机
+)
+
+type RedPacketStatus int
+
+const (
+	RedPacketActive   RedPacketStatus = iota // 0 可领取
+	RedPacketFinished                        // 1 已领完
+	RedPacketExpired                         // 2 已过期退款
+)
+
+// RedPacketExtra 红包消息的 extra_data
+type RedPacketExtra struct {
+	RedPacketId uint          `json:"red_packet_id"`
+	Mode        RedPacketMode `json:"mode"`
+	Greeting    string        `json:"greeting"`
+}
+
+// RedPacketNotice 红包被领取时推送给发送者
+type RedPacketNotice struct {
+	RedPacketId uint   `json:"red_packet_id"`
+	MessageId   *uint  `json:"message_id"`
+	UserId      uint   `json:"user_id"`      // 领取者
+	Nickname    string `json:"nickname"`     // 领取者昵称
+	Amount      int64  `json:"amount"`       // 领取金额（分）
+	RemainCount int    `json:"remain_count"` // 剩余个数
+	Finished    bool   `json:"finished"`     // 是否已领完
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Report 用户举报
+type Report struct {
+	gorm.Model
+	ReporterId uint             `json:"reporter_id"`                // 举报人ID
+	TargetType ReportTargetType `json:"target_type" gorm:"size:16"` // 举报对象类型 message/user/group
+	TargetId   uint             `json:"target_id"`                  // 举报对象ID
+	Category   ReportCategory   `json:"category" gorm:"size:32"`    // 举报类别
+	Comment    *string          `json:"comment" gorm:"size:500"`    // 举报说明
+	Snapshot   *string          `json:"snapshot" gorm:"type:text"`  // 举报时的对象快照（JSON），避免撤回/删除后证据丢失
+	Status     ReportStatus     `json:"status"`                     // 处理状态
+	HandlerId  *uint            `json:"handler_id"`                 // 处理人
+	Result     *string          `json:"result" gorm:"size:500"`     // 处理结果说明，会通知给举报人
+	HandledAt  *time.Time       `json:"handled_at"`                 // 处理完成时间
+}
+
+func (m *Report) TableName() string {
+	return "reports"
+}
+
+type ReportTargetType string
+
+const (
+	ReportMessage ReportTargetType = "message"
+	ReportUser    ReportTargetType = "user"
+	ReportGroup   ReportTargetType = "group"
+)
+
+type ReportCategory string
+
+const (
+	ReportSpam    ReportCategory = "spam"    // 垃圾广告
+	ReportAbuse   ReportCategory = "abuse"   // 辱骂骚扰
+	ReportPorn    ReportCategory = "porn"    // 色情低俗
+	ReportFraud   ReportCategory = "fraud"   // 诈骗
+	ReportIllegal ReportCategory = "illegal" // 违法违规
+	ReportOther   ReportCategory = "other"   // 其他
+)
+
+// Valid 是否为支持的举报类别
+func (c ReportCategory) Valid() bool {
+	switch c {
+	case ReportSpam, ReportAbuse, ReportPorn, ReportFraud, ReportIllegal, ReportOther:
+		return true
+	}
+	return false
+}
+
+// ReportStatus 举报处理状态：待处理 -> 处理中 -> 已处理/已驳回
+type ReportStatus int
+
+const (
+	ReportPending    ReportStatus = iota // 0 待处理
+	ReportProcessing                     // 1 处理中
+	ReportResolved                       // 2 已处理（举报成立）
+	ReportRejected                       // 3 已驳回（举报不成立）
+)
+
+// Closed 是否已结案
+func (s ReportStatus) Closed() bool {
+	return s == ReportResolved || s == ReportRejected
+}
+
+// ReportSnapshot 举报对象快照
+type ReportSnapshot struct {
+	Message *ReportMessageSnapshot `json:"message,omitempty"`
+	User    *ReportUserSnapshot    `json:"user,omitempty"`
+	Group   *ReportGroupSnapshot   `json:"group,omitempty"`
+}
+
+type ReportMessageSnapshot struct {
+	Id         uint             `json:"id"`
+	SenderId   int64            `json:"sender_id"`
+	ReceiverId *int64           `json:"receiver_id"`
+	GroupId    *int64           `json:"group_id"`
+	Type       *MessageType     `json:"type"`
+	Content    *MessagePartList `json:"content"`
+	ExtraData  interface{}      `json:"extra_data"`
+	SentAt     time.Time        `json:"sent_at"`
+}
+
+type ReportUserSnapshot struct {
+	Id       uint    `json:"id"`
+	Username string  `json:"username"`
+	Nickname *string `json:"nickname"`
+	Avatar   *string `json:"avatar"`
+	Desc     *string `json:"desc"`
+}
+
+type ReportGroupSnapshot struct {
+	Id      uint   `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Avatar  string `json:"avatar"`
+	Desc    string `json:"description"`
+	OwnerId uint   `json:"owner_id"`
+}
+
+// ReportNotice 举报结案通知
+type ReportNotice struct {
+	ReportId   uint             `json:"report_id"`
+	TargetType ReportTargetType `json:"target_type"`
+	TargetId   uint             `json:"target_id"`
+	Status     ReportStatus     `json:"status"`
+	Result     *string          `json:"result"`
+}
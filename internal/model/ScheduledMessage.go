@@ -0,0 +1,38 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ScheduledMessage 定时消息，到达发送时间后由定时任务按普通消息发送；保存在数据库中，服务重启后继续生效
+type ScheduledMessage struct {
+	gorm.Model
+	SenderId      uint             `json:"sender_id" gorm:"index"`                             // 发送者ID
+	TargetType    TargetType       `json:"target_type"`                                        // 私聊/群聊
+	TargetId      uint             `json:"target_id"`                                          // 好友id或群组id
+	Type          MessageType      `json:"type"`                                               // 消息类型
+	Content       *MessagePartList `json:"content" gorm:"type:json"`                           // 消息内容
+	ReplyId       *int64           `json:"reply_id"`                                           // 回复的消息ID
+	Ttl           int              `json:"ttl"`                                                // 发出的消息的销毁时长（秒）
+	BurnAfterRead bool             `json:"burn_after_read"`                                    // 发出的消息是否阅后即焚
+	SendAt        time.Time        `json:"send_at" gorm:"index:idx_status_send_at,priority:2"` // 计划发送时间
+	Status        ScheduleStatus   `json:"status" gorm:"index:idx_status_send_at,priority:1"`  // 状态
+	MessageId     *uint            `json:"message_id"`                                         // 发送后生成的消息ID
+	FailReason    string           `json:"fail_reason" gorm:"size:255"`                        // 发送失败的原因
+	ClaimedAt     *time.Time       `json:"claimed_at"`                                         // 领取发送的时间，发送中超过租约仍未完成的会被重新领取
+}
+
+func (m *ScheduledMessage) TableName() string {
+	return "scheduled_messages"
+}
+
+type ScheduleStatus int
+
+const (
+	SchedulePending  ScheduleStatus = iota // 0 待发送
+	ScheduleSending                        // 1 发送中
+	ScheduleSent                           // 2 已发送
+	ScheduleCanceled                       // 3 已取消
+	ScheduleFailed                         // 4 发送失败
+)
@@ -0,0 +1,47 @@
+package model
+
+import "gorm.io/gorm"
+
+// StickerPack 表情包，由用户创建，所有人可以搜索和使用其中的表情
+type StickerPack struct {
+	gorm.Model
+	CreatorId    uint   `json:"creator_id" gorm:"not null;index"`        // 创建者，只有创建者可以修改
+	Name         string `json:"name" gorm:"size:64;not null;index"`      // 名称
+	Description  string `json:"description" gorm:"size:256"`             // 简介
+	Cover        string `json:"cover" gorm:"size:512"`                   // 封面，为空时使用第一个表情
+	StickerCount int    `json:"sticker_count" gorm:"not null;default:0"` // 表情数量
+}
+
+func (m *StickerPack) TableName() string {
+	return "sticker_packs"
+}
+
+// Sticker 表情，属于某个表情包或某个群的自定义表情（二选一）
+// 删除时直接删除记录，避免软删除的记录占用群内的短代码
+type Sticker struct {
+	gorm.Model
+	PackId    *uint  `json:"pack_id" gorm:"index"`                                              // 所属表情包
+	GroupId   *uint  `json:"group_id" gorm:"uniqueIndex:uk_group_name,priority:1"`              // 所属群（群自定义表情）
+	Name      string `json:"name" gorm:"size:32;not null;uniqueIndex:uk_group_name,priority:2"` // 名称，群自定义表情为群内唯一的短代码
+	Keywords  string `json:"keywords" gorm:"size:128"`                                          // 搜索关键字，空格分隔
+	Url       string `json:"url" gorm:"size:512;not null"`                                      // 图片地址
+	FileId    uint   `json:"file_id"`                                                           // 上传的文件
+	Width     uint   `json:"width"`                                                             // 图片宽度
+	Height    uint   `json:"height"`                                                            // 图片高度
+	CreatorId uint   `json:"creator_id"`                                                        // 上传者
+}
+
+func (m *Sticker) TableName() string {
+	return "stickers"
+}
+
+// StickerFavorite 用户收藏的表情，取消收藏时直接删除记录
+type StickerFavorite struct {
+	gorm.Model
+	UserId    uint `json:"user_id" gorm:"uniqueIndex:uk_user_sticker,priority:1"`
+	StickerId uint `json:"sticker_id" gorm:"uniqueIndex:uk_user_sticker,priority:2;index"`
+}
+
+func (m *StickerFavorite) TableName() string {
+	return "sticker_favorites"
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ObjectInfo 存储对象元信息
+type ObjectInfo struct {
+	Key         string    `json:"key"`          // 对象路径，如 image/xxx.png
+	Size        int64     `json:"size"`         // 大小（字节）
+	ContentType string    `json:"content_type"` // MIME 类型
+	ETag        string    `json:"etag"`         // 内容标识
+	ModTime     time.Time `json:"mod_time"`     // 最后修改时间
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Thread 话题摘要，回复某条消息时以被回复消息（或其所在话题的根消息）为根建立话题
+type Thread struct {
+	gorm.Model
+	RootId      uint       `json:"root_id" gorm:"uniqueIndex"` // 根消息ID
+	ReplyCount  int        `json:"reply_count"`                // 回复数
+	LastReplyId uint       `json:"last_reply_id"`              // 最后一条回复
+	LastReplyAt *time.Time `json:"last_reply_at" gorm:"index"` // 最后回复时间
+}
+
+func (m *Thread) TableName() string {
+	return "threads"
+}
+
+// ThreadParticipant 话题参与者（根消息的发送者和回复过的人），read_count 为已读的回复数
+type ThreadParticipant struct {
+	gorm.Model
+	RootId    uint `json:"root_id" gorm:"uniqueIndex:uk_root_user,priority:1"`
+	UserId    uint `json:"user_id" gorm:"uniqueIndex:uk_root_user,priority:2;index"`
+	ReadCount int  `json:"read_count"`
+}
+
+func (m *ThreadParticipant) TableName() string {
+	return "thread_participants"
+}
+
+// ThreadNotice 话题有新回复时推送给其他参与者
+type ThreadNotice struct {
+	RootId         uint   `json:"root_id"`
+	MessageId      uint   `json:"message_id"`
+	SenderId       uint   `json:"sender_id"`
+	SenderNickname string `json:"sender_nickname"`
+	ReplyCount     int    `json:"reply_count"`
+	Unread         int    `json:"unread"`  // 接收者在该话题中的未读回复数
+	Preview        string `json:"preview"` // 回复摘要
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// UploadSession 分片上传会话，每个分片作为独立对象写入存储，完成时合并为最终文件
+type UploadSession struct {
+	gorm.Model
+	UploadId  string       `json:"upload_id" gorm:"size:36;uniqueIndex"` // 上传ID
+	UserID    uint         `json:"user_id"`                              // 上传者ID
+	Name      string       `json:"name" gorm:"size:255"`                 // 原始文件名
+	Size      int64        `json:"size"`                                 // 文件总大小（字节）
+	Sha256    string       `json:"sha256" gorm:"size:64"`                // 客户端声明的文件 SHA-256，完成时校验
+	PartSize  int64        `json:"part_size"`                            // 分片大小，最后一片可以更小
+	PartCount int          `json:"part_count"`                           // 分片数量
+	Status    UploadStatus `json:"status"`                               // 会话状态
+	FileID    *uint        `json:"file_id"`                              // 完成后生成的文件ID
+	ExpireAt  time.Time    `json:"expire_at"`                            // 过期时间，过期未完成的会话会被清理
+	// 秒传校验区间，他人已上传过相同内容时由服务端随机选取，长度为 0 表示不能秒传
+	ProofOffset int64 `json:"-"`
+	ProofLength int64 `json:"-"`
+}
+
+func (m *UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// UploadStatus 上传会话状态
+type UploadStatus int
+
+const (
+	UploadUploading UploadStatus = iota // 0 上传中
+	UploadCompleted                     // 1 已完成
+	UploadAborted                       // 2 已取消/已过期
+)
+
+// PartKey 分片在存储中的对象路径，分片序号从 1 开始
+func (m *UploadSession) PartKey(partNumber int) string {
+	return fmt.Sprintf("uploads/%s/%05d", m.UploadId, partNumber)
+}
+
+// PartLength 指定分片应有的大小
+func (m *UploadSession) PartLength(partNumber int) int64 {
+	if partNumber == m.PartCount {
+		return m.Size - m.PartSize*int64(m.PartCount-1)
+	}
+	return m.PartSize
+}
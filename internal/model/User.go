@@ -2,6 +2,7 @@ package model
 
 import (
 	"gorm.io/gorm"
+	"time"
 )
 
 type User struct {
@@ -12,6 +13,7 @@ type User struct {
 	Desc          *string      `json:"desc"`                             // 简介
 	Phone         *string      `json:"phone" validate:"omitempty,phone"` // 用户手机号
 	Email         *string      `json:"email" validate:"omitempty,email"` // 用户邮箱
+	EmailVerified bool         `json:"email_verified"`                   // 邮箱是否已验证，修改邮箱后需重新验证
 	Avatar        *string      `json:"avatar,omitempty"`                 // 用户头像URL（可选）
 	ClientIp      string       `json:"client_ip"`                        // 客户端IP地址
 	ClientPort    string       `json:"client_port"`                      // 客户端端口号
@@ -21,8 +23,23 @@ type User struct {
 	Status        Status       `json:"status"`                           // 用户状态（如激活、禁用等）
 	OnlineStatus  OnlineStatus `json:"online_status"`                    // 用户在线状态（如在线、离线、忙碌等）
 	DeviceInfo    *string      `json:"device_info"`                      // 客户端设备信息
+	Role          UserRole     `json:"role"`                             // 平台角色（0=普通用户，1=平台管理员）
+	BanReason     *string      `json:"ban_reason"`                       // 封禁原因
+	BanEnd        *time.Time   `json:"ban_end"`                          // 封禁截止时间（null 表示永久封禁）
 }
 
 func (u *User) TableName() string {
 	return "users"
 }
+
+// IsBanned 是否处于封禁中，封禁到期后视为未封禁
+func (u *User) IsBanned() bool {
+	if u.Status != Disable {
+		return false
+	}
+	return u.BanEnd == nil || u.BanEnd.After(time.Now())
+}
+
+func (u *User) IsPlatformAdmin() bool {
+	return u.Role == PlatformAdmin
+}
@@ -0,0 +1,17 @@
+package model
+
+import "gorm.io/gorm"
+
+// UserIdentity 外部身份（OIDC）与本地用户的绑定关系
+type UserIdentity struct {
+	gorm.Model
+	UserId   uint    `json:"user_id"`                 // 本地用户ID
+	Provider string  `json:"provider" gorm:"size:64"` // 身份提供方名称
+	Subject  string  `json:"subject" gorm:"size:255"` // 提供方内的用户唯一标识(sub)
+	Email    *string `json:"email" gorm:"size:255"`   // 登录时提供方返回的邮箱
+	Name     *string `json:"name" gorm:"size:255"`    // 登录时提供方返回的名称
+}
+
+func (m *UserIdentity) TableName() string {
+	return "user_identities"
+}
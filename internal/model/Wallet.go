@@ -0,0 +1,38 @@
+package model
+
+import "gorm.io/gorm"
+
+// Wallet 用户虚拟钱包，金额单位为分
+type Wallet struct {
+	gorm.Model
+	UserId  uint  `json:"user_id" gorm:"uniqueIndex"` // 用户ID
+	Balance int64 `json:"balance"`                    // 余额（分）
+}
+
+func (m *Wallet) TableName() string {
+	return "wallets"
+}
+
+// WalletLog 钱包流水，每次余额变动记录一条
+type WalletLog struct {
+	gorm.Model
+	UserId      uint          `json:"user_id" gorm:"index"`   // 用户ID
+	Type        WalletLogType `json:"type" gorm:"size:16"`    // 变动类型
+	Amount      int64         `json:"amount"`                 // 变动金额（分），支出为负数
+	RedPacketId *uint         `json:"red_packet_id"`          // 关联的红包
+	Remark      *string       `json:"remark" gorm:"size:255"` // 备注（如充值原因）
+	OperatorId  *uint         `json:"operator_id"`            // 充值操作的管理员
+}
+
+func (m *WalletLog) TableName() string {
+	return "wallet_logs"
+}
+
+type WalletLogType string
+
+const (
+	WalletRecharge     WalletLogType = "recharge"      // 管理员充值
+	WalletSendPacket   WalletLogType = "send_packet"   // 发红包
+	WalletClaimPacket  WalletLogType = "claim_packet"  // 抢红包
+	WalletRefundPacket WalletLogType = "refund_packet" // 红包过期退款
+)
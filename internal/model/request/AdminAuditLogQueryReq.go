@@ -0,0 +1,11 @@
+package model
+
+// AdminAuditLogQueryRequest 审计日志查询
+type AdminAuditLogQueryRequest struct {
+	OperatorId uint   `json:"operator_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetId   uint   `json:"target_id"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"pageSize"`
+}
@@ -0,0 +1,14 @@
+package model
+
+// AdminBanRequest 封禁用户
+type AdminBanRequest struct {
+	UserId   uint   `json:"user_id" binding:"required"` // 被封禁的用户ID
+	Reason   string `json:"reason" binding:"required"`  // 封禁原因
+	Duration int64  `json:"duration"`                   // 封禁时长（秒），0 表示永久
+}
+
+// AdminUserOperateRequest 针对单个用户的管理操作（解封、强制下线）
+type AdminUserOperateRequest struct {
+	UserId uint   `json:"user_id" binding:"required"`
+	Reason string `json:"reason"`
+}
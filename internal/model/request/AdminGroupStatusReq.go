@@ -0,0 +1,7 @@
+package model
+
+// AdminGroupStatusRequest 停用/启用群组
+type AdminGroupStatusRequest struct {
+	GroupId uint   `json:"group_id" binding:"required"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// AdminMessageDeleteRequest 管理员删除消息
+type AdminMessageDeleteRequest struct {
+	MessageId uint   `json:"message_id" binding:"required"`
+	Reason    string `json:"reason"`
+}
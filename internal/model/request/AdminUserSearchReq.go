@@ -0,0 +1,12 @@
+package model
+
+import "go-chat/internal/model"
+
+// AdminUserSearchRequest 管理后台用户搜索
+type AdminUserSearchRequest struct {
+	Keyword  string        `json:"keyword"` // 用户名/昵称/邮箱/手机号模糊搜索
+	UserId   uint          `json:"user_id"` // 用户ID，精准查询
+	Status   *model.Status `json:"status"`  // 用户状态
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
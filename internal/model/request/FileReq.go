@@ -0,0 +1,24 @@
+package model
+
+// UploadInitRequest 创建分片上传会话
+type UploadInitRequest struct {
+	Name   string `json:"name" binding:"required"`   // 原始文件名
+	Size   int64  `json:"size" binding:"required"`   // 文件大小（字节）
+	Sha256 string `json:"sha256" binding:"required"` // 文件 SHA-256（十六进制）
+}
+
+// UploadSessionRequest 按上传ID操作会话
+type UploadSessionRequest struct {
+	UploadId string `json:"upload_id" form:"upload_id" binding:"required"`
+}
+
+// UploadCompleteRequest 完成分片上传，秒传时 proof 为校验区间字节的十六进制编码
+type UploadCompleteRequest struct {
+	UploadId string `json:"upload_id" binding:"required"`
+	Proof    string `json:"proof"`
+}
+
+// FileSignRequest 批量生成文件签名地址
+type FileSignRequest struct {
+	Urls []string `json:"urls" binding:"required,max=100"`
+}
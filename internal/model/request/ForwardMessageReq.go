@@ -0,0 +1,15 @@
+package model
+
+import "go-chat/internal/model"
+
+// ForwardMessageReq 转发消息，逐条转发或合并为一条聊天记录转发到多个会话
+type ForwardMessageReq struct {
+	MessageIds []uint          `json:"message_ids" binding:"required,min=1,max=100"` // 被转发的消息ID
+	Merged     bool            `json:"merged"`                                       // 是否合并转发，合并转发的消息必须来自同一会话
+	Targets    []ForwardTarget `json:"targets" binding:"required,min=1,max=9,dive"`  // 转发目标
+}
+
+type ForwardTarget struct {
+	TargetType *model.TargetType `json:"target_type" binding:"required"` // 0 私聊 1 群聊
+	TargetId   uint              `json:"target_id" binding:"required"`   // 好友id或群组id
+}
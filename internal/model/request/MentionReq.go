@@ -0,0 +1,15 @@
+package model
+
+// MentionQueryRequest 查询“@我的”消息
+type MentionQueryRequest struct {
+	GroupId    uint `json:"group_id"`    // 只查某个群，0 为全部
+	UnreadOnly bool `json:"unread_only"` // 只查未读
+	Page       int  `json:"page"`
+	PageSize   int  `json:"pageSize"`
+}
+
+// MentionReadRequest 标记 @ 提醒已读，mention_ids 为空时标记整个群（group_id 为 0 时为全部）
+type MentionReadRequest struct {
+	GroupId    uint   `json:"group_id"`
+	MentionIds []uint `json:"mention_ids"`
+}
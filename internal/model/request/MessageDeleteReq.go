@@ -0,0 +1,15 @@
+package model
+
+import "go-chat/internal/model"
+
+// MessageDeleteReq 删除消息，仅自己删除或对所有人删除（撤回）
+type MessageDeleteReq struct {
+	MessageIds  []uint `json:"message_ids" binding:"required,min=1,max=100"` // 消息ID
+	ForEveryone bool   `json:"for_everyone"`                                 // 是否对所有人删除，须在撤回时限内或由群主、管理员操作
+}
+
+// ConversationClearReq 清空会话的聊天记录，只对自己生效
+type ConversationClearReq struct {
+	TargetId   uint              `json:"target_id" binding:"required"`   // 好友id或群组id
+	TargetType *model.TargetType `json:"target_type" binding:"required"` // 私聊或群聊
+}
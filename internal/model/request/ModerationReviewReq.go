@@ -0,0 +1,18 @@
+package model
+
+import "go-chat/internal/model"
+
+// ModerationReviewQueryRequest 审核队列查询
+type ModerationReviewQueryRequest struct {
+	Status   *model.Status `json:"status"`    // 0 待审核 1 通过 2 驳回，为空查询全部
+	SenderId uint          `json:"sender_id"` // 发送者ID
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
+
+// ModerationReviewHandleRequest 处理审核
+type ModerationReviewHandleRequest struct {
+	ReviewId uint   `json:"review_id" binding:"required"`
+	Approve  bool   `json:"approve"` // true 通过，false 驳回并删除消息
+	Remark   string `json:"remark"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "go-chat/internal/model"
+
+// PinMessageReq 置顶或取消置顶消息
+type PinMessageReq struct {
+	MessageId uint `json:"message_id" binding:"required"`
+}
+
+// PinListReq 查询会话的置顶消息
+type PinListReq struct {
+	TargetId   uint              `json:"target_id" binding:"required"`   // 好友id或群组id
+	TargetType *model.TargetType `json:"target_type" binding:"required"` // 私聊或群聊
+}
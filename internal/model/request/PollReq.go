@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// PollCreateRequest 在群中发起投票
+type PollCreateRequest struct {
+	GroupId    uint       `json:"group_id" binding:"required"`
+	Question   string     `json:"question" binding:"required"`
+	Options    []string   `json:"options" binding:"required,min=2"` // 选项内容，按顺序展示
+	Multiple   bool       `json:"multiple"`                         // 是否多选
+	MaxChoices int        `json:"max_choices"`                      // 多选最多可选的项数，为 0 时不限
+	Anonymous  bool       `json:"anonymous"`                        // 匿名投票
+	Deadline   *time.Time `json:"deadline"`                         // 截止时间，为空时直到手动结束
+}
+
+// PollVoteRequest 投票，已投过时改为本次选择
+type PollVoteRequest struct {
+	PollId    uint   `json:"poll_id" binding:"required"`
+	OptionIds []uint `json:"option_ids" binding:"required,min=1"`
+}
+
+// PollCloseRequest 提前结束投票
+type PollCloseRequest struct {
+	PollId uint `json:"poll_id" binding:"required"`
+}
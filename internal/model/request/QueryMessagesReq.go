@@ -5,15 +5,27 @@ import (
 	"time"
 )
 
+// QueryDirection 历史消息的翻页方向
+type QueryDirection string
+
+const (
+	QueryBefore QueryDirection = "before" // 早于游标的消息，游标为 0 时从最新的消息开始
+	QueryAfter  QueryDirection = "after"  // 晚于游标的消息，游标为 0 时从最早的消息开始
+	QueryAround QueryDirection = "around" // 游标前后的消息（包含游标本身），用于跳转到某条消息
+)
+
 // QueryMessagesRequest 查询消息列表请求参数
 type QueryMessagesRequest struct {
 	TargetId   uint              `json:"target_id" binding:"required"`   // 目标ID 好友id或群组id
 	TargetType *model.TargetType `json:"target_type" binding:"required"` // 目标类型 私聊或群聊
-	Cursor     uint              `json:"cursor"`                         // 游标 上次查询的最小id 默认为0
-	Limit      int               `json:"limit"`                          // 限制数量
-	//以下为拓展,暂时不会做
-	MessageTypes *[]model.MessageType `json:"message_types"` // 只查特定类型的消息（如图片、文本）
-	Keyword      *string              `json:"keyword"`       // 模糊搜索
-	StartTime    time.Time            `json:"start_time"`    // 起始时间
-	EndTime      time.Time            `json:"end_time"`      // 结束时间
+	Cursor     uint              `json:"cursor"`                         // 游标 消息id，before 时传上次返回的 cursor，after 时传 newer_cursor
+	Direction  QueryDirection    `json:"direction"`                      // 翻页方向 before/after/around，默认 before
+	Limit      int               `json:"limit"`                          // 限制数量，默认 20，最大 100
+	// 过滤条件，翻页时保持不变
+	MessageTypes  []model.MessageType `json:"message_types"`  // 只查特定类型的消息（如图片、文本）
+	SenderId      uint                `json:"sender_id"`      // 只查某个用户发送的消息
+	HasAttachment bool                `json:"has_attachment"` // 只查包含图片、语音等附件的消息
+	Keyword       *string             `json:"keyword"`        // 模糊搜索
+	StartTime     time.Time           `json:"start_time"`     // 起始时间
+	EndTime       time.Time           `json:"end_time"`       // 结束时间
 }
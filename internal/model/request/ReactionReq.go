@@ -0,0 +1,7 @@
+package model
+
+// ReactionReq 添加或取消表情回应
+type ReactionReq struct {
+	MessageId uint   `json:"message_id" binding:"required"`
+	Emoji     string `json:"emoji" binding:"required"`
+}
@@ -4,3 +4,8 @@ type ReadMessageReq struct {
 	MessageId uint `json:"message_id"`
 	UserId    uint `json:"user_id"`
 }
+
+// PlayVoiceReq 标记语音已播放
+type PlayVoiceReq struct {
+	MessageId uint `json:"message_id" binding:"required"`
+}
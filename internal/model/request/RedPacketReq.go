@@ -0,0 +1,33 @@
+package model
+
+import "go-chat/internal/model"
+
+// RedPacketSendRequest 发红包，金额单位为分
+type RedPacketSendRequest struct {
+	TargetType *model.TargetType   `json:"target_type" binding:"required"` // 0 私聊 1 群聊
+	ReceiverId uint                `json:"receiver_id"`                    // 私聊接收者
+	GroupId    uint                `json:"group_id"`                       // 群组ID
+	Mode       model.RedPacketMode `json:"mode" binding:"required"`        // fixed 普通红包（amount 为单个金额） random 拼手气红包（amount 为总金额）
+	Amount     int64               `json:"amount" binding:"required"`      // 金额（分）
+	Count      int                 `json:"count" binding:"required"`       // 个数
+	Greeting   string              `json:"greeting"`                       // 祝福语
+}
+
+// RedPacketClaimRequest 抢红包
+type RedPacketClaimRequest struct {
+	RedPacketId uint `json:"red_packet_id" binding:"required"`
+}
+
+// WalletLogQueryRequest 钱包流水查询
+type WalletLogQueryRequest struct {
+	Type     model.WalletLogType `json:"type"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+// AdminWalletRechargeRequest 管理员给用户钱包充值
+type AdminWalletRechargeRequest struct {
+	UserId uint   `json:"user_id" binding:"required"`
+	Amount int64  `json:"amount" binding:"required"` // 充值金额（分）
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "go-chat/internal/model"
+
+// ReportCreateRequest 提交举报
+type ReportCreateRequest struct {
+	TargetType model.ReportTargetType `json:"target_type" binding:"required"` // message/user/group
+	TargetId   uint                   `json:"target_id" binding:"required"`
+	Category   model.ReportCategory   `json:"category" binding:"required"` // spam/abuse/porn/fraud/illegal/other
+	Comment    string                 `json:"comment"`
+}
+
+// ReportQueryRequest 举报查询
+type ReportQueryRequest struct {
+	ReporterId uint                   `json:"reporter_id"`
+	TargetType model.ReportTargetType `json:"target_type"`
+	TargetId   uint                   `json:"target_id"`
+	Category   model.ReportCategory   `json:"category"`
+	Status     *model.ReportStatus    `json:"status"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"pageSize"`
+}
+
+// ReportHandleRequest 处理举报
+type ReportHandleRequest struct {
+	ReportId uint               `json:"report_id" binding:"required"`
+	Status   model.ReportStatus `json:"status"` // 1 处理中 2 已处理 3 已驳回
+	Result   string             `json:"result"` // 处理结果说明
+}
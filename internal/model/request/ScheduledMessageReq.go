@@ -0,0 +1,30 @@
+package model
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// ScheduleMessageReq 创建定时消息
+type ScheduleMessageReq struct {
+	TargetType    *model.TargetType     `json:"target_type" binding:"required"`   // 0 私聊 1 群聊
+	TargetId      uint                  `json:"target_id" binding:"required"`     // 好友id或群组id
+	Type          *model.MessageType    `json:"type" binding:"required"`          // 消息类型，支持文本、图片、语音
+	Content       model.MessagePartList `json:"content" binding:"required,min=1"` // 消息内容
+	ReplyId       *int64                `json:"reply_id"`                         // 回复的消息ID
+	Ttl           int                   `json:"ttl"`                              // 发出的消息的销毁时长（秒）
+	BurnAfterRead bool                  `json:"burn_after_read"`                  // 发出的消息是否阅后即焚
+	SendAt        time.Time             `json:"send_at" binding:"required"`       // 计划发送时间
+}
+
+// ScheduleCancelReq 取消定时消息
+type ScheduleCancelReq struct {
+	Id uint `json:"id" binding:"required"`
+}
+
+// ScheduleQueryRequest 查询自己的定时消息
+type ScheduleQueryRequest struct {
+	Status   *model.ScheduleStatus `json:"status"` // 只查某个状态，为空时查全部
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
+}
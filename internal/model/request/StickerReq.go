@@ -0,0 +1,41 @@
+package model
+
+// StickerPackCreateRequest 创建表情包
+type StickerPackCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// StickerPackUpdateRequest 修改表情包，字段为空时不修改
+type StickerPackUpdateRequest struct {
+	PackId      uint    `json:"pack_id" binding:"required"`
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Cover       *string `json:"cover"` // 封面，只能使用包内表情的图片地址
+}
+
+// StickerPackIdRequest 按ID操作表情包
+type StickerPackIdRequest struct {
+	PackId uint `json:"pack_id" binding:"required"`
+}
+
+// StickerPackQueryRequest 分页查询表情包，keyword 匹配表情包名称、简介以及包内表情的名称和关键字
+type StickerPackQueryRequest struct {
+	Keyword  string `json:"keyword"`
+	Mine     bool   `json:"mine"` // 只查自己创建的表情包
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+}
+
+// StickerAddRequest 上传表情，pack_id 和 group_id 二选一，图片通过 file 字段上传
+type StickerAddRequest struct {
+	PackId   uint   `form:"pack_id"`  // 添加到自己的表情包
+	GroupId  uint   `form:"group_id"` // 添加为群自定义表情，仅群主和管理员
+	Name     string `form:"name" binding:"required"`
+	Keywords string `form:"keywords"`
+}
+
+// StickerIdRequest 按ID操作表情
+type StickerIdRequest struct {
+	StickerId uint `json:"sticker_id" binding:"required"`
+}
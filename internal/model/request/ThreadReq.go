@@ -0,0 +1,20 @@
+package model
+
+// ThreadRepliesRequest 按时间顺序分页查询话题的回复
+type ThreadRepliesRequest struct {
+	RootId uint `json:"root_id" binding:"required"` // 根消息ID
+	Cursor uint `json:"cursor"`                     // 上次查询的最大 id，默认为 0
+	Limit  int  `json:"limit"`
+}
+
+// ThreadQueryRequest 查询参与的话题
+type ThreadQueryRequest struct {
+	UnreadOnly bool `json:"unread_only"` // 只查有未读回复的话题
+	Page       int  `json:"page"`
+	PageSize   int  `json:"pageSize"`
+}
+
+// ThreadReadRequest 标记话题的回复全部已读
+type ThreadReadRequest struct {
+	RootId uint `json:"root_id" binding:"required"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// AdminUserVo 管理后台用户信息（不含密码）
+type AdminUserVo struct {
+	Id           uint               `json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	Username     string             `json:"username"`
+	Nickname     *string            `json:"nickname"`
+	Phone        *string            `json:"phone"`
+	Email        *string            `json:"email"`
+	Avatar       *string            `json:"avatar"`
+	LoginTime    int64              `json:"login_time"`
+	Status       model.Status       `json:"status"`
+	OnlineStatus model.OnlineStatus `json:"online_status"`
+	Role         model.UserRole     `json:"role"`
+	BanReason    *string            `json:"ban_reason"`
+	BanEnd       *time.Time         `json:"ban_end"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// FileUsageVo 用户存储用量，quota 为 0 表示不限制
+type FileUsageVo struct {
+	Files     int64 `json:"files"`     // 上传的文件数，秒传命中他人已上传的文件不计入
+	Used      int64 `json:"used"`      // 已用空间（字节）
+	Quota     int64 `json:"quota"`     // 配额（字节）
+	Remaining int64 `json:"remaining"` // 剩余空间（字节），不限制时为 -1
+}
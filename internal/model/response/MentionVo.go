@@ -0,0 +1,10 @@
+package model
+
+import "go-chat/internal/model"
+
+// MentionVo “@我的”列表项，附带被撤回前的消息内容
+type MentionVo struct {
+	model.Mention
+	IsRead  bool       `json:"is_read"`
+	Message *MessageVo `json:"message"` // 消息已被删除时为空
+}
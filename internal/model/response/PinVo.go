@@ -0,0 +1,10 @@
+package model
+
+import "go-chat/internal/model"
+
+// PinVo 置顶消息
+type PinVo struct {
+	model.Pin
+	PinnedByNickname string     `json:"pinned_by_nickname"`
+	Message          *MessageVo `json:"message"`
+}
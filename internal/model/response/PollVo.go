@@ -0,0 +1,24 @@
+package model
+
+import "go-chat/internal/model"
+
+// PollVo 投票详情，匿名投票不返回投票人
+type PollVo struct {
+	model.Poll
+	CreatorNickname string         `json:"creator_nickname"`
+	Closed          bool           `json:"closed"`     // 是否已结束（含已过截止时间）
+	Options         []PollOptionVo `json:"options"`    // 选项，按创建顺序
+	MyChoices       []uint         `json:"my_choices"` // 当前用户选中的选项，未投票为空
+}
+
+type PollOptionVo struct {
+	Id        uint          `json:"id"`
+	Content   string        `json:"content"`
+	VoteCount int           `json:"vote_count"`
+	Voters    []PollVoterVo `json:"voters"` // 投票人，匿名投票为空
+}
+
+type PollVoterVo struct {
+	UserId   uint   `json:"user_id"`
+	Nickname string `json:"nickname"`
+}
@@ -5,11 +5,13 @@ import (
 	"time"
 )
 
-// QueryMessagesResponse 查询消息列表
+// QueryMessagesResponse 查询消息列表，无论翻页方向 list 都按 id 从新到旧排列
 type QueryMessagesResponse struct {
-	List    []*MessageVo `json:"list"`     // 消息列表
-	Cursor  int64        `json:"cursor"`   // 下一页游标（最小 id）
-	HasMore bool         `json:"has_more"` // 是否还有更多数据
+	List        []*MessageVo `json:"list"`         // 消息列表
+	Cursor      int64        `json:"cursor"`       // 向前翻页的游标（最小 id）
+	HasMore     bool         `json:"has_more"`     // 是否还有更早的消息
+	NewerCursor int64        `json:"newer_cursor"` // 向后翻页的游标（最大 id）
+	HasNewer    bool         `json:"has_newer"`    // 是否还有更新的消息
 }
 
 type MessageVo struct {
@@ -20,19 +22,32 @@ type MessageVo struct {
 	ReceiverId   *int64
 	GroupId      *int64
 	ReplyId      *int64
+	RootId       *int64
 	ReaderIdList *model.ReaderIdList
+	PlayedIdList *model.ReaderIdList
 	TargetType   *model.TargetType
 	Content      *model.MessagePartList
 	Type         *model.MessageType
 	Status       *model.Status
 	ExtraData    interface{} `json:"extra_data" gorm:"type:json;comment:扩展字段"` // 扩展字段（如红包、投票等结构）
 
+	Ttl           int        `json:"ttl"`             // 销毁时长（秒），0 为普通消息
+	BurnAfterRead bool       `json:"burn_after_read"` // 阅后即焚
+	ExpireAt      *time.Time `json:"expire_at"`       // 销毁时间
+
+	LinkPreviews *model.LinkPreviewList `json:"link_previews"` // 链接预览，异步生成后通过 link_preview 推送
+	RevokedBy    *int64                 `json:"revoked_by"`    // 撤回者，撤回的消息不返回内容
+
 	//额外信息
 	Reply              *MessageVo `json:"reply"`
 	SenderNickName     *string
 	SenderAvatar       *string
 	SenderOnlineStatus *model.OnlineStatus
 	IsRead             bool
+	IsPlayed           bool         // 语音消息当前用户是否已播放
+	Reactions          []ReactionVo `json:"reactions"` // 表情回应，按首次回应的时间排序
+	Thread             *ThreadVo    `json:"thread"`    // 话题摘要，仅有回复的根消息
+	Pinned             bool         `json:"pinned"`    // 是否在会话中置顶
 }
 
 func (m *MessageVo) GetFieldsFromMessage(msg *model.Message) {
@@ -43,10 +58,23 @@ func (m *MessageVo) GetFieldsFromMessage(msg *model.Message) {
 	m.ReceiverId = msg.ReceiverId
 	m.GroupId = msg.GroupId
 	m.ReplyId = msg.ReplyId
+	m.RootId = msg.RootId
 	m.ReaderIdList = msg.ReaderIdList
+	m.PlayedIdList = msg.PlayedIdList
 	m.TargetType = msg.TargetType
 	m.Content = msg.Content
 	m.Type = msg.Type
 	m.Status = msg.Status
 	m.ExtraData = msg.ExtraData
+	m.Ttl = msg.Ttl
+	m.BurnAfterRead = msg.BurnAfterRead
+	m.ExpireAt = msg.ExpireAt
+	m.LinkPreviews = msg.LinkPreviews
+	m.RevokedBy = msg.RevokedBy
+	// 撤回的消息只保留在库中供举报审核使用，不再向用户返回内容
+	if msg.Status != nil && *msg.Status == model.Disable {
+		m.Content = nil
+		m.ExtraData = nil
+		m.LinkPreviews = nil
+	}
 }
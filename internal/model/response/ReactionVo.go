@@ -0,0 +1,14 @@
+package model
+
+// ReactionVo 消息上某个表情的回应汇总
+type ReactionVo struct {
+	Emoji   string           `json:"emoji"`
+	Count   int              `json:"count"`
+	Reacted bool             `json:"reacted"` // 当前用户是否回应过
+	Users   []ReactionUserVo `json:"users"`   // 回应的用户，按回应时间排序
+}
+
+type ReactionUserVo struct {
+	UserId   uint   `json:"user_id"`
+	Nickname string `json:"nickname"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// RedPacketVo 红包详情，未领完前只返回已领取的记录
+type RedPacketVo struct {
+	model.RedPacket
+	SenderNickname string             `json:"sender_nickname"`
+	Claimed        *RedPacketClaimVo  `json:"claimed"` // 当前用户领取的记录，未领取为空
+	Claims         []RedPacketClaimVo `json:"claims"`  // 领取记录
+	Best           *uint              `json:"best"`    // 拼手气红包领完后的手气最佳用户
+}
+
+type RedPacketClaimVo struct {
+	UserId    uint      `json:"user_id"`
+	Nickname  string    `json:"nickname"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WalletVo 钱包余额
+type WalletVo struct {
+	UserId  uint  `json:"user_id"`
+	Balance int64 `json:"balance"` // 余额（分）
+}
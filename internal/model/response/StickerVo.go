@@ -0,0 +1,9 @@
+package model
+
+import "go-chat/internal/model"
+
+// StickerPackVo 表情包详情
+type StickerPackVo struct {
+	model.StickerPack
+	Stickers []model.Sticker `json:"stickers"` // 包内表情，按添加顺序
+}
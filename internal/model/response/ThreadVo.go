@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ThreadVo 话题摘要，附在根消息上返回
+type ThreadVo struct {
+	RootId           uint                  `json:"root_id"`
+	ReplyCount       int                   `json:"reply_count"`
+	LastReplyId      uint                  `json:"last_reply_id"`
+	LastReplyAt      *time.Time            `json:"last_reply_at"`
+	ParticipantCount int                   `json:"participant_count"`
+	Participants     []ThreadParticipantVo `json:"participants"` // 最早参与的几位
+	Joined           bool                  `json:"joined"`       // 当前用户是否参与
+	Unread           int                   `json:"unread"`       // 当前用户的未读回复数，未参与时为 0
+	Root             *MessageVo            `json:"root,omitempty"`
+}
+
+type ThreadParticipantVo struct {
+	UserId   uint    `json:"user_id"`
+	Nickname string  `json:"nickname"`
+	Avatar   *string `json:"avatar"`
+}
+
+// ThreadRepliesResponse 话题回复列表
+type ThreadRepliesResponse struct {
+	Root    *MessageVo   `json:"root"`     // 根消息（含话题摘要）
+	List    []*MessageVo `json:"list"`     // 回复列表，按时间顺序
+	Cursor  int64        `json:"cursor"`   // 下一页游标（最大 id）
+	HasMore bool         `json:"has_more"` // 是否还有更多回复
+}
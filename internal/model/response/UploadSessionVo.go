@@ -0,0 +1,35 @@
+package model
+
+import (
+	"go-chat/internal/model"
+	"time"
+)
+
+// UploadSessionVo 分片上传会话，断点续传时只需上传 uploaded 为 false 的分片
+// 自己上传过相同内容时 file 不为空，status 为已完成，不需要上传；
+// 他人上传过相同内容时返回 proof，提交校验区间的字节即可秒传，否则仍需上传全部分片
+type UploadSessionVo struct {
+	UploadId  string             `json:"upload_id"`
+	Status    model.UploadStatus `json:"status"`
+	Size      int64              `json:"size"`
+	PartSize  int64              `json:"part_size"`
+	PartCount int                `json:"part_count"`
+	ExpireAt  time.Time          `json:"expire_at"`
+	Parts     []UploadPartVo     `json:"parts"`
+	File      *model.File        `json:"file,omitempty"`
+	Proof     *UploadProofVo     `json:"proof,omitempty"`
+}
+
+// UploadProofVo 秒传校验区间，客户端读取文件中从 offset 开始的 length 个字节
+type UploadProofVo struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// UploadPartVo 分片信息，url 为 PUT 上传地址
+type UploadPartVo struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	Uploaded   bool   `json:"uploaded"`
+	Url        string `json:"url,omitempty"`
+}
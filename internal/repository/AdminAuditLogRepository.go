@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type AdminAuditLogRepository struct {
+}
+
+var (
+	AdminAuditLogRepositoryInstance *AdminAuditLogRepository
+	adminAuditLogOnce               sync.Once
+)
+
+func InitAdminAuditLogRepository() {
+	adminAuditLogOnce.Do(func() {
+		AdminAuditLogRepositoryInstance = &AdminAuditLogRepository{}
+	})
+}
+
+func (r *AdminAuditLogRepository) Create(log *model.AdminAuditLog, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(log).Error
+}
+
+func (r *AdminAuditLogRepository) Page(req request.AdminAuditLogQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.AdminAuditLog], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.AdminAuditLog{})
+	if req.OperatorId != 0 {
+		query = query.Where("operator_id = ?", req.OperatorId)
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetId != 0 {
+		query = query.Where("target_id = ?", req.TargetId)
+	}
+	result := &pagination.PageResult[model.AdminAuditLog]{Records: []model.AdminAuditLog{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"go-chat/internal/db"
 	"go-chat/internal/model"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type FileRepository struct {
@@ -21,3 +24,150 @@ func (r *FileRepository) Create(file *model.File, tx ...*gorm.DB) error {
 	gormDB := db.GetGormDB(tx...)
 	return gormDB.Create(file).Error
 }
+
+func (r *FileRepository) GetById(id uint, tx ...*gorm.DB) (*model.File, error) {
+	gormDB := db.GetGormDB(tx...)
+	var file model.File
+	err := gormDB.First(&file, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *FileRepository) GetBySha256(sha256 string, tx ...*gorm.DB) (*model.File, error) {
+	gormDB := db.GetGormDB(tx...)
+	var file model.File
+	err := gormDB.Where("sha256 = ?", sha256).Order("id").First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *FileRepository) GetBySha256AndUser(sha256 string, userId uint, tx ...*gorm.DB) (*model.File, error) {
+	gormDB := db.GetGormDB(tx...)
+	var file model.File
+	err := gormDB.Where("sha256 = ? AND user_id = ?", sha256, userId).Order("id").First(&file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *FileRepository) IsOwnedBy(sha256 string, userId uint, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	var count int64
+	err := gormDB.Model(&model.File{}).Where("sha256 = ? AND user_id = ?", sha256, userId).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *FileRepository) IsVisibleTo(urls []string, userId uint, tx ...*gorm.DB) (bool, error) {
+	if len(urls) == 0 {
+		return false, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	var count int64
+	// 头像对所有登录用户可见，但只认用户自己上传的头像和群成员上传的群头像，
+	// 避免把别人的文件地址设为头像来获取访问权限
+	err := gormDB.Model(&model.User{}).Where("avatar IN ?", urls).
+		Where("EXISTS (SELECT 1 FROM files WHERE files.url IN ? AND files.user_id = users.id AND files.deleted_at IS NULL)", urls).
+		Limit(1).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = gormDB.Model(&model.Group{}).Where("avatar IN ?", urls).
+		Where("EXISTS (SELECT 1 FROM files JOIN group_members ON group_members.member_id = files.user_id "+
+			"WHERE files.url IN ? AND group_members.group_id = `groups`.id AND files.deleted_at IS NULL AND group_members.deleted_at IS NULL)", urls).
+		Limit(1).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	// 表情包中的表情（包括用作封面的）对所有登录用户可见，群自定义表情只对群成员可见
+	err = gormDB.Model(&model.Sticker{}).Where("url IN ?", urls).
+		Where("(pack_id IS NOT NULL OR group_id IN (SELECT group_id FROM group_members WHERE member_id = ? AND deleted_at IS NULL))", userId).
+		Limit(1).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	// 私聊的收发双方、群聊的当前成员可以看到消息中的文件
+	contains := gormDB.Where("JSON_CONTAINS(content, JSON_OBJECT('content', ?))", urls[0])
+	for _, url := range urls[1:] {
+		contains = contains.Or("JSON_CONTAINS(content, JSON_OBJECT('content', ?))", url)
+	}
+	// 合并转发的聊天记录把文件保存在 extra_data 中，JSON_SEARCH 的 % 和 _ 是通配符需要转义
+	for _, url := range urls {
+		contains = contains.Or("(type = ? AND JSON_SEARCH(extra_data, 'one', ?) IS NOT NULL)",
+			model.ForwardedCotent, jsonSearchEscaper.Replace(url))
+	}
+	err = gormDB.Model(&model.Message{}).
+		Where(contains).
+		Where("((target_type = ? AND (sender_id = ? OR receiver_id = ?)) OR "+
+			"(target_type = ? AND group_id IN (SELECT group_id FROM group_members WHERE member_id = ? AND deleted_at IS NULL)))",
+			model.PrivateTarget, userId, userId, model.GroupTarget, userId).
+		Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+var jsonSearchEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (r *FileRepository) Usage(userId uint, tx ...*gorm.DB) (int64, int64, error) {
+	gormDB := db.GetGormDB(tx...)
+	var usage struct {
+		Files int64
+		Size  int64
+	}
+	err := gormDB.Model(&model.File{}).
+		Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS size").
+		Where("user_id = ?", userId).
+		// 复用他人已上传内容的记录不占用空间，只统计同内容中最早的记录
+		Where("NOT EXISTS (SELECT 1 FROM files AS origin WHERE origin.sha256 = files.sha256 AND origin.id < files.id AND origin.deleted_at IS NULL)").
+		Scan(&usage).Error
+	return usage.Files, usage.Size, err
+}
+
+func (r *FileRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.File{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *FileRepository) UpdateBySha256(sha256 string, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.File{}).Where("sha256 = ?", sha256).Updates(updates).Error
+}
+
+func (r *FileRepository) AdjustRefs(sha256s []string, delta int, tx ...*gorm.DB) error {
+	if len(sha256s) == 0 || delta == 0 {
+		return nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	// orphaned_at 需在 ref_count 之前赋值，MySQL 按顺序计算 SET 子句
+	return gormDB.Exec("UPDATE files SET "+
+		"orphaned_at = CASE WHEN ref_count + ? <= 0 THEN ? ELSE NULL END, "+
+		"ref_count = GREATEST(ref_count + ?, 0) "+
+		"WHERE sha256 IN ? AND deleted_at IS NULL",
+		delta, time.Now(), delta, sha256s).Error
+}
+
+func (r *FileRepository) ListOrphaned(before time.Time, limit int, tx ...*gorm.DB) ([]model.File, error) {
+	gormDB := db.GetGormDB(tx...)
+	var files []model.File
+	err := gormDB.Where("ref_count <= 0 AND orphaned_at < ?", before).
+		Order("id").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+func (r *FileRepository) DeleteOrphaned(id uint, before time.Time, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Unscoped().Where("id = ? AND ref_count <= 0 AND orphaned_at < ?", id, before).Delete(&model.File{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+type MentionRepository struct {
+}
+
+var (
+	MentionRepositoryInstance *MentionRepository
+	mentionOnce               sync.Once
+)
+
+func InitMentionRepository() {
+	mentionOnce.Do(func() {
+		MentionRepositoryInstance = &MentionRepository{}
+	})
+}
+
+func (r *MentionRepository) CreateBatch(mentions []*model.Mention, tx ...*gorm.DB) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.CreateInBatches(mentions, 500).Error
+}
+
+func (r *MentionRepository) Page(userId uint, req request.MentionQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Mention], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.Mention{}).Where("user_id = ?", userId)
+	if req.GroupId != 0 {
+		query = query.Where("group_id = ?", req.GroupId)
+	}
+	if req.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	result := &pagination.PageResult[model.Mention]{Records: []model.Mention{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *MentionRepository) MarkRead(userId uint, req request.MentionReadRequest, tx ...*gorm.DB) (int64, error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.Mention{}).Where("user_id = ? AND read_at IS NULL", userId)
+	if req.GroupId != 0 {
+		query = query.Where("group_id = ?", req.GroupId)
+	}
+	if len(req.MentionIds) > 0 {
+		query = query.Where("id IN ?", req.MentionIds)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *MentionRepository) MarkReadByMessageId(userId uint, messageId uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.Mention{}).
+		Where("user_id = ? AND message_id = ? AND read_at IS NULL", userId, messageId).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *MentionRepository) DeleteByMessageId(messageId uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Where("message_id = ?", messageId).Delete(&model.Mention{}).Error
+}
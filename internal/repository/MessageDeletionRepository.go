@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type MessageDeletionRepository struct {
+}
+
+var (
+	MessageDeletionRepositoryInstance *MessageDeletionRepository
+	messageDeletionOnce               sync.Once
+)
+
+func InitMessageDeletionRepository() {
+	messageDeletionOnce.Do(func() {
+		MessageDeletionRepositoryInstance = &MessageDeletionRepository{}
+	})
+}
+
+func (r *MessageDeletionRepository) Create(userId uint, messageIds []uint, tx ...*gorm.DB) error {
+	if len(messageIds) == 0 {
+		return nil
+	}
+	deletions := make([]model.MessageDeletion, 0, len(messageIds))
+	for _, messageId := range messageIds {
+		deletions = append(deletions, model.MessageDeletion{UserId: userId, MessageId: messageId})
+	}
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&deletions).Error
+}
+
+func (r *MessageDeletionRepository) SaveClear(clear *model.ConversationClear, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"cleared_id", "updated_at"}),
+	}).Create(clear).Error
+}
@@ -6,7 +6,9 @@ import (
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
 	"gorm.io/gorm"
+	"strings"
 	"sync"
+	"time"
 )
 
 type MessageRepository struct {
@@ -36,18 +38,35 @@ func (r *MessageRepository) GetById(id uint) (message *model.Message, err error)
 	return
 }
 
+func (r *MessageRepository) GetByIdList(ids []uint) ([]*model.Message, error) {
+	var messages []*model.Message
+	if len(ids) == 0 {
+		return messages, nil
+	}
+	err := db.Mysql.Where("id IN ?", ids).Find(&messages).Error
+	return messages, err
+}
+
 func (r *MessageRepository) UpdateFields(id uint, fields map[string]interface{}) (err error) {
 	err = db.Mysql.Model(&model.Message{}).Where("id = ?", id).Updates(fields).Error
 	return
 }
 
+func (r *MessageRepository) Delete(id uint, tx ...*gorm.DB) (err error) {
+	gormDB := db.GetGormDB(tx...)
+	err = gormDB.Delete(&model.Message{}, id).Error
+	return
+}
+
 func (r *MessageRepository) QueryHistoryMessages(userId uint, req *request.QueryMessagesRequest) ([]*model.Message, error) {
 	tx := db.Mysql.Model(&model.Message{})
 	switch *req.TargetType {
 	case model.PrivateTarget:
+		// 双方的两个方向用新的语句构造成一组，整体加括号后再与其他条件 AND，
+		// 否则 OR 会把后面的游标、过滤和删除条件只作用在其中一个方向上
 		tx = tx.Where("target_type = ?", model.PrivateTarget).
 			Where(
-				tx.Where("sender_id = ? AND receiver_id = ?", userId, req.TargetId).
+				db.Mysql.Where("sender_id = ? AND receiver_id = ?", userId, req.TargetId).
 					Or("sender_id = ? AND receiver_id = ?", req.TargetId, userId),
 			)
 	case model.GroupTarget:
@@ -57,12 +76,31 @@ func (r *MessageRepository) QueryHistoryMessages(userId uint, req *request.Query
 		return nil, errors.New("非法的 target_type")
 	}
 
-	if req.Cursor > 0 {
+	tx = tx.Scopes(visibleTo(userId, *req.TargetType, req.TargetId))
+
+	// 向后翻页按 id 升序取游标之后的消息，向前翻页按 id 降序取游标之前的消息
+	order := "id DESC"
+	if req.Direction == request.QueryAfter {
+		tx = tx.Where("id > ?", req.Cursor)
+		order = "id ASC"
+	} else if req.Cursor > 0 {
 		tx = tx.Where("id < ?", req.Cursor)
 	}
 
-	if req.MessageTypes != nil {
-		tx = tx.Where("type = ?", *req.MessageTypes)
+	if len(req.MessageTypes) > 0 {
+		tx = tx.Where("type IN ?", req.MessageTypes)
+	}
+	if req.SenderId > 0 {
+		tx = tx.Where("sender_id = ?", req.SenderId)
+	}
+	if req.HasAttachment {
+		conditions := make([]string, 0, len(model.AttachmentTypes))
+		args := make([]interface{}, 0, len(model.AttachmentTypes))
+		for _, contentType := range model.AttachmentTypes {
+			conditions = append(conditions, "JSON_SEARCH(content, 'one', ?, NULL, '$[*].type') IS NOT NULL")
+			args = append(args, contentType)
+		}
+		tx = tx.Where(strings.Join(conditions, " OR "), args...)
 	}
 	if req.Keyword != nil {
 		tx = tx.Where("JSON_EXTRACT(content, '$[*].text') LIKE ?", "%"+*req.Keyword+"%")
@@ -75,11 +113,39 @@ func (r *MessageRepository) QueryHistoryMessages(userId uint, req *request.Query
 	}
 
 	limit := req.Limit
-	if limit <= 0 || limit > 100 {
+	if limit <= 0 {
 		limit = 20
+	} else if limit > 100 {
+		limit = 100
 	}
 
 	var messages []*model.Message
-	err := tx.Order("id DESC").Limit(limit + 1).Find(&messages).Error
+	err := tx.Order(order).Limit(limit + 1).Find(&messages).Error
+	return messages, err
+}
+
+func (r *MessageRepository) QueryThreadReplies(userId uint, rootId uint, targetType model.TargetType, targetId uint, cursor uint, limit int) ([]*model.Message, error) {
+	var messages []*model.Message
+	err := db.Mysql.Where("root_id = ? AND id > ?", rootId, cursor).
+		Scopes(visibleTo(userId, targetType, targetId)).
+		Order("id ASC").Limit(limit + 1).Find(&messages).Error
+	return messages, err
+}
+
+// visibleTo 排除用户在会话中看不到的消息，历史消息和话题回复共用
+func visibleTo(userId uint, targetType model.TargetType, targetId uint) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		// 到期的限时消息在定时任务删除前也不再返回
+		tx = tx.Where("expire_at IS NULL OR expire_at > ?", time.Now())
+		// 自己删除的消息和清空聊天记录之前的消息不再返回
+		return tx.Where("NOT EXISTS (SELECT 1 FROM message_deletions d WHERE d.message_id = messages.id AND d.user_id = ? AND d.deleted_at IS NULL)", userId).
+			Where("id > COALESCE((SELECT c.cleared_id FROM conversation_clears c WHERE c.user_id = ? AND c.target_type = ? AND c.target_id = ? AND c.deleted_at IS NULL), 0)",
+				userId, targetType, targetId)
+	}
+}
+
+func (r *MessageRepository) ListExpired(now time.Time, limit int) ([]*model.Message, error) {
+	var messages []*model.Message
+	err := db.Mysql.Where("expire_at <= ?", now).Order("expire_at").Limit(limit).Find(&messages).Error
 	return messages, err
 }
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type ModerationReviewRepository struct {
+}
+
+var (
+	ModerationReviewRepositoryInstance *ModerationReviewRepository
+	moderationReviewOnce               sync.Once
+)
+
+func InitModerationReviewRepository() {
+	moderationReviewOnce.Do(func() {
+		ModerationReviewRepositoryInstance = &ModerationReviewRepository{}
+	})
+}
+
+func (r *ModerationReviewRepository) Create(review *model.ModerationReview, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(review).Error
+}
+
+func (r *ModerationReviewRepository) GetById(id uint, tx ...*gorm.DB) (*model.ModerationReview, error) {
+	gormDB := db.GetGormDB(tx...)
+	var review model.ModerationReview
+	err := gormDB.First(&review, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *ModerationReviewRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.ModerationReview{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ModerationReviewRepository) Page(req request.ModerationReviewQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.ModerationReview], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.ModerationReview{})
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	if req.SenderId != 0 {
+		query = query.Where("sender_id = ?", req.SenderId)
+	}
+	result := &pagination.PageResult[model.ModerationReview]{Records: []model.ModerationReview{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
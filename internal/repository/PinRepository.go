@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type PinRepository struct {
+}
+
+var (
+	PinRepositoryInstance *PinRepository
+	pinOnce               sync.Once
+)
+
+func InitPinRepository() {
+	pinOnce.Do(func() {
+		PinRepositoryInstance = &PinRepository{}
+	})
+}
+
+func (r *PinRepository) Create(pin *model.Pin, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *PinRepository) Delete(messageId uint, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Unscoped().Where("message_id = ?", messageId).Delete(&model.Pin{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PinRepository) CountByConversationForUpdate(conversation string, tx *gorm.DB) (int64, error) {
+	var count int64
+	// 会话还没有置顶时锁住 conversation 索引上的间隙，同样阻止并发写入
+	err := tx.Model(&model.Pin{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("conversation = ?", conversation).Count(&count).Error
+	return count, err
+}
+
+func (r *PinRepository) ListByConversation(conversation string, tx ...*gorm.DB) ([]model.Pin, error) {
+	gormDB := db.GetGormDB(tx...)
+	pins := make([]model.Pin, 0)
+	err := gormDB.Where("conversation = ?", conversation).Order("id DESC").Find(&pins).Error
+	return pins, err
+}
+
+func (r *PinRepository) PinnedMessageIds(messageIds []uint, tx ...*gorm.DB) ([]uint, error) {
+	ids := make([]uint, 0)
+	if len(messageIds) == 0 {
+		return ids, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Model(&model.Pin{}).Where("message_id IN ?", messageIds).Pluck("message_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type PollRepository struct {
+}
+
+var (
+	PollRepositoryInstance *PollRepository
+	pollOnce               sync.Once
+)
+
+func InitPollRepository() {
+	pollOnce.Do(func() {
+		PollRepositoryInstance = &PollRepository{}
+	})
+}
+
+func (r *PollRepository) Create(poll *model.Poll, options []*model.PollOption, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	if err := gormDB.Create(poll).Error; err != nil {
+		return err
+	}
+	for _, option := range options {
+		option.PollId = poll.ID
+	}
+	return gormDB.Create(options).Error
+}
+
+func (r *PollRepository) GetById(id uint, tx ...*gorm.DB) (*model.Poll, error) {
+	gormDB := db.GetGormDB(tx...)
+	return r.first(gormDB, id)
+}
+
+func (r *PollRepository) GetByIdForUpdate(id uint, tx *gorm.DB) (*model.Poll, error) {
+	return r.first(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *PollRepository) first(gormDB *gorm.DB, id uint) (*model.Poll, error) {
+	var poll model.Poll
+	err := gormDB.First(&poll, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &poll, nil
+}
+
+func (r *PollRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.Poll{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *PollRepository) GetOptions(pollId uint, tx ...*gorm.DB) ([]model.PollOption, error) {
+	gormDB := db.GetGormDB(tx...)
+	var options []model.PollOption
+	err := gormDB.Where("poll_id = ?", pollId).Order("id").Find(&options).Error
+	return options, err
+}
+
+func (r *PollRepository) AddVoteCount(optionIds []uint, delta int, tx ...*gorm.DB) error {
+	if len(optionIds) == 0 {
+		return nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.PollOption{}).Where("id IN ?", optionIds).
+		UpdateColumn("vote_count", gorm.Expr("vote_count + ?", delta)).Error
+}
+
+func (r *PollRepository) GetVote(pollId uint, userId uint, tx ...*gorm.DB) (*model.PollVote, error) {
+	gormDB := db.GetGormDB(tx...)
+	var vote model.PollVote
+	err := gormDB.Where("poll_id = ? AND user_id = ?", pollId, userId).First(&vote).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &vote, nil
+}
+
+func (r *PollRepository) GetVotes(pollId uint, tx ...*gorm.DB) ([]model.PollVote, error) {
+	gormDB := db.GetGormDB(tx...)
+	var votes []model.PollVote
+	err := gormDB.Where("poll_id = ?", pollId).Order("id").Find(&votes).Error
+	return votes, err
+}
+
+func (r *PollRepository) SaveVote(vote *model.PollVote, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	if vote.ID == 0 {
+		return gormDB.Create(vote).Error
+	}
+	return gormDB.Model(&model.PollVote{}).Where("id = ?", vote.ID).Update("option_ids", vote.OptionIds).Error
+}
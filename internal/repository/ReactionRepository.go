@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type ReactionRepository struct {
+}
+
+var (
+	ReactionRepositoryInstance *ReactionRepository
+	reactionOnce               sync.Once
+)
+
+func InitReactionRepository() {
+	reactionOnce.Do(func() {
+		ReactionRepositoryInstance = &ReactionRepository{}
+	})
+}
+
+func (r *ReactionRepository) Create(reaction *model.Reaction, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *ReactionRepository) Delete(messageId uint, userId uint, emoji string, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Unscoped().
+		Where("message_id = ? AND user_id = ? AND emoji = ?", messageId, userId, emoji).
+		Delete(&model.Reaction{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ReactionRepository) ListByMessageIds(messageIds []uint, tx ...*gorm.DB) ([]model.Reaction, error) {
+	reactions := make([]model.Reaction, 0)
+	if len(messageIds) == 0 {
+		return reactions, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Where("message_id IN ?", messageIds).Order("id").Find(&reactions).Error
+	return reactions, err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+type RedPacketRepository struct {
+}
+
+var (
+	RedPacketRepositoryInstance *RedPacketRepository
+	redPacketOnce               sync.Once
+)
+
+func InitRedPacketRepository() {
+	redPacketOnce.Do(func() {
+		RedPacketRepositoryInstance = &RedPacketRepository{}
+	})
+}
+
+func (r *RedPacketRepository) Create(packet *model.RedPacket, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(packet).Error
+}
+
+func (r *RedPacketRepository) GetById(id uint, tx ...*gorm.DB) (*model.RedPacket, error) {
+	gormDB := db.GetGormDB(tx...)
+	return r.first(gormDB, id)
+}
+
+func (r *RedPacketRepository) GetByIdForUpdate(id uint, tx *gorm.DB) (*model.RedPacket, error) {
+	return r.first(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *RedPacketRepository) first(gormDB *gorm.DB, id uint) (*model.RedPacket, error) {
+	var packet model.RedPacket
+	err := gormDB.First(&packet, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &packet, nil
+}
+
+func (r *RedPacketRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.RedPacket{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *RedPacketRepository) ListExpired(now time.Time, limit int, tx ...*gorm.DB) ([]model.RedPacket, error) {
+	gormDB := db.GetGormDB(tx...)
+	var packets []model.RedPacket
+	err := gormDB.Where("status = ? AND expire_at < ?", model.RedPacketActive, now).
+		Order("id").Limit(limit).Find(&packets).Error
+	return packets, err
+}
+
+func (r *RedPacketRepository) CreateClaim(claim *model.RedPacketClaim, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(claim).Error
+}
+
+func (r *RedPacketRepository) GetClaim(packetId uint, userId uint, tx ...*gorm.DB) (*model.RedPacketClaim, error) {
+	gormDB := db.GetGormDB(tx...)
+	var claim model.RedPacketClaim
+	err := gormDB.Where("red_packet_id = ? AND user_id = ?", packetId, userId).First(&claim).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *RedPacketRepository) GetClaims(packetId uint, tx ...*gorm.DB) ([]model.RedPacketClaim, error) {
+	gormDB := db.GetGormDB(tx...)
+	var claims []model.RedPacketClaim
+	err := gormDB.Where("red_packet_id = ?", packetId).Order("id").Find(&claims).Error
+	return claims, err
+}
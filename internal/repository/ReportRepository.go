@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type ReportRepository struct {
+}
+
+var (
+	ReportRepositoryInstance *ReportRepository
+	reportOnce               sync.Once
+)
+
+func InitReportRepository() {
+	reportOnce.Do(func() {
+		ReportRepositoryInstance = &ReportRepository{}
+	})
+}
+
+func (r *ReportRepository) Create(report *model.Report, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(report).Error
+}
+
+func (r *ReportRepository) GetById(id uint, tx ...*gorm.DB) (*model.Report, error) {
+	gormDB := db.GetGormDB(tx...)
+	var report model.Report
+	err := gormDB.First(&report, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *ReportRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.Report{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ReportRepository) ExistsOpen(reporterId uint, targetType model.ReportTargetType, targetId uint, tx ...*gorm.DB) bool {
+	gormDB := db.GetGormDB(tx...)
+	var count int64
+	err := gormDB.Model(&model.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status IN ?",
+			reporterId, targetType, targetId, []model.ReportStatus{model.ReportPending, model.ReportProcessing}).
+		Count(&count).Error
+	if err != nil {
+		return false
+	}
+	return count > 0
+}
+
+func (r *ReportRepository) Page(req request.ReportQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Report], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.Report{})
+	if req.ReporterId != 0 {
+		query = query.Where("reporter_id = ?", req.ReporterId)
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetId != 0 {
+		query = query.Where("target_id = ?", req.TargetId)
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	result := &pagination.PageResult[model.Report]{Records: []model.Report{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
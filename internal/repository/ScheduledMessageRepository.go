@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+type ScheduledMessageRepository struct {
+}
+
+var (
+	ScheduledMessageRepositoryInstance *ScheduledMessageRepository
+	scheduledMessageOnce               sync.Once
+)
+
+func InitScheduledMessageRepository() {
+	scheduledMessageOnce.Do(func() {
+		ScheduledMessageRepositoryInstance = &ScheduledMessageRepository{}
+	})
+}
+
+func (r *ScheduledMessageRepository) Create(scheduled *model.ScheduledMessage, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(scheduled).Error
+}
+
+func (r *ScheduledMessageRepository) ListDue(now time.Time, staleBefore time.Time, limit int, tx ...*gorm.DB) ([]model.ScheduledMessage, error) {
+	gormDB := db.GetGormDB(tx...)
+	var list []model.ScheduledMessage
+	err := gormDB.Where("(status = ? AND send_at <= ?) OR (status = ? AND claimed_at < ?)",
+		model.SchedulePending, now, model.ScheduleSending, staleBefore).
+		Order("send_at, id").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *ScheduledMessageRepository) Claim(id uint, now time.Time, staleBefore time.Time, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	// 重新领取时以原领取时间为条件，多个实例同时领取只有一个能成功
+	result := gormDB.Model(&model.ScheduledMessage{}).
+		Where("id = ? AND (status = ? OR (status = ? AND claimed_at < ?))", id, model.SchedulePending, model.ScheduleSending, staleBefore).
+		Updates(map[string]interface{}{"status": model.ScheduleSending, "claimed_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ScheduledMessageRepository) Transit(id uint, from model.ScheduleStatus, to model.ScheduleStatus, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Model(&model.ScheduledMessage{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ScheduledMessageRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.ScheduledMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ScheduledMessageRepository) GetById(id uint, tx ...*gorm.DB) (*model.ScheduledMessage, error) {
+	gormDB := db.GetGormDB(tx...)
+	var scheduled model.ScheduledMessage
+	err := gormDB.First(&scheduled, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+func (r *ScheduledMessageRepository) Page(senderId uint, req request.ScheduleQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.ScheduledMessage], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.ScheduledMessage{}).Where("sender_id = ?", senderId)
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	result := &pagination.PageResult[model.ScheduledMessage]{Records: []model.ScheduledMessage{}}
+	_, err := pagination.Paginate(query.Order("send_at DESC, id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
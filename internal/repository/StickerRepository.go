@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type StickerRepository struct {
+}
+
+var (
+	StickerRepositoryInstance *StickerRepository
+	stickerOnce               sync.Once
+)
+
+func InitStickerRepository() {
+	stickerOnce.Do(func() {
+		StickerRepositoryInstance = &StickerRepository{}
+	})
+}
+
+func (r *StickerRepository) CreatePack(pack *model.StickerPack, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(pack).Error
+}
+
+func (r *StickerRepository) GetPackById(id uint, tx ...*gorm.DB) (*model.StickerPack, error) {
+	gormDB := db.GetGormDB(tx...)
+	var pack model.StickerPack
+	err := gormDB.First(&pack, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pack, nil
+}
+
+func (r *StickerRepository) UpdatePack(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.StickerPack{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *StickerRepository) AddStickerCount(packId uint, delta int, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.StickerPack{}).Where("id = ?", packId).
+		UpdateColumn("sticker_count", gorm.Expr("sticker_count + ?", delta)).Error
+}
+
+func (r *StickerRepository) DeletePack(id uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Delete(&model.StickerPack{}, id).Error
+}
+
+func (r *StickerRepository) PagePacks(userId uint, req request.StickerPackQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.StickerPack], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.StickerPack{})
+	if req.Mine {
+		query = query.Where("creator_id = ?", userId)
+	} else {
+		// 空表情包没有可用的表情，只有创建者自己能看到
+		query = query.Where("sticker_count > 0")
+	}
+	if req.Keyword != "" {
+		like := "%" + req.Keyword + "%"
+		stickerPacks := gormDB.Model(&model.Sticker{}).Select("pack_id").
+			Where("pack_id IS NOT NULL AND (name LIKE ? OR keywords LIKE ?)", like, like)
+		query = query.Where("name LIKE ? OR description LIKE ? OR id IN (?)", like, like, stickerPacks)
+	}
+	result := &pagination.PageResult[model.StickerPack]{Records: []model.StickerPack{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *StickerRepository) Create(sticker *model.Sticker, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Clauses(clause.OnConflict{DoNothing: true}).Create(sticker)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *StickerRepository) GetById(id uint, tx ...*gorm.DB) (*model.Sticker, error) {
+	gormDB := db.GetGormDB(tx...)
+	var sticker model.Sticker
+	err := gormDB.First(&sticker, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sticker, nil
+}
+
+func (r *StickerRepository) GetByIds(ids []uint, tx ...*gorm.DB) ([]model.Sticker, error) {
+	stickers := make([]model.Sticker, 0)
+	if len(ids) == 0 {
+		return stickers, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Where("id IN ?", ids).Find(&stickers).Error
+	return stickers, err
+}
+
+func (r *StickerRepository) Delete(id uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Unscoped().Delete(&model.Sticker{}, id).Error
+}
+
+func (r *StickerRepository) DeleteByPackId(packId uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Unscoped().Where("pack_id = ?", packId).Delete(&model.Sticker{}).Error
+}
+
+func (r *StickerRepository) ListByPackId(packId uint, tx ...*gorm.DB) ([]model.Sticker, error) {
+	gormDB := db.GetGormDB(tx...)
+	stickers := make([]model.Sticker, 0)
+	err := gormDB.Where("pack_id = ?", packId).Order("id ASC").Find(&stickers).Error
+	return stickers, err
+}
+
+func (r *StickerRepository) ListByGroupId(groupId uint, tx ...*gorm.DB) ([]model.Sticker, error) {
+	gormDB := db.GetGormDB(tx...)
+	stickers := make([]model.Sticker, 0)
+	err := gormDB.Where("group_id = ?", groupId).Order("id ASC").Find(&stickers).Error
+	return stickers, err
+}
+
+func (r *StickerRepository) CountByGroupId(groupId uint, tx ...*gorm.DB) (int64, error) {
+	gormDB := db.GetGormDB(tx...)
+	var count int64
+	err := gormDB.Model(&model.Sticker{}).Where("group_id = ?", groupId).Count(&count).Error
+	return count, err
+}
+
+func (r *StickerRepository) AddFavorite(userId uint, stickerId uint, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.StickerFavorite{UserId: userId, StickerId: stickerId})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *StickerRepository) RemoveFavorite(userId uint, stickerId uint, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Unscoped().Where("user_id = ? AND sticker_id = ?", userId, stickerId).Delete(&model.StickerFavorite{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *StickerRepository) RemoveFavoritesBySticker(stickerIds []uint, tx ...*gorm.DB) error {
+	if len(stickerIds) == 0 {
+		return nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Unscoped().Where("sticker_id IN ?", stickerIds).Delete(&model.StickerFavorite{}).Error
+}
+
+func (r *StickerRepository) CountFavorites(userId uint, tx ...*gorm.DB) (int64, error) {
+	gormDB := db.GetGormDB(tx...)
+	var count int64
+	err := gormDB.Model(&model.StickerFavorite{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+func (r *StickerRepository) ListFavorites(userId uint, tx ...*gorm.DB) ([]model.Sticker, error) {
+	gormDB := db.GetGormDB(tx...)
+	stickers := make([]model.Sticker, 0)
+	err := gormDB.Model(&model.Sticker{}).
+		Joins("JOIN sticker_favorites ON sticker_favorites.sticker_id = stickers.id AND sticker_favorites.deleted_at IS NULL").
+		Where("sticker_favorites.user_id = ?", userId).
+		Order("sticker_favorites.id DESC").Find(&stickers).Error
+	return stickers, err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+type ThreadRepository struct {
+}
+
+var (
+	ThreadRepositoryInstance *ThreadRepository
+	threadOnce               sync.Once
+)
+
+func InitThreadRepository() {
+	threadOnce.Do(func() {
+		ThreadRepositoryInstance = &ThreadRepository{}
+	})
+}
+
+func (r *ThreadRepository) AddReply(rootId uint, replyId uint, replyAt time.Time, tx ...*gorm.DB) (*model.Thread, error) {
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "root_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reply_count":   gorm.Expr("reply_count + 1"),
+			"last_reply_id": gorm.Expr("GREATEST(last_reply_id, ?)", replyId),
+			"last_reply_at": replyAt,
+		}),
+	}).Create(&model.Thread{RootId: rootId, ReplyCount: 1, LastReplyId: replyId, LastReplyAt: &replyAt}).Error
+	if err != nil {
+		return nil, err
+	}
+	var thread model.Thread
+	if err := gormDB.Where("root_id = ?", rootId).First(&thread).Error; err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+func (r *ThreadRepository) Join(rootId uint, userId uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ThreadParticipant{RootId: rootId, UserId: userId}).Error
+}
+
+func (r *ThreadRepository) RecordReply(rootId uint, userId uint, replyCount int, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "root_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"read_count": gorm.Expr("read_count + 1")}),
+	}).Create(&model.ThreadParticipant{RootId: rootId, UserId: userId, ReadCount: replyCount}).Error
+}
+
+func (r *ThreadRepository) MarkRead(rootId uint, userId uint, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.ThreadParticipant{}).
+		Where("root_id = ? AND user_id = ?", rootId, userId).
+		Update("read_count", gorm.Expr("(SELECT reply_count FROM threads WHERE root_id = ?)", rootId)).Error
+}
+
+func (r *ThreadRepository) GetByRootIds(rootIds []uint, tx ...*gorm.DB) ([]model.Thread, error) {
+	threads := make([]model.Thread, 0)
+	if len(rootIds) == 0 {
+		return threads, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Where("root_id IN ?", rootIds).Find(&threads).Error
+	return threads, err
+}
+
+func (r *ThreadRepository) GetParticipants(rootIds []uint, tx ...*gorm.DB) ([]model.ThreadParticipant, error) {
+	participants := make([]model.ThreadParticipant, 0)
+	if len(rootIds) == 0 {
+		return participants, nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	err := gormDB.Where("root_id IN ?", rootIds).Order("id").Find(&participants).Error
+	return participants, err
+}
+
+func (r *ThreadRepository) PageJoined(userId uint, req request.ThreadQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.Thread], error) {
+	gormDB := db.GetGormDB(tx...)
+	joined := gormDB.Model(&model.ThreadParticipant{}).Select("root_id").Where("user_id = ?", userId)
+	if req.UnreadOnly {
+		joined = joined.Where("read_count < (SELECT reply_count FROM threads t WHERE t.root_id = thread_participants.root_id)")
+	}
+	query := gormDB.Model(&model.Thread{}).Where("root_id IN (?)", joined)
+	result := &pagination.PageResult[model.Thread]{Records: []model.Thread{}}
+	_, err := pagination.Paginate(query.Order("last_reply_at DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+type UploadSessionRepository struct {
+}
+
+var (
+	UploadSessionRepositoryInstance *UploadSessionRepository
+	uploadSessionOnce               sync.Once
+)
+
+func InitUploadSessionRepository() {
+	uploadSessionOnce.Do(func() {
+		UploadSessionRepositoryInstance = &UploadSessionRepository{}
+	})
+}
+
+func (r *UploadSessionRepository) Create(session *model.UploadSession, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(session).Error
+}
+
+func (r *UploadSessionRepository) GetByUploadId(uploadId string, tx ...*gorm.DB) (*model.UploadSession, error) {
+	gormDB := db.GetGormDB(tx...)
+	var session model.UploadSession
+	err := gormDB.Where("upload_id = ?", uploadId).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *UploadSessionRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.UploadSession{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *UploadSessionRepository) ListExpired(now time.Time, limit int, tx ...*gorm.DB) ([]model.UploadSession, error) {
+	gormDB := db.GetGormDB(tx...)
+	var sessions []model.UploadSession
+	err := gormDB.Where("status = ? AND expire_at < ?", model.UploadUploading, now).
+		Order("id").Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
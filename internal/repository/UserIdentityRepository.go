@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	"gorm.io/gorm"
+	"sync"
+)
+
+type UserIdentityRepository struct {
+}
+
+var (
+	UserIdentityRepositoryInstance *UserIdentityRepository
+	userIdentityOnce               sync.Once
+)
+
+func InitUserIdentityRepository() {
+	userIdentityOnce.Do(func() {
+		UserIdentityRepositoryInstance = &UserIdentityRepository{}
+	})
+}
+
+func (r *UserIdentityRepository) Create(identity *model.UserIdentity, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(identity).Error
+}
+
+func (r *UserIdentityRepository) GetByProviderAndSubject(provider, subject string, tx ...*gorm.DB) (*model.UserIdentity, error) {
+	gormDB := db.GetGormDB(tx...)
+	var identity model.UserIdentity
+	err := gormDB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepository) GetByUserId(userId uint, tx ...*gorm.DB) ([]model.UserIdentity, error) {
+	gormDB := db.GetGormDB(tx...)
+	var identities []model.UserIdentity
+	err := gormDB.Where("user_id = ?", userId).Find(&identities).Error
+	return identities, err
+}
+
+func (r *UserIdentityRepository) UpdateFields(id uint, updates map[string]interface{}, tx ...*gorm.DB) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Model(&model.UserIdentity{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -2,11 +2,14 @@ package repository
 
 import (
 	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
 	"go-chat/internal/db"
 	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
 	"gorm.io/gorm"
 	"sync"
+	"time"
 )
 
 type UserRepository struct {
@@ -46,6 +49,13 @@ func (r *UserRepository) GetByName(username *string, tx ...*gorm.DB) (user *mode
 	return user, err
 }
 
+// ListByEmail 查询使用该邮箱的所有用户，邮箱不唯一
+func (r *UserRepository) ListByEmail(email string, tx ...*gorm.DB) (users []*model.User, err error) {
+	gormDB := db.GetGormDB(tx...)
+	err = gormDB.Where("email = ?", email).Order("id").Find(&users).Error
+	return
+}
+
 // Save 保存用户
 func (r *UserRepository) Save(user *model.User, tx ...*gorm.DB) (err error) {
 	gormDB := db.GetGormDB(tx...)
@@ -127,3 +137,39 @@ func (r *UserRepository) GetUsersWithHeartbeatBefore(cutoffTime int64, tx ...*go
 		Find(&users).Error
 	return users, err
 }
+
+// PageForAdmin 管理后台分页搜索用户
+func (r *UserRepository) PageForAdmin(req request.AdminUserSearchRequest, tx ...*gorm.DB) (*pagination.PageResult[response.AdminUserVo], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.User{}).
+		Select("id, created_at, username, nickname, phone, email, avatar, login_time, `status`, online_status, role, ban_reason, ban_end")
+	if req.UserId != 0 {
+		query = query.Where("id = ?", req.UserId)
+	}
+	if req.Keyword != "" {
+		like := "%" + req.Keyword + "%"
+		query = query.Where("username LIKE ? OR nickname LIKE ? OR email LIKE ? OR phone LIKE ?", like, like, like, like)
+	}
+	if req.Status != nil {
+		query = query.Where("`status` = ?", *req.Status)
+	}
+	result := &pagination.PageResult[response.AdminUserVo]{Records: []response.AdminUserVo{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UnbanExpired 解除已到期的封禁，返回解封的用户数
+func (r *UserRepository) UnbanExpired(now time.Time, tx ...*gorm.DB) (int64, error) {
+	gormDB := db.GetGormDB(tx...)
+	result := gormDB.Model(&model.User{}).
+		Where("`status` = ? AND ban_end IS NOT NULL AND ban_end <= ?", model.Disable, now).
+		Updates(map[string]interface{}{
+			"status":     model.Enable,
+			"ban_reason": nil,
+			"ban_end":    nil,
+		})
+	return result.RowsAffected, result.Error
+}
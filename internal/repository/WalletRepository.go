@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+)
+
+type WalletRepository struct {
+}
+
+var (
+	WalletRepositoryInstance *WalletRepository
+	walletOnce               sync.Once
+)
+
+func InitWalletRepository() {
+	walletOnce.Do(func() {
+		WalletRepositoryInstance = &WalletRepository{}
+	})
+}
+
+func (r *WalletRepository) GetByUserId(userId uint, tx ...*gorm.DB) (*model.Wallet, error) {
+	gormDB := db.GetGormDB(tx...)
+	var wallet model.Wallet
+	err := gormDB.Where("user_id = ?", userId).First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *WalletRepository) Credit(userId uint, amount int64, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"balance": gorm.Expr("balance + ?", amount)}),
+	}).Create(&model.Wallet{UserId: userId, Balance: amount}).Error
+}
+
+func (r *WalletRepository) Debit(userId uint, amount int64, tx ...*gorm.DB) (bool, error) {
+	gormDB := db.GetGormDB(tx...)
+	// 余额判断和扣减在同一条语句中完成，并发扣款不会透支
+	result := gormDB.Model(&model.Wallet{}).
+		Where("user_id = ? AND balance >= ?", userId, amount).
+		Update("balance", gorm.Expr("balance - ?", amount))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *WalletRepository) CreateLog(log *model.WalletLog, tx ...*gorm.DB) error {
+	gormDB := db.GetGormDB(tx...)
+	return gormDB.Create(log).Error
+}
+
+func (r *WalletRepository) PageLogs(userId uint, req request.WalletLogQueryRequest, tx ...*gorm.DB) (*pagination.PageResult[model.WalletLog], error) {
+	gormDB := db.GetGormDB(tx...)
+	query := gormDB.Model(&model.WalletLog{}).Where("user_id = ?", userId)
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	result := &pagination.PageResult[model.WalletLog]{Records: []model.WalletLog{}}
+	_, err := pagination.Paginate(query.Order("id DESC"), req.Page, req.PageSize, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
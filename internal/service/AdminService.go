@@ -0,0 +1,311 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/utils/logUtil"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+type AdminService struct {
+	userRepository          interfacerepository.UserRepositoryInterface
+	groupRepository         interfacerepository.GroupRepositoryInterface
+	messageRepository       interfacerepository.MessageRepositoryInterface
+	adminAuditLogRepository interfacerepository.AdminAuditLogRepositoryInterface
+	walletRepository        interfacerepository.WalletRepositoryInterface
+	sessionManager          interfacemanager.SessionManager
+	wsHandler               interfacehandler.WsHandlerInterface
+	fileRefService          interfacesservice.FileRefServiceInterface
+}
+
+var (
+	AdminServiceInstance *AdminService
+	adminOnce            sync.Once
+)
+
+func InitAdminService(userRepository interfacerepository.UserRepositoryInterface,
+	groupRepository interfacerepository.GroupRepositoryInterface,
+	messageRepository interfacerepository.MessageRepositoryInterface,
+	adminAuditLogRepository interfacerepository.AdminAuditLogRepositoryInterface,
+	walletRepository interfacerepository.WalletRepositoryInterface,
+	sessionManager interfacemanager.SessionManager,
+	wsHandler interfacehandler.WsHandlerInterface,
+	fileRefService interfacesservice.FileRefServiceInterface) {
+	adminOnce.Do(func() {
+		AdminServiceInstance = &AdminService{
+			userRepository:          userRepository,
+			groupRepository:         groupRepository,
+			messageRepository:       messageRepository,
+			adminAuditLogRepository: adminAuditLogRepository,
+			walletRepository:        walletRepository,
+			sessionManager:          sessionManager,
+			wsHandler:               wsHandler,
+			fileRefService:          fileRefService,
+		}
+	})
+}
+
+// IsPlatformAdmin 用户是否为未被封禁的平台管理员
+func (s *AdminService) IsPlatformAdmin(userId uint) (bool, error) {
+	user, err := s.userRepository.GetById(userId)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.IsPlatformAdmin() && !user.IsBanned(), nil
+}
+
+// SearchUsers 用户搜索
+func (s *AdminService) SearchUsers(req request.AdminUserSearchRequest) (*pagination.PageResult[response.AdminUserVo], error) {
+	return s.userRepository.PageForAdmin(req)
+}
+
+// BanUser 封禁用户：修改状态、吊销 token 并踢下线
+func (s *AdminService) BanUser(operatorId uint, ip string, req request.AdminBanRequest) error {
+	if req.UserId == operatorId {
+		return errors.New("不能封禁自己")
+	}
+	if req.Duration < 0 {
+		return errors.New("封禁时长不合法")
+	}
+	var banEnd *time.Time
+	if req.Duration > 0 {
+		end := time.Now().Add(time.Duration(req.Duration) * time.Second)
+		banEnd = &end
+	}
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		user, err := s.userRepository.GetById(req.UserId, tx)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("用户不存在")
+		}
+		if user.IsPlatformAdmin() {
+			return errors.New("不能封禁平台管理员")
+		}
+		err = s.userRepository.UpdateFields(req.UserId, map[string]interface{}{
+			"status":        model.Disable,
+			"ban_reason":    req.Reason,
+			"ban_end":       banEnd,
+			"online_status": model.Offline,
+		}, tx)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, model.BanUserAction, model.AuditTargetUser, req.UserId, req.Reason, map[string]interface{}{
+			"duration": req.Duration,
+			"ban_end":  banEnd,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.kickUser(req.UserId, "账号已被封禁: "+req.Reason)
+	return nil
+}
+
+// UnbanUser 解封用户
+func (s *AdminService) UnbanUser(operatorId uint, ip string, req request.AdminUserOperateRequest) error {
+	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		user, err := s.userRepository.GetById(req.UserId, tx)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("用户不存在")
+		}
+		if user.Status != model.Disable {
+			return errors.New("用户未被封禁")
+		}
+		err = s.userRepository.UpdateFields(req.UserId, map[string]interface{}{
+			"status":     model.Enable,
+			"ban_reason": nil,
+			"ban_end":    nil,
+		}, tx)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, model.UnbanUserAction, model.AuditTargetUser, req.UserId, req.Reason, nil)
+	})
+}
+
+// ForceLogout 强制下线：吊销已签发的 token 并断开 ws 连接
+func (s *AdminService) ForceLogout(operatorId uint, ip string, req request.AdminUserOperateRequest) error {
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		user, err := s.userRepository.GetById(req.UserId, tx)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("用户不存在")
+		}
+		err = s.userRepository.UpdateFields(req.UserId, map[string]interface{}{
+			"online_status": model.Offline,
+		}, tx)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, model.ForceLogoutAction, model.AuditTargetUser, req.UserId, req.Reason, nil)
+	})
+	if err != nil {
+		return err
+	}
+	s.kickUser(req.UserId, "已被管理员强制下线")
+	return nil
+}
+
+// DisableGroup 停用群组，停用后不能发送消息和加入
+func (s *AdminService) DisableGroup(operatorId uint, ip string, req request.AdminGroupStatusRequest) error {
+	return s.changeGroupStatus(operatorId, ip, req, model.Disable, model.DisableGroupAction)
+}
+
+// EnableGroup 恢复群组
+func (s *AdminService) EnableGroup(operatorId uint, ip string, req request.AdminGroupStatusRequest) error {
+	return s.changeGroupStatus(operatorId, ip, req, model.Enable, model.EnableGroupAction)
+}
+
+func (s *AdminService) changeGroupStatus(operatorId uint, ip string, req request.AdminGroupStatusRequest,
+	status model.Status, action model.AdminAction) error {
+	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		group, err := s.groupRepository.GetByID(req.GroupId, tx)
+		if err != nil {
+			return fmt.Errorf("群组不存在: %w", err)
+		}
+		if group.Status == status {
+			return errors.New("群组状态未变化")
+		}
+		if err := s.groupRepository.Update(req.GroupId, map[string]interface{}{"status": status}, tx); err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, action, model.AuditTargetGroup, req.GroupId, req.Reason, nil)
+	})
+}
+
+// DeleteMessage 删除违规消息，消息内容快照记录到审计日志
+func (s *AdminService) DeleteMessage(operatorId uint, ip string, req request.AdminMessageDeleteRequest) error {
+	message, err := s.messageRepository.GetById(req.MessageId)
+	if err != nil {
+		return err
+	}
+	if message == nil {
+		return errors.New("消息不存在")
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := s.messageRepository.Delete(req.MessageId, tx); err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, model.DeleteMessageAction, model.AuditTargetMessage, req.MessageId, req.Reason, map[string]interface{}{
+			"sender_id":   message.SenderId,
+			"receiver_id": message.ReceiverId,
+			"group_id":    message.GroupId,
+			"content":     message.Content,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	releaseFileRefs(s.fileRefService, messageFileUrls(message)...)
+	return nil
+}
+
+// RechargeWallet 给用户钱包充值，记录钱包流水和审计日志
+func (s *AdminService) RechargeWallet(operatorId uint, ip string, req request.AdminWalletRechargeRequest) error {
+	if req.Amount <= 0 {
+		return errors.New("充值金额不合法")
+	}
+	user, err := s.userRepository.GetById(req.UserId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("用户不存在")
+	}
+	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := s.walletRepository.Credit(req.UserId, req.Amount, tx); err != nil {
+			return err
+		}
+		log := &model.WalletLog{
+			UserId:     req.UserId,
+			Type:       model.WalletRecharge,
+			Amount:     req.Amount,
+			OperatorId: &operatorId,
+		}
+		if req.Reason != "" {
+			log.Remark = &req.Reason
+		}
+		if err := s.walletRepository.CreateLog(log, tx); err != nil {
+			return err
+		}
+		return s.audit(tx, operatorId, ip, model.RechargeWalletAction, model.AuditTargetUser, req.UserId, req.Reason, map[string]interface{}{
+			"amount": req.Amount,
+		})
+	})
+}
+
+// AuditLogs 审计日志查询
+func (s *AdminService) AuditLogs(req request.AdminAuditLogQueryRequest) (*pagination.PageResult[model.AdminAuditLog], error) {
+	return s.adminAuditLogRepository.Page(req)
+}
+
+// ReleaseExpiredBans 解除已到期的封禁
+func (s *AdminService) ReleaseExpiredBans() error {
+	count, err := s.userRepository.UnbanExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logUtil.Infof("已自动解封 %d 个到期用户", count)
+	}
+	return nil
+}
+
+// kickUser 吊销 token、断开 ws 连接并通知好友下线
+func (s *AdminService) kickUser(userId uint, reason string) {
+	if err := s.sessionManager.RevokeUserTokens(userId); err != nil {
+		logUtil.Errorf("吊销用户(%d) token 失败: %v", userId, err)
+	}
+	s.wsHandler.ForceOffline(int64(userId), reason)
+	go s.wsHandler.OnlineStatusNotice(int64(userId), model.OnlineStatusNotice{
+		UserId:       userId,
+		OnlineStatus: model.Offline,
+		ActionType:   model.LogoutAction,
+	})
+}
+
+func (s *AdminService) audit(tx *gorm.DB, operatorId uint, ip string, action model.AdminAction,
+	targetType string, targetId uint, reason string, detail map[string]interface{}) error {
+	log := &model.AdminAuditLog{
+		OperatorId: operatorId,
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Ip:         ip,
+	}
+	if reason != "" {
+		log.Reason = &reason
+	}
+	if detail != nil {
+		detailJson, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		detailStr := string(detailJson)
+		log.Detail = &detailStr
+	}
+	if err := s.adminAuditLogRepository.Create(log, tx); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
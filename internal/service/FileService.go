@@ -1,37 +1,1155 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go-chat/configs"
 	interfacemanager "go-chat/internal/interfaces/manager"
 	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
 	fileutil "go-chat/internal/utils/fileUtil"
+	"go-chat/internal/utils/imageUtil"
+	"go-chat/internal/utils/logUtil"
+	"go-chat/internal/utils/signUtil"
+	"gorm.io/gorm"
+	"image"
+	"io"
+	"math/big"
 	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPartSize       int64 = 8 << 20
+	minPartSize           int64 = 1 << 20
+	maxUploadParts              = 10000
+	defaultUploadExpire         = 24 * time.Hour
+	defaultPresignExpire        = time.Hour
+	defaultGcGracePeriod        = 24 * time.Hour
+	defaultDownloadExpire       = 10 * time.Minute
+	// uploadHeadSize 完成上传时读取的文件头大小，用于识别类型和图片宽高
+	uploadHeadSize = 64 << 10
+	// maxImageProcessSize 分片上传的图片超过该大小时不再读入内存处理，按原样保存
+	maxImageProcessSize int64 = 64 << 20
+	// uploadProofLength 秒传校验区间的长度
+	uploadProofLength int64 = 32
 )
 
 type FileService struct {
-	fileRepository interfacerepository.FileRepositoryInterface
-	minioManager   interfacemanager.MinioManager
+	fileRepository          interfacerepository.FileRepositoryInterface
+	uploadSessionRepository interfacerepository.UploadSessionRepositoryInterface
+	storage                 interfacemanager.Storage
+	mediaJobs               interfacemanager.JobQueue
+	scanner                 interfacemanager.VirusScanner
 }
 
 var FileServiceInstance *FileService
 
+// InitFileService mediaJobs 为空时不处理音视频，scanner 为空时不做病毒扫描
 func InitFileService(fileRepository interfacerepository.FileRepositoryInterface,
-	minioManager interfacemanager.MinioManager) {
+	uploadSessionRepository interfacerepository.UploadSessionRepositoryInterface,
+	storage interfacemanager.Storage,
+	mediaJobs interfacemanager.JobQueue,
+	scanner interfacemanager.VirusScanner) {
 	FileServiceInstance = &FileService{
-		fileRepository: fileRepository,
-		minioManager:   minioManager,
+		fileRepository:          fileRepository,
+		uploadSessionRepository: uploadSessionRepository,
+		storage:                 storage,
+		mediaJobs:               mediaJobs,
+		scanner:                 scanner,
 	}
 }
 
 func (s *FileService) Upload(id uint, file *multipart.FileHeader) (url string, err error) {
+	if err := checkUploadSize("", uint64(file.Size)); err != nil {
+		return "", err
+	}
+	checksum, err := fileSha256(file)
+	if err != nil {
+		return "", err
+	}
+	//相同内容已上传过，文件内容已完整收到，直接复用存储对象
+	existing, err := s.findDuplicate(checksum, file.Size)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		if err := checkUploadType(existing, file.Filename); err != nil {
+			return "", err
+		}
+		claimed, err := s.claimFile(id, file.Filename, existing)
+		if err != nil {
+			return "", err
+		}
+		return claimed.Url, nil
+	}
+
 	parseFile, err := fileutil.ParseFile(file)
 	if err != nil {
 		return "", err
 	}
-	parseFile.ID = id
-	//上传minio获取url
-	url, err = s.minioManager.UploadToMinio(file)
-	parseFile.Url = url
-	//保存数据库
-	err = s.fileRepository.Create(parseFile)
-	return url, nil
+	if err := s.checkUploadPolicy(id, parseFile, file.Filename); err != nil {
+		return "", err
+	}
+	parseFile.UserID = id
+	parseFile.Sha256 = checksum
+	parseFile.ObjectKey = objectKey(parseFile.Type, checksum, parseFile.Ext)
+
+	reader, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer reader.Close()
+	ctx := context.Background()
+	if err := s.scan(ctx, reader, file.Filename); err != nil {
+		return "", err
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	//上传到存储，图片先去除元数据并生成缩略图
+	if parseFile.Type == "image" {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("读取文件失败: %w", err)
+		}
+		err = s.processImage(ctx, parseFile, data)
+	} else {
+		_, err = s.storage.Put(ctx, parseFile.ObjectKey, reader, file.Size, parseFile.Mime)
+	}
+	if err != nil {
+		return "", fmt.Errorf("文件上传失败: %w", err)
+	}
+	if err = s.createFile(parseFile); err != nil {
+		return "", err
+	}
+	return parseFile.Url, nil
+}
+
+// checkUploadPolicy 新文件的完整校验：类型、大小和用户配额
+func (s *FileService) checkUploadPolicy(userId uint, file *model.File, name string) error {
+	if err := checkUploadType(file, name); err != nil {
+		return err
+	}
+	return s.checkQuota(userId, file.Size)
+}
+
+// checkUploadType 校验扩展名与内容是否一致、MIME 白名单和按类别的大小上限
+func checkUploadType(file *model.File, name string) error {
+	if !fileutil.ExtensionMatches(name, file) {
+		return fmt.Errorf("%w: 文件扩展名与内容不符", interfacesservice.ErrFileTypeNotAllowed)
+	}
+	if allowed := configs.AppConfig.Upload.AllowedMimes; len(allowed) > 0 && !matchMime(allowed, file.Mime) {
+		return fmt.Errorf("%w: %s", interfacesservice.ErrFileTypeNotAllowed, file.Mime)
+	}
+	return checkUploadSize(file.Type, file.Size)
+}
+
+// checkUploadSize fileType 为空时只检查总上限
+func checkUploadSize(fileType string, size uint64) error {
+	uploadConfig := configs.AppConfig.Upload
+	limit := uploadConfig.MaxSize
+	if typeLimit, ok := uploadConfig.TypeMaxSize[fileType]; ok && fileType != "" {
+		limit = typeLimit
+	}
+	if limit > 0 && size > uint64(limit) {
+		return fmt.Errorf("%w: 不能超过 %s", interfacesservice.ErrFileTooLarge, formatSize(limit))
+	}
+	return nil
+}
+
+// checkQuota 已用空间加上本次文件大小不能超过配额
+func (s *FileService) checkQuota(userId uint, size uint64) error {
+	quota := configs.AppConfig.Upload.UserQuota
+	if quota <= 0 {
+		return nil
+	}
+	_, used, err := s.fileRepository.Usage(userId)
+	if err != nil {
+		return err
+	}
+	if used+int64(size) > quota {
+		return fmt.Errorf("%w: 已使用 %s，配额 %s", interfacesservice.ErrQuotaExceeded, formatSize(used), formatSize(quota))
+	}
+	return nil
+}
+
+// scan 病毒扫描，扫描服务异常时拒绝上传
+func (s *FileService) scan(ctx context.Context, reader io.Reader, name string) error {
+	if s.scanner == nil {
+		return nil
+	}
+	virus, err := s.scanner.Scan(ctx, reader, name)
+	if err != nil {
+		return fmt.Errorf("病毒扫描失败: %w", err)
+	}
+	if virus != "" {
+		logUtil.Warnf("上传文件未通过病毒扫描(%s): %s", name, virus)
+		return fmt.Errorf("%w: %s", interfacesservice.ErrFileInfected, virus)
+	}
+	return nil
+}
+
+func (s *FileService) scanObject(ctx context.Context, key, name string) error {
+	if s.scanner == nil {
+		return nil
+	}
+	reader, _, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return s.scan(ctx, reader, name)
+}
+
+// Usage 用户已用存储空间和配额
+func (s *FileService) Usage(userId uint) (*response.FileUsageVo, error) {
+	files, used, err := s.fileRepository.Usage(userId)
+	if err != nil {
+		return nil, err
+	}
+	quota := configs.AppConfig.Upload.UserQuota
+	usage := &response.FileUsageVo{Files: files, Used: used, Quota: max(quota, 0), Remaining: -1}
+	if quota > 0 {
+		usage.Remaining = max(quota-used, 0)
+	}
+	return usage, nil
+}
+
+// matchMime 支持 image/* 形式的通配
+func matchMime(patterns []string, mime string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mime, prefix+"/") {
+				return true
+			}
+		} else if pattern == mime || pattern == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, suffix := float64(size)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + suffix
+}
+
+// objectKey 按 类型/哈希前两位/哈希.扩展名 生成对象路径，相同内容对应同一个对象
+func objectKey(fileType, checksum, ext string) string {
+	key := fmt.Sprintf("%s/%s/%s", fileType, checksum[:2], checksum)
+	if ext != "" {
+		key += "." + ext
+	}
+	return key
+}
+
+// variantKey 衍生对象路径：类型/哈希前两位/哈希_名称.扩展名
+func variantKey(file *model.File, name, ext string) string {
+	return fmt.Sprintf("%s/%s/%s_%s.%s", file.Type, file.Sha256[:2], file.Sha256, name, ext)
+}
+
+// keySha256 从对象路径中解析内容哈希，不是按内容哈希生成的路径返回空
+func keySha256(key string) string {
+	name := path.Base(key)
+	if i := strings.IndexAny(name, "_."); i >= 0 {
+		name = name[:i]
+	}
+	if decoded, err := hex.DecodeString(name); err != nil || len(decoded) != sha256.Size {
+		return ""
+	}
+	return name
+}
+
+// processImage 图片处理：自动摆正、去除 EXIF/GPS 元数据、生成多尺寸缩略图和可选的 WebP 版本
+// 原图写入 file.ObjectKey，衍生对象记录在 file.Variants；无法解码的图片按原样保存
+func (s *FileService) processImage(ctx context.Context, file *model.File, data []byte) error {
+	imageConfig := configs.AppConfig.Image
+	if imageConfig.Disabled {
+		return s.putBytes(ctx, file.ObjectKey, data, file.Mime)
+	}
+	decoded, err := imageUtil.Decode(data, imageMaxPixels())
+	if err != nil {
+		logUtil.Warnf("图片解码失败，按原样保存(%s): %v", file.ObjectKey, err)
+		return s.putBytes(ctx, file.ObjectKey, data, file.Mime)
+	}
+	quality := imageQuality()
+	sanitized, err := imageUtil.Sanitize(data, decoded, quality)
+	if err != nil {
+		logUtil.Warnf("图片元数据清理失败，按原样保存(%s): %v", file.ObjectKey, err)
+		sanitized = data
+	}
+	if err := s.putBytes(ctx, file.ObjectKey, sanitized, file.Mime); err != nil {
+		return err
+	}
+	bounds := decoded.Image.Bounds()
+	width, height := uint(bounds.Dx()), uint(bounds.Dy())
+	file.Width, file.Height = &width, &height
+
+	variants := make(model.FileVariantList, 0)
+	thumbFormat := imageUtil.ThumbnailFormat(decoded.Format)
+	if imageConfig.WebP {
+		thumbFormat = imageUtil.FormatWebP
+	}
+	for _, size := range imageThumbnailSizes() {
+		// 原图比缩略图小时不再生成
+		if int(width) <= size && int(height) <= size {
+			continue
+		}
+		variant, err := s.putVariant(ctx, file, fmt.Sprintf("thumb_%d", size), imageUtil.Resize(decoded.Image, size), thumbFormat, quality)
+		if err != nil {
+			s.deleteVariants(ctx, variants)
+			return err
+		}
+		variants = append(variants, variant)
+	}
+	if imageConfig.WebP && decoded.Format != imageUtil.FormatWebP && decoded.Format != imageUtil.FormatGIF {
+		variant, err := s.putVariant(ctx, file, "webp", decoded.Image, imageUtil.FormatWebP, quality)
+		if err != nil {
+			s.deleteVariants(ctx, variants)
+			return err
+		}
+		variants = append(variants, variant)
+	}
+	file.Variants = &variants
+	return nil
+}
+
+// processStoredImage 对已合并到存储中的图片做与普通上传相同的处理
+func (s *FileService) processStoredImage(ctx context.Context, file *model.File) error {
+	reader, _, err := s.storage.Get(ctx, file.ObjectKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	return s.processImage(ctx, file, data)
+}
+
+func (s *FileService) putVariant(ctx context.Context, file *model.File, name string, img image.Image, format string, quality int) (*model.FileVariant, error) {
+	var buf bytes.Buffer
+	if err := imageUtil.Encode(&buf, img, format, quality); err != nil {
+		return nil, fmt.Errorf("生成%s失败: %w", name, err)
+	}
+	key := variantKey(file, name, imageUtil.Ext(format))
+	mime := imageUtil.Mime(format)
+	if err := s.putBytes(ctx, key, buf.Bytes(), mime); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	return &model.FileVariant{
+		Name:      name,
+		Url:       s.storage.URL(key),
+		ObjectKey: key,
+		Mime:      mime,
+		Size:      uint64(buf.Len()),
+		Width:     uint(bounds.Dx()),
+		Height:    uint(bounds.Dy()),
+	}, nil
+}
+
+func (s *FileService) putBytes(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+	return err
+}
+
+func (s *FileService) deleteVariants(ctx context.Context, variants model.FileVariantList) {
+	for _, variant := range variants {
+		s.deleteObject(ctx, variant.ObjectKey)
+	}
+}
+
+// deleteFileObjects 删除文件的原始对象和全部衍生对象
+func (s *FileService) deleteFileObjects(ctx context.Context, file *model.File) {
+	s.deleteObject(ctx, file.ObjectKey)
+	if file.Variants != nil {
+		s.deleteVariants(ctx, *file.Variants)
+	}
+}
+
+func fileSha256(file *multipart.FileHeader) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer reader.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// findDuplicate 查找内容相同的文件，命中且无引用时刷新孤立时间，避免秒传后还没发送就被回收
+func (s *FileService) findDuplicate(checksum string, size int64) (*model.File, error) {
+	file, err := s.fileRepository.GetBySha256(checksum)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || file.Size != uint64(size) {
+		return nil, nil
+	}
+	if file.RefCount <= 0 {
+		now := time.Now()
+		if err := s.fileRepository.UpdateFields(file.ID, map[string]interface{}{"orphaned_at": now}); err != nil {
+			return nil, err
+		}
+		file.OrphanedAt = &now
+	}
+	return file, nil
+}
+
+// claimFile 复用相同内容的存储对象，为用户生成自己的文件记录，用户已有记录时直接返回
+// 调用前需确认用户确实持有文件内容；引用数和孤立时间与同内容的记录保持一致，不占用配额
+func (s *FileService) claimFile(userId uint, name string, existing *model.File) (*model.File, error) {
+	owned, err := s.fileRepository.GetBySha256AndUser(existing.Sha256, userId)
+	if err != nil || owned != nil {
+		return owned, err
+	}
+	file := *existing
+	file.Model = gorm.Model{}
+	file.UserID = userId
+	file.Name = name
+	if err := s.fileRepository.Create(&file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// createFile 保存新上传的文件，尚无引用，超过宽限期仍未被引用会被回收；保存失败时删除对象
+func (s *FileService) createFile(file *model.File) error {
+	now := time.Now()
+	file.Url = s.storage.URL(file.ObjectKey)
+	file.RefCount = 0
+	file.OrphanedAt = &now
+	media := s.mediaJobs != nil && (file.Type == "audio" || file.Type == "video")
+	if media {
+		file.MediaStatus = model.MediaProcessing
+	}
+	if err := s.fileRepository.Create(file); err != nil {
+		s.deleteFileObjects(context.Background(), file)
+		return err
+	}
+	if media {
+		s.enqueueMedia(file)
+	}
+	return nil
+}
+
+// enqueueMedia 投递音视频处理任务，投递失败时标记为处理失败，不影响上传结果
+func (s *FileService) enqueueMedia(file *model.File) {
+	err := s.mediaJobs.Publish(model.MediaJob{FileId: file.ID})
+	if err == nil {
+		return
+	}
+	logUtil.Errorf("音视频处理任务投递失败(%d): %v", file.ID, err)
+	file.MediaStatus = model.MediaFailed
+	if err := s.fileRepository.UpdateFields(file.ID, map[string]interface{}{"media_status": model.MediaFailed}); err != nil {
+		logUtil.Errorf("更新文件处理状态失败(%d): %v", file.ID, err)
+	}
+}
+
+// AddRefs 增加文件引用，非本存储的地址会被忽略，引用缩略图等同于引用原文件
+func (s *FileService) AddRefs(urls ...string) error {
+	return s.fileRepository.AdjustRefs(s.urlSha256s(urls), 1)
+}
+
+// ReleaseRefs 释放文件引用，引用数归零后等待垃圾回收
+func (s *FileService) ReleaseRefs(urls ...string) error {
+	return s.fileRepository.AdjustRefs(s.urlSha256s(urls), -1)
+}
+
+// FileByUrl 按访问地址查找文件，缩略图等衍生文件的地址返回原文件
+func (s *FileService) FileByUrl(url string) (*model.File, error) {
+	shas := s.urlSha256s([]string{url})
+	if len(shas) == 0 {
+		return nil, nil
+	}
+	return s.fileRepository.GetBySha256(shas[0])
+}
+
+// urlSha256s 把访问地址转换为文件内容哈希并去重，同一文件的原图和缩略图只计一次
+func (s *FileService) urlSha256s(urls []string) []string {
+	prefix := s.storage.URL("")
+	seen := make(map[string]struct{}, len(urls))
+	shas := make([]string, 0, len(urls))
+	for _, url := range urls {
+		key, ok := strings.CutPrefix(url, prefix)
+		if !ok {
+			continue
+		}
+		sha := keySha256(key)
+		if sha == "" {
+			continue
+		}
+		if _, ok := seen[sha]; ok {
+			continue
+		}
+		seen[sha] = struct{}{}
+		shas = append(shas, sha)
+	}
+	return shas
+}
+
+// CollectGarbage 删除超过宽限期仍无引用的文件和对象，返回删除数量
+func (s *FileService) CollectGarbage() (int, error) {
+	before := time.Now().Add(-parseDuration(configs.AppConfig.Storage.GcGracePeriod, defaultGcGracePeriod))
+	files, err := s.fileRepository.ListOrphaned(before, 100)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	ctx := context.Background()
+	for _, file := range files {
+		//删除前再次确认无引用，避免和新增引用并发
+		deleted, err := s.fileRepository.DeleteOrphaned(file.ID, before)
+		if err != nil {
+			return count, err
+		}
+		if !deleted {
+			continue
+		}
+		count++
+		//并发上传可能产生同一内容的多条记录，仍有记录时保留对象
+		if file.Sha256 != "" {
+			other, err := s.fileRepository.GetBySha256(file.Sha256)
+			if err != nil {
+				return count, err
+			}
+			if other != nil {
+				continue
+			}
+		}
+		s.deleteFileObjects(ctx, &file)
+	}
+	return count, nil
+}
+
+// AuthorizeObject 上传过该内容的用户、能看到引用该文件的消息的用户可以读取，头像对所有登录用户可见
+// 不是文件记录的对象（如上传分片）只能通过签名地址访问
+func (s *FileService) AuthorizeObject(userId uint, key string) error {
+	file, err := s.objectFile(key)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return interfacesservice.ErrFileForbidden
+	}
+	owned, err := s.fileRepository.IsOwnedBy(file.Sha256, userId)
+	if err != nil || owned {
+		return err
+	}
+	visible, err := s.fileRepository.IsVisibleTo(file.Urls(), userId)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return interfacesservice.ErrFileForbidden
+	}
+	return nil
+}
+
+// objectFile 对象对应的文件记录（原图或衍生文件），不是文件记录的对象返回 nil
+func (s *FileService) objectFile(key string) (*model.File, error) {
+	sha := keySha256(key)
+	if sha == "" {
+		return nil, nil
+	}
+	file, err := s.fileRepository.GetBySha256(sha)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	if file.ObjectKey != key && file.Variant(key) == nil {
+		return nil, nil
+	}
+	return file, nil
+}
+
+// CheckUsable 本存储的地址必须是用户有权读取的文件，非本存储的地址不校验
+func (s *FileService) CheckUsable(userId uint, urls ...string) error {
+	for _, key := range s.urlKeys(urls) {
+		if err := s.AuthorizeObject(userId, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckOwned 本存储的地址必须是用户自己上传过的文件，非本存储的地址不校验
+func (s *FileService) CheckOwned(userId uint, urls ...string) error {
+	for _, key := range s.urlKeys(urls) {
+		file, err := s.objectFile(key)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			return interfacesservice.ErrFileForbidden
+		}
+		owned, err := s.fileRepository.IsOwnedBy(file.Sha256, userId)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return interfacesservice.ErrFileForbidden
+		}
+	}
+	return nil
+}
+
+// urlKeys 取出本存储地址的对象键，前缀相同但没有对象键的地址按空键返回，校验时会被拒绝
+func (s *FileService) urlKeys(urls []string) []string {
+	prefix := s.storage.URL("")
+	keys := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if key, ok := strings.CutPrefix(url, prefix); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *FileService) StatObject(key string) (*model.ObjectInfo, error) {
+	return s.storage.Stat(context.Background(), key)
+}
+
+func (s *FileService) GetObjectRange(key string, offset, length int64) (io.ReadCloser, *model.ObjectInfo, error) {
+	return s.storage.GetRange(context.Background(), key, offset, length)
+}
+
+func (s *FileService) DownloadUrl(key string) (string, error) {
+	if !configs.AppConfig.Storage.DownloadRedirect {
+		return "", nil
+	}
+	return s.storage.Presign(context.Background(), http.MethodGet, key, downloadExpire())
+}
+
+func (s *FileService) SignUrls(userId uint, urls []string) (map[string]string, error) {
+	baseUrl := s.storage.URL("")
+	signed := make(map[string]string, len(urls))
+	for _, url := range urls {
+		key, ok := strings.CutPrefix(url, baseUrl)
+		if !ok || key == "" {
+			continue
+		}
+		if err := s.AuthorizeObject(userId, key); err != nil {
+			if errors.Is(err, interfacesservice.ErrFileForbidden) {
+				continue
+			}
+			return nil, err
+		}
+		signed[url] = signUtil.PresignURL(baseUrl, http.MethodGet, key, downloadExpire())
+	}
+	return signed, nil
+}
+
+// PutObject 通过预签名地址直接写入对象
+func (s *FileService) PutObject(key string, reader io.Reader, size int64, contentType string) (*model.ObjectInfo, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return s.storage.Put(context.Background(), key, reader, size, contentType)
+}
+
+// InitUpload 创建分片上传会话，返回每个分片的预签名上传地址
+func (s *FileService) InitUpload(userId uint, req request.UploadInitRequest) (*response.UploadSessionVo, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("文件名不能为空")
+	}
+	if req.Size <= 0 {
+		return nil, errors.New("文件大小不正确")
+	}
+	checksum := strings.ToLower(req.Sha256)
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return nil, errors.New("sha256 格式不正确")
+	}
+	if err := checkUploadSize("", uint64(req.Size)); err != nil {
+		return nil, err
+	}
+	//秒传：自己上传过相同内容时直接返回，他人上传过时需要先证明持有文件内容
+	existing, err := s.findDuplicate(checksum, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := checkUploadType(existing, name); err != nil {
+			return nil, err
+		}
+		owned, err := s.fileRepository.GetBySha256AndUser(checksum, userId)
+		if err != nil {
+			return nil, err
+		}
+		if owned != nil {
+			return &response.UploadSessionVo{
+				Status: model.UploadCompleted,
+				Size:   req.Size,
+				Parts:  []response.UploadPartVo{},
+				File:   owned,
+			}, nil
+		}
+	}
+	if err := s.checkQuota(userId, uint64(req.Size)); err != nil {
+		return nil, err
+	}
+	partSize := uploadPartSize()
+	partCount := int((req.Size + partSize - 1) / partSize)
+	if partCount > maxUploadParts {
+		return nil, fmt.Errorf("文件过大，分片数量不能超过 %d", maxUploadParts)
+	}
+	session := &model.UploadSession{
+		UploadId:  uuid.NewString(),
+		UserID:    userId,
+		Name:      name,
+		Size:      req.Size,
+		Sha256:    checksum,
+		PartSize:  partSize,
+		PartCount: partCount,
+		Status:    model.UploadUploading,
+		ExpireAt:  time.Now().Add(parseDuration(configs.AppConfig.Storage.UploadExpire, defaultUploadExpire)),
+	}
+	if existing != nil {
+		if err := s.issueProof(session, existing); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.uploadSessionRepository.Create(session); err != nil {
+		return nil, err
+	}
+	return s.sessionVo(session, false)
+}
+
+// GetUploadSession 查询上传进度，断点续传时重新获取未上传分片的地址
+func (s *FileService) GetUploadSession(userId uint, uploadId string) (*response.UploadSessionVo, error) {
+	session, err := s.getUploadSession(userId, uploadId)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessionVo(session, session.Status == model.UploadUploading)
+}
+
+// issueProof 随机选取秒传校验区间；图片去除元数据后存储的内容与原文件不同，无法校验，只能完整上传
+func (s *FileService) issueProof(session *model.UploadSession, existing *model.File) error {
+	info, err := s.storage.Stat(context.Background(), existing.ObjectKey)
+	if err != nil {
+		return err
+	}
+	if info == nil || info.Size != session.Size {
+		return nil
+	}
+	length := min(session.Size, uploadProofLength)
+	offset, err := rand.Int(rand.Reader, big.NewInt(session.Size-length+1))
+	if err != nil {
+		return err
+	}
+	session.ProofOffset, session.ProofLength = offset.Int64(), length
+	return nil
+}
+
+// CompleteUpload 校验全部分片的大小和 SHA-256 后合并为最终文件并保存文件记录
+// 他人已上传过相同内容时，提交正确的校验区间字节或上传的分片校验通过后复用存储对象
+func (s *FileService) CompleteUpload(userId uint, req request.UploadCompleteRequest) (*model.File, error) {
+	session, err := s.getUploadSession(userId, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != model.UploadUploading {
+		return nil, errors.New("上传会话已结束")
+	}
+	ctx := context.Background()
+	existing, err := s.findDuplicate(session.Sha256, session.Size)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		file, err := s.completeDuplicate(ctx, session, existing, req.Proof)
+		if err != nil {
+			return nil, err
+		}
+		s.finishSession(ctx, session, file.ID)
+		return file, nil
+	}
+	if err := s.verifyParts(ctx, session); err != nil {
+		return nil, err
+	}
+
+	// 读取文件头识别类型，决定最终的对象路径
+	head, err := s.readUploadHead(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	file, err := fileutil.ParseHead(session.Name, session.Size, head)
+	if err != nil {
+		return nil, err
+	}
+	// 未通过校验的上传直接取消，客户端无需重试
+	if err := s.checkUploadPolicy(userId, file, session.Name); err != nil {
+		if abortErr := s.abortSession(session); abortErr != nil {
+			logUtil.Errorf("取消上传会话失败(%s): %v", session.UploadId, abortErr)
+		}
+		return nil, err
+	}
+	file.UserID = userId
+	file.Sha256 = session.Sha256
+	file.ObjectKey = objectKey(file.Type, session.Sha256, file.Ext)
+
+	// 分片已校验 SHA-256，按顺序读取写入最终对象
+	reader := &partReader{ctx: ctx, storage: s.storage, session: session}
+	if _, err = s.storage.Put(ctx, file.ObjectKey, reader, session.Size, file.Mime); err != nil {
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+	if err := s.scanObject(ctx, file.ObjectKey, session.Name); err != nil {
+		s.deleteObject(ctx, file.ObjectKey)
+		if !errors.Is(err, interfacesservice.ErrFileInfected) {
+			return nil, err
+		}
+		if abortErr := s.abortSession(session); abortErr != nil {
+			logUtil.Errorf("取消上传会话失败(%s): %v", session.UploadId, abortErr)
+		}
+		return nil, err
+	}
+	if file.Type == "image" && session.Size <= maxImageProcessSize {
+		if err = s.processStoredImage(ctx, file); err != nil {
+			s.deleteObject(ctx, file.ObjectKey)
+			return nil, err
+		}
+	}
+	if err = s.createFile(file); err != nil {
+		return nil, err
+	}
+	s.finishSession(ctx, session, file.ID)
+	return file, nil
+}
+
+// completeDuplicate 相同内容已存在时校验用户持有文件内容，通过后生成用户自己的文件记录
+func (s *FileService) completeDuplicate(ctx context.Context, session *model.UploadSession, existing *model.File, proof string) (*model.File, error) {
+	if proof != "" {
+		if err := s.verifyProof(ctx, session, existing, proof); err != nil {
+			return nil, err
+		}
+	} else if err := s.verifyParts(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.claimFile(session.UserID, session.Name, existing)
+}
+
+// verifyProof 比对校验区间的字节，失败后作废本次校验，只能改为上传完整文件
+func (s *FileService) verifyProof(ctx context.Context, session *model.UploadSession, existing *model.File, proof string) error {
+	if session.ProofLength <= 0 {
+		return interfacesservice.ErrUploadProof
+	}
+	expected, err := hex.DecodeString(proof)
+	if err == nil && int64(len(expected)) == session.ProofLength {
+		var actual []byte
+		actual, err = s.readRange(ctx, existing.ObjectKey, session.ProofOffset, session.ProofLength)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(expected, actual) == 1 {
+			return nil
+		}
+	}
+	if err := s.uploadSessionRepository.UpdateFields(session.ID, map[string]interface{}{"proof_length": 0}); err != nil {
+		return err
+	}
+	return interfacesservice.ErrUploadProof
+}
+
+func (s *FileService) readRange(ctx context.Context, key string, offset, length int64) ([]byte, error) {
+	reader, _, err := s.storage.GetRange(ctx, key, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(io.LimitReader(reader, length))
+}
+
+// verifyParts 全部分片都已上传、大小正确且 SHA-256 与声明一致
+// 校验在写入最终对象之前完成，相同内容的对象由多条记录共用，不能被未通过校验的内容覆盖
+func (s *FileService) verifyParts(ctx context.Context, session *model.UploadSession) error {
+	for i := 1; i <= session.PartCount; i++ {
+		info, err := s.storage.Stat(ctx, session.PartKey(i))
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return fmt.Errorf("分片 %d 未上传", i)
+		}
+		if info.Size != session.PartLength(i) {
+			return fmt.Errorf("分片 %d 大小不正确: 期望 %d, 实际 %d", i, session.PartLength(i), info.Size)
+		}
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, &partReader{ctx: ctx, storage: s.storage, session: session}); err != nil {
+		return fmt.Errorf("读取分片失败: %w", err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != session.Sha256 {
+		return errors.New("文件校验失败，SHA-256 不一致")
+	}
+	return nil
+}
+
+// finishSession 标记会话完成并删除分片
+func (s *FileService) finishSession(ctx context.Context, session *model.UploadSession, fileId uint) {
+	if err := s.uploadSessionRepository.UpdateFields(session.ID, map[string]interface{}{
+		"status":  model.UploadCompleted,
+		"file_id": fileId,
+	}); err != nil {
+		logUtil.Errorf("更新上传会话失败(%s): %v", session.UploadId, err)
+	}
+	s.deleteParts(ctx, session)
+}
+
+// AbortUpload 取消上传并删除已上传的分片
+func (s *FileService) AbortUpload(userId uint, uploadId string) error {
+	session, err := s.getUploadSession(userId, uploadId)
+	if err != nil {
+		return err
+	}
+	if session.Status != model.UploadUploading {
+		return errors.New("上传会话已结束")
+	}
+	return s.abortSession(session)
+}
+
+// CleanExpiredUploads 清理过期未完成的上传会话，返回清理数量
+func (s *FileService) CleanExpiredUploads() (int, error) {
+	sessions, err := s.uploadSessionRepository.ListExpired(time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+	for i := range sessions {
+		if err := s.abortSession(&sessions[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(sessions), nil
+}
+
+func (s *FileService) abortSession(session *model.UploadSession) error {
+	if err := s.uploadSessionRepository.UpdateFields(session.ID, map[string]interface{}{
+		"status": model.UploadAborted,
+	}); err != nil {
+		return err
+	}
+	s.deleteParts(context.Background(), session)
+	return nil
+}
+
+func (s *FileService) getUploadSession(userId uint, uploadId string) (*model.UploadSession, error) {
+	session, err := s.uploadSessionRepository.GetByUploadId(uploadId)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userId {
+		return nil, errors.New("上传会话不存在")
+	}
+	if session.Status == model.UploadUploading && time.Now().After(session.ExpireAt) {
+		return nil, errors.New("上传会话已过期")
+	}
+	return session, nil
+}
+
+// sessionVo 组装会话信息，checkUploaded 为 true 时检查每个分片是否已上传
+func (s *FileService) sessionVo(session *model.UploadSession, checkUploaded bool) (*response.UploadSessionVo, error) {
+	vo := &response.UploadSessionVo{
+		UploadId:  session.UploadId,
+		Status:    session.Status,
+		Size:      session.Size,
+		PartSize:  session.PartSize,
+		PartCount: session.PartCount,
+		ExpireAt:  session.ExpireAt,
+		Parts:     make([]response.UploadPartVo, 0, session.PartCount),
+	}
+	if session.Status != model.UploadUploading {
+		return vo, nil
+	}
+	if session.ProofLength > 0 {
+		vo.Proof = &response.UploadProofVo{Offset: session.ProofOffset, Length: session.ProofLength}
+	}
+	ctx := context.Background()
+	presignExpire := parseDuration(configs.AppConfig.Storage.PresignExpire, defaultPresignExpire)
+	for i := 1; i <= session.PartCount; i++ {
+		part := response.UploadPartVo{PartNumber: i, Size: session.PartLength(i)}
+		if checkUploaded {
+			info, err := s.storage.Stat(ctx, session.PartKey(i))
+			if err != nil {
+				return nil, err
+			}
+			part.Uploaded = info != nil && info.Size == part.Size
+		}
+		if !part.Uploaded {
+			url, err := s.storage.Presign(ctx, http.MethodPut, session.PartKey(i), presignExpire)
+			if err != nil {
+				return nil, err
+			}
+			part.Url = url
+		}
+		vo.Parts = append(vo.Parts, part)
+	}
+	return vo, nil
+}
+
+func (s *FileService) readUploadHead(ctx context.Context, session *model.UploadSession) ([]byte, error) {
+	reader, _, err := s.storage.Get(ctx, session.PartKey(1))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(io.LimitReader(reader, uploadHeadSize))
+}
+
+func (s *FileService) deleteParts(ctx context.Context, session *model.UploadSession) {
+	for i := 1; i <= session.PartCount; i++ {
+		s.deleteObject(ctx, session.PartKey(i))
+	}
+}
+
+func (s *FileService) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		logUtil.Errorf("删除对象失败(%s): %v", key, err)
+	}
+}
+
+// partReader 按顺序串联读取全部分片
+type partReader struct {
+	ctx     context.Context
+	storage interfacemanager.Storage
+	session *model.UploadSession
+	next    int
+	current io.ReadCloser
+}
+
+func (r *partReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= r.session.PartCount {
+				return 0, io.EOF
+			}
+			r.next++
+			reader, _, err := r.storage.Get(r.ctx, r.session.PartKey(r.next))
+			if err != nil {
+				return 0, fmt.Errorf("读取分片 %d 失败: %w", r.next, err)
+			}
+			r.current = reader
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func uploadPartSize() int64 {
+	partSize := configs.AppConfig.Storage.PartSize
+	if partSize <= 0 {
+		return defaultPartSize
+	}
+	if partSize < minPartSize {
+		return minPartSize
+	}
+	return partSize
+}
+
+func imageThumbnailSizes() []int {
+	if sizes := configs.AppConfig.Image.ThumbnailSizes; len(sizes) > 0 {
+		return sizes
+	}
+	return []int{160, 480, 1080}
+}
+
+func imageQuality() int {
+	if quality := configs.AppConfig.Image.Quality; quality > 0 && quality <= 100 {
+		return quality
+	}
+	return 85
+}
+
+func imageMaxPixels() int {
+	if maxPixels := configs.AppConfig.Image.MaxPixels; maxPixels > 0 {
+		return maxPixels
+	}
+	return 40_000_000
+}
+
+func downloadExpire() time.Duration {
+	return parseDuration(configs.AppConfig.Storage.DownloadExpire, defaultDownloadExpire)
+}
+
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// addFileRefs 增加引用，失败只记录日志，不影响业务
+func addFileRefs(fileRefService interfacesservice.FileRefServiceInterface, urls ...string) {
+	if fileRefService == nil || len(urls) == 0 {
+		return
+	}
+	if err := fileRefService.AddRefs(urls...); err != nil {
+		logUtil.Errorf("增加文件引用失败: %v", err)
+	}
+}
+
+// releaseFileRefs 释放引用，失败只记录日志，文件会多保留到下次引用变更
+func releaseFileRefs(fileRefService interfacesservice.FileRefServiceInterface, urls ...string) {
+	if fileRefService == nil || len(urls) == 0 {
+		return
+	}
+	if err := fileRefService.ReleaseRefs(urls...); err != nil {
+		logUtil.Errorf("释放文件引用失败: %v", err)
+	}
+}
+
+// messageFileUrls 消息中可能引用上传文件的地址（除文本外的片段），合并转发的消息还包括聊天记录中的文件
+func messageFileUrls(message *model.Message) []string {
+	urls := make([]string, 0)
+	if message.Content != nil {
+		for _, part := range *message.Content {
+			if part == nil || part.Content == nil || part.IsText() {
+				continue
+			}
+			urls = append(urls, *part.Content)
+		}
+	}
+	var record model.ForwardRecord
+	if message.Type != nil && *message.Type == model.ForwardedCotent && message.ExtraData.Decode(&record) == nil {
+		urls = append(urls, record.FileUrls()...)
+	}
+	return urls
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/lty120712/gorm-pagination/pagination"
 	"go-chat/internal/db"
 	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
@@ -23,6 +24,7 @@ type GroupService struct {
 	userRepository              interfacerepository.UserRepositoryInterface
 	groupMemberRepository       interfacerepository.GroupMemberRepositoryInterface
 	groupAnnouncementRepository interfacerepository.GroupAnnouncementRepositoryInterface
+	fileRefService              interfacesservice.FileRefServiceInterface
 }
 
 var (
@@ -35,6 +37,7 @@ func InitGroupService(groupRepository interfacerepository.GroupRepositoryInterfa
 	userRepository interfacerepository.UserRepositoryInterface,
 	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
 	groupAnnouncementRepository interfacerepository.GroupAnnouncementRepositoryInterface,
+	fileRefService interfacesservice.FileRefServiceInterface,
 ) {
 	groupOnce.Do(func() {
 		GroupServiceInstance = &GroupService{
@@ -43,6 +46,7 @@ func InitGroupService(groupRepository interfacerepository.GroupRepositoryInterfa
 			userRepository:              userRepository,
 			groupMemberRepository:       groupMemberRepository,
 			groupAnnouncementRepository: groupAnnouncementRepository,
+			fileRefService:              fileRefService,
 		}
 	})
 }
@@ -115,9 +119,14 @@ func (s GroupService) Create(req *request.GroupCreateRequest) error {
 
 	return nil
 }
-func (s GroupService) Update(req *request.GroupUpdateRequest) error {
+func (s GroupService) Update(userId uint, req *request.GroupUpdateRequest) error {
+	if req.Avatar != nil && s.fileRefService != nil {
+		if err := s.fileRefService.CheckOwned(userId, *req.Avatar); err != nil {
+			return err
+		}
+	}
 	// 检查是否存在群组
-	_, err := s.groupRepository.GetByID(req.GroupId)
+	group, err := s.groupRepository.GetByID(req.GroupId)
 	if err != nil {
 		return fmt.Errorf("群组不存在: %v", err)
 	}
@@ -135,10 +144,25 @@ func (s GroupService) Update(req *request.GroupUpdateRequest) error {
 		updates["max_num"] = *req.MaxNum
 	}
 
-	return s.groupRepository.Update(req.GroupId, updates)
+	if err := s.groupRepository.Update(req.GroupId, updates); err != nil {
+		return err
+	}
+	//头像变更时转移文件引用
+	if req.Avatar != nil && *req.Avatar != group.Avatar {
+		addFileRefs(s.fileRefService, *req.Avatar)
+		releaseFileRefs(s.fileRefService, group.Avatar)
+	}
+	return nil
 }
 func (s GroupService) Join(groupId uint, userId uint) error {
 	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		group, err := s.groupRepository.GetByID(groupId, tx)
+		if err != nil {
+			return fmt.Errorf("群组不存在: %w", err)
+		}
+		if group.Status == model.Disable {
+			return errors.New("群组已被停用")
+		}
 		exists := s.groupMemberRepository.ExistsByGroupIdAndUserId(groupId, userId, tx)
 		if exists {
 			return errors.New("用户已加入该群组")
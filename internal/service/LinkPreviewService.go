@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"go-chat/configs"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/linkUtil"
+	"go-chat/internal/utils/logUtil"
+	"time"
+)
+
+const (
+	defaultLinkPreviewTimeout  = 5 * time.Second
+	defaultLinkPreviewCacheTtl = 24 * time.Hour
+	defaultLinkPreviewMaxLinks = 3
+	defaultLinkPreviewWorkers  = 4
+	// linkPreviewFailedTtl 抓取失败的链接短时间内不再重试
+	linkPreviewFailedTtl = 10 * time.Minute
+)
+
+type LinkPreviewService struct {
+	messageRepository     interfacerepository.MessageRepositoryInterface
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	fetcher               interfacemanager.LinkFetcher
+	cache                 interfacemanager.LinkPreviewCache
+	wsHandler             interfacehandler.WsHandlerInterface
+	// workers 限制同时抓取的消息数
+	workers chan struct{}
+}
+
+var LinkPreviewServiceInstance *LinkPreviewService
+
+func InitLinkPreviewService(messageRepository interfacerepository.MessageRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	fetcher interfacemanager.LinkFetcher,
+	cache interfacemanager.LinkPreviewCache,
+	wsHandler interfacehandler.WsHandlerInterface) {
+	workers := configs.AppConfig.LinkPreview.Workers
+	if workers <= 0 {
+		workers = defaultLinkPreviewWorkers
+	}
+	LinkPreviewServiceInstance = &LinkPreviewService{
+		messageRepository:     messageRepository,
+		groupMemberRepository: groupMemberRepository,
+		fetcher:               fetcher,
+		cache:                 cache,
+		wsHandler:             wsHandler,
+		workers:               make(chan struct{}, workers),
+	}
+}
+
+// Unfurl 只处理文本消息，不阻塞发送流程
+func (s *LinkPreviewService) Unfurl(message *model.Message) {
+	if configs.AppConfig.LinkPreview.Disabled || message == nil || message.Content == nil {
+		return
+	}
+	if message.Type == nil || *message.Type != model.TextContent {
+		return
+	}
+	links := linkUtil.Extract(*message.Content, linkPreviewMaxLinks())
+	if len(links) == 0 {
+		return
+	}
+	messageId := message.ID
+	go func() {
+		s.workers <- struct{}{}
+		defer func() { <-s.workers }()
+		s.attach(messageId, links)
+	}()
+}
+
+func (s *LinkPreviewService) attach(messageId uint, links []string) {
+	previews := make(model.LinkPreviewList, 0, len(links))
+	for _, link := range links {
+		if preview := s.preview(link); preview != nil && !preview.Empty() {
+			previews = append(previews, preview)
+		}
+	}
+	if len(previews) == 0 {
+		return
+	}
+	// 抓取期间消息可能已被撤回或删除
+	message, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		logUtil.Errorf("查询消息(%d)失败: %v", messageId, err)
+		return
+	}
+	if message == nil || (message.Status != nil && *message.Status == model.Disable) {
+		return
+	}
+	if err := s.messageRepository.UpdateFields(messageId, map[string]interface{}{"link_previews": &previews}); err != nil {
+		logUtil.Errorf("保存消息(%d)链接预览失败: %v", messageId, err)
+		return
+	}
+	s.notify(message, previews)
+}
+
+// preview 优先读缓存，抓取失败时缓存空预览
+func (s *LinkPreviewService) preview(link string) *model.LinkPreview {
+	if s.cache != nil {
+		cached, ok, err := s.cache.Get(link)
+		if err != nil {
+			logUtil.Warnf("读取链接预览缓存失败: %v", err)
+		} else if ok {
+			return cached
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), linkPreviewTimeout())
+	defer cancel()
+	preview, err := s.fetcher.Fetch(ctx, link)
+	ttl := linkPreviewCacheTtl()
+	if err != nil {
+		logUtil.Warnf("抓取链接预览失败(%s): %v", link, err)
+		preview, ttl = &model.LinkPreview{Url: link}, linkPreviewFailedTtl
+	}
+	if s.cache != nil {
+		if err := s.cache.Set(link, preview, ttl); err != nil {
+			logUtil.Warnf("写入链接预览缓存失败: %v", err)
+		}
+	}
+	return preview
+}
+
+func (s *LinkPreviewService) notify(message *model.Message, previews model.LinkPreviewList) {
+	if s.wsHandler == nil {
+		return
+	}
+	var userIds []int64
+	if *message.TargetType == model.PrivateTarget {
+		userIds = []int64{message.SenderId, *message.ReceiverId}
+	} else {
+		memberList, err := s.groupMemberRepository.GetMemberListByGroupId(uint(*message.GroupId))
+		if err != nil {
+			logUtil.Errorf("查询群(%d)成员失败: %v", *message.GroupId, err)
+			return
+		}
+		for _, member := range memberList {
+			userIds = append(userIds, int64(member.UserId))
+		}
+	}
+	s.wsHandler.LinkPreviewNotice(userIds, model.LinkPreviewNotice{
+		MessageId:  message.ID,
+		TargetType: *message.TargetType,
+		SenderId:   message.SenderId,
+		ReceiverId: message.ReceiverId,
+		GroupId:    message.GroupId,
+		Previews:   previews,
+	})
+}
+
+func linkPreviewTimeout() time.Duration {
+	timeout, err := time.ParseDuration(configs.AppConfig.LinkPreview.Timeout)
+	if err != nil || timeout <= 0 {
+		return defaultLinkPreviewTimeout
+	}
+	return timeout
+}
+
+func linkPreviewCacheTtl() time.Duration {
+	ttl, err := time.ParseDuration(configs.AppConfig.LinkPreview.CacheTtl)
+	if err != nil || ttl <= 0 {
+		return defaultLinkPreviewCacheTtl
+	}
+	return ttl
+}
+
+func linkPreviewMaxLinks() int {
+	if maxLinks := configs.AppConfig.LinkPreview.MaxLinks; maxLinks > 0 {
+		return maxLinks
+	}
+	return defaultLinkPreviewMaxLinks
+}
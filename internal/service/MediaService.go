@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-chat/configs"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/logUtil"
+	"image"
+	"io"
+	"os"
+	"path"
+	"time"
+)
+
+const (
+	defaultMediaTimeout    = 2 * time.Minute
+	defaultWaveformSamples = 100
+	// posterAt 视频封面默认截取第 1 秒，视频更短时取中间
+	posterAt = 1.0
+)
+
+type MediaService struct {
+	fileRepository interfacerepository.FileRepositoryInterface
+	storage        interfacemanager.Storage
+	processor      interfacemanager.MediaProcessor
+	wsHandler      interfacehandler.WsHandlerInterface
+}
+
+var MediaServiceInstance *MediaService
+
+func InitMediaService(fileRepository interfacerepository.FileRepositoryInterface,
+	storage interfacemanager.Storage,
+	processor interfacemanager.MediaProcessor,
+	wsHandler interfacehandler.WsHandlerInterface) {
+	MediaServiceInstance = &MediaService{
+		fileRepository: fileRepository,
+		storage:        storage,
+		processor:      processor,
+		wsHandler:      wsHandler,
+	}
+}
+
+func (s *MediaService) Process(job model.MediaJob) error {
+	file, err := s.fileRepository.GetById(job.FileId)
+	if err != nil {
+		return err
+	}
+	// 文件已被回收或任务重复投递
+	if file == nil || file.MediaStatus == model.MediaReady {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mediaTimeout())
+	defer cancel()
+
+	updates, err := s.process(ctx, file)
+	status := model.MediaReady
+	if err != nil {
+		logUtil.Errorf("音视频处理失败(%d): %v", file.ID, err)
+		status = model.MediaFailed
+	}
+	updates["media_status"] = status
+	file.MediaStatus = status
+	// 秒传复用同一内容的记录在处理完成前复制了处理中的状态，按内容哈希一并更新
+	if err := s.fileRepository.UpdateBySha256(file.Sha256, updates); err != nil {
+		return fmt.Errorf("更新文件失败: %w", err)
+	}
+	if s.wsHandler != nil {
+		go s.wsHandler.MediaNotice(int64(file.UserID), model.MediaNotice{FileId: file.ID, Status: status, File: file})
+	}
+	return nil
+}
+
+// process 探测失败视为处理失败；封面和波形失败只记录日志，时长等信息仍然保存
+func (s *MediaService) process(ctx context.Context, file *model.File) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	localPath, err := s.download(ctx, file)
+	if err != nil {
+		return updates, err
+	}
+	defer os.Remove(localPath)
+
+	info, err := s.processor.Probe(ctx, localPath)
+	if err != nil {
+		return updates, err
+	}
+	file.Duration = &info.Duration
+	updates["duration"] = file.Duration
+	if info.Width > 0 && info.Height > 0 {
+		file.Width, file.Height = &info.Width, &info.Height
+		updates["width"], updates["height"] = info.Width, info.Height
+	}
+
+	switch file.Type {
+	case "video":
+		variant, err := s.poster(ctx, file, localPath, info.Duration)
+		if err != nil {
+			logUtil.Warnf("生成视频封面失败(%d): %v", file.ID, err)
+			break
+		}
+		variants := model.FileVariantList{}
+		if file.Variants != nil {
+			variants = *file.Variants
+		}
+		variants = append(variants, variant)
+		file.Variants = &variants
+		updates["variants"] = file.Variants
+	case "audio":
+		peaks, err := s.processor.Waveform(ctx, localPath, waveformSamples())
+		if err != nil {
+			logUtil.Warnf("生成音频波形失败(%d): %v", file.ID, err)
+			break
+		}
+		waveform := model.WaveformList(peaks)
+		file.Waveform = &waveform
+		updates["waveform"] = file.Waveform
+	}
+	return updates, nil
+}
+
+// download ffmpeg 需要可随机读取的文件，先把对象下载到临时文件
+func (s *MediaService) download(ctx context.Context, file *model.File) (string, error) {
+	reader, _, err := s.storage.Get(ctx, file.ObjectKey)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	tmpFile, err := os.CreateTemp("", "media-*"+path.Ext(file.ObjectKey))
+	if err != nil {
+		return "", err
+	}
+	defer tmpFile.Close()
+	if _, err := io.Copy(tmpFile, reader); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
+
+func (s *MediaService) poster(ctx context.Context, file *model.File, localPath string, duration float64) (*model.FileVariant, error) {
+	at := posterAt
+	if duration < at*2 {
+		at = duration / 2
+	}
+	data, err := s.processor.Poster(ctx, localPath, at)
+	if err != nil {
+		return nil, err
+	}
+	key := variantKey(file, "poster", "jpg")
+	if _, err := s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		return nil, err
+	}
+	variant := &model.FileVariant{
+		Name:      "poster",
+		Url:       s.storage.URL(key),
+		ObjectKey: key,
+		Mime:      "image/jpeg",
+		Size:      uint64(len(data)),
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		variant.Width, variant.Height = uint(cfg.Width), uint(cfg.Height)
+	}
+	return variant, nil
+}
+
+func mediaTimeout() time.Duration {
+	timeout, err := time.ParseDuration(configs.AppConfig.Media.Timeout)
+	if err != nil || timeout <= 0 {
+		return defaultMediaTimeout
+	}
+	return timeout
+}
+
+func waveformSamples() int {
+	if samples := configs.AppConfig.Media.WaveformSamples; samples > 0 {
+		return samples
+	}
+	return defaultWaveformSamples
+}
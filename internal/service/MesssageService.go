@@ -1,20 +1,61 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/configs"
+	"go-chat/internal/db"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacemanager "go-chat/internal/interfaces/manager"
 	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
 	"go-chat/internal/utils"
+	"go-chat/internal/utils/logUtil"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	forwardSingleMax    = 30 // 逐条转发最多的消息数
+	forwardSummaryLines = 4  // 聊天记录摘要的行数
+
+	threadParticipantPreview = 5 // 话题摘要中展示的参与者人数
+
+	historyPageSize = 20  // 历史消息默认每页条数
+	historyPageMax  = 100 // 历史消息每页最多条数
+
+	scheduleBatchSize  = 100             // 定时任务每次处理的定时消息数
+	scheduleClaimLease = 5 * time.Minute // 发送中的定时消息超过该时间仍未完成视为发送中断，重新领取
+	expireBatchSize    = 100             // 定时任务每次删除的限时消息数
 )
 
 type MessageService struct {
 	messageRepository     interfacerepository.MessageRepositoryInterface
 	userRepository        interfacerepository.UserRepositoryInterface
+	groupRepository       interfacerepository.GroupRepositoryInterface
 	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	reviewRepository      interfacerepository.ModerationReviewRepositoryInterface
+	moderator             interfacemanager.Moderator
+	fileRefService        interfacesservice.FileRefServiceInterface
+	wsHandler             interfacehandler.WsHandlerInterface
+	mentionRepository     interfacerepository.MentionRepositoryInterface
+	reactionRepository    interfacerepository.ReactionRepositoryInterface
+	threadRepository      interfacerepository.ThreadRepositoryInterface
+	pinRepository         interfacerepository.PinRepositoryInterface
+	scheduledRepository   interfacerepository.ScheduledMessageRepositoryInterface
+	linkPreviewService    interfacesservice.LinkPreviewServiceInterface
+	stickerRepository     interfacerepository.StickerRepositoryInterface
+	deletionRepository    interfacerepository.MessageDeletionRepositoryInterface
 }
 
 var (
@@ -24,19 +65,53 @@ var (
 
 func InitMessageService(messageRepository interfacerepository.MessageRepositoryInterface,
 	userRepository interfacerepository.UserRepositoryInterface,
-	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface) {
+	groupRepository interfacerepository.GroupRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	reviewRepository interfacerepository.ModerationReviewRepositoryInterface,
+	moderator interfacemanager.Moderator,
+	fileRefService interfacesservice.FileRefServiceInterface,
+	wsHandler interfacehandler.WsHandlerInterface,
+	mentionRepository interfacerepository.MentionRepositoryInterface,
+	reactionRepository interfacerepository.ReactionRepositoryInterface,
+	threadRepository interfacerepository.ThreadRepositoryInterface,
+	pinRepository interfacerepository.PinRepositoryInterface,
+	scheduledRepository interfacerepository.ScheduledMessageRepositoryInterface,
+	linkPreviewService interfacesservice.LinkPreviewServiceInterface,
+	stickerRepository interfacerepository.StickerRepositoryInterface,
+	deletionRepository interfacerepository.MessageDeletionRepositoryInterface) {
 	messageOnce.Do(func() {
 		MessageServiceInstance = &MessageService{
 			messageRepository:     messageRepository,
 			userRepository:        userRepository,
+			groupRepository:       groupRepository,
 			groupMemberRepository: groupMemberRepository,
+			reviewRepository:      reviewRepository,
+			moderator:             moderator,
+			fileRefService:        fileRefService,
+			wsHandler:             wsHandler,
+			mentionRepository:     mentionRepository,
+			reactionRepository:    reactionRepository,
+			threadRepository:      threadRepository,
+			pinRepository:         pinRepository,
+			scheduledRepository:   scheduledRepository,
+			linkPreviewService:    linkPreviewService,
+			stickerRepository:     stickerRepository,
+			deletionRepository:    deletionRepository,
 		}
 	})
 }
 
 // SendMessage 发送消息（支持私聊和群聊）
-// msg 是已经构造好的 message 对象（建议外部构建 content 等）
+// msg 是已经构造好的 message 对象（建议外部构建 content 等），合并转发的聊天记录只能由 Forward 生成
 func (s *MessageService) SendMessage(msg *model.Message) (*response.MessageVo, error) {
+	if msg != nil && msg.Type != nil && *msg.Type == model.ForwardedCotent {
+		return nil, errors.New("聊天记录只能通过转发生成")
+	}
+	return s.send(msg)
+}
+
+// send 保存并投递消息，不限制消息类型
+func (s *MessageService) send(msg *model.Message) (*response.MessageVo, error) {
 	if msg == nil {
 		return nil, errors.New("消息不能为空")
 	}
@@ -55,16 +130,104 @@ func (s *MessageService) SendMessage(msg *model.Message) (*response.MessageVo, e
 	if len(*msg.Content) == 0 {
 		return nil, errors.New("消息内容不能为空")
 	}
+	// 播放状态只能由接收者上报
+	msg.PlayedIdList = nil
+	if err := s.checkMessageFiles(msg); err != nil {
+		return nil, err
+	}
+	if err := s.prepareVoice(msg); err != nil {
+		return nil, err
+	}
+	if err := prepareEphemeral(msg); err != nil {
+		return nil, err
+	}
+	if err := s.prepareStickers(msg); err != nil {
+		return nil, err
+	}
+	if *msg.TargetType == model.GroupTarget {
+		group, err := s.groupRepository.GetByID(uint(*msg.GroupId))
+		if err != nil {
+			return nil, fmt.Errorf("群组不存在: %w", err)
+		}
+		if group.Status == model.Disable {
+			return nil, errors.New("群组已被停用")
+		}
+	}
+	root, err := s.prepareReply(msg)
+	if err != nil {
+		return nil, err
+	}
+	mentioned, mentionAll, err := s.prepareMentions(msg)
+	if err != nil {
+		return nil, err
+	}
+	//内容审核，打码会直接修改 msg.Content
+	var moderation *model.ModerationResult
+	if s.moderator != nil {
+		moderation = s.moderator.Moderate(msg)
+		if moderation.Action == model.ModerationReject {
+			return nil, fmt.Errorf("消息未通过内容审核: %s", moderation.Reason())
+		}
+	}
 	if err := s.messageRepository.Save(msg); err != nil {
 		return nil, err
 	}
+	addFileRefs(s.fileRefService, messageFileUrls(msg)...)
+	if moderation != nil && moderation.Action == model.ModerationFlag {
+		s.submitReview(msg, moderation)
+	}
 	vo, err := s.GetMessageById(msg.ID)
 	if err != nil {
 		return nil, err
 	}
+	s.notifyMentions(vo, mentioned, mentionAll)
+	s.addThreadReply(root, msg, vo)
+	if s.linkPreviewService != nil {
+		s.linkPreviewService.Unfurl(msg)
+	}
 	return vo, nil
 }
 
+// checkMessageFiles 消息引用的本存储文件必须是发送者能读取的，避免借消息把别人的文件授权给会话中的其他人
+// 表情片段的地址由服务端按表情填充，不需要校验
+func (s *MessageService) checkMessageFiles(msg *model.Message) error {
+	if s.fileRefService == nil {
+		return nil
+	}
+	urls := make([]string, 0)
+	for _, part := range *msg.Content {
+		if part == nil || part.Content == nil || part.IsText() || part.Type == model.StickerPart {
+			continue
+		}
+		urls = append(urls, *part.Content)
+	}
+	var record model.ForwardRecord
+	if *msg.Type == model.ForwardedCotent && msg.ExtraData.Decode(&record) == nil {
+		urls = append(urls, record.FileUrls()...)
+	}
+	return s.fileRefService.CheckUsable(uint(msg.SenderId), urls...)
+}
+
+// submitReview 被标记的消息正常投递，同时进入人工审核队列
+func (s *MessageService) submitReview(msg *model.Message, moderation *model.ModerationResult) {
+	review := &model.ModerationReview{
+		MessageId: msg.ID,
+		SenderId:  uint(msg.SenderId),
+		Status:    model.Todo,
+	}
+	if hits, err := json.Marshal(moderation.Hits); err == nil {
+		hitsStr := string(hits)
+		review.Hits = &hitsStr
+	}
+	if content, err := json.Marshal(moderation.OriginalContent); err == nil {
+		contentStr := string(content)
+		review.Content = &contentStr
+	}
+	if err := s.reviewRepository.Create(review); err != nil {
+		logUtil.Errorf("消息(%d)加入审核队列失败: %v", msg.ID, err)
+	}
+}
+
 // GetMessageById  获取消息
 func (s *MessageService) GetMessageById(id uint) (*response.MessageVo, error) {
 	message, err := s.messageRepository.GetById(id)
@@ -105,148 +268,1844 @@ func (s *MessageService) ReadMessage(messageId uint, userId uint) error {
 	updateFields := map[string]interface{}{
 		"reader_id_list": message.ReaderIdList,
 	}
+	if expireAt := burnAfterRead(message, userId); expireAt != nil {
+		updateFields["expire_at"] = expireAt
+	}
 	err = s.messageRepository.UpdateFields(messageId, updateFields)
 	if err != nil {
 		return err
 	}
+	//4.看到消息即视为看到其中的 @ 提醒
+	if *message.TargetType == model.GroupTarget && s.mentionRepository != nil {
+		return s.mentionRepository.MarkReadByMessageId(userId, messageId)
+	}
 	return nil
 }
 
+// QueryMessages 查询历史消息，支持向前、向后翻页和跳转到某条消息；返回的消息都按 id 从新到旧排列
 func (s *MessageService) QueryMessages(userId uint, req *request.QueryMessagesRequest) (*response.QueryMessagesResponse, error) {
-	// 查询历史消息
-	messages, err := s.messageRepository.QueryHistoryMessages(userId, req)
+	query := *req
+	query.Limit = historyLimit(req.Limit)
+	var (
+		messages          []*model.Message
+		hasMore, hasNewer bool
+		err               error
+	)
+	switch query.Direction {
+	case "", request.QueryBefore:
+		// 游标之后的消息就是上一页，不再单独查询
+		messages, hasMore, err = s.queryHistory(userId, query, request.QueryBefore, query.Cursor, query.Limit)
+		hasNewer = query.Cursor > 0
+	case request.QueryAfter:
+		messages, hasNewer, err = s.queryHistory(userId, query, request.QueryAfter, query.Cursor, query.Limit)
+		hasMore = query.Cursor > 0
+	case request.QueryAround:
+		if query.Cursor == 0 {
+			return nil, errors.New("请指定要跳转的消息")
+		}
+		// 游标本身和更新的消息占一半（向上取整），更早的消息占另一半
+		olderLimit := query.Limit / 2
+		var older []*model.Message
+		if messages, hasNewer, err = s.queryHistory(userId, query, request.QueryAfter, query.Cursor-1, query.Limit-olderLimit); err != nil {
+			return nil, err
+		}
+		if older, hasMore, err = s.queryHistory(userId, query, request.QueryBefore, query.Cursor, olderLimit); err != nil {
+			return nil, err
+		}
+		messages = append(messages, older...)
+	default:
+		return nil, errors.New("翻页方向不合法")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.buildMessageVos(userId, messages)
 	if err != nil {
 		return nil, err
 	}
 
-	// 是否有更多消息
-	hasMore := false
-	if len(messages) > req.Limit {
-		hasMore = true
-		messages = messages[:req.Limit]
+	// 计算游标，没有消息时保持原游标
+	cursor, newerCursor := int64(query.Cursor), int64(query.Cursor)
+	if len(list) > 0 {
+		cursor = int64(list[len(list)-1].ID)
+		newerCursor = int64(list[0].ID)
+	}
+
+	return &response.QueryMessagesResponse{
+		List:        list,
+		Cursor:      cursor,
+		HasMore:     hasMore,
+		NewerCursor: newerCursor,
+		HasNewer:    hasNewer,
+	}, nil
+}
+
+// queryHistory 按一个方向查询最多 limit 条消息，返回按 id 降序排列的消息和该方向是否还有更多
+// limit 为 0 时只判断是否还有更多
+func (s *MessageService) queryHistory(userId uint, query request.QueryMessagesRequest, direction request.QueryDirection,
+	cursor uint, limit int) ([]*model.Message, bool, error) {
+	query.Direction, query.Cursor, query.Limit = direction, cursor, max(limit, 1)
+	messages, err := s.messageRepository.QueryHistoryMessages(userId, &query)
+	if err != nil {
+		return nil, false, err
 	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if direction == request.QueryAfter {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, hasMore, nil
+}
 
-	// 获取发送者ID列表
-	senderIds := make([]uint, len(messages))
-	for i, msg := range messages {
-		senderIds[i] = uint(msg.SenderId)
+// buildMessageVos 组装消息列表，发送者、引用的消息、表情回应和话题摘要都按批查询
+func (s *MessageService) buildMessageVos(userId uint, messages []*model.Message) ([]*response.MessageVo, error) {
+	list := make([]*response.MessageVo, 0, len(messages))
+	if len(messages) == 0 {
+		return list, nil
+	}
+	// 被引用的消息
+	var replyIds []uint
+	for _, msg := range messages {
+		if msg.ReplyId != nil && !utils.Contains(replyIds, uint(*msg.ReplyId)) {
+			replyIds = append(replyIds, uint(*msg.ReplyId))
+		}
+	}
+	quoted := make(map[uint]*model.Message, len(replyIds))
+	if len(replyIds) > 0 {
+		replies, err := s.messageRepository.GetByIdList(replyIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range replies {
+			quoted[reply.ID] = reply
+		}
 	}
 
-	// 获取发送者信息
-	var idToUserMap = make(map[uint]*model.User)
+	// 消息和被引用消息的发送者，群聊优先显示群昵称
+	related := append([]*model.Message{}, messages...)
+	for _, reply := range quoted {
+		related = append(related, reply)
+	}
+	var senderIds, groupIds []uint
+	for _, msg := range related {
+		if !utils.Contains(senderIds, uint(msg.SenderId)) {
+			senderIds = append(senderIds, uint(msg.SenderId))
+		}
+		if msg.GroupId != nil && !utils.Contains(groupIds, uint(*msg.GroupId)) {
+			groupIds = append(groupIds, uint(*msg.GroupId))
+		}
+	}
+	users := make(map[uint]model.User, len(senderIds))
 	userList, _ := s.userRepository.GetByIdList(senderIds)
 	for _, user := range userList {
-		user1 := user
-		idToUserMap[user.ID] = &user1
+		users[user.ID] = user
 	}
-
-	// 获取群组成员
-	if *req.TargetType == model.GroupTarget {
-		memberList, _ := s.groupMemberRepository.GetMemberListByGroupId(req.TargetId)
-		if memberList != nil {
-			for _, member := range memberList {
-				if user, exists := idToUserMap[member.UserId]; exists {
-					*user.Nickname = member.Nickname
-				}
+	groupNicknames := make(map[uint]map[uint]string, len(groupIds))
+	for _, groupId := range groupIds {
+		nicknames := make(map[uint]string)
+		memberList, _ := s.groupMemberRepository.GetMemberListByGroupId(groupId)
+		for _, member := range memberList {
+			if member.Nickname != "" {
+				nicknames[member.UserId] = member.Nickname
 			}
 		}
+		groupNicknames[groupId] = nicknames
 	}
 
-	// 构建 MessageVo 列表
-	var list []*response.MessageVo
+	messageIds := make([]uint, 0, len(messages))
+	for _, msg := range messages {
+		messageIds = append(messageIds, msg.ID)
+	}
+	reactions, err := s.reactionsOf(userId, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := s.threadsOf(userId, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	var pinned []uint
+	if s.pinRepository != nil {
+		if pinned, err = s.pinRepository.PinnedMessageIds(messageIds); err != nil {
+			return nil, err
+		}
+	}
 	for _, msg := range messages {
-		// 填充基本的消息信息
-		sender := idToUserMap[uint(msg.SenderId)]
-		messageVo := &response.MessageVo{
-			ID:                 msg.ID,
-			CreatedAt:          msg.CreatedAt,
-			UpdatedAt:          msg.UpdatedAt,
-			SenderId:           msg.SenderId,
-			ReceiverId:         msg.ReceiverId,
-			GroupId:            msg.GroupId,
-			ReplyId:            msg.ReplyId,
-			ReaderIdList:       msg.ReaderIdList,
-			TargetType:         msg.TargetType,
-			Content:            msg.Content,
-			Type:               msg.Type,
-			Status:             msg.Status,
-			ExtraData:          msg.ExtraData,
-			IsRead:             msg.ReaderIdList != nil && utils.Contains(*msg.ReaderIdList, userId),
-			SenderNickName:     sender.Nickname,
-			SenderAvatar:       sender.Avatar,
-			SenderOnlineStatus: &sender.OnlineStatus,
-		}
-
-		// 如果有 ReplyId，查询并填充被引用的消息
+		vo := newMessageVo(userId, msg, users, groupNicknames)
 		if msg.ReplyId != nil {
-			replyMessage, err := s.messageRepository.GetById(uint(*msg.ReplyId))
-			if err == nil && replyMessage != nil {
-				messageVo.Reply = &response.MessageVo{
-					ID:                 replyMessage.ID,
-					CreatedAt:          replyMessage.CreatedAt,
-					UpdatedAt:          replyMessage.UpdatedAt,
-					SenderId:           replyMessage.SenderId,
-					ReceiverId:         replyMessage.ReceiverId,
-					GroupId:            replyMessage.GroupId,
-					ReplyId:            replyMessage.ReplyId,
-					ReaderIdList:       replyMessage.ReaderIdList,
-					TargetType:         replyMessage.TargetType,
-					Content:            replyMessage.Content,
-					Type:               replyMessage.Type,
-					Status:             replyMessage.Status,
-					ExtraData:          replyMessage.ExtraData,
-					SenderNickName:     sender.Nickname,
-					SenderAvatar:       sender.Avatar,
-					SenderOnlineStatus: &sender.OnlineStatus,
-				}
+			if reply, ok := quoted[uint(*msg.ReplyId)]; ok {
+				vo.Reply = newMessageVo(userId, reply, users, groupNicknames)
 			}
 		}
-
-		list = append(list, messageVo)
+		vo.Reactions = reactions[msg.ID]
+		vo.Thread = threads[msg.ID]
+		vo.Pinned = utils.Contains(pinned, msg.ID)
+		list = append(list, vo)
 	}
+	return list, nil
+}
 
-	// 计算游标
-	var cursor int64 = 0
-	if len(list) > 0 {
-		cursor = int64(list[len(list)-1].ID)
+// newMessageVo 填充消息及其发送者信息
+func newMessageVo(userId uint, msg *model.Message, users map[uint]model.User, groupNicknames map[uint]map[uint]string) *response.MessageVo {
+	vo := &response.MessageVo{}
+	vo.GetFieldsFromMessage(msg)
+	vo.IsRead = msg.ReaderIdList != nil && utils.Contains(*msg.ReaderIdList, userId)
+	vo.IsPlayed = msg.PlayedIdList != nil && utils.Contains(*msg.PlayedIdList, userId)
+	if sender, ok := users[uint(msg.SenderId)]; ok {
+		vo.SenderNickName = sender.Nickname
+		vo.SenderAvatar = sender.Avatar
+		onlineStatus := sender.OnlineStatus
+		vo.SenderOnlineStatus = &onlineStatus
 	}
-
-	return &response.QueryMessagesResponse{
-		List:    list,
-		Cursor:  cursor,
-		HasMore: hasMore,
-	}, nil
+	if msg.GroupId != nil {
+		if nickname, ok := groupNicknames[uint(*msg.GroupId)][uint(msg.SenderId)]; ok {
+			vo.SenderNickName = &nickname
+		}
+	}
+	return vo
 }
 
+// Revoke 撤回消息（对所有人删除），撤回的消息保留在库中供举报审核使用，不再向用户返回内容
 func (s *MessageService) Revoke(userId uint, messageId uint) error {
-
 	message, err := s.messageRepository.GetById(messageId)
 	if err != nil {
 		return fmt.Errorf("消息未找到: %w", err)
 	}
+	if message == nil {
+		return errors.New("消息不存在")
+	}
+	if err := s.checkRevokePermission(userId, message); err != nil {
+		return err
+	}
+	return s.revoke(userId, message)
+}
 
-	if *message.TargetType == model.PrivateTarget {
-		if message.SenderId != int64(userId) {
-			return fmt.Errorf("没有权限撤回此消息")
+// checkRevokePermission 发送者可以在撤回时限内撤回自己的消息，群主和管理员可以随时撤回群内任何人的消息
+func (s *MessageService) checkRevokePermission(userId uint, message *model.Message) error {
+	if !s.canView(userId, message) {
+		return errors.New("消息不存在")
+	}
+	if message.Status != nil && *message.Status == model.Disable {
+		return errors.New("消息已撤回")
+	}
+	if *message.TargetType == model.GroupTarget && s.groupMemberRepository.IsOwnerOrAdmin(uint(*message.GroupId), userId) {
+		return nil
+	}
+	if message.SenderId != int64(userId) {
+		return errors.New("没有权限撤回他人消息")
+	}
+	if window := revokeWindow(); time.Since(message.CreatedAt) > window {
+		return fmt.Errorf("只能撤回 %d 秒内发送的消息", int(window.Seconds()))
+	}
+	return nil
+}
+
+func (s *MessageService) revoke(userId uint, message *model.Message) error {
+	revokedBy := int64(userId)
+	fields := map[string]interface{}{
+		"status":     model.Disable,
+		"revoked_by": &revokedBy,
+		"revoked_at": time.Now(),
+	}
+	if err := s.messageRepository.UpdateFields(message.ID, fields); err != nil {
+		return fmt.Errorf("撤回消息失败: %w", err)
+	}
+	// 撤回的消息不再出现在“@我的”中
+	if *message.TargetType == model.GroupTarget && s.mentionRepository != nil {
+		if err := s.mentionRepository.DeleteByMessageId(message.ID); err != nil {
+			logUtil.Errorf("删除消息(%d)的 @ 提醒失败: %v", message.ID, err)
+		}
+	}
+	// 撤回的消息同时取消置顶
+	if s.pinRepository != nil {
+		if _, err := s.pinRepository.Delete(message.ID); err != nil {
+			logUtil.Errorf("取消消息(%d)的置顶失败: %v", message.ID, err)
+		}
+	}
+	if s.wsHandler != nil {
+		if userIds, err := s.conversationUserIds(message); err == nil {
+			s.wsHandler.MessageRevokedNotice(userIds, model.MessageRevokedNotice{
+				MessageId:  message.ID,
+				TargetType: *message.TargetType,
+				SenderId:   message.SenderId,
+				ReceiverId: message.ReceiverId,
+				GroupId:    message.GroupId,
+				OperatorId: userId,
+			})
 		}
 	}
+	return nil
+}
 
-	if *message.TargetType == model.GroupTarget {
+// DeleteMessages 删除消息：仅自己删除时只对自己隐藏，对所有人删除即撤回
+// 先校验全部消息，任一消息不满足条件时都不删除
+func (s *MessageService) DeleteMessages(userId uint, req request.MessageDeleteReq) error {
+	messages, err := s.messageRepository.GetByIdList(req.MessageIds)
+	if err != nil {
+		return err
+	}
+	byId := make(map[uint]*model.Message, len(messages))
+	for _, message := range messages {
+		byId[message.ID] = message
+	}
+	var messageIds []uint
+	for _, messageId := range req.MessageIds {
+		message, ok := byId[messageId]
+		if !ok || !s.canView(userId, message) {
+			return fmt.Errorf("消息(%d)不存在", messageId)
+		}
+		if req.ForEveryone {
+			if err := s.checkRevokePermission(userId, message); err != nil {
+				return fmt.Errorf("消息(%d): %w", messageId, err)
+			}
+		}
+		if !utils.Contains(messageIds, messageId) {
+			messageIds = append(messageIds, messageId)
+		}
+	}
 
-		isAdminOrOwner := s.groupMemberRepository.IsOwnerOrAdmin(userId, uint(*message.GroupId))
-		if message.SenderId != int64(userId) && !isAdminOrOwner {
-			return fmt.Errorf("没有权限撤回他人消息")
+	if req.ForEveryone {
+		for _, messageId := range messageIds {
+			if err := s.revoke(userId, byId[messageId]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := s.deletionRepository.Create(userId, messageIds); err != nil {
+		return fmt.Errorf("删除消息失败: %w", err)
+	}
+	// 删除的消息中 @ 自己的提醒视为已读
+	if s.mentionRepository != nil {
+		for _, messageId := range messageIds {
+			if *byId[messageId].TargetType != model.GroupTarget {
+				continue
+			}
+			if err := s.mentionRepository.MarkReadByMessageId(userId, messageId); err != nil {
+				logUtil.Errorf("标记消息(%d)的 @ 提醒已读失败: %v", messageId, err)
+			}
 		}
 	}
+	return nil
+}
 
-	fields := map[string]interface{}{
-		"status": model.Disable,
+// ClearConversation 清空会话的聊天记录，只对自己生效；之后收到的新消息照常显示
+func (s *MessageService) ClearConversation(userId uint, req request.ConversationClearReq) error {
+	if *req.TargetType != model.PrivateTarget && *req.TargetType != model.GroupTarget {
+		return errors.New("消息目标类型不合法")
+	}
+	latest, err := s.messageRepository.QueryHistoryMessages(userId, &request.QueryMessagesRequest{
+		TargetId:   req.TargetId,
+		TargetType: req.TargetType,
+		Limit:      1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+	return s.deletionRepository.SaveClear(&model.ConversationClear{
+		UserId:     userId,
+		TargetType: *req.TargetType,
+		TargetId:   req.TargetId,
+		ClearedId:  latest[0].ID,
+	})
+}
+
+// prepareEphemeral 校验限时消息：普通限时消息发送时开始计时，阅后即焚的消息在接收者读取后开始计时
+// 销毁时间由服务端计算，忽略客户端传入的值
+func prepareEphemeral(msg *model.Message) error {
+	msg.ExpireAt = nil
+	if msg.Ttl == 0 && !msg.BurnAfterRead {
+		return nil
+	}
+	if msg.Ttl <= 0 {
+		return errors.New("请设置消息的销毁时长")
+	}
+	if maxTtl := messageTtlMax(); msg.Ttl > maxTtl {
+		return fmt.Errorf("消息销毁时长不能超过 %d 秒", maxTtl)
+	}
+	if *msg.Type == model.RedBagContent || *msg.Type == model.SystemContent {
+		return errors.New("该类型的消息不能设置销毁时长")
+	}
+	if msg.BurnAfterRead {
+		if *msg.TargetType != model.PrivateTarget {
+			return errors.New("阅后即焚仅支持私聊")
+		}
+		return nil
+	}
+	expireAt := time.Now().Add(time.Duration(msg.Ttl) * time.Second)
+	msg.ExpireAt = &expireAt
+	return nil
+}
+
+// burnAfterRead 接收者第一次读取阅后即焚的消息时返回销毁时间，其他情况返回 nil
+func burnAfterRead(message *model.Message, userId uint) *time.Time {
+	if !message.BurnAfterRead || message.ExpireAt != nil || message.ReceiverId == nil || *message.ReceiverId != int64(userId) {
+		return nil
 	}
+	expireAt := time.Now().Add(time.Duration(message.Ttl) * time.Second)
+	return &expireAt
+}
 
-	err = s.messageRepository.UpdateFields(messageId, fields)
+// prepareVoice 校验语音消息：只能包含一段语音，引用已处理完成的音频文件，时长不超过上限
+// 时长和波形以服务端解析的结果为准，覆盖客户端传入的值
+func (s *MessageService) prepareVoice(msg *model.Message) error {
+	var voice *model.MessagePart
+	for _, part := range *msg.Content {
+		if part != nil && part.Type == model.Voice {
+			voice = part
+		}
+	}
+	isVoice := *msg.Type == model.VoiceContent
+	if voice == nil && !isVoice {
+		return nil
+	}
+	if voice == nil || !isVoice || len(*msg.Content) != 1 {
+		return errors.New("语音消息只能包含一段语音")
+	}
+	if voice.Content == nil || s.fileRefService == nil {
+		return errors.New("语音文件不存在")
+	}
+	file, err := s.fileRefService.FileByUrl(*voice.Content)
 	if err != nil {
-		return fmt.Errorf("撤回消息失败: %w", err)
+		return err
 	}
+	if file == nil || file.Url != *voice.Content || file.Type != "audio" {
+		return errors.New("语音文件不存在")
+	}
+	if file.MediaStatus == model.MediaProcessing {
+		return errors.New("语音正在处理，请稍后发送")
+	}
+	if file.Duration == nil {
+		return errors.New("无法识别语音时长")
+	}
+	if maxDuration := voiceMaxDuration(); *file.Duration > float64(maxDuration) {
+		return fmt.Errorf("语音不能超过 %d 秒", maxDuration)
+	}
+	voice.Duration = file.Duration
+	voice.Waveform = file.Waveform
+	return nil
+}
 
+// prepareStickers 校验表情片段：表情必须存在，群自定义表情只能在所属的群中使用
+// 片段的内容由服务端填充为表情的图片地址
+func (s *MessageService) prepareStickers(msg *model.Message) error {
+	var parts []*model.MessagePart
+	var ids []uint
+	for _, part := range *msg.Content {
+		if part == nil {
+			continue
+		}
+		if part.Type != model.StickerPart {
+			part.StickerId = nil
+			continue
+		}
+		if part.StickerId == nil {
+			return errors.New("表情不存在")
+		}
+		parts = append(parts, part)
+		ids = append(ids, *part.StickerId)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	if *msg.Type != model.TextContent {
+		return errors.New("表情只能在文本消息中发送")
+	}
+	if s.stickerRepository == nil {
+		return errors.New("表情不存在")
+	}
+	stickers, err := s.stickerRepository.GetByIds(ids)
+	if err != nil {
+		return err
+	}
+	stickerMap := make(map[uint]model.Sticker, len(stickers))
+	for _, sticker := range stickers {
+		stickerMap[sticker.ID] = sticker
+	}
+	for _, part := range parts {
+		sticker, ok := stickerMap[*part.StickerId]
+		if !ok {
+			return errors.New("表情不存在")
+		}
+		if sticker.GroupId != nil && (*msg.TargetType != model.GroupTarget || uint(*msg.GroupId) != *sticker.GroupId) {
+			return errors.New("群自定义表情只能在所属的群中使用")
+		}
+		url := sticker.Url
+		part.Content = &url
+	}
 	return nil
 }
+
+// prepareMentions 校验 @ 片段：只能在群聊中使用，被 @ 的用户必须是群成员，@所有人 仅群主和管理员可用
+// 片段的文字由服务端按群昵称填充，返回需要提醒的用户（不含发送者自己）
+func (s *MessageService) prepareMentions(msg *model.Message) ([]uint, bool, error) {
+	var parts []*model.MessagePart
+	for _, part := range *msg.Content {
+		if part != nil && (part.Type == model.MentionUser || part.Type == model.MentionAll) {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return nil, false, nil
+	}
+	if *msg.TargetType != model.GroupTarget {
+		return nil, false, errors.New("只能在群聊中@成员")
+	}
+	groupId, senderId := uint(*msg.GroupId), uint(msg.SenderId)
+	memberList, err := s.groupMemberRepository.GetMemberListByGroupId(groupId)
+	if err != nil {
+		return nil, false, err
+	}
+	members := make(map[uint]response.MemberVo, len(memberList))
+	for _, member := range memberList {
+		members[member.UserId] = member
+	}
+
+	mentionAll := false
+	var mentioned []uint
+	for _, part := range parts {
+		if part.Type == model.MentionAll {
+			if !s.groupMemberRepository.IsOwnerOrAdmin(groupId, senderId) {
+				return nil, false, errors.New("只有群主和管理员可以@所有人")
+			}
+			mentionAll = true
+			continue
+		}
+		if part.UserId == nil {
+			return nil, false, errors.New("请指定要@的成员")
+		}
+		if _, ok := members[*part.UserId]; !ok {
+			return nil, false, errors.New("@的用户不是群成员")
+		}
+		if !utils.Contains(mentioned, *part.UserId) {
+			mentioned = append(mentioned, *part.UserId)
+		}
+	}
+
+	// 没有群昵称的成员使用用户昵称
+	nicknames := make(map[uint]string, len(mentioned))
+	var missing []uint
+	for _, userId := range mentioned {
+		if nickname := members[userId].Nickname; nickname != "" {
+			nicknames[userId] = nickname
+		} else {
+			missing = append(missing, userId)
+		}
+	}
+	if len(missing) > 0 {
+		users, _ := s.userRepository.GetByIdList(missing)
+		for _, user := range users {
+			if user.Nickname != nil {
+				nicknames[user.ID] = *user.Nickname
+			}
+		}
+	}
+	for _, part := range parts {
+		text := "@所有人"
+		if part.Type == model.MentionAll {
+			part.UserId = nil
+		} else {
+			text = "@" + nicknames[*part.UserId]
+		}
+		part.Content = &text
+	}
+
+	recipients := mentioned
+	if mentionAll {
+		recipients = make([]uint, 0, len(memberList))
+		for _, member := range memberList {
+			recipients = append(recipients, member.UserId)
+		}
+	}
+	result := make([]uint, 0, len(recipients))
+	for _, userId := range recipients {
+		if userId != senderId {
+			result = append(result, userId)
+		}
+	}
+	return result, mentionAll, nil
+}
+
+// notifyMentions 写入被提醒者的“@我的”记录，并单独推送 @ 提醒；失败只记录日志，不影响消息发送
+func (s *MessageService) notifyMentions(vo *response.MessageVo, userIds []uint, mentionAll bool) {
+	if len(userIds) == 0 || s.mentionRepository == nil {
+		return
+	}
+	mentioned := make(map[uint]bool)
+	if mentionAll {
+		for _, part := range *vo.Content {
+			if part != nil && part.Type == model.MentionUser && part.UserId != nil {
+				mentioned[*part.UserId] = true
+			}
+		}
+	}
+	mentions := make([]*model.Mention, 0, len(userIds))
+	for _, userId := range userIds {
+		mentions = append(mentions, &model.Mention{
+			UserId:    userId,
+			GroupId:   uint(*vo.GroupId),
+			MessageId: vo.ID,
+			SenderId:  uint(vo.SenderId),
+			// 同时被单独 @ 的成员按单独 @ 提醒
+			IsAll: mentionAll && !mentioned[userId],
+		})
+	}
+	if err := s.mentionRepository.CreateBatch(mentions); err != nil {
+		logUtil.Errorf("保存消息(%d)的 @ 提醒失败: %v", vo.ID, err)
+		return
+	}
+	if s.wsHandler == nil {
+		return
+	}
+	notice := model.MentionNotice{
+		MessageId: vo.ID,
+		GroupId:   uint(*vo.GroupId),
+		SenderId:  uint(vo.SenderId),
+		Preview:   messagePreview(*vo.Type, vo.Content),
+	}
+	if vo.SenderNickName != nil {
+		notice.SenderNickname = *vo.SenderNickName
+	}
+	for _, mention := range mentions {
+		notice.MentionId = mention.ID
+		notice.IsAll = mention.IsAll
+		s.wsHandler.MentionNotice(int64(mention.UserId), notice)
+	}
+}
+
+// MentionList 查询“@我的”消息，最新的在前
+func (s *MessageService) MentionList(userId uint, req request.MentionQueryRequest) (*pagination.PageResult[response.MentionVo], error) {
+	page, err := s.mentionRepository.Page(userId, req)
+	if err != nil {
+		return nil, err
+	}
+	messageIds := make([]uint, 0, len(page.Records))
+	for _, mention := range page.Records {
+		messageIds = append(messageIds, mention.MessageId)
+	}
+	messages, err := s.messageRepository.GetByIdList(messageIds)
+	if err != nil {
+		return nil, err
+	}
+	senderIds := make([]uint, 0, len(messages))
+	for _, message := range messages {
+		senderIds = append(senderIds, uint(message.SenderId))
+	}
+	users := make(map[uint]model.User)
+	userList, _ := s.userRepository.GetByIdList(senderIds)
+	for _, user := range userList {
+		users[user.ID] = user
+	}
+	vos := make(map[uint]*response.MessageVo, len(messages))
+	for _, message := range messages {
+		vo := &response.MessageVo{}
+		vo.GetFieldsFromMessage(message)
+		if sender, ok := users[uint(message.SenderId)]; ok {
+			vo.SenderNickName = sender.Nickname
+			vo.SenderAvatar = sender.Avatar
+		}
+		vo.IsRead = message.ReaderIdList != nil && utils.Contains(*message.ReaderIdList, userId)
+		vos[message.ID] = vo
+	}
+
+	result := &pagination.PageResult[response.MentionVo]{
+		Total:    page.Total,
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Records:  make([]response.MentionVo, 0, len(page.Records)),
+	}
+	for _, mention := range page.Records {
+		result.Records = append(result.Records, response.MentionVo{
+			Mention: mention,
+			IsRead:  mention.ReadAt != nil,
+			Message: vos[mention.MessageId],
+		})
+	}
+	return result, nil
+}
+
+// ReadMentions 标记 @ 提醒已读，返回标记的条数
+func (s *MessageService) ReadMentions(userId uint, req request.MentionReadRequest) (int64, error) {
+	return s.mentionRepository.MarkRead(userId, req)
+}
+
+// AddReaction 添加表情回应，重复添加同一表情不报错；每人对同一条消息的表情数有上限
+func (s *MessageService) AddReaction(userId uint, req request.ReactionReq) ([]response.ReactionVo, error) {
+	if !validEmoji(req.Emoji) {
+		return nil, errors.New("不支持的表情")
+	}
+	message, err := s.reactableMessage(userId, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.reactionRepository.ListByMessageIds([]uint{message.ID})
+	if err != nil {
+		return nil, err
+	}
+	emojis := 0
+	for _, reaction := range existing {
+		if reaction.UserId != userId {
+			continue
+		}
+		if reaction.Emoji == req.Emoji {
+			return s.messageReactions(userId, message.ID)
+		}
+		emojis++
+	}
+	if maxEmojis := reactionMaxPerUser(); emojis >= maxEmojis {
+		return nil, fmt.Errorf("每条消息最多回应 %d 个表情", maxEmojis)
+	}
+	created, err := s.reactionRepository.Create(&model.Reaction{MessageId: message.ID, UserId: userId, Emoji: req.Emoji})
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.messageReactions(userId, message.ID)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.notifyReaction(userId, message, req.Emoji, model.ReactionAdd, reactions)
+	}
+	return reactions, nil
+}
+
+// RemoveReaction 取消表情回应，没有回应过时不报错
+func (s *MessageService) RemoveReaction(userId uint, req request.ReactionReq) ([]response.ReactionVo, error) {
+	message, err := s.reactableMessage(userId, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.reactionRepository.Delete(message.ID, userId, req.Emoji)
+	if err != nil {
+		return nil, err
+	}
+	reactions, err := s.messageReactions(userId, message.ID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		s.notifyReaction(userId, message, req.Emoji, model.ReactionRemove, reactions)
+	}
+	return reactions, nil
+}
+
+// reactableMessage 只能回应自己看得到、未撤回的消息
+func (s *MessageService) reactableMessage(userId uint, messageId uint) (*model.Message, error) {
+	message, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || !s.canView(userId, message) {
+		return nil, errors.New("消息不存在")
+	}
+	if message.Status != nil && *message.Status == model.Disable {
+		return nil, errors.New("消息已撤回")
+	}
+	return message, nil
+}
+
+func (s *MessageService) messageReactions(userId uint, messageId uint) ([]response.ReactionVo, error) {
+	reactions, err := s.reactionsOf(userId, []uint{messageId})
+	if err != nil {
+		return nil, err
+	}
+	return reactions[messageId], nil
+}
+
+// reactionsOf 按消息汇总表情回应，回应者昵称一次批量查询
+func (s *MessageService) reactionsOf(userId uint, messageIds []uint) (map[uint][]response.ReactionVo, error) {
+	result := make(map[uint][]response.ReactionVo)
+	if s.reactionRepository == nil || len(messageIds) == 0 {
+		return result, nil
+	}
+	reactions, err := s.reactionRepository.ListByMessageIds(messageIds)
+	if err != nil {
+		return nil, err
+	}
+	userIds := make([]uint, 0, len(reactions))
+	for _, reaction := range reactions {
+		if !utils.Contains(userIds, reaction.UserId) {
+			userIds = append(userIds, reaction.UserId)
+		}
+	}
+	nicknames := make(map[uint]string, len(userIds))
+	userList, _ := s.userRepository.GetByIdList(userIds)
+	for _, user := range userList {
+		if user.Nickname != nil {
+			nicknames[user.ID] = *user.Nickname
+		}
+	}
+	for _, reaction := range reactions {
+		list := result[reaction.MessageId]
+		index := -1
+		for i := range list {
+			if list[i].Emoji == reaction.Emoji {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			list = append(list, response.ReactionVo{Emoji: reaction.Emoji, Users: []response.ReactionUserVo{}})
+			index = len(list) - 1
+		}
+		list[index].Count++
+		list[index].Reacted = list[index].Reacted || reaction.UserId == userId
+		list[index].Users = append(list[index].Users, response.ReactionUserVo{UserId: reaction.UserId, Nickname: nicknames[reaction.UserId]})
+		result[reaction.MessageId] = list
+	}
+	return result, nil
+}
+
+// notifyReaction 推送回应变化给会话中除回应者以外的人
+func (s *MessageService) notifyReaction(userId uint, message *model.Message, emoji string,
+	action model.ReactionAction, reactions []response.ReactionVo) {
+	if s.wsHandler == nil {
+		return
+	}
+	notice := model.ReactionNotice{MessageId: message.ID, UserId: userId, Emoji: emoji, Action: action}
+	for _, reaction := range reactions {
+		if reaction.Emoji == emoji {
+			notice.Count = reaction.Count
+		}
+	}
+	if user, err := s.userRepository.GetById(userId); err == nil && user != nil && user.Nickname != nil {
+		notice.Nickname = *user.Nickname
+	}
+	var recipients []int64
+	if *message.TargetType == model.PrivateTarget {
+		recipients = []int64{message.SenderId, *message.ReceiverId}
+	} else {
+		members, err := s.groupMemberRepository.GetMemberListByGroupId(uint(*message.GroupId))
+		if err != nil {
+			logUtil.Errorf("推送消息(%d)的表情回应失败: %v", message.ID, err)
+			return
+		}
+		for _, member := range members {
+			recipients = append(recipients, int64(member.UserId))
+		}
+	}
+	others := make([]int64, 0, len(recipients))
+	for _, id := range recipients {
+		if id != int64(userId) {
+			others = append(others, id)
+		}
+	}
+	s.wsHandler.ReactionNotice(others, notice)
+}
+
+// prepareReply 只能回复同一会话中未撤回的消息，回复归入被回复消息所在的话题，返回话题的根消息
+func (s *MessageService) prepareReply(msg *model.Message) (*model.Message, error) {
+	msg.RootId = nil
+	if msg.ReplyId == nil {
+		return nil, nil
+	}
+	target, err := s.messageRepository.GetById(uint(*msg.ReplyId))
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || conversationKey(target) != conversationKey(msg) {
+		return nil, errors.New("回复的消息不存在")
+	}
+	if target.Status != nil && *target.Status == model.Disable {
+		return nil, errors.New("不能回复已撤回的消息")
+	}
+	root := target
+	if target.RootId != nil {
+		if root, err = s.messageRepository.GetById(uint(*target.RootId)); err != nil {
+			return nil, err
+		}
+		if root == nil {
+			return nil, errors.New("话题不存在")
+		}
+	}
+	rootId := int64(root.ID)
+	msg.RootId = &rootId
+	return root, nil
+}
+
+// addThreadReply 更新话题摘要，并通知仍在会话中的其他参与者；失败只记录日志，不影响消息发送
+func (s *MessageService) addThreadReply(root *model.Message, msg *model.Message, vo *response.MessageVo) {
+	if root == nil || s.threadRepository == nil {
+		return
+	}
+	senderId := uint(msg.SenderId)
+	thread, err := s.threadRepository.AddReply(root.ID, msg.ID, msg.CreatedAt)
+	if err == nil && uint(root.SenderId) != senderId {
+		err = s.threadRepository.Join(root.ID, uint(root.SenderId))
+	}
+	if err == nil {
+		err = s.threadRepository.RecordReply(root.ID, senderId, thread.ReplyCount)
+	}
+	if err != nil {
+		logUtil.Errorf("更新话题(%d)失败: %v", root.ID, err)
+		return
+	}
+	if s.wsHandler == nil {
+		return
+	}
+	participants, err := s.threadRepository.GetParticipants([]uint{root.ID})
+	if err != nil {
+		logUtil.Errorf("查询话题(%d)参与者失败: %v", root.ID, err)
+		return
+	}
+	var members []uint
+	if *root.TargetType == model.GroupTarget {
+		memberList, _ := s.groupMemberRepository.GetMemberListByGroupId(uint(*root.GroupId))
+		for _, member := range memberList {
+			members = append(members, member.UserId)
+		}
+	}
+	notice := model.ThreadNotice{
+		RootId:     root.ID,
+		MessageId:  vo.ID,
+		SenderId:   senderId,
+		ReplyCount: thread.ReplyCount,
+		Preview:    messagePreview(*vo.Type, vo.Content),
+	}
+	if vo.SenderNickName != nil {
+		notice.SenderNickname = *vo.SenderNickName
+	}
+	for _, participant := range participants {
+		if participant.UserId == senderId || (members != nil && !utils.Contains(members, participant.UserId)) {
+			continue
+		}
+		notice.Unread = thread.ReplyCount - participant.ReadCount
+		s.wsHandler.ThreadNotice(int64(participant.UserId), notice)
+	}
+}
+
+// threadsOf 批量查询话题摘要，只返回有回复的根消息
+func (s *MessageService) threadsOf(userId uint, messageIds []uint) (map[uint]*response.ThreadVo, error) {
+	result := make(map[uint]*response.ThreadVo)
+	if s.threadRepository == nil || len(messageIds) == 0 {
+		return result, nil
+	}
+	threads, err := s.threadRepository.GetByRootIds(messageIds)
+	if err != nil || len(threads) == 0 {
+		return result, err
+	}
+	rootIds := make([]uint, 0, len(threads))
+	for _, thread := range threads {
+		rootIds = append(rootIds, thread.RootId)
+		result[thread.RootId] = &response.ThreadVo{
+			RootId:       thread.RootId,
+			ReplyCount:   thread.ReplyCount,
+			LastReplyId:  thread.LastReplyId,
+			LastReplyAt:  thread.LastReplyAt,
+			Participants: []response.ThreadParticipantVo{},
+		}
+	}
+	participants, err := s.threadRepository.GetParticipants(rootIds)
+	if err != nil {
+		return nil, err
+	}
+	var userIds []uint
+	for _, participant := range participants {
+		if !utils.Contains(userIds, participant.UserId) {
+			userIds = append(userIds, participant.UserId)
+		}
+	}
+	users := make(map[uint]model.User, len(userIds))
+	userList, _ := s.userRepository.GetByIdList(userIds)
+	for _, user := range userList {
+		users[user.ID] = user
+	}
+	for _, participant := range participants {
+		thread := result[participant.RootId]
+		thread.ParticipantCount++
+		if participant.UserId == userId {
+			thread.Joined = true
+			thread.Unread = max(thread.ReplyCount-participant.ReadCount, 0)
+		}
+		if len(thread.Participants) < threadParticipantPreview {
+			vo := response.ThreadParticipantVo{UserId: participant.UserId}
+			if user, ok := users[participant.UserId]; ok {
+				vo.Avatar = user.Avatar
+				if user.Nickname != nil {
+					vo.Nickname = *user.Nickname
+				}
+			}
+			thread.Participants = append(thread.Participants, vo)
+		}
+	}
+	return result, nil
+}
+
+// ThreadReplies 按时间顺序分页查看话题的回复，传入回复的 id 时查看其所在的话题
+func (s *MessageService) ThreadReplies(userId uint, req request.ThreadRepliesRequest) (*response.ThreadRepliesResponse, error) {
+	root, err := s.threadRoot(userId, req.RootId)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	replies, err := s.messageRepository.QueryThreadReplies(userId, root.ID, *root.TargetType, conversationTarget(userId, root), req.Cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(replies) > limit
+	if hasMore {
+		replies = replies[:limit]
+	}
+	list, err := s.buildMessageVos(userId, append([]*model.Message{root}, replies...))
+	if err != nil {
+		return nil, err
+	}
+	resp := &response.ThreadRepliesResponse{Root: list[0], List: list[1:], HasMore: hasMore}
+	if len(replies) > 0 {
+		resp.Cursor = int64(replies[len(replies)-1].ID)
+	}
+	return resp, nil
+}
+
+// ThreadList 查询参与的话题，最近有回复的在前；已看不到的根消息不返回内容
+func (s *MessageService) ThreadList(userId uint, req request.ThreadQueryRequest) (*pagination.PageResult[response.ThreadVo], error) {
+	page, err := s.threadRepository.PageJoined(userId, req)
+	if err != nil {
+		return nil, err
+	}
+	rootIds := make([]uint, 0, len(page.Records))
+	for _, thread := range page.Records {
+		rootIds = append(rootIds, thread.RootId)
+	}
+	roots, err := s.messageRepository.GetByIdList(rootIds)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*model.Message, 0, len(roots))
+	for _, root := range roots {
+		if s.canView(userId, root) {
+			visible = append(visible, root)
+		}
+	}
+	vos, err := s.buildMessageVos(userId, visible)
+	if err != nil {
+		return nil, err
+	}
+	rootVos := make(map[uint]*response.MessageVo, len(vos))
+	for _, vo := range vos {
+		rootVos[vo.ID] = vo
+	}
+	threads, err := s.threadsOf(userId, rootIds)
+	if err != nil {
+		return nil, err
+	}
+	result := &pagination.PageResult[response.ThreadVo]{
+		Total:    page.Total,
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Records:  make([]response.ThreadVo, 0, len(page.Records)),
+	}
+	for _, record := range page.Records {
+		thread := threads[record.RootId]
+		if thread == nil {
+			continue
+		}
+		if root := rootVos[record.RootId]; root != nil {
+			root.Thread = nil
+			thread.Root = root
+		}
+		result.Records = append(result.Records, *thread)
+	}
+	return result, nil
+}
+
+// ReadThread 将话题的回复全部标记为已读，只能标记自己能看到的话题
+func (s *MessageService) ReadThread(userId uint, req request.ThreadReadRequest) error {
+	root, err := s.threadRoot(userId, req.RootId)
+	if err != nil {
+		return err
+	}
+	return s.threadRepository.MarkRead(root.ID, userId)
+}
+
+// threadRoot 查询话题的根消息，传入回复的 id 时返回其所在话题的根消息；看不到的消息视为不存在
+func (s *MessageService) threadRoot(userId uint, messageId uint) (*model.Message, error) {
+	root, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if root != nil && root.RootId != nil {
+		if root, err = s.messageRepository.GetById(uint(*root.RootId)); err != nil {
+			return nil, err
+		}
+	}
+	if root == nil || !s.canView(userId, root) {
+		return nil, errors.New("消息不存在")
+	}
+	return root, nil
+}
+
+// PinMessage 置顶消息：私聊双方都可以置顶，群聊仅群主和管理员；置顶后在会话中发一条系统消息
+func (s *MessageService) PinMessage(userId uint, req request.PinMessageReq) (*response.MessageVo, error) {
+	message, err := s.pinnableMessage(userId, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	conversation := conversationKey(message)
+	// 统计和写入在同一事务中，锁住会话中已有的置顶，并发置顶不会超过上限
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		count, err := s.pinRepository.CountByConversationForUpdate(conversation, tx)
+		if err != nil {
+			return err
+		}
+		if maxCount := pinMaxCount(); count >= int64(maxCount) {
+			return fmt.Errorf("每个会话最多置顶 %d 条消息", maxCount)
+		}
+		created, err := s.pinRepository.Create(&model.Pin{Conversation: conversation, MessageId: message.ID, PinnedBy: userId}, tx)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return errors.New("消息已置顶")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.sendPinNotice(userId, message, model.PinAdd)
+}
+
+// UnpinMessage 取消置顶，权限与置顶相同
+func (s *MessageService) UnpinMessage(userId uint, req request.PinMessageReq) (*response.MessageVo, error) {
+	message, err := s.messageRepository.GetById(req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || !s.canView(userId, message) {
+		return nil, errors.New("消息不存在")
+	}
+	if err := s.checkPinPermission(userId, message); err != nil {
+		return nil, err
+	}
+	deleted, err := s.pinRepository.Delete(message.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, errors.New("消息未置顶")
+	}
+	return s.sendPinNotice(userId, message, model.PinRemove)
+}
+
+// pinnableMessage 只能置顶自己看得到、未撤回的普通消息
+func (s *MessageService) pinnableMessage(userId uint, messageId uint) (*model.Message, error) {
+	message, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || !s.canView(userId, message) {
+		return nil, errors.New("消息不存在")
+	}
+	if message.Status != nil && *message.Status == model.Disable {
+		return nil, errors.New("消息已撤回")
+	}
+	if message.Type != nil && *message.Type == model.SystemContent {
+		return nil, errors.New("系统消息不能置顶")
+	}
+	return message, s.checkPinPermission(userId, message)
+}
+
+func (s *MessageService) checkPinPermission(userId uint, message *model.Message) error {
+	if *message.TargetType == model.GroupTarget && !s.groupMemberRepository.IsOwnerOrAdmin(uint(*message.GroupId), userId) {
+		return errors.New("只有群主和管理员可以置顶消息")
+	}
+	return nil
+}
+
+// sendPinNotice 在会话中发送置顶变化的系统消息并推送给会话成员
+func (s *MessageService) sendPinNotice(userId uint, message *model.Message, action model.PinAction) (*response.MessageVo, error) {
+	extra, err := model.NewExtraData(model.PinExtra{Action: action, MessageId: message.ID, OperatorId: userId})
+	if err != nil {
+		return nil, err
+	}
+	nickname := ""
+	if user, err := s.userRepository.GetById(userId); err == nil && user != nil && user.Nickname != nil {
+		nickname = *user.Nickname
+	}
+	text := fmt.Sprintf("%s 置顶了一条消息：%s", nickname, messagePreview(*message.Type, message.Content))
+	if action == model.PinRemove {
+		text = fmt.Sprintf("%s 取消置顶了一条消息：%s", nickname, messagePreview(*message.Type, message.Content))
+	}
+	target := request.ForwardTarget{TargetType: message.TargetType}
+	if *message.TargetType == model.GroupTarget {
+		target.TargetId = uint(*message.GroupId)
+	} else if message.SenderId == int64(userId) {
+		target.TargetId = uint(*message.ReceiverId)
+	} else {
+		target.TargetId = uint(message.SenderId)
+	}
+	notice := forwardMessage(userId, target, model.SystemContent, model.MessagePartList{{Type: model.Text, Content: &text}}, extra)
+	vo, err := s.SendMessage(notice)
+	if err != nil {
+		return nil, err
+	}
+	if s.wsHandler != nil {
+		s.wsHandler.DeliverMessage(int64(userId), vo)
+	}
+	return vo, nil
+}
+
+// PinnedList 会话的置顶消息，最近置顶的在前
+func (s *MessageService) PinnedList(userId uint, req request.PinListReq) ([]response.PinVo, error) {
+	var conversation string
+	switch *req.TargetType {
+	case model.PrivateTarget:
+		conversation = privateConversation(int64(userId), int64(req.TargetId))
+	case model.GroupTarget:
+		if !s.groupMemberRepository.ExistsByGroupIdAndUserId(req.TargetId, userId) {
+			return nil, errors.New("不是该群的成员")
+		}
+		conversation = groupConversation(int64(req.TargetId))
+	default:
+		return nil, errors.New("消息目标类型不合法")
+	}
+	pins, err := s.pinRepository.ListByConversation(conversation)
+	if err != nil {
+		return nil, err
+	}
+	messageIds := make([]uint, 0, len(pins))
+	userIds := make([]uint, 0, len(pins))
+	for _, pin := range pins {
+		messageIds = append(messageIds, pin.MessageId)
+		userIds = append(userIds, pin.PinnedBy)
+	}
+	messages, err := s.messageRepository.GetByIdList(messageIds)
+	if err != nil {
+		return nil, err
+	}
+	vos, err := s.buildMessageVos(userId, messages)
+	if err != nil {
+		return nil, err
+	}
+	messageVos := make(map[uint]*response.MessageVo, len(vos))
+	for _, vo := range vos {
+		messageVos[vo.ID] = vo
+	}
+	nicknames, _ := s.userRepository.GetNickNamesByIds(userIds)
+	list := make([]response.PinVo, 0, len(pins))
+	for _, pin := range pins {
+		list = append(list, response.PinVo{Pin: pin, PinnedByNickname: nicknames[pin.PinnedBy], Message: messageVos[pin.MessageId]})
+	}
+	return list, nil
+}
+
+// ScheduleMessage 创建定时消息，发送前只做基本校验，到时按普通消息发送，失败原因记录在定时消息上
+func (s *MessageService) ScheduleMessage(userId uint, req request.ScheduleMessageReq) (*model.ScheduledMessage, error) {
+	now := time.Now()
+	if !req.SendAt.After(now) {
+		return nil, errors.New("发送时间必须晚于当前时间")
+	}
+	if maxDays := scheduleMaxDays(); req.SendAt.After(now.AddDate(0, 0, maxDays)) {
+		return nil, fmt.Errorf("最多只能提前 %d 天定时发送", maxDays)
+	}
+	switch *req.Type {
+	case model.TextContent, model.ImageContent, model.VoiceContent:
+	default:
+		return nil, errors.New("该类型的消息不能定时发送")
+	}
+	if err := s.checkScheduleTarget(userId, *req.TargetType, req.TargetId); err != nil {
+		return nil, err
+	}
+	content := req.Content
+	message := forwardMessage(userId, request.ForwardTarget{TargetType: req.TargetType, TargetId: req.TargetId}, *req.Type, content, nil)
+	message.Ttl, message.BurnAfterRead = req.Ttl, req.BurnAfterRead
+	if err := prepareEphemeral(message); err != nil {
+		return nil, err
+	}
+	scheduled := &model.ScheduledMessage{
+		SenderId:      userId,
+		TargetType:    *req.TargetType,
+		TargetId:      req.TargetId,
+		Type:          *req.Type,
+		Content:       &content,
+		ReplyId:       req.ReplyId,
+		Ttl:           req.Ttl,
+		BurnAfterRead: req.BurnAfterRead,
+		SendAt:        req.SendAt,
+		Status:        model.SchedulePending,
+	}
+	if err := s.scheduledRepository.Create(scheduled); err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// CancelScheduled 取消自己尚未发送的定时消息
+func (s *MessageService) CancelScheduled(userId uint, req request.ScheduleCancelReq) error {
+	scheduled, err := s.scheduledRepository.GetById(req.Id)
+	if err != nil {
+		return err
+	}
+	if scheduled == nil || scheduled.SenderId != userId {
+		return errors.New("定时消息不存在")
+	}
+	canceled, err := s.scheduledRepository.Transit(req.Id, model.SchedulePending, model.ScheduleCanceled)
+	if err != nil {
+		return err
+	}
+	if !canceled {
+		return errors.New("定时消息已发送或已取消")
+	}
+	return nil
+}
+
+// ScheduledList 自己的定时消息，发送时间晚的在前
+func (s *MessageService) ScheduledList(userId uint, req request.ScheduleQueryRequest) (*pagination.PageResult[model.ScheduledMessage], error) {
+	return s.scheduledRepository.Page(userId, req)
+}
+
+// DispatchScheduled 发送到期的定时消息，返回处理的条数
+// 发送前先领取（改为发送中并记录领取时间），多个实例同时执行时同一条消息只会被一个实例领取；
+// 领取后超过 scheduleClaimLease 仍在发送中的视为发送中断（如服务重启），会被重新领取发送
+func (s *MessageService) DispatchScheduled() (int, error) {
+	now := time.Now()
+	staleBefore := now.Add(-scheduleClaimLease)
+	list, err := s.scheduledRepository.ListDue(now, staleBefore, scheduleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range list {
+		scheduled := &list[i]
+		claimed, err := s.scheduledRepository.Claim(scheduled.ID, now, staleBefore)
+		if err != nil {
+			return count, err
+		}
+		if !claimed {
+			continue
+		}
+		count++
+		fields := map[string]interface{}{"status": model.ScheduleSent}
+		vo, err := s.sendScheduled(scheduled)
+		if err != nil {
+			logUtil.Warnf("定时消息(%d)发送失败: %v", scheduled.ID, err)
+			fields = map[string]interface{}{"status": model.ScheduleFailed, "fail_reason": err.Error()}
+		} else {
+			fields["message_id"] = vo.ID
+		}
+		if err := s.scheduledRepository.UpdateFields(scheduled.ID, fields); err != nil {
+			logUtil.Errorf("更新定时消息(%d)状态失败: %v", scheduled.ID, err)
+		}
+	}
+	return count, nil
+}
+
+// sendScheduled 按发送时的身份重新校验目标后发送定时消息
+func (s *MessageService) sendScheduled(scheduled *model.ScheduledMessage) (*response.MessageVo, error) {
+	if err := s.checkScheduleTarget(scheduled.SenderId, scheduled.TargetType, scheduled.TargetId); err != nil {
+		return nil, err
+	}
+	targetType := scheduled.TargetType
+	target := request.ForwardTarget{TargetType: &targetType, TargetId: scheduled.TargetId}
+	message := forwardMessage(scheduled.SenderId, target, scheduled.Type, *scheduled.Content, nil)
+	message.ReplyId = scheduled.ReplyId
+	message.Ttl, message.BurnAfterRead = scheduled.Ttl, scheduled.BurnAfterRead
+	vo, err := s.SendMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if s.wsHandler != nil {
+		s.wsHandler.DeliverMessage(int64(scheduled.SenderId), vo)
+	}
+	return vo, nil
+}
+
+// checkScheduleTarget 私聊目标必须存在且不是自己，群聊目标必须是自己所在的群
+func (s *MessageService) checkScheduleTarget(userId uint, targetType model.TargetType, targetId uint) error {
+	switch targetType {
+	case model.PrivateTarget:
+		if targetId == userId {
+			return errors.New("不能给自己发送消息")
+		}
+		user, err := s.userRepository.GetById(targetId)
+		if err != nil || user == nil {
+			return errors.New("接收者不存在")
+		}
+	case model.GroupTarget:
+		if !s.groupMemberRepository.ExistsByGroupIdAndUserId(targetId, userId) {
+			return errors.New("不是目标群的成员")
+		}
+	default:
+		return errors.New("消息目标类型不合法")
+	}
+	return nil
+}
+
+// ExpireMessages 删除到期的限时消息并通知会话成员，返回删除的条数
+func (s *MessageService) ExpireMessages() (int, error) {
+	messages, err := s.messageRepository.ListExpired(time.Now(), expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, message := range messages {
+		if err := s.messageRepository.Delete(message.ID); err != nil {
+			return i, err
+		}
+		if *message.TargetType == model.GroupTarget && s.mentionRepository != nil {
+			if err := s.mentionRepository.DeleteByMessageId(message.ID); err != nil {
+				logUtil.Errorf("删除消息(%d)的 @ 提醒失败: %v", message.ID, err)
+			}
+		}
+		if s.pinRepository != nil {
+			if _, err := s.pinRepository.Delete(message.ID); err != nil {
+				logUtil.Errorf("取消消息(%d)的置顶失败: %v", message.ID, err)
+			}
+		}
+		releaseFileRefs(s.fileRefService, messageFileUrls(message)...)
+		s.notifyExpired(message)
+	}
+	return len(messages), nil
+}
+
+// notifyExpired 私聊推送给双方，群聊推送给在线的群成员
+func (s *MessageService) notifyExpired(message *model.Message) {
+	if s.wsHandler == nil {
+		return
+	}
+	userIds, err := s.conversationUserIds(message)
+	if err != nil {
+		return
+	}
+	s.wsHandler.MessageExpiredNotice(userIds, model.MessageExpiredNotice{
+		MessageId:  message.ID,
+		TargetType: *message.TargetType,
+		SenderId:   message.SenderId,
+		ReceiverId: message.ReceiverId,
+		GroupId:    message.GroupId,
+	})
+}
+
+// conversationUserIds 消息所在会话的用户：私聊的双方或群聊的全部成员
+func (s *MessageService) conversationUserIds(message *model.Message) ([]int64, error) {
+	if *message.TargetType == model.PrivateTarget {
+		return []int64{message.SenderId, *message.ReceiverId}, nil
+	}
+	memberList, err := s.groupMemberRepository.GetMemberListByGroupId(uint(*message.GroupId))
+	if err != nil {
+		logUtil.Errorf("查询群(%d)成员失败: %v", *message.GroupId, err)
+		return nil, err
+	}
+	userIds := make([]int64, 0, len(memberList))
+	for _, member := range memberList {
+		userIds = append(userIds, int64(member.UserId))
+	}
+	return userIds, nil
+}
+
+// PlayVoice 标记语音已播放，同时视为已读；发送者自己播放不记录
+func (s *MessageService) PlayVoice(userId uint, messageId uint) error {
+	message, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		return err
+	}
+	if message == nil || !s.canView(userId, message) {
+		return errors.New("消息不存在")
+	}
+	if message.Type == nil || *message.Type != model.VoiceContent {
+		return errors.New("不是语音消息")
+	}
+	if message.Status != nil && *message.Status == model.Disable {
+		return errors.New("消息已撤回")
+	}
+	if message.SenderId == int64(userId) {
+		return nil
+	}
+	if message.PlayedIdList == nil {
+		message.PlayedIdList = &model.ReaderIdList{}
+	}
+	if message.ReaderIdList == nil {
+		message.ReaderIdList = &model.ReaderIdList{}
+	}
+	if utils.Contains(*message.PlayedIdList, userId) {
+		return nil
+	}
+	*message.PlayedIdList = append(*message.PlayedIdList, userId)
+	if !utils.Contains(*message.ReaderIdList, userId) {
+		*message.ReaderIdList = append(*message.ReaderIdList, userId)
+	}
+	fields := map[string]interface{}{
+		"played_id_list": message.PlayedIdList,
+		"reader_id_list": message.ReaderIdList,
+	}
+	if expireAt := burnAfterRead(message, userId); expireAt != nil {
+		fields["expire_at"] = expireAt
+	}
+	return s.messageRepository.UpdateFields(messageId, fields)
+}
+
+// canView 私聊的双方、群聊的成员可以看到消息
+func (s *MessageService) canView(userId uint, message *model.Message) bool {
+	if *message.TargetType == model.PrivateTarget {
+		return message.SenderId == int64(userId) || (message.ReceiverId != nil && *message.ReceiverId == int64(userId))
+	}
+	return message.GroupId != nil && s.groupMemberRepository.ExistsByGroupIdAndUserId(uint(*message.GroupId), userId)
+}
+
+// Forward 转发消息：转发者必须能看到全部源消息、能向全部目标发送
+// 逐条转发复制每条消息的内容，合并转发生成一条带聊天记录快照的消息；中途失败时已发出的消息不会撤回
+func (s *MessageService) Forward(userId uint, req request.ForwardMessageReq) ([]*response.MessageVo, error) {
+	if !req.Merged && len(req.MessageIds) > forwardSingleMax {
+		return nil, fmt.Errorf("逐条转发最多 %d 条消息", forwardSingleMax)
+	}
+	sources, err := s.forwardSources(userId, req.MessageIds, req.Merged)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range req.Targets {
+		if err := s.checkForwardTarget(userId, target); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		record      *model.ForwardRecord
+		recordExtra model.ExtraData
+	)
+	if req.Merged {
+		record = s.buildForwardRecord(sources)
+		if recordExtra, err = model.NewExtraData(record); err != nil {
+			return nil, err
+		}
+	}
+
+	list := make([]*response.MessageVo, 0)
+	for _, target := range req.Targets {
+		var messages []*model.Message
+		if record != nil {
+			title := record.Title
+			messages = append(messages, forwardMessage(userId, target, model.ForwardedCotent,
+				model.MessagePartList{{Type: model.Text, Content: &title}}, recordExtra))
+		} else {
+			for _, source := range sources {
+				messages = append(messages, forwardCopy(userId, target, source))
+			}
+		}
+		for _, message := range messages {
+			vo, err := s.send(message)
+			if err != nil {
+				return list, err
+			}
+			if s.wsHandler != nil {
+				s.wsHandler.DeliverMessage(int64(userId), vo)
+			}
+			list = append(list, vo)
+		}
+	}
+	return list, nil
+}
+
+// forwardSources 按时间顺序取出源消息，撤回的消息、红包、投票和系统消息不能转发，合并转发要求来自同一会话
+func (s *MessageService) forwardSources(userId uint, messageIds []uint, merged bool) ([]*model.Message, error) {
+	sources := make([]*model.Message, 0, len(messageIds))
+	seen := make(map[uint]struct{}, len(messageIds))
+	for _, id := range messageIds {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		message, err := s.messageRepository.GetById(id)
+		if err != nil {
+			return nil, err
+		}
+		if message == nil || !s.canView(userId, message) {
+			return nil, errors.New("消息不存在")
+		}
+		if message.Status != nil && *message.Status == model.Disable {
+			return nil, errors.New("消息已撤回，无法转发")
+		}
+		if message.Type != nil && *message.Type == model.RedBagContent {
+			return nil, errors.New("红包消息不能转发")
+		}
+		if message.Type != nil && *message.Type == model.PollContent {
+			return nil, errors.New("投票消息不能转发")
+		}
+		if message.Type != nil && *message.Type == model.SystemContent {
+			return nil, errors.New("系统消息不能转发")
+		}
+		if message.Ttl > 0 {
+			return nil, errors.New("限时消息不能转发")
+		}
+		if merged && len(sources) > 0 && conversationKey(sources[0]) != conversationKey(message) {
+			return nil, errors.New("合并转发的消息必须来自同一会话")
+		}
+		sources = append(sources, message)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+	return sources, nil
+}
+
+// checkForwardTarget 私聊目标必须存在且不是自己，群聊目标必须是自己所在的群
+func (s *MessageService) checkForwardTarget(userId uint, target request.ForwardTarget) error {
+	switch *target.TargetType {
+	case model.PrivateTarget:
+		if target.TargetId == userId {
+			return errors.New("不能转发给自己")
+		}
+		user, err := s.userRepository.GetById(target.TargetId)
+		if err != nil || user == nil {
+			return errors.New("转发对象不存在")
+		}
+	case model.GroupTarget:
+		if !s.groupMemberRepository.ExistsByGroupIdAndUserId(target.TargetId, userId) {
+			return errors.New("不是目标群的成员")
+		}
+	default:
+		return errors.New("消息目标类型不合法")
+	}
+	return nil
+}
+
+// buildForwardRecord 生成聊天记录快照，标题取会话双方昵称或群名
+func (s *MessageService) buildForwardRecord(sources []*model.Message) *model.ForwardRecord {
+	userIds := make([]uint, 0, len(sources)+1)
+	for _, message := range sources {
+		userIds = append(userIds, uint(message.SenderId))
+	}
+	first := sources[0]
+	if *first.TargetType == model.PrivateTarget && first.ReceiverId != nil {
+		userIds = append(userIds, uint(*first.ReceiverId))
+	}
+	users := make(map[uint]model.User)
+	userList, _ := s.userRepository.GetByIdList(userIds)
+	for _, user := range userList {
+		users[user.ID] = user
+	}
+	nickname := func(id uint) string {
+		if user, ok := users[id]; ok && user.Nickname != nil {
+			return *user.Nickname
+		}
+		return ""
+	}
+
+	record := &model.ForwardRecord{Title: "群聊的聊天记录", Items: make([]model.ForwardItem, 0, len(sources))}
+	if *first.TargetType == model.PrivateTarget && first.ReceiverId != nil {
+		a, b := uint(first.SenderId), uint(*first.ReceiverId)
+		if a > b {
+			a, b = b, a
+		}
+		record.Title = fmt.Sprintf("%s和%s的聊天记录", nickname(a), nickname(b))
+	} else if group, err := s.groupRepository.GetByID(uint(*first.GroupId)); err == nil && group != nil {
+		record.Title = group.Name + "的聊天记录"
+	}
+	for _, message := range sources {
+		item := model.ForwardItem{
+			MessageId:      message.ID,
+			SenderId:       message.SenderId,
+			SenderNickname: nickname(uint(message.SenderId)),
+			SenderAvatar:   users[uint(message.SenderId)].Avatar,
+			Type:           *message.Type,
+			Content:        message.Content,
+			CreatedAt:      message.CreatedAt,
+		}
+		if *message.Type == model.ForwardedCotent {
+			item.ExtraData = message.ExtraData
+		}
+		record.Items = append(record.Items, item)
+		if len(record.Summary) < forwardSummaryLines {
+			record.Summary = append(record.Summary, item.SenderNickname+": "+messagePreview(item.Type, item.Content))
+		}
+	}
+	return record
+}
+
+// ForwardRecord 查看合并转发的聊天记录，需要能看到这条转发消息
+func (s *MessageService) ForwardRecord(userId uint, messageId uint) (*model.ForwardRecord, error) {
+	message, err := s.messageRepository.GetById(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || !s.canView(userId, message) {
+		return nil, errors.New("消息不存在")
+	}
+	if message.Type == nil || *message.Type != model.ForwardedCotent {
+		return nil, errors.New("不是聊天记录消息")
+	}
+	if message.Status != nil && *message.Status == model.Disable {
+		return nil, errors.New("消息已撤回")
+	}
+	record := &model.ForwardRecord{}
+	if err := message.ExtraData.Decode(record); err != nil {
+		return nil, fmt.Errorf("聊天记录解析失败: %w", err)
+	}
+	return record, nil
+}
+
+// forwardCopy 逐条转发时复制消息内容，语音的时长和波形、表情的图片地址由发送时重新填充
+// 转发到原群时保留 @成员，其余情况和 @所有人 都转为文字，不再提醒
+func forwardCopy(userId uint, target request.ForwardTarget, source *model.Message) *model.Message {
+	sameGroup := *target.TargetType == model.GroupTarget && *source.TargetType == model.GroupTarget &&
+		source.GroupId != nil && uint(*source.GroupId) == target.TargetId
+	content := make(model.MessagePartList, 0, len(*source.Content))
+	for _, part := range *source.Content {
+		if part == nil {
+			continue
+		}
+		copied := *part
+		if part.Type == model.MentionAll || (part.Type == model.MentionUser && !sameGroup) {
+			text := "@"
+			if part.Content != nil {
+				text = *part.Content
+			}
+			copied = model.MessagePart{Type: model.Text, Content: &text}
+		}
+		content = append(content, &copied)
+	}
+	var extra model.ExtraData
+	if *source.Type == model.ForwardedCotent {
+		extra = source.ExtraData
+	}
+	return forwardMessage(userId, target, *source.Type, content, extra)
+}
+
+func forwardMessage(userId uint, target request.ForwardTarget, messageType model.MessageType,
+	content model.MessagePartList, extra model.ExtraData) *model.Message {
+	targetType := *target.TargetType
+	targetId := int64(target.TargetId)
+	message := &model.Message{
+		SenderId:   int64(userId),
+		TargetType: &targetType,
+		Type:       &messageType,
+		Content:    &content,
+		ExtraData:  extra,
+	}
+	if targetType == model.PrivateTarget {
+		message.ReceiverId = &targetId
+	} else {
+		message.GroupId = &targetId
+	}
+	message.InitFields()
+	return message
+}
+
+// conversationKey 消息所属会话，私聊按双方用户区分
+func conversationKey(message *model.Message) string {
+	if *message.TargetType == model.GroupTarget {
+		return groupConversation(*message.GroupId)
+	}
+	return privateConversation(message.SenderId, *message.ReceiverId)
+}
+
+// conversationTarget 从用户的角度看消息所属会话的目标，私聊为对方id，群聊为群id
+func conversationTarget(userId uint, message *model.Message) uint {
+	if *message.TargetType == model.GroupTarget {
+		return uint(*message.GroupId)
+	}
+	if message.SenderId == int64(userId) {
+		return uint(*message.ReceiverId)
+	}
+	return uint(message.SenderId)
+}
+
+func groupConversation(groupId int64) string {
+	return fmt.Sprintf("g%d", groupId)
+}
+
+func privateConversation(a, b int64) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("p%d-%d", a, b)
+}
+
+// messagePreview 消息的文字摘要，非文本内容用类型占位
+func messagePreview(messageType model.MessageType, content *model.MessagePartList) string {
+	switch messageType {
+	case model.ForwardedCotent:
+		return "[聊天记录]"
+	case model.VoiceContent:
+		return "[语音]"
+	}
+	var builder strings.Builder
+	if content != nil {
+		for _, part := range *content {
+			if part == nil || part.Content == nil {
+				continue
+			}
+			switch part.Type {
+			case model.Image:
+				builder.WriteString("[图片]")
+			case model.Voice:
+				builder.WriteString("[语音]")
+			case model.StickerPart:
+				builder.WriteString("[表情]")
+			default:
+				builder.WriteString(*part.Content)
+			}
+		}
+	}
+	return builder.String()
+}
+
+func messageTtlMax() int {
+	if maxTtl := configs.AppConfig.Message.TtlMax; maxTtl > 0 {
+		return maxTtl
+	}
+	return 7 * 24 * 3600
+}
+
+// historyLimit 每页历史消息的条数，默认 20，最多 100
+func historyLimit(limit int) int {
+	if limit <= 0 {
+		return historyPageSize
+	}
+	return min(limit, historyPageMax)
+}
+
+func revokeWindow() time.Duration {
+	if window := configs.AppConfig.Message.RevokeWindow; window > 0 {
+		return time.Duration(window) * time.Second
+	}
+	return 2 * time.Minute
+}
+
+func scheduleMaxDays() int {
+	if maxDays := configs.AppConfig.Message.ScheduleMaxDays; maxDays > 0 {
+		return maxDays
+	}
+	return 30
+}
+
+func pinMaxCount() int {
+	if maxCount := configs.AppConfig.Message.PinMaxCount; maxCount > 0 {
+		return maxCount
+	}
+	return 10
+}
+
+func reactionMaxPerUser() int {
+	if maxEmojis := configs.AppConfig.Message.ReactionMaxPerUser; maxEmojis > 0 {
+		return maxEmojis
+	}
+	return 3
+}
+
+// validEmoji 表情不能包含文字和空白，长度不超过 32 字节
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 || !utf8.ValidString(emoji) {
+		return false
+	}
+	for _, r := range emoji {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func voiceMaxDuration() int {
+	if maxDuration := configs.AppConfig.Message.VoiceMaxDuration; maxDuration > 0 {
+		return maxDuration
+	}
+	return 60
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"github.com/lty120712/gorm-pagination/pagination"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"sync"
+	"time"
+)
+
+type ModerationService struct {
+	reviewRepository interfacerepository.ModerationReviewRepositoryInterface
+	adminService     interfacesservice.AdminServiceInterface
+	moderator        interfacemanager.Moderator
+}
+
+var (
+	ModerationServiceInstance *ModerationService
+	moderationOnce            sync.Once
+)
+
+func InitModerationService(reviewRepository interfacerepository.ModerationReviewRepositoryInterface,
+	adminService interfacesservice.AdminServiceInterface,
+	moderator interfacemanager.Moderator) {
+	moderationOnce.Do(func() {
+		ModerationServiceInstance = &ModerationService{
+			reviewRepository: reviewRepository,
+			adminService:     adminService,
+			moderator:        moderator,
+		}
+	})
+}
+
+// Reviews 审核队列查询
+func (s *ModerationService) Reviews(req request.ModerationReviewQueryRequest) (*pagination.PageResult[model.ModerationReview], error) {
+	return s.reviewRepository.Page(req)
+}
+
+// HandleReview 处理审核：通过则保留消息，驳回则删除消息并记录审计日志
+func (s *ModerationService) HandleReview(operatorId uint, ip string, req request.ModerationReviewHandleRequest) error {
+	review, err := s.reviewRepository.GetById(req.ReviewId)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		return errors.New("审核记录不存在")
+	}
+	if review.Status != model.Todo {
+		return errors.New("该记录已审核")
+	}
+	status := model.Accept
+	if !req.Approve {
+		status = model.Reject
+		reason := req.Remark
+		if reason == "" {
+			reason = "内容审核驳回"
+		}
+		err = s.adminService.DeleteMessage(operatorId, ip, request.AdminMessageDeleteRequest{
+			MessageId: review.MessageId,
+			Reason:    reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	updates := map[string]interface{}{
+		"status":      status,
+		"reviewer_id": operatorId,
+		"reviewed_at": time.Now(),
+	}
+	if req.Remark != "" {
+		updates["remark"] = req.Remark
+	}
+	return s.reviewRepository.UpdateFields(review.ID, updates)
+}
+
+// ReloadWords 重新加载敏感词表，force 为 false 时仅在词表变化后重建
+func (s *ModerationService) ReloadWords(force bool) error {
+	return s.moderator.Reload(force)
+}
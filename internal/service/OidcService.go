@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-chat/configs"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/idUtil"
+	"go-chat/internal/utils/logUtil"
+	"go-chat/internal/utils/oidcUtil"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type OidcService struct {
+	userService            interfacesservice.UserServiceInterface
+	userRepository         interfacerepository.UserRepositoryInterface
+	userIdentityRepository interfacerepository.UserIdentityRepositoryInterface
+	stateStore             interfacemanager.OidcStateStore
+
+	mu        sync.Mutex
+	providers map[string]*oidcUtil.Provider
+}
+
+var (
+	OidcServiceInstance *OidcService
+	oidcOnce            sync.Once
+)
+
+func InitOidcService(userService interfacesservice.UserServiceInterface,
+	userRepository interfacerepository.UserRepositoryInterface,
+	userIdentityRepository interfacerepository.UserIdentityRepositoryInterface,
+	stateStore interfacemanager.OidcStateStore) {
+	oidcOnce.Do(func() {
+		OidcServiceInstance = &OidcService{
+			userService:            userService,
+			userRepository:         userRepository,
+			userIdentityRepository: userIdentityRepository,
+			stateStore:             stateStore,
+			providers:              make(map[string]*oidcUtil.Provider),
+		}
+	})
+}
+
+// AuthUrl 生成跳转到身份提供方的授权地址，bindUserId 非0 时表示为已登录用户绑定外部身份
+// browserKey 为发起请求的浏览器 cookie，state 只能由同一浏览器回调，防止登录 CSRF
+func (s *OidcService) AuthUrl(providerName string, bindUserId uint, browserKey string) (string, error) {
+	if browserKey == "" {
+		return "", errors.New("缺少浏览器标识")
+	}
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+	state, err := oidcUtil.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidcUtil.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidcUtil.RandomString(48)
+	if err != nil {
+		return "", err
+	}
+	err = s.stateStore.Save(state, &model.OidcState{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		BindUserId:   bindUserId,
+		BrowserKey:   hashBrowserKey(browserKey),
+	}, stateTTL())
+	if err != nil {
+		return "", fmt.Errorf("保存登录状态失败: %w", err)
+	}
+	return provider.AuthCodeURL(state, nonce, oidcUtil.CodeChallengeS256(verifier)), nil
+}
+
+// Callback 处理身份提供方回调：校验 state 和发起授权的浏览器、换取并校验 ID Token、关联或创建本地用户，返回本系统 jwt
+func (s *OidcService) Callback(providerName, code, state, browserKey string) (token string, err error) {
+	if code == "" || state == "" {
+		return "", errors.New("缺少 code 或 state")
+	}
+	loginState, err := s.stateStore.Take(state)
+	if err != nil {
+		return "", err
+	}
+	if loginState == nil || loginState.Provider != providerName {
+		return "", errors.New("登录状态无效或已过期")
+	}
+	// 他人诱导打开的回调地址不会带有发起授权时的 cookie，不能借此登录或绑定到他人账号
+	if browserKey == "" || subtle.ConstantTimeCompare([]byte(loginState.BrowserKey), []byte(hashBrowserKey(browserKey))) != 1 {
+		return "", errors.New("登录状态与当前浏览器不匹配，请重新发起登录")
+	}
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	oauthToken, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return "", err
+	}
+	claims, err := provider.VerifyIdToken(ctx, oauthToken.IdToken, loginState.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.resolveUser(providerName, claims, loginState.BindUserId)
+	if err != nil {
+		return "", err
+	}
+	return s.userService.LoginWithUser(user)
+}
+
+// Identities 查询用户已绑定的外部身份
+func (s *OidcService) Identities(userId uint) ([]model.UserIdentity, error) {
+	return s.userIdentityRepository.GetByUserId(userId)
+}
+
+// resolveUser 按 已绑定身份 -> 已验证邮箱 -> 自动创建 的顺序找到本地用户
+func (s *OidcService) resolveUser(providerName string, claims *oidcUtil.IdTokenClaims, bindUserId uint) (*model.User, error) {
+	config := findOidcProviderConfig(providerName)
+	identity, err := s.userIdentityRepository.GetByProviderAndSubject(providerName, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		if bindUserId != 0 && identity.UserId != bindUserId {
+			return nil, errors.New("该外部账号已绑定其他用户")
+		}
+		_ = s.userIdentityRepository.UpdateFields(identity.ID, map[string]interface{}{
+			"email": nullableString(claims.Email),
+			"name":  nullableString(claims.Name),
+		})
+		user, err := s.userRepository.GetById(identity.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("绑定的用户不存在")
+		}
+		return user, nil
+	}
+
+	var user *model.User
+	switch {
+	case bindUserId != 0:
+		user, err = s.userRepository.GetById(bindUserId)
+	case config.LinkByEmail && claims.EmailVerified && claims.Email != "":
+		user, err = s.userByVerifiedEmail(claims.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		if bindUserId != 0 {
+			return nil, errors.New("用户不存在")
+		}
+		if !config.AutoProvision {
+			return nil, errors.New("该外部账号未绑定本地用户")
+		}
+		if user, err = s.provisionUser(providerName, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.userIdentityRepository.Create(&model.UserIdentity{
+		UserId:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    nullableString(claims.Email),
+		Name:     nullableString(claims.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("绑定外部身份失败: %w", err)
+	}
+	logUtil.Infof("用户(%d)已绑定外部身份 %s:%s", user.ID, providerName, claims.Subject)
+	return user, nil
+}
+
+// userByVerifiedEmail 按邮箱自动关联本地用户，本地邮箱未经验证或对应多个用户时不自动关联，
+// 避免他人预先填写相同邮箱接管外部账号，需要用户登录后通过绑定接口关联
+func (s *OidcService) userByVerifiedEmail(email string) (*model.User, error) {
+	users, err := s.userRepository.ListByEmail(email)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	if len(users) > 1 || !users[0].EmailVerified {
+		return nil, errors.New("该邮箱无法自动关联本地用户，请登录后在账号设置中绑定外部账号")
+	}
+	return users[0], nil
+}
+
+// provisionUser 首次登录自动创建用户，密码为随机值，只能通过 OIDC 登录
+func (s *OidcService) provisionUser(providerName string, claims *oidcUtil.IdTokenClaims) (*model.User, error) {
+	username := claims.PreferredUsername
+	if username == "" && claims.Email != "" {
+		username = strings.Split(claims.Email, "@")[0]
+	}
+	if username == "" {
+		username = providerName + "_" + claims.Subject
+	}
+	existing, err := s.userRepository.GetByName(&username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		username = username + "_" + idUtil.GenerateId()[:6]
+	}
+
+	randomPassword, err := oidcUtil.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	nickname := claims.Name
+	if nickname == "" {
+		nickname = username
+	}
+	user := &model.User{
+		Username:     username,
+		Password:     string(hashedPassword),
+		Nickname:     &nickname,
+		Status:       model.Enable,
+		OnlineStatus: model.Offline,
+	}
+	if claims.EmailVerified && claims.Email != "" {
+		user.Email = &claims.Email
+		user.EmailVerified = true
+	}
+	if err := s.userRepository.Save(user); err != nil {
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+	return user, nil
+}
+
+// getProvider 懒加载提供方客户端，避免身份提供方不可用时影响服务启动
+func (s *OidcService) getProvider(name string) (*oidcUtil.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if provider, ok := s.providers[name]; ok {
+		return provider, nil
+	}
+	config := findOidcProviderConfig(name)
+	if config.Name == "" {
+		return nil, fmt.Errorf("未配置身份提供方: %s", name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	provider, err := oidcUtil.NewProvider(ctx, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.providers[name] = provider
+	return provider, nil
+}
+
+func findOidcProviderConfig(name string) configs.OidcProviderConfig {
+	for _, p := range configs.AppConfig.Oidc.Providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return configs.OidcProviderConfig{}
+}
+
+func stateTTL() time.Duration {
+	ttl, err := time.ParseDuration(configs.AppConfig.Oidc.StateTTL)
+	if err != nil || ttl <= 0 {
+		return 10 * time.Minute
+	}
+	return ttl
+}
+
+func hashBrowserKey(browserKey string) string {
+	sum := sha256.Sum256([]byte(browserKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
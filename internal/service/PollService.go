@@ -0,0 +1,352 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-chat/internal/db"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/utils/logUtil"
+	"go-chat/internal/utils/pollUtil"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	pollQuestionMaxLen = 256
+	pollOptionMaxLen   = 64
+	pollOptionMax      = 20
+)
+
+type PollService struct {
+	pollRepository        interfacerepository.PollRepositoryInterface
+	userRepository        interfacerepository.UserRepositoryInterface
+	groupRepository       interfacerepository.GroupRepositoryInterface
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	messageService        interfacesservice.MessageServiceInterface
+	wsHandler             interfacehandler.WsHandlerInterface
+}
+
+var (
+	PollServiceInstance *PollService
+	pollOnce            sync.Once
+)
+
+func InitPollService(pollRepository interfacerepository.PollRepositoryInterface,
+	userRepository interfacerepository.UserRepositoryInterface,
+	groupRepository interfacerepository.GroupRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	messageService interfacesservice.MessageServiceInterface,
+	wsHandler interfacehandler.WsHandlerInterface) {
+	pollOnce.Do(func() {
+		PollServiceInstance = &PollService{
+			pollRepository:        pollRepository,
+			userRepository:        userRepository,
+			groupRepository:       groupRepository,
+			groupMemberRepository: groupMemberRepository,
+			messageService:        messageService,
+			wsHandler:             wsHandler,
+		}
+	})
+}
+
+// Create 发起投票：保存投票和选项后生成投票消息并推送给群成员
+func (s *PollService) Create(userId uint, req request.PollCreateRequest) (*response.MessageVo, error) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" || utf8.RuneCountInString(question) > pollQuestionMaxLen {
+		return nil, fmt.Errorf("投票主题不能为空且不能超过 %d 个字", pollQuestionMaxLen)
+	}
+	if len(req.Options) > pollOptionMax {
+		return nil, fmt.Errorf("最多 %d 个选项", pollOptionMax)
+	}
+	options := make([]*model.PollOption, 0, len(req.Options))
+	seen := make(map[string]bool, len(req.Options))
+	for _, content := range req.Options {
+		content = strings.TrimSpace(content)
+		if content == "" || utf8.RuneCountInString(content) > pollOptionMaxLen {
+			return nil, fmt.Errorf("选项不能为空且不能超过 %d 个字", pollOptionMaxLen)
+		}
+		if seen[content] {
+			return nil, errors.New("选项不能重复")
+		}
+		seen[content] = true
+		options = append(options, &model.PollOption{Content: content})
+	}
+	maxChoices := 1
+	if req.Multiple {
+		maxChoices = req.MaxChoices
+		if maxChoices == 0 {
+			maxChoices = len(options)
+		}
+		if maxChoices < 1 || maxChoices > len(options) {
+			return nil, errors.New("可选项数不合法")
+		}
+	}
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		return nil, errors.New("截止时间必须晚于当前时间")
+	}
+	group, err := s.groupRepository.GetByID(req.GroupId)
+	if err != nil || group == nil {
+		return nil, errors.New("群组不存在")
+	}
+	if group.Status == model.Disable {
+		return nil, errors.New("群组已被停用")
+	}
+	if !s.groupMemberRepository.ExistsByGroupIdAndUserId(req.GroupId, userId) {
+		return nil, errors.New("不是群成员")
+	}
+
+	poll := &model.Poll{
+		CreatorId:  userId,
+		GroupId:    req.GroupId,
+		Question:   question,
+		Multiple:   req.Multiple,
+		MaxChoices: maxChoices,
+		Anonymous:  req.Anonymous,
+		Deadline:   req.Deadline,
+		Status:     model.PollOpen,
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		return s.pollRepository.Create(poll, options, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vo, err := s.messageService.SendMessage(s.buildMessage(poll))
+	if err != nil {
+		// 消息发送失败的投票直接结束，不会再出现在群里
+		if closeErr := s.pollRepository.UpdateFields(poll.ID, map[string]interface{}{"status": model.PollClosed}); closeErr != nil {
+			logUtil.Errorf("投票(%d)关闭失败: %v", poll.ID, closeErr)
+		}
+		return nil, err
+	}
+	if err := s.pollRepository.UpdateFields(poll.ID, map[string]interface{}{"message_id": vo.ID}); err != nil {
+		logUtil.Errorf("投票(%d)关联消息失败: %v", poll.ID, err)
+	}
+	s.wsHandler.DeliverMessage(int64(userId), vo)
+	return vo, nil
+}
+
+func (s *PollService) buildMessage(poll *model.Poll) *model.Message {
+	targetType := model.GroupTarget
+	messageType := model.PollContent
+	groupId := int64(poll.GroupId)
+	text := "[投票] " + poll.Question
+	content := model.MessagePartList{{Type: model.Text, Content: &text}}
+	extra, _ := model.NewExtraData(model.PollExtra{
+		PollId:    poll.ID,
+		Question:  poll.Question,
+		Multiple:  poll.Multiple,
+		Anonymous: poll.Anonymous,
+		Deadline:  poll.Deadline,
+	})
+	message := &model.Message{
+		SenderId:   int64(poll.CreatorId),
+		GroupId:    &groupId,
+		TargetType: &targetType,
+		Type:       &messageType,
+		Content:    &content,
+		ExtraData:  extra,
+	}
+	message.InitFields()
+	return message
+}
+
+// Vote 投票或修改投票：在事务中锁定投票，按新旧选择的差异增减选项票数
+// 选票按 (poll_id, user_id) 唯一，并发投票时同一成员也只会计一票
+func (s *PollService) Vote(userId uint, req request.PollVoteRequest) (*response.PollVo, error) {
+	poll, err := s.pollRepository.GetById(req.PollId)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil || !s.canView(userId, poll) {
+		return nil, errors.New("投票不存在")
+	}
+	if poll.Closed(time.Now()) {
+		return nil, errors.New("投票已结束")
+	}
+	options, err := s.pollRepository.GetOptions(poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	optionIds := make([]uint, 0, len(options))
+	for _, option := range options {
+		optionIds = append(optionIds, option.ID)
+	}
+	choices, err := pollUtil.NormalizeChoices(req.OptionIds, optionIds, poll.MaxChoices)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.pollRepository.GetByIdForUpdate(poll.ID, tx)
+		if err != nil {
+			return err
+		}
+		if locked == nil || locked.Closed(time.Now()) {
+			return errors.New("投票已结束")
+		}
+		vote, err := s.pollRepository.GetVote(poll.ID, userId, tx)
+		if err != nil {
+			return err
+		}
+		var previous []uint
+		if vote == nil {
+			vote = &model.PollVote{PollId: poll.ID, UserId: userId}
+			if err := s.pollRepository.UpdateFields(poll.ID, map[string]interface{}{
+				"voter_count": gorm.Expr("voter_count + 1"),
+			}, tx); err != nil {
+				return err
+			}
+		} else if vote.OptionIds != nil {
+			previous = *vote.OptionIds
+		}
+		removed, added := pollUtil.Diff(previous, choices)
+		if err := s.pollRepository.AddVoteCount(removed, -1, tx); err != nil {
+			return err
+		}
+		if err := s.pollRepository.AddVoteCount(added, 1, tx); err != nil {
+			return err
+		}
+		list := model.PollChoiceList(choices)
+		vote.OptionIds = &list
+		return s.pollRepository.SaveVote(vote, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(poll.ID)
+	return s.Detail(userId, poll.ID)
+}
+
+// Close 发起人或群主、管理员可以提前结束投票
+func (s *PollService) Close(userId uint, req request.PollCloseRequest) (*response.PollVo, error) {
+	poll, err := s.pollRepository.GetById(req.PollId)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil || !s.canView(userId, poll) {
+		return nil, errors.New("投票不存在")
+	}
+	if poll.CreatorId != userId && !s.groupMemberRepository.IsOwnerOrAdmin(poll.GroupId, userId) {
+		return nil, errors.New("只有发起人和群管理员可以结束投票")
+	}
+	if poll.Closed(time.Now()) {
+		return nil, errors.New("投票已结束")
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.pollRepository.GetByIdForUpdate(poll.ID, tx); err != nil {
+			return err
+		}
+		return s.pollRepository.UpdateFields(poll.ID, map[string]interface{}{"status": model.PollClosed}, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(poll.ID)
+	return s.Detail(userId, poll.ID)
+}
+
+// Detail 投票详情和结果，实名投票附带每个选项的投票人
+func (s *PollService) Detail(userId uint, pollId uint) (*response.PollVo, error) {
+	poll, err := s.pollRepository.GetById(pollId)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil || !s.canView(userId, poll) {
+		return nil, errors.New("投票不存在")
+	}
+	options, err := s.pollRepository.GetOptions(pollId)
+	if err != nil {
+		return nil, err
+	}
+	var votes []model.PollVote
+	if poll.Anonymous {
+		vote, err := s.pollRepository.GetVote(pollId, userId)
+		if err != nil {
+			return nil, err
+		}
+		if vote != nil {
+			votes = append(votes, *vote)
+		}
+	} else if votes, err = s.pollRepository.GetVotes(pollId); err != nil {
+		return nil, err
+	}
+
+	userIds := []uint{poll.CreatorId}
+	voters := make(map[uint][]uint, len(options))
+	vo := &response.PollVo{Poll: *poll, Closed: poll.Closed(time.Now())}
+	for _, vote := range votes {
+		if vote.OptionIds == nil {
+			continue
+		}
+		if vote.UserId == userId {
+			vo.MyChoices = *vote.OptionIds
+		}
+		if poll.Anonymous {
+			continue
+		}
+		userIds = append(userIds, vote.UserId)
+		for _, optionId := range *vote.OptionIds {
+			voters[optionId] = append(voters[optionId], vote.UserId)
+		}
+	}
+	nicknames, _ := s.userRepository.GetNickNamesByIds(userIds)
+	vo.CreatorNickname = nicknames[poll.CreatorId]
+	vo.Options = make([]response.PollOptionVo, 0, len(options))
+	for _, option := range options {
+		optionVo := response.PollOptionVo{Id: option.ID, Content: option.Content, VoteCount: option.VoteCount, Voters: []response.PollVoterVo{}}
+		for _, voterId := range voters[option.ID] {
+			optionVo.Voters = append(optionVo.Voters, response.PollVoterVo{UserId: voterId, Nickname: nicknames[voterId]})
+		}
+		vo.Options = append(vo.Options, optionVo)
+	}
+	return vo, nil
+}
+
+// notify 推送最新的票数给在线的群成员，失败只记录日志
+func (s *PollService) notify(pollId uint) {
+	poll, err := s.pollRepository.GetById(pollId)
+	if err != nil || poll == nil {
+		logUtil.Errorf("查询投票(%d)失败: %v", pollId, err)
+		return
+	}
+	options, err := s.pollRepository.GetOptions(pollId)
+	if err != nil {
+		logUtil.Errorf("查询投票(%d)选项失败: %v", pollId, err)
+		return
+	}
+	memberList, err := s.groupMemberRepository.GetMemberListByGroupId(poll.GroupId)
+	if err != nil {
+		logUtil.Errorf("查询群(%d)成员失败: %v", poll.GroupId, err)
+		return
+	}
+	userIds := make([]int64, 0, len(memberList))
+	for _, member := range memberList {
+		userIds = append(userIds, int64(member.UserId))
+	}
+	notice := model.PollNotice{
+		PollId:     poll.ID,
+		MessageId:  poll.MessageId,
+		GroupId:    poll.GroupId,
+		VoterCount: poll.VoterCount,
+		Options:    make([]model.PollOptionCount, 0, len(options)),
+		Closed:     poll.Closed(time.Now()),
+	}
+	for _, option := range options {
+		notice.Options = append(notice.Options, model.PollOptionCount{OptionId: option.ID, VoteCount: option.VoteCount})
+	}
+	s.wsHandler.PollNotice(userIds, notice)
+}
+
+// canView 投票所在群的成员可以查看和投票
+func (s *PollService) canView(userId uint, poll *model.Poll) bool {
+	return s.groupMemberRepository.ExistsByGroupIdAndUserId(poll.GroupId, userId)
+}
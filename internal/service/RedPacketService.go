@@ -0,0 +1,452 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/configs"
+	"go-chat/internal/db"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/utils/logUtil"
+	"go-chat/internal/utils/redPacketUtil"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	redPacketGreetingMaxLen  = 64
+	redPacketDefaultGreeting = "恭喜发财，大吉大利"
+)
+
+type RedPacketService struct {
+	redPacketRepository   interfacerepository.RedPacketRepositoryInterface
+	walletRepository      interfacerepository.WalletRepositoryInterface
+	userRepository        interfacerepository.UserRepositoryInterface
+	groupRepository       interfacerepository.GroupRepositoryInterface
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	messageService        interfacesservice.MessageServiceInterface
+	store                 interfacemanager.RedPacketStore
+	wsHandler             interfacehandler.WsHandlerInterface
+}
+
+var (
+	RedPacketServiceInstance *RedPacketService
+	redPacketOnce            sync.Once
+)
+
+func InitRedPacketService(redPacketRepository interfacerepository.RedPacketRepositoryInterface,
+	walletRepository interfacerepository.WalletRepositoryInterface,
+	userRepository interfacerepository.UserRepositoryInterface,
+	groupRepository interfacerepository.GroupRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	messageService interfacesservice.MessageServiceInterface,
+	store interfacemanager.RedPacketStore,
+	wsHandler interfacehandler.WsHandlerInterface) {
+	redPacketOnce.Do(func() {
+		RedPacketServiceInstance = &RedPacketService{
+			redPacketRepository:   redPacketRepository,
+			walletRepository:      walletRepository,
+			userRepository:        userRepository,
+			groupRepository:       groupRepository,
+			groupMemberRepository: groupMemberRepository,
+			messageService:        messageService,
+			store:                 store,
+			wsHandler:             wsHandler,
+		}
+	})
+}
+
+// Send 发红包：校验后在事务中扣款并创建红包，拆分好的金额写入缓存，最后生成红包消息
+// 扣款之后的任一步骤失败都会把红包作废并退款
+func (s *RedPacketService) Send(userId uint, req request.RedPacketSendRequest) (*response.MessageVo, error) {
+	greeting := strings.TrimSpace(req.Greeting)
+	if greeting == "" {
+		greeting = redPacketDefaultGreeting
+	}
+	if utf8.RuneCountInString(greeting) > redPacketGreetingMaxLen {
+		return nil, fmt.Errorf("祝福语不能超过 %d 个字", redPacketGreetingMaxLen)
+	}
+	total, amounts, err := splitRedPacket(req.Mode, req.Amount, req.Count)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTarget(userId, req); err != nil {
+		return nil, err
+	}
+
+	ttl := redPacketExpireTime()
+	packet := &model.RedPacket{
+		SenderId:     userId,
+		TargetType:   *req.TargetType,
+		Mode:         req.Mode,
+		TotalAmount:  total,
+		Count:        req.Count,
+		RemainAmount: total,
+		RemainCount:  req.Count,
+		Greeting:     greeting,
+		Status:       model.RedPacketActive,
+		ExpireAt:     time.Now().Add(ttl),
+	}
+	if *req.TargetType == model.PrivateTarget {
+		packet.ReceiverId = &req.ReceiverId
+	} else {
+		packet.GroupId = &req.GroupId
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		ok, err := s.walletRepository.Debit(userId, total, tx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("余额不足")
+		}
+		if err := s.redPacketRepository.Create(packet, tx); err != nil {
+			return err
+		}
+		return s.walletRepository.CreateLog(&model.WalletLog{
+			UserId:      userId,
+			Type:        model.WalletSendPacket,
+			Amount:      -total,
+			RedPacketId: &packet.ID,
+		}, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(packet.ID, amounts, ttl); err != nil {
+		s.discard(packet.ID)
+		return nil, fmt.Errorf("红包发送失败: %w", err)
+	}
+	vo, err := s.messageService.SendMessage(s.buildMessage(packet))
+	if err != nil {
+		s.discard(packet.ID)
+		return nil, err
+	}
+	if err := s.redPacketRepository.UpdateFields(packet.ID, map[string]interface{}{"message_id": vo.ID}); err != nil {
+		logUtil.Errorf("红包(%d)关联消息失败: %v", packet.ID, err)
+	}
+	s.wsHandler.DeliverMessage(int64(userId), vo)
+	return vo, nil
+}
+
+// checkTarget 私聊红包不能发给自己，群红包只能发到自己所在的可用群
+func (s *RedPacketService) checkTarget(userId uint, req request.RedPacketSendRequest) error {
+	switch *req.TargetType {
+	case model.PrivateTarget:
+		if req.ReceiverId == 0 || req.ReceiverId == userId {
+			return errors.New("接收者不合法")
+		}
+		receiver, err := s.userRepository.GetById(req.ReceiverId)
+		if err != nil || receiver == nil {
+			return errors.New("接收者不存在")
+		}
+	case model.GroupTarget:
+		group, err := s.groupRepository.GetByID(req.GroupId)
+		if err != nil || group == nil {
+			return errors.New("群组不存在")
+		}
+		if group.Status == model.Disable {
+			return errors.New("群组已被停用")
+		}
+		if !s.groupMemberRepository.ExistsByGroupIdAndUserId(req.GroupId, userId) {
+			return errors.New("不是群成员")
+		}
+	default:
+		return errors.New("消息目标类型不合法")
+	}
+	return nil
+}
+
+func (s *RedPacketService) buildMessage(packet *model.RedPacket) *model.Message {
+	targetType := packet.TargetType
+	messageType := model.RedBagContent
+	greeting := packet.Greeting
+	content := model.MessagePartList{{Type: model.Text, Content: &greeting}}
+	extra, _ := model.NewExtraData(model.RedPacketExtra{
+		RedPacketId: packet.ID,
+		Mode:        packet.Mode,
+		Greeting:    packet.Greeting,
+	})
+	message := &model.Message{
+		SenderId:   int64(packet.SenderId),
+		TargetType: &targetType,
+		Type:       &messageType,
+		Content:    &content,
+		ExtraData:  extra,
+	}
+	if packet.ReceiverId != nil {
+		receiverId := int64(*packet.ReceiverId)
+		message.ReceiverId = &receiverId
+	}
+	if packet.GroupId != nil {
+		groupId := int64(*packet.GroupId)
+		message.GroupId = &groupId
+	}
+	message.InitFields()
+	return message
+}
+
+// Claim 抢红包：先从缓存原子地取出一份金额，再在事务中锁定红包、写入领取记录并入账
+// 落库失败时把金额归还缓存，保证缓存与数据库一致
+func (s *RedPacketService) Claim(userId uint, packetId uint) (*response.RedPacketVo, error) {
+	packet, err := s.redPacketRepository.GetById(packetId)
+	if err != nil {
+		return nil, err
+	}
+	if packet == nil || !s.canView(userId, packet) {
+		return nil, errors.New("红包不存在")
+	}
+	if packet.TargetType == model.PrivateTarget && packet.SenderId == userId {
+		return nil, errors.New("不能领取自己发出的私聊红包")
+	}
+	if claim, err := s.redPacketRepository.GetClaim(packetId, userId); err != nil {
+		return nil, err
+	} else if claim != nil {
+		return nil, interfacemanager.ErrRedPacketClaimed
+	}
+	if packet.Status == model.RedPacketExpired || time.Now().After(packet.ExpireAt) {
+		return nil, errors.New("红包已过期")
+	}
+	if packet.Status == model.RedPacketFinished {
+		return nil, interfacemanager.ErrRedPacketEmpty
+	}
+
+	amount, err := s.store.Take(packetId, userId)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		locked, err := s.redPacketRepository.GetByIdForUpdate(packetId, tx)
+		if err != nil {
+			return err
+		}
+		if locked == nil || locked.Status != model.RedPacketActive || locked.RemainCount <= 0 || locked.RemainAmount < amount {
+			return interfacemanager.ErrRedPacketEmpty
+		}
+		if err := s.redPacketRepository.CreateClaim(&model.RedPacketClaim{
+			RedPacketId: packetId,
+			UserId:      userId,
+			Amount:      amount,
+		}, tx); err != nil {
+			return err
+		}
+		packet = locked
+		packet.RemainAmount -= amount
+		packet.RemainCount--
+		updates := map[string]interface{}{
+			"remain_amount": packet.RemainAmount,
+			"remain_count":  packet.RemainCount,
+		}
+		if packet.RemainCount == 0 {
+			packet.Status = model.RedPacketFinished
+			updates["status"] = packet.Status
+		}
+		if err := s.redPacketRepository.UpdateFields(packetId, updates, tx); err != nil {
+			return err
+		}
+		if err := s.walletRepository.Credit(userId, amount, tx); err != nil {
+			return err
+		}
+		return s.walletRepository.CreateLog(&model.WalletLog{
+			UserId:      userId,
+			Type:        model.WalletClaimPacket,
+			Amount:      amount,
+			RedPacketId: &packetId,
+		}, tx)
+	})
+	if err != nil {
+		if restoreErr := s.store.Restore(packetId, userId, amount); restoreErr != nil {
+			logUtil.Errorf("红包(%d)归还金额失败: %v", packetId, restoreErr)
+		}
+		return nil, err
+	}
+	if packet.Status == model.RedPacketFinished {
+		_ = s.store.Remove(packetId)
+	}
+	if packet.SenderId != userId {
+		nickname, _ := s.userRepository.GetNickNamesById(userId)
+		s.wsHandler.RedPacketNotice(int64(packet.SenderId), model.RedPacketNotice{
+			RedPacketId: packetId,
+			MessageId:   packet.MessageId,
+			UserId:      userId,
+			Nickname:    nickname,
+			Amount:      amount,
+			RemainCount: packet.RemainCount,
+			Finished:    packet.Status == model.RedPacketFinished,
+		})
+	}
+	return s.Detail(userId, packetId)
+}
+
+// Detail 红包详情和领取记录，拼手气红包领完后标记手气最佳
+func (s *RedPacketService) Detail(userId uint, packetId uint) (*response.RedPacketVo, error) {
+	packet, err := s.redPacketRepository.GetById(packetId)
+	if err != nil {
+		return nil, err
+	}
+	if packet == nil || !s.canView(userId, packet) {
+		return nil, errors.New("红包不存在")
+	}
+	claims, err := s.redPacketRepository.GetClaims(packetId)
+	if err != nil {
+		return nil, err
+	}
+	userIds := []uint{packet.SenderId}
+	for _, claim := range claims {
+		userIds = append(userIds, claim.UserId)
+	}
+	nicknames, _ := s.userRepository.GetNickNamesByIds(userIds)
+	vo := &response.RedPacketVo{
+		RedPacket:      *packet,
+		SenderNickname: nicknames[packet.SenderId],
+		Claims:         make([]response.RedPacketClaimVo, 0, len(claims)),
+	}
+	var best *model.RedPacketClaim
+	for i, claim := range claims {
+		claimVo := response.RedPacketClaimVo{
+			UserId:    claim.UserId,
+			Nickname:  nicknames[claim.UserId],
+			Amount:    claim.Amount,
+			CreatedAt: claim.CreatedAt,
+		}
+		vo.Claims = append(vo.Claims, claimVo)
+		if claim.UserId == userId {
+			vo.Claimed = &claimVo
+		}
+		if best == nil || claim.Amount > best.Amount {
+			best = &claims[i]
+		}
+	}
+	if packet.Mode == model.RedPacketRandom && packet.Status == model.RedPacketFinished && best != nil {
+		vo.Best = &best.UserId
+	}
+	return vo, nil
+}
+
+// RefundExpired 过期未领完的红包作废，剩余金额退回发送者
+func (s *RedPacketService) RefundExpired() (int, error) {
+	packets, err := s.redPacketRepository.ListExpired(time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+	for i := range packets {
+		if err := s.discard(packets[i].ID); err != nil {
+			return i, err
+		}
+	}
+	return len(packets), nil
+}
+
+// discard 作废红包并退回剩余金额，与领取共用行锁，不会重复退款
+func (s *RedPacketService) discard(packetId uint) error {
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		packet, err := s.redPacketRepository.GetByIdForUpdate(packetId, tx)
+		if err != nil {
+			return err
+		}
+		if packet == nil || packet.Status != model.RedPacketActive {
+			return nil
+		}
+		if err := s.redPacketRepository.UpdateFields(packetId, map[string]interface{}{
+			"status": model.RedPacketExpired,
+		}, tx); err != nil {
+			return err
+		}
+		if packet.RemainAmount == 0 {
+			return nil
+		}
+		if err := s.walletRepository.Credit(packet.SenderId, packet.RemainAmount, tx); err != nil {
+			return err
+		}
+		return s.walletRepository.CreateLog(&model.WalletLog{
+			UserId:      packet.SenderId,
+			Type:        model.WalletRefundPacket,
+			Amount:      packet.RemainAmount,
+			RedPacketId: &packetId,
+		}, tx)
+	})
+	if err != nil {
+		logUtil.Errorf("红包(%d)退款失败: %v", packetId, err)
+		return err
+	}
+	_ = s.store.Remove(packetId)
+	return nil
+}
+
+// canView 私聊红包的双方、群红包所在群的成员可以查看
+func (s *RedPacketService) canView(userId uint, packet *model.RedPacket) bool {
+	if packet.TargetType == model.PrivateTarget {
+		return packet.SenderId == userId || (packet.ReceiverId != nil && *packet.ReceiverId == userId)
+	}
+	return packet.GroupId != nil && s.groupMemberRepository.ExistsByGroupIdAndUserId(*packet.GroupId, userId)
+}
+
+func (s *RedPacketService) Wallet(userId uint) (*response.WalletVo, error) {
+	wallet, err := s.walletRepository.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	vo := &response.WalletVo{UserId: userId}
+	if wallet != nil {
+		vo.Balance = wallet.Balance
+	}
+	return vo, nil
+}
+
+func (s *RedPacketService) WalletLogs(userId uint, req request.WalletLogQueryRequest) (*pagination.PageResult[model.WalletLog], error) {
+	return s.walletRepository.PageLogs(userId, req)
+}
+
+// splitRedPacket 校验金额和个数并拆分，普通红包 amount 为单个金额，拼手气红包 amount 为总金额
+func splitRedPacket(mode model.RedPacketMode, amount int64, count int) (int64, []int64, error) {
+	maxAmount, maxCount := redPacketLimits()
+	if count <= 0 || count > maxCount {
+		return 0, nil, fmt.Errorf("红包个数需在 1 到 %d 之间", maxCount)
+	}
+	if amount <= 0 || amount > maxAmount {
+		return 0, nil, errors.New("红包金额不合法")
+	}
+	switch mode {
+	case model.RedPacketFixed:
+		total := amount * int64(count)
+		if total > maxAmount {
+			return 0, nil, fmt.Errorf("红包总金额不能超过 %.2f 元", float64(maxAmount)/100)
+		}
+		return total, redPacketUtil.SplitFixed(amount, count), nil
+	case model.RedPacketRandom:
+		if amount < int64(count) {
+			return 0, nil, errors.New("每个红包至少 0.01 元")
+		}
+		return amount, redPacketUtil.SplitRandom(amount, count), nil
+	default:
+		return 0, nil, errors.New("不支持的红包类型")
+	}
+}
+
+func redPacketLimits() (int64, int) {
+	maxAmount, maxCount := configs.AppConfig.RedPacket.MaxAmount, configs.AppConfig.RedPacket.MaxCount
+	if maxAmount <= 0 {
+		maxAmount = 20000
+	}
+	if maxCount <= 0 {
+		maxCount = 100
+	}
+	return maxAmount, maxCount
+}
+
+func redPacketExpireTime() time.Duration {
+	if ttl, err := time.ParseDuration(configs.AppConfig.RedPacket.ExpireTime); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
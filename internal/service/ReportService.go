@@ -0,0 +1,213 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	interfacehandler "go-chat/internal/interfaces/handler"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/utils/logUtil"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const reportCommentMaxLen = 500
+
+type ReportService struct {
+	reportRepository      interfacerepository.ReportRepositoryInterface
+	messageRepository     interfacerepository.MessageRepositoryInterface
+	userRepository        interfacerepository.UserRepositoryInterface
+	groupRepository       interfacerepository.GroupRepositoryInterface
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	wsHandler             interfacehandler.WsHandlerInterface
+}
+
+var (
+	ReportServiceInstance *ReportService
+	reportOnce            sync.Once
+)
+
+func InitReportService(reportRepository interfacerepository.ReportRepositoryInterface,
+	messageRepository interfacerepository.MessageRepositoryInterface,
+	userRepository interfacerepository.UserRepositoryInterface,
+	groupRepository interfacerepository.GroupRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	wsHandler interfacehandler.WsHandlerInterface) {
+	reportOnce.Do(func() {
+		ReportServiceInstance = &ReportService{
+			reportRepository:      reportRepository,
+			messageRepository:     messageRepository,
+			userRepository:        userRepository,
+			groupRepository:       groupRepository,
+			groupMemberRepository: groupMemberRepository,
+			wsHandler:             wsHandler,
+		}
+	})
+}
+
+// Create 提交举报，同时保存被举报对象的快照
+func (s *ReportService) Create(reporterId uint, req request.ReportCreateRequest) (*model.Report, error) {
+	if !req.Category.Valid() {
+		return nil, errors.New("不支持的举报类别")
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(comment) > reportCommentMaxLen {
+		return nil, fmt.Errorf("举报说明不能超过 %d 个字", reportCommentMaxLen)
+	}
+	snapshot, err := s.snapshot(reporterId, req.TargetType, req.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	if s.reportRepository.ExistsOpen(reporterId, req.TargetType, req.TargetId) {
+		return nil, errors.New("已举报过该对象，请等待处理结果")
+	}
+	snapshotJson, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	snapshotStr := string(snapshotJson)
+	report := &model.Report{
+		ReporterId: reporterId,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		Category:   req.Category,
+		Snapshot:   &snapshotStr,
+		Status:     model.ReportPending,
+	}
+	if comment != "" {
+		report.Comment = &comment
+	}
+	if err := s.reportRepository.Create(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// MyReports 查询我提交的举报
+func (s *ReportService) MyReports(reporterId uint, req request.ReportQueryRequest) (*pagination.PageResult[model.Report], error) {
+	req.ReporterId = reporterId
+	return s.reportRepository.Page(req)
+}
+
+// List 管理员查询举报
+func (s *ReportService) List(req request.ReportQueryRequest) (*pagination.PageResult[model.Report], error) {
+	return s.reportRepository.Page(req)
+}
+
+// Handle 处理举报，结案（已处理/已驳回）时通知举报人
+func (s *ReportService) Handle(handlerId uint, req request.ReportHandleRequest) error {
+	if req.Status != model.ReportProcessing && !req.Status.Closed() {
+		return errors.New("处理状态不合法")
+	}
+	report, err := s.reportRepository.GetById(req.ReportId)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return errors.New("举报不存在")
+	}
+	if report.Status.Closed() {
+		return errors.New("该举报已结案")
+	}
+	updates := map[string]interface{}{
+		"status":     req.Status,
+		"handler_id": handlerId,
+	}
+	var result *string
+	if req.Result != "" {
+		result = &req.Result
+		updates["result"] = req.Result
+	}
+	if req.Status.Closed() {
+		updates["handled_at"] = time.Now()
+	}
+	if err := s.reportRepository.UpdateFields(report.ID, updates); err != nil {
+		return err
+	}
+	if req.Status.Closed() {
+		go s.wsHandler.ReportNotice(int64(report.ReporterId), model.ReportNotice{
+			ReportId:   report.ID,
+			TargetType: report.TargetType,
+			TargetId:   report.TargetId,
+			Status:     req.Status,
+			Result:     result,
+		})
+	}
+	logUtil.Infof("举报(%d)已由(%d)更新为状态 %d", report.ID, handlerId, req.Status)
+	return nil
+}
+
+// snapshot 校验举报对象并生成快照；只能举报自己能看到的消息
+func (s *ReportService) snapshot(reporterId uint, targetType model.ReportTargetType, targetId uint) (*model.ReportSnapshot, error) {
+	switch targetType {
+	case model.ReportMessage:
+		message, err := s.messageRepository.GetById(targetId)
+		if err != nil {
+			return nil, err
+		}
+		if message == nil {
+			return nil, errors.New("消息不存在")
+		}
+		if !s.canSeeMessage(reporterId, message) {
+			return nil, errors.New("无权举报该消息")
+		}
+		if message.SenderId == int64(reporterId) {
+			return nil, errors.New("不能举报自己的消息")
+		}
+		return &model.ReportSnapshot{Message: &model.ReportMessageSnapshot{
+			Id:         message.ID,
+			SenderId:   message.SenderId,
+			ReceiverId: message.ReceiverId,
+			GroupId:    message.GroupId,
+			Type:       message.Type,
+			Content:    message.Content,
+			ExtraData:  message.ExtraData,
+			SentAt:     message.CreatedAt,
+		}}, nil
+	case model.ReportUser:
+		if targetId == reporterId {
+			return nil, errors.New("不能举报自己")
+		}
+		user, err := s.userRepository.GetById(targetId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("用户不存在")
+		}
+		return &model.ReportSnapshot{User: &model.ReportUserSnapshot{
+			Id:       user.ID,
+			Username: user.Username,
+			Nickname: user.Nickname,
+			Avatar:   user.Avatar,
+			Desc:     user.Desc,
+		}}, nil
+	case model.ReportGroup:
+		group, err := s.groupRepository.GetByID(targetId)
+		if err != nil {
+			return nil, fmt.Errorf("群组不存在: %w", err)
+		}
+		return &model.ReportSnapshot{Group: &model.ReportGroupSnapshot{
+			Id:      group.ID,
+			Code:    group.Code,
+			Name:    group.Name,
+			Avatar:  group.Avatar,
+			Desc:    group.Desc,
+			OwnerId: group.OwnerId,
+		}}, nil
+	default:
+		return nil, errors.New("不支持的举报对象类型")
+	}
+}
+
+func (s *ReportService) canSeeMessage(userId uint, message *model.Message) bool {
+	if message.TargetType != nil && *message.TargetType == model.GroupTarget {
+		return message.GroupId != nil && s.groupMemberRepository.ExistsByGroupIdAndUserId(uint(*message.GroupId), userId)
+	}
+	return message.SenderId == int64(userId) || (message.ReceiverId != nil && *message.ReceiverId == int64(userId))
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/configs"
+	"go-chat/internal/db"
+	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"gorm.io/gorm"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	stickerPackNameMaxLen   = 64
+	stickerPackDescMaxLen   = 256
+	stickerNameMaxLen       = 32
+	stickerKeywordsMaxLen   = 128
+	defaultStickerMaxSize   = 1 << 20
+	defaultStickerPackMax   = 120
+	defaultGroupStickerMax  = 100
+	defaultStickerFavorites = 300
+)
+
+// stickerShortcode 群自定义表情的短代码，群内唯一
+var stickerShortcode = regexp.MustCompile(`^[A-Za-z0-9_]{2,32}$`)
+
+type StickerService struct {
+	stickerRepository     interfacerepository.StickerRepositoryInterface
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface
+	fileService           interfacesservice.FileServiceInterface
+}
+
+var (
+	StickerServiceInstance *StickerService
+	stickerOnce            sync.Once
+)
+
+func InitStickerService(stickerRepository interfacerepository.StickerRepositoryInterface,
+	groupMemberRepository interfacerepository.GroupMemberRepositoryInterface,
+	fileService interfacesservice.FileServiceInterface) {
+	stickerOnce.Do(func() {
+		StickerServiceInstance = &StickerService{
+			stickerRepository:     stickerRepository,
+			groupMemberRepository: groupMemberRepository,
+			fileService:           fileService,
+		}
+	})
+}
+
+func (s *StickerService) CreatePack(userId uint, req request.StickerPackCreateRequest) (*model.StickerPack, error) {
+	name, description, err := checkStickerPack(req.Name, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	pack := &model.StickerPack{CreatorId: userId, Name: name, Description: description}
+	if err := s.stickerRepository.CreatePack(pack); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+func (s *StickerService) UpdatePack(userId uint, req request.StickerPackUpdateRequest) (*model.StickerPack, error) {
+	pack, err := s.ownPack(userId, req.PackId)
+	if err != nil {
+		return nil, err
+	}
+	name, description := pack.Name, pack.Description
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Description != nil {
+		description = *req.Description
+	}
+	if name, description, err = checkStickerPack(name, description); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{"name": name, "description": description}
+	if req.Cover != nil && *req.Cover != pack.Cover {
+		stickers, err := s.stickerRepository.ListByPackId(pack.ID)
+		if err != nil {
+			return nil, err
+		}
+		if stickerByUrl(stickers, *req.Cover) == nil {
+			return nil, errors.New("封面只能使用包内的表情")
+		}
+		updates["cover"] = *req.Cover
+	}
+	if err := s.stickerRepository.UpdatePack(pack.ID, updates); err != nil {
+		return nil, err
+	}
+	return s.stickerRepository.GetPackById(pack.ID)
+}
+
+func (s *StickerService) DeletePack(userId uint, req request.StickerPackIdRequest) error {
+	pack, err := s.ownPack(userId, req.PackId)
+	if err != nil {
+		return err
+	}
+	var urls []string
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		stickers, err := s.stickerRepository.ListByPackId(pack.ID, tx)
+		if err != nil {
+			return err
+		}
+		ids := make([]uint, 0, len(stickers))
+		for _, sticker := range stickers {
+			ids = append(ids, sticker.ID)
+			urls = append(urls, sticker.Url)
+		}
+		if err := s.stickerRepository.RemoveFavoritesBySticker(ids, tx); err != nil {
+			return err
+		}
+		if err := s.stickerRepository.DeleteByPackId(pack.ID, tx); err != nil {
+			return err
+		}
+		return s.stickerRepository.DeletePack(pack.ID, tx)
+	})
+	if err != nil {
+		return err
+	}
+	releaseFileRefs(s.fileService, urls...)
+	return nil
+}
+
+func (s *StickerService) PackList(userId uint, req request.StickerPackQueryRequest) (*pagination.PageResult[model.StickerPack], error) {
+	req.Keyword = strings.TrimSpace(req.Keyword)
+	return s.stickerRepository.PagePacks(userId, req)
+}
+
+func (s *StickerService) SearchPacks(userId uint, req request.StickerPackQueryRequest) (*pagination.PageResult[model.StickerPack], error) {
+	if strings.TrimSpace(req.Keyword) == "" {
+		return nil, errors.New("搜索关键字不能为空")
+	}
+	return s.PackList(userId, req)
+}
+
+func (s *StickerService) PackDetail(userId uint, packId uint) (*response.StickerPackVo, error) {
+	pack, err := s.stickerRepository.GetPackById(packId)
+	if err != nil {
+		return nil, err
+	}
+	if pack == nil {
+		return nil, errors.New("表情包不存在")
+	}
+	stickers, err := s.stickerRepository.ListByPackId(pack.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &response.StickerPackVo{StickerPack: *pack, Stickers: stickers}, nil
+}
+
+func (s *StickerService) AddSticker(userId uint, req request.StickerAddRequest, file *multipart.FileHeader) (*model.Sticker, error) {
+	if (req.PackId == 0) == (req.GroupId == 0) {
+		return nil, errors.New("表情包和群只能选择一个")
+	}
+	name := strings.TrimSpace(req.Name)
+	keywords := strings.Join(strings.Fields(req.Keywords), " ")
+	if utf8.RuneCountInString(keywords) > stickerKeywordsMaxLen {
+		return nil, fmt.Errorf("关键字不能超过 %d 个字", stickerKeywordsMaxLen)
+	}
+	sticker := &model.Sticker{Name: name, Keywords: keywords, CreatorId: userId}
+	var pack *model.StickerPack
+	if req.PackId != 0 {
+		if name == "" || utf8.RuneCountInString(name) > stickerNameMaxLen {
+			return nil, fmt.Errorf("表情名称不能为空且不能超过 %d 个字", stickerNameMaxLen)
+		}
+		var err error
+		if pack, err = s.ownPack(userId, req.PackId); err != nil {
+			return nil, err
+		}
+		if maxCount := stickerPackMax(); pack.StickerCount >= maxCount {
+			return nil, fmt.Errorf("每个表情包最多 %d 个表情", maxCount)
+		}
+		sticker.PackId = &pack.ID
+	} else {
+		if !stickerShortcode.MatchString(name) {
+			return nil, errors.New("群表情名称只能包含 2-32 个字母、数字或下划线")
+		}
+		if !s.groupMemberRepository.IsOwnerOrAdmin(req.GroupId, userId) {
+			return nil, errors.New("只有群主和管理员可以管理群表情")
+		}
+		count, err := s.stickerRepository.CountByGroupId(req.GroupId)
+		if err != nil {
+			return nil, err
+		}
+		if maxCount := groupStickerMax(); count >= int64(maxCount) {
+			return nil, fmt.Errorf("每个群最多 %d 个自定义表情", maxCount)
+		}
+		sticker.GroupId = &req.GroupId
+	}
+	if err := s.upload(userId, file, sticker); err != nil {
+		return nil, err
+	}
+	created, err := s.stickerRepository.Create(sticker)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.New("群内已有同名表情")
+	}
+	addFileRefs(s.fileService, sticker.Url)
+	if pack != nil {
+		if err := s.stickerRepository.AddStickerCount(pack.ID, 1); err != nil {
+			return nil, err
+		}
+		if pack.Cover == "" {
+			if err := s.stickerRepository.UpdatePack(pack.ID, map[string]interface{}{"cover": sticker.Url}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sticker, nil
+}
+
+// upload 表情只能是图片，沿用文件上传的类型、配额和安全检查
+func (s *StickerService) upload(userId uint, file *multipart.FileHeader, sticker *model.Sticker) error {
+	if file == nil {
+		return errors.New("请上传表情图片")
+	}
+	if maxSize := stickerMaxSize(); file.Size > maxSize {
+		return fmt.Errorf("表情图片不能超过 %d KB", maxSize/1024)
+	}
+	url, err := s.fileService.Upload(userId, file)
+	if err != nil {
+		return err
+	}
+	uploaded, err := s.fileService.FileByUrl(url)
+	if err != nil {
+		return err
+	}
+	if uploaded == nil || uploaded.Type != "image" {
+		return errors.New("表情只能是图片")
+	}
+	sticker.Url = url
+	sticker.FileId = uploaded.ID
+	if uploaded.Width != nil && uploaded.Height != nil {
+		sticker.Width, sticker.Height = *uploaded.Width, *uploaded.Height
+	}
+	return nil
+}
+
+// RemoveSticker 表情包的创建者可以删除包内表情，群主和管理员可以删除群表情
+func (s *StickerService) RemoveSticker(userId uint, req request.StickerIdRequest) error {
+	sticker, err := s.stickerRepository.GetById(req.StickerId)
+	if err != nil {
+		return err
+	}
+	if sticker == nil {
+		return errors.New("表情不存在")
+	}
+	var pack *model.StickerPack
+	if sticker.PackId != nil {
+		if pack, err = s.ownPack(userId, *sticker.PackId); err != nil {
+			return err
+		}
+	} else if sticker.GroupId == nil || !s.groupMemberRepository.IsOwnerOrAdmin(*sticker.GroupId, userId) {
+		return errors.New("只有群主和管理员可以管理群表情")
+	}
+	if err := s.stickerRepository.Delete(sticker.ID); err != nil {
+		return err
+	}
+	if err := s.stickerRepository.RemoveFavoritesBySticker([]uint{sticker.ID}); err != nil {
+		return err
+	}
+	releaseFileRefs(s.fileService, sticker.Url)
+	if pack == nil {
+		return nil
+	}
+	if err := s.stickerRepository.AddStickerCount(pack.ID, -1); err != nil {
+		return err
+	}
+	// 删除的是封面时改用剩下的第一个表情
+	if pack.Cover == sticker.Url {
+		stickers, err := s.stickerRepository.ListByPackId(pack.ID)
+		if err != nil {
+			return err
+		}
+		cover := ""
+		if len(stickers) > 0 {
+			cover = stickers[0].Url
+		}
+		return s.stickerRepository.UpdatePack(pack.ID, map[string]interface{}{"cover": cover})
+	}
+	return nil
+}
+
+func (s *StickerService) GroupStickers(userId uint, groupId uint) ([]model.Sticker, error) {
+	if !s.groupMemberRepository.ExistsByGroupIdAndUserId(groupId, userId) {
+		return nil, errors.New("不是群成员")
+	}
+	return s.stickerRepository.ListByGroupId(groupId)
+}
+
+func (s *StickerService) AddFavorite(userId uint, req request.StickerIdRequest) error {
+	sticker, err := s.stickerRepository.GetById(req.StickerId)
+	if err != nil {
+		return err
+	}
+	if sticker == nil || (sticker.GroupId != nil && !s.groupMemberRepository.ExistsByGroupIdAndUserId(*sticker.GroupId, userId)) {
+		return errors.New("表情不存在")
+	}
+	count, err := s.stickerRepository.CountFavorites(userId)
+	if err != nil {
+		return err
+	}
+	if maxCount := stickerFavoriteMax(); count >= int64(maxCount) {
+		return fmt.Errorf("最多收藏 %d 个表情", maxCount)
+	}
+	added, err := s.stickerRepository.AddFavorite(userId, sticker.ID)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return errors.New("已经收藏过该表情")
+	}
+	return nil
+}
+
+func (s *StickerService) RemoveFavorite(userId uint, req request.StickerIdRequest) error {
+	removed, err := s.stickerRepository.RemoveFavorite(userId, req.StickerId)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("没有收藏该表情")
+	}
+	return nil
+}
+
+func (s *StickerService) Favorites(userId uint) ([]model.Sticker, error) {
+	return s.stickerRepository.ListFavorites(userId)
+}
+
+func (s *StickerService) ownPack(userId uint, packId uint) (*model.StickerPack, error) {
+	pack, err := s.stickerRepository.GetPackById(packId)
+	if err != nil {
+		return nil, err
+	}
+	if pack == nil {
+		return nil, errors.New("表情包不存在")
+	}
+	if pack.CreatorId != userId {
+		return nil, errors.New("只能管理自己创建的表情包")
+	}
+	return pack, nil
+}
+
+func checkStickerPack(name string, description string) (string, string, error) {
+	name, description = strings.TrimSpace(name), strings.TrimSpace(description)
+	if name == "" || utf8.RuneCountInString(name) > stickerPackNameMaxLen {
+		return "", "", fmt.Errorf("表情包名称不能为空且不能超过 %d 个字", stickerPackNameMaxLen)
+	}
+	if utf8.RuneCountInString(description) > stickerPackDescMaxLen {
+		return "", "", fmt.Errorf("表情包简介不能超过 %d 个字", stickerPackDescMaxLen)
+	}
+	return name, description, nil
+}
+
+func stickerByUrl(stickers []model.Sticker, url string) *model.Sticker {
+	for i := range stickers {
+		if stickers[i].Url == url {
+			return &stickers[i]
+		}
+	}
+	return nil
+}
+
+func stickerMaxSize() int64 {
+	if maxSize := configs.AppConfig.Sticker.MaxSize; maxSize > 0 {
+		return maxSize
+	}
+	return defaultStickerMaxSize
+}
+
+func stickerPackMax() int {
+	if maxCount := configs.AppConfig.Sticker.PackMaxCount; maxCount > 0 {
+		return maxCount
+	}
+	return defaultStickerPackMax
+}
+
+func groupStickerMax() int {
+	if maxCount := configs.AppConfig.Sticker.GroupMaxCount; maxCount > 0 {
+		return maxCount
+	}
+	return defaultGroupStickerMax
+}
+
+func stickerFavoriteMax() int {
+	if maxCount := configs.AppConfig.Sticker.FavoriteMaxCount; maxCount > 0 {
+		return maxCount
+	}
+	return defaultStickerFavorites
+}
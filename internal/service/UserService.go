@@ -6,6 +6,7 @@ import (
 	"go-chat/internal/db"
 	interfacehandler "go-chat/internal/interfaces/handler"
 	interfacerepository "go-chat/internal/interfaces/repository"
+	interfacesservice "go-chat/internal/interfaces/service"
 	"go-chat/internal/model"
 	request "go-chat/internal/model/request"
 	response "go-chat/internal/model/response"
@@ -20,6 +21,7 @@ import (
 type UserService struct {
 	userRepository interfacerepository.UserRepositoryInterface
 	wsHandler      interfacehandler.WsHandlerInterface
+	fileRefService interfacesservice.FileRefServiceInterface
 }
 
 var (
@@ -27,11 +29,13 @@ var (
 	once                sync.Once
 )
 
-func InitUserService(wsHandler interfacehandler.WsHandlerInterface, userRepository interfacerepository.UserRepositoryInterface) *UserService {
+func InitUserService(wsHandler interfacehandler.WsHandlerInterface, userRepository interfacerepository.UserRepositoryInterface,
+	fileRefService interfacesservice.FileRefServiceInterface) *UserService {
 	once.Do(func() {
 		UserServiceInstance = &UserService{
 			wsHandler:      wsHandler,
 			userRepository: userRepository,
+			fileRefService: fileRefService,
 		}
 	})
 	return UserServiceInstance
@@ -72,10 +76,6 @@ func (u *UserService) Register(username, password, rePassword *string) (err erro
 func (u *UserService) Login(username, password *string) (token string, err error) {
 	//根据username查询
 	user, err := u.userRepository.GetByName(username)
-	// todo 1.非封禁状态 之后维护redis 黑名单
-	if user.Status == model.Disable {
-		return "", errors.New("用户被封禁")
-	}
 	if err != nil {
 		return "", err
 	}
@@ -86,6 +86,18 @@ func (u *UserService) Login(username, password *string) (token string, err error
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(*password)); err != nil {
 		return "", errors.New("用户名或密码错误")
 	}
+	return u.LoginWithUser(user)
+}
+
+// LoginWithUser 已通过身份校验的用户登录（密码登录、OIDC 登录共用），签发 jwt 并更新在线状态
+func (u *UserService) LoginWithUser(user *model.User) (token string, err error) {
+	// 封禁中的用户不能登录，已签发的 token 由 SessionManager 吊销
+	if user.IsBanned() {
+		if user.BanReason != nil {
+			return "", errors.New("用户被封禁: " + *user.BanReason)
+		}
+		return "", errors.New("用户被封禁")
+	}
 	//jwt 返回
 	token, err = jwtUtil.GenerateJWT(user.ID)
 	if err != nil {
@@ -140,6 +152,13 @@ func (u *UserService) OnlineStatusChange(id uint, onlineStatus model.OnlineStatu
 }
 
 func (u *UserService) UpdateUser(updateRequest *request.UserUpdateRequest) error {
+	//头像对所有人可见，只能使用自己上传的文件
+	if updateRequest.Avatar != nil && u.fileRefService != nil {
+		if err := u.fileRefService.CheckOwned(updateRequest.ID, *updateRequest.Avatar); err != nil {
+			return err
+		}
+	}
+	var oldAvatar *string
 	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
 
 		user, err := u.userRepository.GetById(updateRequest.ID, tx)
@@ -164,6 +183,9 @@ func (u *UserService) UpdateUser(updateRequest *request.UserUpdateRequest) error
 		}
 		if updateRequest.Email != nil {
 			updates["email"] = updateRequest.Email
+			if user.Email == nil || *user.Email != *updateRequest.Email {
+				updates["email_verified"] = false
+			}
 		}
 		if len(updates) == 0 {
 			return errors.New("没有可更新的字段")
@@ -172,11 +194,19 @@ func (u *UserService) UpdateUser(updateRequest *request.UserUpdateRequest) error
 		if err != nil {
 			return fmt.Errorf("更新用户失败: %v", err)
 		}
+		oldAvatar = user.Avatar
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+	//头像变更时转移文件引用
+	if updateRequest.Avatar != nil && (oldAvatar == nil || *oldAvatar != *updateRequest.Avatar) {
+		addFileRefs(u.fileRefService, *updateRequest.Avatar)
+		if oldAvatar != nil {
+			releaseFileRefs(u.fileRefService, *oldAvatar)
+		}
+	}
 	return nil
 }
 
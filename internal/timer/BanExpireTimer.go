@@ -0,0 +1,19 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// BanExpireTimer 每分钟解除已到期的封禁
+func BanExpireTimer() {
+	_, err := Timer.AddFunc("0 * * * * *", func() {
+		if err := service.AdminServiceInstance.ReleaseExpiredBans(); err != nil {
+			logrus.Errorf("解除到期封禁失败: %v", err)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "BanExpireTimer", err)
+		return
+	}
+}
@@ -0,0 +1,23 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// FileGcTimer 每小时回收超过宽限期仍无引用的文件
+func FileGcTimer() {
+	_, err := Timer.AddFunc("0 30 * * * *", func() {
+		count, err := service.FileServiceInstance.CollectGarbage()
+		if err != nil {
+			logrus.Errorf("回收无引用文件失败: %v", err)
+		}
+		if count > 0 {
+			logrus.Infof("回收无引用文件 %d 个", count)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "FileGcTimer", err)
+		return
+	}
+}
@@ -0,0 +1,23 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// MessageExpireTimer 每10秒删除到期的限时消息
+func MessageExpireTimer() {
+	_, err := Timer.AddFunc("5/10 * * * * *", func() {
+		count, err := service.MessageServiceInstance.ExpireMessages()
+		if err != nil {
+			logrus.Errorf("删除到期的限时消息失败: %v", err)
+		}
+		if count > 0 {
+			logrus.Infof("删除到期的限时消息 %d 条", count)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "MessageExpireTimer", err)
+		return
+	}
+}
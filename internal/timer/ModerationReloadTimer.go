@@ -0,0 +1,19 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// ModerationReloadTimer 每30秒检查敏感词配置和词表文件，有变化时热加载
+func ModerationReloadTimer() {
+	_, err := Timer.AddFunc("*/30 * * * * *", func() {
+		if err := service.ModerationServiceInstance.ReloadWords(false); err != nil {
+			logrus.Errorf("敏感词表热加载失败: %v", err)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "ModerationReloadTimer", err)
+		return
+	}
+}
@@ -0,0 +1,23 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// RedPacketExpireTimer 每分钟把过期未领完的红包退款给发送者
+func RedPacketExpireTimer() {
+	_, err := Timer.AddFunc("15 * * * * *", func() {
+		count, err := service.RedPacketServiceInstance.RefundExpired()
+		if err != nil {
+			logrus.Errorf("过期红包退款失败: %v", err)
+		}
+		if count > 0 {
+			logrus.Infof("过期红包退款 %d 个", count)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "RedPacketExpireTimer", err)
+		return
+	}
+}
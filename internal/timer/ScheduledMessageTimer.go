@@ -0,0 +1,23 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// ScheduledMessageTimer 每10秒发送到期的定时消息
+func ScheduledMessageTimer() {
+	_, err := Timer.AddFunc("*/10 * * * * *", func() {
+		count, err := service.MessageServiceInstance.DispatchScheduled()
+		if err != nil {
+			logrus.Errorf("发送定时消息失败: %v", err)
+		}
+		if count > 0 {
+			logrus.Infof("发送定时消息 %d 条", count)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "ScheduledMessageTimer", err)
+		return
+	}
+}
@@ -8,5 +8,12 @@ var Timer = cron.New(cron.WithSeconds())
 
 func InitTimer() {
 	HeartBeatTimer()
+	BanExpireTimer()
+	ModerationReloadTimer()
+	UploadCleanTimer()
+	FileGcTimer()
+	RedPacketExpireTimer()
+	ScheduledMessageTimer()
+	MessageExpireTimer()
 	Timer.Start()
 }
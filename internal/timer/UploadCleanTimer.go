@@ -0,0 +1,23 @@
+package timer
+
+import (
+	"github.com/sirupsen/logrus"
+	"go-chat/internal/service"
+)
+
+// UploadCleanTimer 每10分钟清理过期未完成的分片上传
+func UploadCleanTimer() {
+	_, err := Timer.AddFunc("0 */10 * * * *", func() {
+		count, err := service.FileServiceInstance.CleanExpiredUploads()
+		if err != nil {
+			logrus.Errorf("清理过期分片上传失败: %v", err)
+		}
+		if count > 0 {
+			logrus.Infof("清理过期分片上传 %d 个", count)
+		}
+	})
+	if err != nil {
+		logrus.Errorf("定时任务(%v)添加失败: %v", "UploadCleanTimer", err)
+		return
+	}
+}
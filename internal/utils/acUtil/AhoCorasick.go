@@ -0,0 +1,145 @@
+package acUtil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match 一次命中，Start/End 为命中词在原文 rune 切片中的下标区间 [Start, End)
+type Match struct {
+	Word  string
+	Start int
+	End   int
+}
+
+type node struct {
+	children map[rune]*node
+	fail     *node
+	// 以该节点结尾的词长度（rune 数），用于回推起始位置
+	outputs []int
+	words   []string
+}
+
+// Automaton Aho-Corasick 多模式匹配自动机，构建后只读，可并发使用
+type Automaton struct {
+	root *node
+	size int
+}
+
+// New 根据词表构建自动机，匹配时忽略大小写，空白词会被忽略
+func New(words []string) *Automaton {
+	a := &Automaton{root: &node{children: make(map[rune]*node)}}
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		a.insert(word)
+	}
+	a.build()
+	return a
+}
+
+// Size 词表中有效词的数量
+func (a *Automaton) Size() int {
+	return a.size
+}
+
+func (a *Automaton) insert(word string) {
+	cur := a.root
+	runes := normalize([]rune(word))
+	for _, r := range runes {
+		next, ok := cur.children[r]
+		if !ok {
+			next = &node{children: make(map[rune]*node)}
+			cur.children[r] = next
+		}
+		cur = next
+	}
+	for _, w := range cur.words {
+		if w == word {
+			return
+		}
+	}
+	cur.outputs = append(cur.outputs, len(runes))
+	cur.words = append(cur.words, word)
+	a.size++
+}
+
+// build 广度优先构建失败指针，并把失败链上的输出合并到当前节点
+func (a *Automaton) build() {
+	queue := make([]*node, 0, len(a.root.children))
+	for _, child := range a.root.children {
+		child.fail = a.root
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range cur.children {
+			fail := cur.fail
+			for fail != nil && fail.children[r] == nil {
+				fail = fail.fail
+			}
+			if fail == nil {
+				child.fail = a.root
+			} else {
+				child.fail = fail.children[r]
+			}
+			child.outputs = append(child.outputs, child.fail.outputs...)
+			child.words = append(child.words, child.fail.words...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAll 返回文本中所有命中（含重叠命中）
+func (a *Automaton) FindAll(text string) []Match {
+	if a == nil || a.size == 0 {
+		return nil
+	}
+	runes := normalize([]rune(text))
+	var matches []Match
+	cur := a.root
+	for i, r := range runes {
+		for cur != a.root && cur.children[r] == nil {
+			cur = cur.fail
+		}
+		if next, ok := cur.children[r]; ok {
+			cur = next
+		}
+		for j, length := range cur.outputs {
+			matches = append(matches, Match{Word: cur.words[j], Start: i + 1 - length, End: i + 1})
+		}
+	}
+	return matches
+}
+
+// Contains 文本是否命中任意词
+func (a *Automaton) Contains(text string) bool {
+	return len(a.FindAll(text)) > 0
+}
+
+// Mask 将命中的片段替换为 mask 字符，返回替换后的文本和命中列表
+func (a *Automaton) Mask(text string, mask rune) (string, []Match) {
+	matches := a.FindAll(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+	runes := []rune(text)
+	for _, m := range matches {
+		for i := m.Start; i < m.End; i++ {
+			runes[i] = mask
+		}
+	}
+	return string(runes), matches
+}
+
+// normalize 统一转小写，使匹配忽略大小写；不改变 rune 数量，保证下标可以映射回原文
+func normalize(runes []rune) []rune {
+	result := make([]rune, len(runes))
+	for i, r := range runes {
+		result[i] = unicode.ToLower(r)
+	}
+	return result
+}
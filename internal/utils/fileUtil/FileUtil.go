@@ -2,7 +2,6 @@ package fileutil
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"github.com/h2non/filetype"
 	"image"
@@ -11,22 +10,15 @@ import (
 	_ "image/png"
 	"io"
 	"mime/multipart"
-	"os"
-	"os/exec"
+	"net/http"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"go-chat/internal/model"
 )
 
-type ffprobeOutput struct {
-	Format struct {
-		Duration string `json:"duration"`
-	} `json:"format"`
-}
-
-// ParseFile 解析上传文件，返回统一的 model.File 信息，包含音视频时长等
+// ParseFile 解析上传文件，返回统一的 model.File 信息
+// 音视频时长等信息由异步处理任务补充
 func ParseFile(fileHeader *multipart.FileHeader) (*model.File, error) {
 	f := &model.File{
 		Name: fileHeader.Filename,
@@ -53,16 +45,8 @@ func ParseFile(fileHeader *multipart.FileHeader) (*model.File, error) {
 	}
 	head = head[:n]
 
-	kind, err := filetype.Match(head)
-	if err != nil {
-		return nil, fmt.Errorf("检测文件类型失败: %w", err)
-	}
-
-	if kind != filetype.Unknown {
-		f.Mime = kind.MIME.Value
-		f.Ext = kind.Extension
-	} else {
-		f.Mime = "application/octet-stream"
+	if err := detectMime(f, head); err != nil {
+		return nil, err
 	}
 
 	// 重新打开文件提取图片宽高
@@ -78,77 +62,67 @@ func ParseFile(fileHeader *multipart.FileHeader) (*model.File, error) {
 		}
 	}
 
-	// 处理音视频时长
-	if strings.HasPrefix(f.Mime, "audio/") || strings.HasPrefix(f.Mime, "video/") {
-		duration, err := getMediaDuration(fileHeader)
-		if err == nil {
-			f.Duration = &duration
-		} else {
-			fmt.Printf("getMediaDuration error: %v\n", err)
-		}
-	}
-
 	// 根据 MIME 和扩展名分类文件类型
 	f.Type = classifyByMime(f.Mime, f.Ext)
 
 	return f, nil
 }
 
-// getMediaDuration 使用 ffprobe 获取音视频时长
-func getMediaDuration(fileHeader *multipart.FileHeader) (float64, error) {
-	file, err := fileHeader.Open()
-	if err != nil {
-		return 0, err
+// ParseHead 根据文件名和文件头部字节解析文件信息，用于分片上传等拿不到完整 multipart 文件的场景
+// head 需包含足够的头部数据才能解析出图片宽高，不解析音视频时长
+func ParseHead(name string, size int64, head []byte) (*model.File, error) {
+	f := &model.File{
+		Name: name,
+		Size: uint64(size),
 	}
-	defer file.Close()
-
-	tmpFile, err := os.CreateTemp("", "upload-*"+filepath.Ext(fileHeader.Filename))
-	if err != nil {
-		return 0, err
+	ext := strings.ToLower(filepath.Ext(name))
+	if len(ext) > 0 {
+		f.Ext = ext[1:]
 	}
-	defer func() {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-	}()
-
-	// 拷贝上传文件内容到临时文件
-	if _, err := io.Copy(tmpFile, file); err != nil {
-		return 0, err
+	if err := detectMime(f, head); err != nil {
+		return nil, err
 	}
-
-	if err := tmpFile.Sync(); err != nil {
-		return 0, err
+	if strings.HasPrefix(f.Mime, "image/") {
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(head)); err == nil {
+			w := uint(cfg.Width)
+			h := uint(cfg.Height)
+			f.Width = &w
+			f.Height = &h
+		}
 	}
+	f.Type = classifyByMime(f.Mime, f.Ext)
+	return f, nil
+}
 
-	cmd := exec.Command("ffprobe",
-		"-hide_banner",
-		"-loglevel", "error",
-		"-show_entries", "format=duration",
-		"-of", "json",
-		tmpFile.Name(),
-	)
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-
-	if err := cmd.Run(); err != nil {
-		return 0, fmt.Errorf("ffprobe 执行失败: %w, 输出: %s", err, out.String())
+// detectMime 按文件头检测 MIME 和扩展名，无法识别时保留原扩展名
+func detectMime(f *model.File, head []byte) error {
+	kind, err := filetype.Match(head)
+	if err != nil {
+		return fmt.Errorf("检测文件类型失败: %w", err)
 	}
-
-	var probeOutput ffprobeOutput
-	if err := json.Unmarshal(out.Bytes(), &probeOutput); err != nil {
-		return 0, fmt.Errorf("ffprobe 输出解析失败: %w", err)
+	if kind != filetype.Unknown {
+		f.Mime = kind.MIME.Value
+		f.Ext = kind.Extension
+	} else {
+		// 二进制格式无法识别时再区分文本和其他文件，去掉 charset 等参数
+		f.Mime, _, _ = strings.Cut(http.DetectContentType(head), ";")
 	}
+	return nil
+}
 
-	duration, err := strconv.ParseFloat(probeOutput.Format.Duration, 64)
-	if err != nil {
-		return 0, fmt.Errorf("时长转换失败: %w", err)
+// ExtensionMatches 文件名的扩展名与按内容识别出的类型是否属于同一类别，用于拒绝伪造扩展名（如把可执行文件改名为 .jpg）
+// 扩展名不是可识别的二进制格式时（文本、源码等）不做检查
+func ExtensionMatches(name string, f *model.File) bool {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	expected := filetype.GetType(ext)
+	if ext == "" || expected == filetype.Unknown || expected.MIME.Value == f.Mime {
+		return true
 	}
-
-	fmt.Printf("ffprobe duration: %f seconds\n", duration) // 调试用
-
-	return duration, nil
+	// docx 等 Office 文档本质是 zip，部分文件只能识别为 zip
+	if f.Mime == "application/zip" && strings.HasPrefix(expected.MIME.Value, "application/vnd.openxmlformats") {
+		return true
+	}
+	return classifyByMime(expected.MIME.Value, ext) == f.Type
 }
 
 // classifyByMime 按 MIME 和扩展名分类文件类型
@@ -0,0 +1,137 @@
+package imageUtil
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// 图片处理全部使用纯 Go 实现，不依赖 ImageMagick 等外部程序
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// ErrTooLarge 图片像素数超过限制，防止解压炸弹
+var ErrTooLarge = errors.New("图片尺寸过大")
+
+// Decoded 解码并按 EXIF 方向摆正后的图片
+type Decoded struct {
+	Image       image.Image
+	Format      string
+	Orientation int // 原始 EXIF 方向，1 表示无需旋转
+}
+
+// Decode 解码图片并自动摆正方向，maxPixels 大于 0 时先检查像素数
+func Decode(data []byte, maxPixels int) (*Decoded, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if maxPixels > 0 && cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	orientation := 1
+	if format == FormatJPEG {
+		orientation = JpegOrientation(data)
+	}
+	return &Decoded{Image: AutoOrient(img, orientation), Format: format, Orientation: orientation}, nil
+}
+
+// Sanitize 去除图片中的 EXIF/GPS 等元数据：需要旋转的 JPEG 重新编码，其余 JPEG/PNG 无损删除元数据段
+func Sanitize(data []byte, decoded *Decoded, quality int) ([]byte, error) {
+	switch decoded.Format {
+	case FormatJPEG:
+		if decoded.Orientation != 1 {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, decoded.Image, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+		return StripJpegMetadata(data)
+	case FormatPNG:
+		return StripPngMetadata(data)
+	}
+	return data, nil
+}
+
+// Resize 等比缩放到最长边不超过 maxSide，不放大
+func Resize(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// Encode 按格式编码，gif 缩略图编码为 png
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	case FormatPNG, FormatGIF:
+		return png.Encode(w, img)
+	default:
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	}
+}
+
+// ThumbnailFormat 缩略图格式：可能带透明通道的 png/gif/webp 用 png，其余用 jpeg
+func ThumbnailFormat(source string) string {
+	switch source {
+	case FormatPNG, FormatGIF, FormatWebP:
+		return FormatPNG
+	}
+	return FormatJPEG
+}
+
+// Mime 格式对应的 MIME 类型
+func Mime(format string) string {
+	return "image/" + format
+}
+
+// Ext 格式对应的扩展名
+func Ext(format string) string {
+	if format == FormatJPEG {
+		return "jpg"
+	}
+	return format
+}
+
+// flatten 透明区域填充白色，避免 jpeg 编码后变黑
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,141 @@
+package imageUtil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	jpegSOI  = 0xD8
+	jpegSOS  = 0xDA
+	jpegEOI  = 0xD9
+	jpegAPP1 = 0xE1
+	jpegAPPD = 0xED
+	jpegCOM  = 0xFE
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	pngHeader  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// jpegSegments 遍历 SOS 之前的 JPEG 段，fn 返回 false 时停止
+func jpegSegments(data []byte, fn func(marker byte, start, end int) bool) (sos int, err error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return 0, errors.New("不是有效的 JPEG")
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 0, errors.New("JPEG 段格式错误")
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // 填充字节
+			i++
+			continue
+		}
+		if marker == jpegSOS || marker == jpegEOI {
+			return i, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, errors.New("JPEG 段长度错误")
+		}
+		if !fn(marker, i, end) {
+			return i, nil
+		}
+		i = end
+	}
+	return 0, errors.New("JPEG 缺少图像数据")
+}
+
+// JpegOrientation 读取 JPEG EXIF 中的方向标记，读取失败返回 1
+func JpegOrientation(data []byte) int {
+	orientation := 1
+	_, _ = jpegSegments(data, func(marker byte, start, end int) bool {
+		payload := data[start+4 : end]
+		if marker != jpegAPP1 || !bytes.HasPrefix(payload, exifHeader) {
+			return true
+		}
+		orientation = tiffOrientation(payload[len(exifHeader):])
+		return false
+	})
+	return orientation
+}
+
+// tiffOrientation 在 TIFF 结构的 IFD0 中查找方向标记(0x0112)
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// StripJpegMetadata 无损删除 JPEG 中的 EXIF/XMP(APP1)、IPTC(APP13) 和注释段，保留 ICC 色彩配置
+func StripJpegMetadata(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, jpegSOI)
+	sos, err := jpegSegments(data, func(marker byte, start, end int) bool {
+		if marker != jpegAPP1 && marker != jpegAPPD && marker != jpegCOM {
+			out = append(out, data[start:end]...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[sos:]...), nil
+}
+
+// StripPngMetadata 无损删除 PNG 中的 eXIf 和文本类数据块
+func StripPngMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngHeader) {
+		return nil, errors.New("不是有效的 PNG")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngHeader...)
+	i := len(pngHeader)
+	for i+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errors.New("PNG 数据块长度错误")
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "iTXt", "zTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
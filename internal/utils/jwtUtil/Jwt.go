@@ -9,9 +9,22 @@ import (
 
 type Claims struct {
 	ID uint `json:"id"`
+	// IssuedAtMilli 毫秒级签发时间，iat 只精确到秒，同一秒内吊销后重新登录签发的 token 需要靠它区分
+	IssuedAtMilli int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IssuedTime 签发时间，旧 token 没有毫秒级签发时间时使用 iat
+func (c *Claims) IssuedTime() time.Time {
+	if c.IssuedAtMilli > 0 {
+		return time.UnixMilli(c.IssuedAtMilli)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
 // GenerateJWT 生成 JWT Token
 func GenerateJWT(id uint) (string, error) {
 	// 获取动态的 JWT 配置信息
@@ -26,12 +39,15 @@ func GenerateJWT(id uint) (string, error) {
 	// 将 Audience 转换为 []string 类型（如果它是单个字符串）
 	audience := []string{jwtConfig.Audience}
 	// 定义 JWT 的 Claims（声明部分）
+	now := time.Now()
 	claims := Claims{
-		ID: id,
+		ID:            id,
+		IssuedAtMilli: now.UnixMilli(), // 签发时间，用于强制下线后使旧 token 失效
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt), // 设置正确的过期时间
-			Issuer:    jwtConfig.Issuer,              // 从配置读取 Issuer
-			Audience:  audience,                      // 转换为 []string 类型
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    jwtConfig.Issuer, // 从配置读取 Issuer
+			Audience:  audience,         // 转换为 []string 类型
 		},
 	}
 	// 创建 JWT Token
@@ -0,0 +1,55 @@
+package linkUtil
+
+import (
+	"go-chat/internal/model"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPattern 匹配文本中的链接，支持省略协议的 www. 开头写法
+var urlPattern = regexp.MustCompile(`(?i)\b((?:https?://|www\.)[^\s<>"'，。）]+)`)
+
+// Extract 按出现顺序提取消息片段中的 http(s) 链接并去重，最多返回 max 个，max <= 0 时不限制
+func Extract(parts model.MessagePartList, max int) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		if part == nil || part.Content == nil {
+			continue
+		}
+		var candidates []string
+		switch part.Type {
+		case model.Link:
+			candidates = []string{*part.Content}
+		case model.Text:
+			candidates = urlPattern.FindAllString(*part.Content, -1)
+		}
+		for _, candidate := range candidates {
+			link := Normalize(candidate)
+			if link == "" || seen[link] {
+				continue
+			}
+			seen[link] = true
+			links = append(links, link)
+			if max > 0 && len(links) >= max {
+				return links
+			}
+		}
+	}
+	return links
+}
+
+// Normalize 补全省略的协议并去掉末尾标点，不是 http(s) 地址时返回空串
+func Normalize(link string) string {
+	link = strings.TrimRight(strings.TrimSpace(link), ".,;:!?)")
+	if strings.HasPrefix(strings.ToLower(link), "www.") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,58 @@
+package pollUtil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// NormalizeChoices 去重并排序选中的选项，校验选项属于该投票且不超过可选项数
+func NormalizeChoices(choices []uint, optionIds []uint, maxChoices int) ([]uint, error) {
+	valid := make(map[uint]bool, len(optionIds))
+	for _, id := range optionIds {
+		valid[id] = true
+	}
+	seen := make(map[uint]bool, len(choices))
+	result := make([]uint, 0, len(choices))
+	for _, id := range choices {
+		if !valid[id] {
+			return nil, errors.New("选项不存在")
+		}
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("请选择选项")
+	}
+	if len(result) > maxChoices {
+		if maxChoices == 1 {
+			return nil, errors.New("单选投票只能选择一项")
+		}
+		return nil, fmt.Errorf("最多只能选择 %d 项", maxChoices)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+// Diff 修改投票时，需要减票的是之前选中现在未选的选项，需要加票的是新选中的选项
+func Diff(previous []uint, current []uint) (removed []uint, added []uint) {
+	before := make(map[uint]bool, len(previous))
+	for _, id := range previous {
+		before[id] = true
+	}
+	after := make(map[uint]bool, len(current))
+	for _, id := range current {
+		after[id] = true
+		if !before[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range previous {
+		if !after[id] {
+			removed = append(removed, id)
+		}
+	}
+	return removed, added
+}
@@ -0,0 +1,26 @@
+package redPacketUtil
+
+import "math/rand/v2"
+
+// SplitFixed 普通红包，每份金额相同
+func SplitFixed(amount int64, count int) []int64 {
+	amounts := make([]int64, count)
+	for i := range amounts {
+		amounts[i] = amount
+	}
+	return amounts
+}
+
+// SplitRandom 拼手气红包，二倍均值法：每份在 [1, 剩余均值的两倍) 之间随机，最后一份取余额
+// 每份至少 1 分，调用方需保证 total >= count
+func SplitRandom(total int64, count int) []int64 {
+	amounts := make([]int64, count)
+	remain := total
+	for i := 0; i < count-1; i++ {
+		left := int64(count - i)
+		amounts[i] = rand.Int64N(remain/left*2-1) + 1
+		remain -= amounts[i]
+	}
+	amounts[count-1] = remain
+	return amounts
+}
@@ -0,0 +1,44 @@
+package signUtil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-chat/configs"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PresignURL 生成带签名和有效期的对象地址，由 /file/object 接口校验
+func PresignURL(baseUrl, method, key string, expires time.Duration) string {
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{}
+	query.Set("method", strings.ToUpper(method))
+	query.Set("expires", expiresAt)
+	query.Set("signature", Sign(method, key, expiresAt))
+	return fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(baseUrl, "/"), key, query.Encode())
+}
+
+// Verify 校验签名和有效期
+func Verify(method, key, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	expected := Sign(method, key, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Sign 使用 storage.signSecret（默认 jwt.secretKey）对 方法+对象路径+过期时间 签名
+func Sign(method, key, expires string) string {
+	secret := configs.AppConfig.Storage.SignSecret
+	if secret == "" {
+		secret = configs.AppConfig.Jwt.SecretKey
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -64,6 +64,17 @@ func (ws *WebSocketManager) SendMessageToAll(message interface{}) {
 	})
 }
 
+// Disconnect 主动断开用户的连接
+func (ws *WebSocketManager) Disconnect(id int64) {
+	conn, ok := WebSocketClient.Connections.LoadAndDelete(id)
+	if !ok {
+		return
+	}
+	if err := conn.(*websocket.Conn).Close(); err != nil {
+		logUtil.Warnf("关闭用户 %v 的连接失败: %s", id, err)
+	}
+}
+
 func (ws *WebSocketManager) GetOnlineUserIds() []int64 {
 	var userIds []int64
 	WebSocketClient.Connections.Range(func(key, value interface{}) bool {
@@ -2,6 +2,7 @@ package wsHandler
 
 import (
 	"go-chat/internal/model"
+	response "go-chat/internal/model/response"
 	"go-chat/internal/service"
 	"go-chat/internal/utils"
 	"go-chat/internal/utils/jsonUtil"
@@ -31,6 +32,44 @@ func (ws *WebSocketHandler) ChatHandler(sendId int64, data interface{}) {
 		})
 		return
 	}
+	// 发送者以 ws 连接的用户为准，防止冒充他人发送
+	message.SenderId = sendId
+	// 红包消息由红包接口在扣款后生成，不能直接发送
+	if message.Type != nil && *message.Type == model.RedBagContent {
+		wsClient.WebSocketClient.SendMessageToOne(sendId, &model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "红包请通过红包接口发送",
+			Data:    nil,
+		})
+		return
+	}
+	// 投票消息由投票接口在保存投票后生成
+	if message.Type != nil && *message.Type == model.PollContent {
+		wsClient.WebSocketClient.SendMessageToOne(sendId, &model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "投票请通过投票接口发起",
+			Data:    nil,
+		})
+		return
+	}
+	// 聊天记录由转发接口按源消息生成，客户端构造的记录内容不可信
+	if message.Type != nil && *message.Type == model.ForwardedCotent {
+		wsClient.WebSocketClient.SendMessageToOne(sendId, &model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "聊天记录请通过转发接口发送",
+			Data:    nil,
+		})
+		return
+	}
+	// 系统消息只能由服务端生成
+	if message.Type != nil && *message.Type == model.SystemContent {
+		wsClient.WebSocketClient.SendMessageToOne(sendId, &model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不能发送系统消息",
+			Data:    nil,
+		})
+		return
+	}
 	message.InitFields()
 	vo, err := service.MessageServiceInstance.SendMessage(message)
 	if err != nil {
@@ -52,9 +91,13 @@ func (ws *WebSocketHandler) ChatHandler(sendId int64, data interface{}) {
 			Time:   time.Now(),
 		},
 	})
-	//消息发送后返回接收者,这里接收者私聊或群聊处理方式不同:
-	if *message.TargetType == model.PrivateTarget {
-		wsClient.WebSocketClient.SendMessageToOne(*message.ReceiverId, &model.Response{
+	ws.DeliverMessage(sendId, vo)
+}
+
+// DeliverMessage 把已保存的消息推送给接收者，私聊推送给对方，群聊推送给在线的群成员
+func (ws *WebSocketHandler) DeliverMessage(sendId int64, vo *response.MessageVo) {
+	if *vo.TargetType == model.PrivateTarget {
+		wsClient.WebSocketClient.SendMessageToOne(*vo.ReceiverId, &model.Response{
 			Code:    http.StatusOK,
 			Message: "success",
 			Data: &wsMessage.Message{
@@ -64,8 +107,8 @@ func (ws *WebSocketHandler) ChatHandler(sendId int64, data interface{}) {
 				Time:   time.Now(),
 			},
 		})
-	} else if *message.TargetType == model.GroupTarget {
-		memberList, err := service.GroupServiceInstance.Member(uint(*message.GroupId))
+	} else if *vo.TargetType == model.GroupTarget {
+		memberList, err := service.GroupServiceInstance.Member(uint(*vo.GroupId))
 		if err != nil {
 			wsClient.WebSocketClient.SendMessageToOne(sendId, &model.Response{
 				Code:    http.StatusInternalServerError,
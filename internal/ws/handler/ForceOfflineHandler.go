@@ -0,0 +1,24 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// ForceOffline 通知用户被强制下线并断开其 ws 连接
+func (ws *WebSocketHandler) ForceOffline(userId int64, reason string) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.ForceLogout,
+			SendId: 0,
+			Data:   reason,
+			Time:   time.Now(),
+		},
+	})
+	wsClient.WebSocketClient.Disconnect(userId)
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// LinkPreviewNotice 链接预览生成后推送给会话中在线的用户，客户端据此补充消息的预览卡片
+func (ws *WebSocketHandler) LinkPreviewNotice(userIds []int64, notice model.LinkPreviewNotice) {
+	wsClient.WebSocketClient.SendMessageToMultiple(userIds, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.LinkPreview,
+			SendId: notice.SenderId,
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// MediaNotice 音视频处理完成后通知上传者
+func (ws *WebSocketHandler) MediaNotice(userId int64, notice model.MediaNotice) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.MediaProcessed,
+			SendId: 0,
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// MentionNotice 群消息 @ 到用户时单独推送，客户端据此高亮并跳转到被 @ 的消息
+func (ws *WebSocketHandler) MentionNotice(userId int64, notice model.MentionNotice) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.Mention,
+			SendId: int64(notice.SenderId),
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
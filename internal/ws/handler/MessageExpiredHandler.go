@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// MessageExpiredNotice 限时消息到期删除时推送给会话中在线的用户，客户端据此移除消息
+func (ws *WebSocketHandler) MessageExpiredNotice(userIds []int64, notice model.MessageExpiredNotice) {
+	wsClient.WebSocketClient.SendMessageToMultiple(userIds, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.MessageExpired,
+			SendId: notice.SenderId,
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// MessageRevokedNotice 消息被撤回时推送给会话中在线的用户，客户端据此将消息显示为已撤回
+func (ws *WebSocketHandler) MessageRevokedNotice(userIds []int64, notice model.MessageRevokedNotice) {
+	wsClient.WebSocketClient.SendMessageToMultiple(userIds, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.MessageRevoked,
+			SendId: int64(notice.OperatorId),
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
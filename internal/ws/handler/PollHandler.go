@@ -0,0 +1,22 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// PollNotice 投票结果变化时推送给在线的群成员
+func (ws *WebSocketHandler) PollNotice(userIds []int64, notice model.PollNotice) {
+	wsClient.WebSocketClient.SendMessageToMultiple(userIds, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type: wsMessage.PollUpdated,
+			Data: notice,
+			Time: time.Now(),
+		},
+	})
+}
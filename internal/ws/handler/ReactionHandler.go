@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// ReactionNotice 表情回应变化时推送给会话中在线的用户
+func (ws *WebSocketHandler) ReactionNotice(userIds []int64, notice model.ReactionNotice) {
+	wsClient.WebSocketClient.SendMessageToMultiple(userIds, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.Reaction,
+			SendId: int64(notice.UserId),
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// RedPacketNotice 红包被领取后通知发送者
+func (ws *WebSocketHandler) RedPacketNotice(userId int64, notice model.RedPacketNotice) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.RedPacketClaimed,
+			SendId: int64(notice.UserId),
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// ReportNotice 举报结案后通知举报人
+func (ws *WebSocketHandler) ReportNotice(userId int64, notice model.ReportNotice) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.ReportResult,
+			SendId: 0,
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
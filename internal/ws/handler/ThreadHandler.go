@@ -0,0 +1,23 @@
+package wsHandler
+
+import (
+	"go-chat/internal/model"
+	wsClient "go-chat/internal/ws/client"
+	wsMessage "go-chat/internal/ws/message"
+	"net/http"
+	"time"
+)
+
+// ThreadNotice 参与的话题有新回复时推送，附带接收者的未读回复数
+func (ws *WebSocketHandler) ThreadNotice(userId int64, notice model.ThreadNotice) {
+	wsClient.WebSocketClient.SendMessageToOne(userId, &model.Response{
+		Code:    http.StatusOK,
+		Message: "success",
+		Data: &wsMessage.Message{
+			Type:   wsMessage.ThreadReply,
+			SendId: int64(notice.SenderId),
+			Data:   notice,
+			Time:   time.Now(),
+		},
+	})
+}
@@ -1,11 +1,14 @@
 package wsHandler
 
 import (
+	"fmt"
+	interfacemanager "go-chat/internal/interfaces/manager"
 	interfacesservice "go-chat/internal/interfaces/service"
 	"go-chat/internal/model"
 	"go-chat/internal/utils/jsonUtil"
 	wsClient "go-chat/internal/ws/client"
 	wsMessage "go-chat/internal/ws/message"
+	"math"
 	"net/http"
 )
 
@@ -13,6 +16,7 @@ type WebSocketHandler struct {
 	userService    interfacesservice.UserServiceInterface
 	messageService interfacesservice.MessageServiceInterface
 	groupService   interfacesservice.GroupServiceInterface
+	rateLimiter    interfacemanager.RateLimitManager
 }
 
 var (
@@ -21,11 +25,13 @@ var (
 
 func InitWebSocketHandler(userService interfacesservice.UserServiceInterface,
 	messageService interfacesservice.MessageServiceInterface,
-	groupService interfacesservice.GroupServiceInterface) {
+	groupService interfacesservice.GroupServiceInterface,
+	rateLimiter interfacemanager.RateLimitManager) {
 	WebSocketHandlerInstance = &WebSocketHandler{
 		userService:    userService,
 		messageService: messageService,
 		groupService:   groupService,
+		rateLimiter:    rateLimiter,
 	}
 }
 func (ws *WebSocketHandler) MessageHandler(id int64, messageBytes []byte) {
@@ -40,11 +46,22 @@ func (ws *WebSocketHandler) MessageHandler(id int64, messageBytes []byte) {
 		})
 		return
 	}
+	if ws.rateLimiter != nil {
+		if result := ws.rateLimiter.AllowEvent(message.Type, id); result != nil && !result.Allowed {
+			wsClient.WebSocketClient.SendMessageToOne(id, &model.Response{
+				Code:    http.StatusTooManyRequests,
+				Message: fmt.Sprintf("请求过于频繁，请 %d 秒后再试", int64(math.Ceil(result.RetryAfter.Seconds()))),
+				Data:    nil,
+			})
+			return
+		}
+	}
+	// 发送者以连接的用户为准，忽略客户端传入的 send_id
 	switch message.Type {
 	case wsMessage.Chat:
-		ws.ChatHandler(message.SendId, message.Data)
+		ws.ChatHandler(id, message.Data)
 	case wsMessage.HeartBeat:
-		ws.HeartBeatHandler(message.SendId, message.Data)
+		ws.HeartBeatHandler(id, message.Data)
 	default:
 		wsClient.WebSocketClient.SendMessageToOne(id, &model.Response{
 			Code:    http.StatusBadRequest,
@@ -20,4 +20,25 @@ const (
 	HeartBeat = "heartbeat" //心跳检测
 
 	HeartBeatAck = "heartbeat_ack" //心跳检测确认
+
+	ForceLogout = "force_logout" // 被强制下线（封禁、管理员踢下线）
+
+	ReportResult = "report_result" // 举报处理结果
+
+	MediaProcessed = "media_processed" // 音视频处理完成
+
+	RedPacketClaimed = "red_packet_claimed" // 红包被领取
+
+	Mention = "mention" // 被 @ 提醒
+
+	Reaction = "reaction" // 表情回应变化
+
+	ThreadReply = "thread_reply" // 参与的话题有新回复
+
+	MessageExpired = "message_expired" // 限时消息到期删除
+	MessageRevoked = "message_revoked" // 消息被撤回
+
+	PollUpdated = "poll_updated" // 投票结果变化
+
+	LinkPreview = "link_preview" // 消息的链接预览已生成
 )
@@ -17,6 +17,46 @@
 SET NAMES utf8mb4;
 SET FOREIGN_KEY_CHECKS = 0;
 
+-- ----------------------------
+-- Table structure for admin_audit_logs
+-- ----------------------------
+DROP TABLE IF EXISTS `admin_audit_logs`;
+CREATE TABLE `admin_audit_logs`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `operator_id` bigint UNSIGNED NOT NULL COMMENT '操作的管理员ID',
+  `action` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '操作类型',
+  `target_type` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '操作对象类型 user/group/message',
+  `target_id` bigint UNSIGNED NOT NULL COMMENT '操作对象ID',
+  `reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '操作原因',
+  `detail` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL COMMENT '操作详情',
+  `ip` varchar(45) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '操作者IP',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_operator_id`(`operator_id` ASC) USING BTREE,
+  INDEX `idx_target`(`target_type` ASC, `target_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '平台管理员操作审计日志' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for conversation_clears
+-- ----------------------------
+DROP TABLE IF EXISTS `conversation_clears`;
+CREATE TABLE `conversation_clears`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '用户ID',
+  `target_type` int NULL DEFAULT NULL COMMENT '会话类型（0=私聊，1=群聊）',
+  `target_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '私聊为对方的用户ID，群聊为群ID',
+  `cleared_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '清空时会话中最新的消息ID，不大于该ID的消息不再显示',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_user_target`(`user_id` ASC, `target_type` ASC, `target_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '清空聊天记录的位置' ROW_FORMAT = Dynamic;
+
 -- ----------------------------
 -- Table structure for files
 -- ----------------------------
@@ -24,19 +64,28 @@ DROP TABLE IF EXISTS `files`;
 CREATE TABLE `files`  (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
   `user_id` bigint UNSIGNED NOT NULL COMMENT '上传的用户ID',
-  `type` enum('image','audio','video','file') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '文件类型',
+  `type` enum('image','audio','video','document','archive','code','file') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '文件类型',
   `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '文件原始名称',
   `ext` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '扩展名，如 jpg/mp3/mp4/zip',
   `mime` varchar(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT 'MIME类型，如 image/png',
   `size` bigint UNSIGNED NOT NULL COMMENT '文件大小（字节）',
   `url` text CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '文件访问URL',
+  `object_key` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '存储中的对象路径',
+  `sha256` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '文件内容SHA-256',
+  `ref_count` int NOT NULL DEFAULT 0 COMMENT '引用次数',
+  `orphaned_at` timestamp(3) NULL DEFAULT NULL COMMENT '引用数归零的时间，超过宽限期后回收',
   `width` int UNSIGNED NULL DEFAULT NULL COMMENT '图片/视频宽度(px)',
   `height` int UNSIGNED NULL DEFAULT NULL COMMENT '图片/视频高度(px)',
   `duration` float NULL DEFAULT NULL COMMENT '时长，单位秒（音视频）',
+  `variants` json NULL COMMENT '衍生文件（缩略图、WebP、视频封面等）',
+  `waveform` json NULL COMMENT '音频波形',
+  `media_status` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NULL DEFAULT NULL COMMENT '音视频处理状态 processing/ready/failed',
   `created_at` timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
   `updated_at` timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
   `deleted_at` timestamp(3) NULL DEFAULT NULL,
-  PRIMARY KEY (`id`) USING BTREE
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_files_sha256`(`sha256` ASC) USING BTREE,
+  INDEX `idx_files_orphaned_at`(`orphaned_at` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 4 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '通用文件存储表' ROW_FORMAT = Dynamic;
 
 -- ----------------------------
@@ -135,6 +184,44 @@ CREATE TABLE `groups`  (
   PRIMARY KEY (`id`) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 5 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci COMMENT = '群组表' ROW_FORMAT = Dynamic;
 
+-- ----------------------------
+-- Table structure for mentions
+-- ----------------------------
+DROP TABLE IF EXISTS `mentions`;
+CREATE TABLE `mentions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '被提醒的用户ID',
+  `group_id` bigint UNSIGNED NOT NULL COMMENT '群组ID',
+  `message_id` bigint UNSIGNED NOT NULL COMMENT '消息ID',
+  `sender_id` bigint UNSIGNED NOT NULL COMMENT '发送者ID',
+  `is_all` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否为@所有人',
+  `read_at` datetime(3) NULL DEFAULT NULL COMMENT '查看时间（null 未读）',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_user_group`(`user_id` ASC, `group_id` ASC) USING BTREE,
+  INDEX `idx_mentions_message_id`(`message_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '群消息@提醒' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for message_deletions
+-- ----------------------------
+DROP TABLE IF EXISTS `message_deletions`;
+CREATE TABLE `message_deletions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '用户ID',
+  `message_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '仅自己删除的消息ID',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_user_message`(`user_id` ASC, `message_id` ASC) USING BTREE,
+  INDEX `idx_message_deletions_message_id`(`message_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '仅自己删除的消息' ROW_FORMAT = Dynamic;
+
 -- ----------------------------
 -- Table structure for messages
 -- ----------------------------
@@ -148,16 +235,388 @@ CREATE TABLE `messages`  (
   `receiver_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '接收者ID（私聊使用）',
   `group_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '群组ID（群聊使用）',
   `reply_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '回复的消息ID',
+  `root_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '话题根消息ID',
   `reader_id_list` json NULL COMMENT '已读用户ID列表',
+  `played_id_list` json NULL COMMENT '已播放语音的用户ID列表',
   `target_type` int NOT NULL COMMENT '消息目标类型',
   `content` json NOT NULL COMMENT '富文本消息内容',
   `type` int NOT NULL COMMENT '消息类型',
   `status` int NULL DEFAULT NULL COMMENT '消息状态 1正常 0撤回',
   `extra_data` json NULL COMMENT '扩展字段',
+  `ttl` int NOT NULL DEFAULT 0 COMMENT '销毁时长（秒），0 为普通消息',
+  `burn_after_read` tinyint(1) NOT NULL DEFAULT 0 COMMENT '阅后即焚，接收者读取后开始计时',
+  `expire_at` datetime(3) NULL DEFAULT NULL COMMENT '销毁时间',
+  `link_previews` json NULL COMMENT '链接预览',
+  `revoked_by` bigint UNSIGNED NULL DEFAULT NULL COMMENT '撤回者ID',
+  `revoked_at` datetime(3) NULL DEFAULT NULL COMMENT '撤回时间',
   PRIMARY KEY (`id`) USING BTREE,
-  INDEX `idx_messages_deleted_at`(`deleted_at` ASC) USING BTREE
+  INDEX `idx_messages_deleted_at`(`deleted_at` ASC) USING BTREE,
+  INDEX `idx_messages_root_id`(`root_id` ASC) USING BTREE,
+  INDEX `idx_messages_expire_at`(`expire_at` ASC) USING BTREE,
+  INDEX `idx_messages_group`(`target_type` ASC, `group_id` ASC, `id` ASC) USING BTREE,
+  INDEX `idx_messages_private`(`target_type` ASC, `sender_id` ASC, `receiver_id` ASC, `id` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 32 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci COMMENT = '聊天消息表' ROW_FORMAT = Dynamic;
 
+-- ----------------------------
+-- Table structure for moderation_reviews
+-- ----------------------------
+DROP TABLE IF EXISTS `moderation_reviews`;
+CREATE TABLE `moderation_reviews`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `message_id` bigint UNSIGNED NOT NULL COMMENT '被标记的消息ID',
+  `sender_id` bigint UNSIGNED NOT NULL COMMENT '发送者ID',
+  `hits` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL COMMENT '命中详情（JSON）',
+  `content` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL COMMENT '审核前的原始内容（JSON）',
+  `status` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 待审核 1 通过 2 驳回',
+  `reviewer_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '审核人',
+  `reviewed_at` datetime(3) NULL DEFAULT NULL COMMENT '审核时间',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '审核备注',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_status`(`status` ASC) USING BTREE,
+  INDEX `idx_sender_id`(`sender_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '内容审核队列' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for pins
+-- ----------------------------
+DROP TABLE IF EXISTS `pins`;
+CREATE TABLE `pins`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '置顶时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `conversation` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '会话标识，私聊为 p{小id}-{大id}，群聊为 g{群id}',
+  `message_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '被置顶的消息ID',
+  `pinned_by` bigint UNSIGNED NULL DEFAULT NULL COMMENT '置顶的用户ID',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_pins_message_id`(`message_id` ASC) USING BTREE,
+  INDEX `idx_pins_conversation`(`conversation` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '置顶消息' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for poll_options
+-- ----------------------------
+DROP TABLE IF EXISTS `poll_options`;
+CREATE TABLE `poll_options`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `poll_id` bigint UNSIGNED NOT NULL COMMENT '投票ID',
+  `content` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '选项内容',
+  `vote_count` int NOT NULL DEFAULT 0 COMMENT '票数',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_poll_options_poll_id`(`poll_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '投票选项' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for poll_votes
+-- ----------------------------
+DROP TABLE IF EXISTS `poll_votes`;
+CREATE TABLE `poll_votes`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '投票时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `poll_id` bigint UNSIGNED NOT NULL COMMENT '投票ID',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '投票人ID',
+  `option_ids` json NULL COMMENT '选中的选项ID列表',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_poll_user`(`poll_id` ASC, `user_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '投票记录，每人每个投票一条' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for polls
+-- ----------------------------
+DROP TABLE IF EXISTS `polls`;
+CREATE TABLE `polls`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `creator_id` bigint UNSIGNED NOT NULL COMMENT '发起人ID',
+  `group_id` bigint UNSIGNED NOT NULL COMMENT '群组ID',
+  `message_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '对应的聊天消息ID',
+  `question` varchar(256) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '投票主题',
+  `multiple` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否多选',
+  `max_choices` int NOT NULL DEFAULT 1 COMMENT '最多可选的项数',
+  `anonymous` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否匿名',
+  `deadline` datetime(3) NULL DEFAULT NULL COMMENT '截止时间',
+  `status` int NOT NULL DEFAULT 0 COMMENT '0 进行中 1 已结束',
+  `voter_count` int NOT NULL DEFAULT 0 COMMENT '投票人数',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_polls_creator_id`(`creator_id` ASC) USING BTREE,
+  INDEX `idx_polls_group_id`(`group_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '群投票' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for reactions
+-- ----------------------------
+DROP TABLE IF EXISTS `reactions`;
+CREATE TABLE `reactions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '回应时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `message_id` bigint UNSIGNED NOT NULL COMMENT '消息ID',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '回应的用户ID',
+  `emoji` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT '表情',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_message_user_emoji`(`message_id` ASC, `user_id` ASC, `emoji` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '消息表情回应' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for red_packet_claims
+-- ----------------------------
+DROP TABLE IF EXISTS `red_packet_claims`;
+CREATE TABLE `red_packet_claims`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '领取时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `red_packet_id` bigint UNSIGNED NOT NULL COMMENT '红包ID',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '领取者ID',
+  `amount` bigint NOT NULL COMMENT '领取金额（分）',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_packet_user`(`red_packet_id` ASC, `user_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '红包领取记录' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for red_packets
+-- ----------------------------
+DROP TABLE IF EXISTS `red_packets`;
+CREATE TABLE `red_packets`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `sender_id` bigint UNSIGNED NOT NULL COMMENT '发送者ID',
+  `target_type` int NOT NULL COMMENT '0 私聊 1 群聊',
+  `receiver_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '私聊接收者ID',
+  `group_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '群组ID',
+  `message_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '对应的聊天消息ID',
+  `mode` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'fixed 普通红包 random 拼手气红包',
+  `total_amount` bigint NOT NULL COMMENT '总金额（分）',
+  `count` int NOT NULL COMMENT '红包个数',
+  `remain_amount` bigint NOT NULL COMMENT '剩余金额（分）',
+  `remain_count` int NOT NULL COMMENT '剩余个数',
+  `greeting` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '祝福语',
+  `status` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 可领取 1 已领完 2 已过期退款',
+  `expire_at` datetime(3) NOT NULL COMMENT '过期时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_red_packets_sender_id`(`sender_id` ASC) USING BTREE,
+  INDEX `idx_status_expire_at`(`status` ASC, `expire_at` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '红包' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for reports
+-- ----------------------------
+DROP TABLE IF EXISTS `reports`;
+CREATE TABLE `reports`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `reporter_id` bigint UNSIGNED NOT NULL COMMENT '举报人ID',
+  `target_type` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '举报对象类型 message/user/group',
+  `target_id` bigint UNSIGNED NOT NULL COMMENT '举报对象ID',
+  `category` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '举报类别',
+  `comment` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '举报说明',
+  `snapshot` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL COMMENT '举报时的对象快照（JSON）',
+  `status` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 待处理 1 处理中 2 已处理 3 已驳回',
+  `handler_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '处理人',
+  `result` varchar(500) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '处理结果说明',
+  `handled_at` datetime(3) NULL DEFAULT NULL COMMENT '处理完成时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_reporter_id`(`reporter_id` ASC) USING BTREE,
+  INDEX `idx_target`(`target_type` ASC, `target_id` ASC) USING BTREE,
+  INDEX `idx_status`(`status` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '用户举报' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for scheduled_messages
+-- ----------------------------
+DROP TABLE IF EXISTS `scheduled_messages`;
+CREATE TABLE `scheduled_messages`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `sender_id` bigint UNSIGNED NOT NULL COMMENT '发送者ID',
+  `target_type` int NOT NULL COMMENT '0 私聊 1 群聊',
+  `target_id` bigint UNSIGNED NOT NULL COMMENT '好友ID或群组ID',
+  `type` int NOT NULL COMMENT '消息类型',
+  `content` json NULL COMMENT '消息内容',
+  `reply_id` bigint NULL DEFAULT NULL COMMENT '回复的消息ID',
+  `ttl` int NOT NULL DEFAULT 0 COMMENT '发出的消息的销毁时长（秒）',
+  `burn_after_read` tinyint(1) NOT NULL DEFAULT 0 COMMENT '发出的消息是否阅后即焚',
+  `send_at` datetime(3) NOT NULL COMMENT '计划发送时间',
+  `status` int NOT NULL DEFAULT 0 COMMENT '0 待发送 1 发送中 2 已发送 3 已取消 4 发送失败',
+  `message_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '发送后生成的消息ID',
+  `fail_reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '发送失败的原因',
+  `claimed_at` datetime(3) NULL DEFAULT NULL COMMENT '领取发送的时间，发送中超过租约仍未完成的会被重新领取',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_scheduled_messages_sender_id`(`sender_id` ASC) USING BTREE,
+  INDEX `idx_status_send_at`(`status` ASC, `send_at` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '定时消息' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for sticker_favorites
+-- ----------------------------
+DROP TABLE IF EXISTS `sticker_favorites`;
+CREATE TABLE `sticker_favorites`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '收藏时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '用户ID',
+  `sticker_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '表情ID',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_user_sticker`(`user_id` ASC, `sticker_id` ASC) USING BTREE,
+  INDEX `idx_sticker_favorites_sticker_id`(`sticker_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '收藏的表情' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for sticker_packs
+-- ----------------------------
+DROP TABLE IF EXISTS `sticker_packs`;
+CREATE TABLE `sticker_packs`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `creator_id` bigint UNSIGNED NOT NULL COMMENT '创建者ID',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '名称',
+  `description` varchar(256) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '简介',
+  `cover` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '封面',
+  `sticker_count` int NOT NULL DEFAULT 0 COMMENT '表情数量',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_sticker_packs_creator_id`(`creator_id` ASC) USING BTREE,
+  INDEX `idx_sticker_packs_name`(`name` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '表情包' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for stickers
+-- ----------------------------
+DROP TABLE IF EXISTS `stickers`;
+CREATE TABLE `stickers`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `pack_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '所属表情包ID',
+  `group_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '所属群ID（群自定义表情）',
+  `name` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '名称，群自定义表情为群内唯一的短代码',
+  `keywords` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '搜索关键字，空格分隔',
+  `url` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '图片地址',
+  `file_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '上传的文件ID',
+  `width` int UNSIGNED NULL DEFAULT NULL COMMENT '图片宽度',
+  `height` int UNSIGNED NULL DEFAULT NULL COMMENT '图片高度',
+  `creator_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '上传者ID',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_group_name`(`group_id` ASC, `name` ASC) USING BTREE,
+  INDEX `idx_stickers_pack_id`(`pack_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '表情包中的表情和群自定义表情' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for thread_participants
+-- ----------------------------
+DROP TABLE IF EXISTS `thread_participants`;
+CREATE TABLE `thread_participants`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '参与时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `root_id` bigint UNSIGNED NOT NULL COMMENT '话题根消息ID',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '参与者ID',
+  `read_count` int NOT NULL DEFAULT 0 COMMENT '已读的回复数',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_root_user`(`root_id` ASC, `user_id` ASC) USING BTREE,
+  INDEX `idx_thread_participants_user_id`(`user_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '话题参与者' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for threads
+-- ----------------------------
+DROP TABLE IF EXISTS `threads`;
+CREATE TABLE `threads`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `root_id` bigint UNSIGNED NOT NULL COMMENT '根消息ID',
+  `reply_count` int NOT NULL DEFAULT 0 COMMENT '回复数',
+  `last_reply_id` bigint UNSIGNED NOT NULL DEFAULT 0 COMMENT '最后一条回复ID',
+  `last_reply_at` datetime(3) NULL DEFAULT NULL COMMENT '最后回复时间',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_threads_root_id`(`root_id` ASC) USING BTREE,
+  INDEX `idx_threads_last_reply_at`(`last_reply_at` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '消息话题' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for upload_sessions
+-- ----------------------------
+DROP TABLE IF EXISTS `upload_sessions`;
+CREATE TABLE `upload_sessions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `upload_id` varchar(36) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '上传ID',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '上传者ID',
+  `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '原始文件名',
+  `size` bigint NOT NULL COMMENT '文件总大小（字节）',
+  `sha256` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '客户端声明的文件 SHA-256',
+  `part_size` bigint NOT NULL COMMENT '分片大小',
+  `part_count` int NOT NULL COMMENT '分片数量',
+  `status` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 上传中 1 已完成 2 已取消/已过期',
+  `file_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '完成后生成的文件ID',
+  `expire_at` datetime(3) NOT NULL COMMENT '过期时间',
+  `proof_offset` bigint NOT NULL DEFAULT 0 COMMENT '秒传校验区间起点',
+  `proof_length` bigint NOT NULL DEFAULT 0 COMMENT '秒传校验区间长度，0 表示不能秒传',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_upload_sessions_upload_id`(`upload_id` ASC) USING BTREE,
+  INDEX `idx_status_expire_at`(`status` ASC, `expire_at` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '分片上传会话' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for user_identities
+-- ----------------------------
+DROP TABLE IF EXISTS `user_identities`;
+CREATE TABLE `user_identities`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL DEFAULT NULL,
+  `updated_at` datetime(3) NULL DEFAULT NULL,
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '本地用户ID',
+  `provider` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '身份提供方名称',
+  `subject` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '提供方用户唯一标识(sub)',
+  `email` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '提供方返回的邮箱',
+  `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '提供方返回的名称',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `uk_provider_subject`(`provider` ASC, `subject` ASC) USING BTREE,
+  INDEX `idx_user_id`(`user_id` ASC) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '外部身份绑定表' ROW_FORMAT = Dynamic;
+
 -- ----------------------------
 -- Table structure for users
 -- ----------------------------
@@ -173,6 +632,7 @@ CREATE TABLE `users`  (
   `desc` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL COMMENT '简介',
   `phone` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '用户手机号',
   `email` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '用户邮箱',
+  `email_verified` tinyint(1) NOT NULL DEFAULT 0 COMMENT '邮箱是否已验证，修改邮箱后需重新验证',
   `avatar` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '用户头像URL（可选）',
   `client_ip` varchar(45) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '客户端IP地址',
   `client_port` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '客户端端口号',
@@ -182,14 +642,54 @@ CREATE TABLE `users`  (
   `status` int UNSIGNED NOT NULL DEFAULT 1 COMMENT '用户状态（如激活、禁用等）',
   `online_status` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '用户在线状态（如在线、离线、忙碌等）',
   `device_info` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '客户端设备信息',
+  `role` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '平台角色（0=普通用户，1=平台管理员）',
+  `ban_reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '封禁原因',
+  `ban_end` datetime(3) NULL DEFAULT NULL COMMENT '封禁截止时间（为空表示永久）',
   PRIMARY KEY (`id`) USING BTREE,
   INDEX `idx_username`(`username` ASC) USING BTREE,
   INDEX `idx_email`(`email` ASC) USING BTREE,
   INDEX `idx_phone`(`phone` ASC) USING BTREE,
   INDEX `idx_online_status`(`online_status` ASC) USING BTREE,
+  INDEX `idx_status_ban_end`(`status` ASC, `ban_end` ASC) USING BTREE,
   INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
 ) ENGINE = InnoDB AUTO_INCREMENT = 1007 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci ROW_FORMAT = Dynamic;
 
+-- ----------------------------
+-- Table structure for wallet_logs
+-- ----------------------------
+DROP TABLE IF EXISTS `wallet_logs`;
+CREATE TABLE `wallet_logs`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '用户ID',
+  `type` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'recharge 充值 send_packet 发红包 claim_packet 抢红包 refund_packet 红包退款',
+  `amount` bigint NOT NULL COMMENT '变动金额（分），支出为负数',
+  `red_packet_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '关联的红包ID',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NULL DEFAULT NULL COMMENT '备注',
+  `operator_id` bigint UNSIGNED NULL DEFAULT NULL COMMENT '充值操作的管理员ID',
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_wallet_logs_user_id`(`user_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '钱包流水' ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Table structure for wallets
+-- ----------------------------
+DROP TABLE IF EXISTS `wallets`;
+CREATE TABLE `wallets`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `created_at` datetime(3) NULL DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) NULL DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) NULL DEFAULT NULL COMMENT '删除时间',
+  `user_id` bigint UNSIGNED NOT NULL COMMENT '用户ID',
+  `balance` bigint NOT NULL DEFAULT 0 COMMENT '余额（分）',
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_wallets_user_id`(`user_id` ASC) USING BTREE,
+  INDEX `idx_deleted_at`(`deleted_at` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '用户钱包' ROW_FORMAT = Dynamic;
+
 -- ----------------------------
 -- Procedure structure for GenerateTestUsers
 -- ----------------------------
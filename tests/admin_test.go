@@ -0,0 +1,226 @@
+package tests
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-chat/internal/middleware"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// adminWsRecorder 记录被强制下线的用户
+type adminWsRecorder struct {
+	fakeWsHandler
+	mu      sync.Mutex
+	offline []int64
+}
+
+func (r *adminWsRecorder) ForceOffline(userId int64, _ string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offline = append(r.offline, userId)
+}
+
+func (r *adminWsRecorder) kicked() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.offline...)
+}
+
+type adminFixture struct {
+	users    *fakeUserRepository
+	audits   *fakeAdminAuditLogRepository
+	sessions *fakeSessionManager
+	ws       *adminWsRecorder
+	service  *service.AdminService
+}
+
+var (
+	adminFixtureOnce   sync.Once
+	sharedAdminFixture *adminFixture
+)
+
+func newAdminFixture(t *testing.T) *adminFixture {
+	adminFixtureOnce.Do(func() {
+		f := &adminFixture{
+			users:    newFakeUserRepository(),
+			audits:   &fakeAdminAuditLogRepository{},
+			sessions: &fakeSessionManager{},
+			ws:       &adminWsRecorder{},
+		}
+		service.InitAdminService(f.users, nil, nil, f.audits, nil, f.sessions, f.ws, nil)
+		f.service = service.AdminServiceInstance
+		sharedAdminFixture = f
+	})
+	fakeTxMysql(t)
+	return sharedAdminFixture
+}
+
+func (f *adminFixture) user(t *testing.T, name string, role model.UserRole) *model.User {
+	user := &model.User{Username: name, Role: role, Status: model.Enable, OnlineStatus: model.Online}
+	if err := f.users.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestAdminBanUser(t *testing.T) {
+	f := newAdminFixture(t)
+	admin := f.user(t, "ban-admin", model.PlatformAdmin)
+	otherAdmin := f.user(t, "ban-other-admin", model.PlatformAdmin)
+	target := f.user(t, "ban-target", model.NormalUser)
+	issuedAt := time.Now().Add(-time.Second)
+
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: admin.ID, Reason: "x"}); err == nil {
+		t.Fatal("不能封禁自己")
+	}
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: otherAdmin.ID, Reason: "x"}); err == nil {
+		t.Fatal("不能封禁平台管理员")
+	}
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: target.ID, Reason: "x", Duration: -1}); err == nil {
+		t.Fatal("封禁时长不能为负数")
+	}
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: 9999, Reason: "x"}); err == nil {
+		t.Fatal("不能封禁不存在的用户")
+	}
+	if len(f.audits.actions()) != 0 || len(f.ws.kicked()) != 0 {
+		t.Fatal("封禁失败时不应记录审计日志或踢人")
+	}
+
+	// 限时封禁：状态改为禁用、记录原因和截止时间，吊销 token 并踢下线
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: target.ID, Reason: "广告", Duration: 3600}); err != nil {
+		t.Fatal(err)
+	}
+	banned, _ := f.users.GetById(target.ID)
+	if !banned.IsBanned() || banned.OnlineStatus != model.Offline {
+		t.Fatalf("用户应被封禁并下线: %+v", banned)
+	}
+	if banned.BanReason == nil || *banned.BanReason != "广告" {
+		t.Fatalf("封禁原因不正确: %v", banned.BanReason)
+	}
+	if banned.BanEnd == nil || banned.BanEnd.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("封禁截止时间不正确: %v", banned.BanEnd)
+	}
+	if !f.sessions.IsTokenRevoked(target.ID, issuedAt) {
+		t.Fatal("封禁后已签发的 token 应失效")
+	}
+	if !reflect.DeepEqual(f.ws.kicked(), []int64{int64(target.ID)}) {
+		t.Fatalf("封禁后应断开用户的连接: %v", f.ws.kicked())
+	}
+	if !reflect.DeepEqual(f.audits.actions(), []model.AdminAction{model.BanUserAction}) {
+		t.Fatalf("封禁应记录审计日志: %v", f.audits.actions())
+	}
+
+	// 不填时长为永久封禁
+	forever := f.user(t, "ban-forever", model.NormalUser)
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: forever.ID, Reason: "诈骗"}); err != nil {
+		t.Fatal(err)
+	}
+	if banned, _ = f.users.GetById(forever.ID); !banned.IsBanned() || banned.BanEnd != nil {
+		t.Fatalf("应为永久封禁: %+v", banned)
+	}
+}
+
+func TestAdminUnbanUser(t *testing.T) {
+	f := newAdminFixture(t)
+	admin := f.user(t, "unban-admin", model.PlatformAdmin)
+	target := f.user(t, "unban-target", model.NormalUser)
+	audits := len(f.audits.actions())
+
+	if err := f.service.UnbanUser(admin.ID, "127.0.0.1", request.AdminUserOperateRequest{UserId: target.ID}); err == nil {
+		t.Fatal("未被封禁的用户不能解封")
+	}
+	if err := f.service.BanUser(admin.ID, "127.0.0.1", request.AdminBanRequest{UserId: target.ID, Reason: "刷屏", Duration: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.UnbanUser(admin.ID, "127.0.0.1", request.AdminUserOperateRequest{UserId: target.ID, Reason: "申诉通过"}); err != nil {
+		t.Fatal(err)
+	}
+	unbanned, _ := f.users.GetById(target.ID)
+	if unbanned.IsBanned() || unbanned.Status != model.Enable || unbanned.BanReason != nil || unbanned.BanEnd != nil {
+		t.Fatalf("解封后应恢复正常并清空封禁信息: %+v", unbanned)
+	}
+	actions := f.audits.actions()[audits:]
+	if !reflect.DeepEqual(actions, []model.AdminAction{model.BanUserAction, model.UnbanUserAction}) {
+		t.Fatalf("封禁和解封都应记录审计日志: %v", actions)
+	}
+	if err := f.service.UnbanUser(admin.ID, "127.0.0.1", request.AdminUserOperateRequest{UserId: target.ID}); err == nil {
+		t.Fatal("不能重复解封")
+	}
+}
+
+func TestAdminForceLogout(t *testing.T) {
+	f := newAdminFixture(t)
+	admin := f.user(t, "logout-admin", model.PlatformAdmin)
+	target := f.user(t, "logout-target", model.NormalUser)
+	issuedAt := time.Now().Add(-time.Second)
+	audits := len(f.audits.actions())
+
+	if err := f.service.ForceLogout(admin.ID, "127.0.0.1", request.AdminUserOperateRequest{UserId: 9999}); err == nil {
+		t.Fatal("不能强制下线不存在的用户")
+	}
+	if err := f.service.ForceLogout(admin.ID, "127.0.0.1", request.AdminUserOperateRequest{UserId: target.ID, Reason: "异常登录"}); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := f.users.GetById(target.ID)
+	if user.OnlineStatus != model.Offline || user.IsBanned() {
+		t.Fatalf("强制下线只修改在线状态: %+v", user)
+	}
+	if !f.sessions.IsTokenRevoked(target.ID, issuedAt) {
+		t.Fatal("强制下线后已签发的 token 应失效")
+	}
+	if f.sessions.IsTokenRevoked(target.ID, time.Now().Add(time.Second)) {
+		t.Fatal("重新登录签发的 token 应有效")
+	}
+	kicked := f.ws.kicked()
+	if len(kicked) == 0 || kicked[len(kicked)-1] != int64(target.ID) {
+		t.Fatalf("强制下线应断开用户的连接: %v", kicked)
+	}
+	if actions := f.audits.actions()[audits:]; !reflect.DeepEqual(actions, []model.AdminAction{model.ForceLogoutAction}) {
+		t.Fatalf("强制下线应记录审计日志: %v", actions)
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newAdminFixture(t)
+	admin := f.user(t, "middleware-admin", model.PlatformAdmin)
+	normal := f.user(t, "middleware-normal", model.NormalUser)
+	bannedAdmin := f.user(t, "middleware-banned-admin", model.PlatformAdmin)
+	if err := f.users.UpdateFields(bannedAdmin.ID, map[string]interface{}{"status": model.Disable}); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(userId uint) int {
+		router := gin.New()
+		router.GET("/admin", func(c *gin.Context) {
+			// 模拟 AuthMiddleware 写入的用户 id
+			c.Set("id", userId)
+		}, middleware.AdminMiddleware(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		return w.Code
+	}
+
+	for name, c := range map[string]struct {
+		userId uint
+		code   int
+	}{
+		"普通用户":    {normal.ID, http.StatusForbidden},
+		"被封禁的管理员": {bannedAdmin.ID, http.StatusForbidden},
+		"不存在的用户":  {9999, http.StatusForbidden},
+		"平台管理员":   {admin.ID, http.StatusOK},
+	} {
+		if code := do(c.userId); code != c.code {
+			t.Fatalf("%s: 状态码应为 %d，实际 %d", name, c.code, code)
+		}
+	}
+}
@@ -0,0 +1,1909 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lty120712/gorm-pagination/pagination"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/utils"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 以下为不依赖 MySQL 的内存版仓库和 ws 处理器，供服务层测试注入使用
+
+type fakeUserRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	users  map[uint]*model.User
+}
+
+func newFakeUserRepository() *fakeUserRepository {
+	return &fakeUserRepository{nextId: 1, users: make(map[uint]*model.User)}
+}
+
+func (r *fakeUserRepository) GetById(id uint, _ ...*gorm.DB) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) GetByName(username *string, _ ...*gorm.DB) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Username == *username {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) ListByEmail(email string, _ ...*gorm.DB) ([]*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*model.User
+	for _, u := range r.users {
+		if u.Email != nil && *u.Email == email {
+			copied := *u
+			users = append(users, &copied)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *fakeUserRepository) Save(user *model.User, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.ID = r.nextId
+	user.CreatedAt = time.Now()
+	r.nextId++
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	return applyUpdates(u, updates)
+}
+
+func (r *fakeUserRepository) GetNickNamesByIds(ids []uint, _ ...*gorm.DB) (map[uint]string, error) {
+	result := make(map[uint]string)
+	for _, id := range ids {
+		if u, _ := r.GetById(id); u != nil && u.Nickname != nil {
+			result[id] = *u.Nickname
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeUserRepository) GetNickNamesById(id uint, _ ...*gorm.DB) (string, error) {
+	if u, _ := r.GetById(id); u != nil && u.Nickname != nil {
+		return *u.Nickname, nil
+	}
+	return "", nil
+}
+
+func (r *fakeUserRepository) GetByIdList(userIdList []uint, _ ...*gorm.DB) ([]model.User, error) {
+	var users []model.User
+	for _, id := range userIdList {
+		if u, _ := r.GetById(id); u != nil {
+			// 服务层会就地改写昵称（如换成群昵称），复制一份避免改到仓库中的数据
+			if u.Nickname != nil {
+				nickname := *u.Nickname
+				u.Nickname = &nickname
+			}
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) GetVoById(id uint, _ ...*gorm.DB) (response.UserVO, error) {
+	u, _ := r.GetById(id)
+	if u == nil {
+		return response.UserVO{}, nil
+	}
+	return response.UserVO{Id: u.ID, Username: u.Username, Nickname: u.Nickname, Email: u.Email}, nil
+}
+
+func (r *fakeUserRepository) UpdateHeartbeatTime(int64, int64, ...*gorm.DB) error {
+	return nil
+}
+
+func (r *fakeUserRepository) GetUsersWithHeartbeatBefore(int64, ...*gorm.DB) ([]model.User, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepository) PageForAdmin(request.AdminUserSearchRequest, ...*gorm.DB) (*pagination.PageResult[response.AdminUserVo], error) {
+	return &pagination.PageResult[response.AdminUserVo]{}, nil
+}
+
+func (r *fakeUserRepository) UnbanExpired(time.Time, ...*gorm.DB) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeUserRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.users)
+}
+
+type fakeUserIdentityRepository struct {
+	mu         sync.Mutex
+	nextId     uint
+	identities []*model.UserIdentity
+}
+
+func (r *fakeUserIdentityRepository) Create(identity *model.UserIdentity, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	identity.ID = r.nextId
+	copied := *identity
+	r.identities = append(r.identities, &copied)
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) GetByProviderAndSubject(provider, subject string, _ ...*gorm.DB) (*model.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			copied := *i
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserIdentityRepository) GetByUserId(userId uint, _ ...*gorm.DB) ([]model.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []model.UserIdentity
+	for _, i := range r.identities {
+		if i.UserId == userId {
+			result = append(result, *i)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeUserIdentityRepository) UpdateFields(uint, map[string]interface{}, ...*gorm.DB) error {
+	return nil
+}
+
+type fakeWsHandler struct{}
+
+func (fakeWsHandler) ChatHandler(int64, interface{})                           {}
+func (fakeWsHandler) HeartBeatHandler(int64, interface{})                      {}
+func (fakeWsHandler) OnlineStatusNotice(int64, model.OnlineStatusNotice)       {}
+func (fakeWsHandler) ForceOffline(int64, string)                               {}
+func (fakeWsHandler) ReportNotice(int64, model.ReportNotice)                   {}
+func (fakeWsHandler) MediaNotice(int64, model.MediaNotice)                     {}
+func (fakeWsHandler) RedPacketNotice(int64, model.RedPacketNotice)             {}
+func (fakeWsHandler) MentionNotice(int64, model.MentionNotice)                 {}
+func (fakeWsHandler) ReactionNotice([]int64, model.ReactionNotice)             {}
+func (fakeWsHandler) ThreadNotice(int64, model.ThreadNotice)                   {}
+func (fakeWsHandler) MessageExpiredNotice([]int64, model.MessageExpiredNotice) {}
+func (fakeWsHandler) MessageRevokedNotice([]int64, model.MessageRevokedNotice) {}
+func (fakeWsHandler) PollNotice([]int64, model.PollNotice)                     {}
+func (fakeWsHandler) LinkPreviewNotice([]int64, model.LinkPreviewNotice)       {}
+func (fakeWsHandler) DeliverMessage(int64, *response.MessageVo)                {}
+
+// fakeSessionManager 在内存中记录每个用户的 token 失效时间点
+type fakeSessionManager struct {
+	mu        sync.Mutex
+	revokedAt map[uint]time.Time
+}
+
+func (m *fakeSessionManager) RevokeUserTokens(userId uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.revokedAt == nil {
+		m.revokedAt = make(map[uint]time.Time)
+	}
+	m.revokedAt[userId] = time.Now()
+	return nil
+}
+
+func (m *fakeSessionManager) IsTokenRevoked(userId uint, issuedAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revokedAt, ok := m.revokedAt[userId]
+	return ok && !issuedAt.After(revokedAt)
+}
+
+type fakeAdminAuditLogRepository struct {
+	mu   sync.Mutex
+	logs []model.AdminAuditLog
+}
+
+func (r *fakeAdminAuditLogRepository) Create(log *model.AdminAuditLog, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log.ID = uint(len(r.logs) + 1)
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+func (r *fakeAdminAuditLogRepository) Page(request.AdminAuditLogQueryRequest, ...*gorm.DB) (*pagination.PageResult[model.AdminAuditLog], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &pagination.PageResult[model.AdminAuditLog]{Records: append([]model.AdminAuditLog(nil), r.logs...), Total: int64(len(r.logs))}, nil
+}
+
+func (r *fakeAdminAuditLogRepository) actions() []model.AdminAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := make([]model.AdminAction, 0, len(r.logs))
+	for _, log := range r.logs {
+		actions = append(actions, log.Action)
+	}
+	return actions
+}
+
+type fakeFileRepository struct {
+	mu       sync.Mutex
+	nextId   uint
+	files    []*model.File
+	visible  map[uint][]string
+	stickers *fakeStickerRepository     // 设置后表情包中的表情对所有人可见
+	members  *fakeGroupMemberRepository // 设置后群自定义表情对群成员可见
+}
+
+func (r *fakeFileRepository) Create(file *model.File, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	file.ID = r.nextId
+	copied := *file
+	r.files = append(r.files, &copied)
+	return nil
+}
+
+func (r *fakeFileRepository) GetById(id uint, _ ...*gorm.DB) (*model.File, error) {
+	return r.get(id), nil
+}
+
+func (r *fakeFileRepository) GetBySha256(sha256 string, _ ...*gorm.DB) (*model.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.Sha256 == sha256 {
+			copied := *f
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeFileRepository) GetBySha256AndUser(sha256 string, userId uint, _ ...*gorm.DB) (*model.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.Sha256 == sha256 && f.UserID == userId {
+			copied := *f
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeFileRepository) IsOwnedBy(sha256 string, userId uint, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.Sha256 == sha256 && f.UserID == userId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsVisibleTo visible 中记录 用户ID -> 可见的文件地址
+func (r *fakeFileRepository) IsVisibleTo(urls []string, userId uint, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.visible[userId] {
+		for _, url := range urls {
+			if u == url {
+				return true, nil
+			}
+		}
+	}
+	if r.stickers == nil {
+		return false, nil
+	}
+	r.stickers.mu.Lock()
+	defer r.stickers.mu.Unlock()
+	for _, sticker := range r.stickers.stickers {
+		for _, url := range urls {
+			if sticker.Url != url {
+				continue
+			}
+			if sticker.PackId != nil || (sticker.GroupId != nil && r.members.ExistsByGroupIdAndUserId(*sticker.GroupId, userId)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// show 模拟用户能看到引用这些地址的消息
+func (r *fakeFileRepository) show(userId uint, urls ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.visible == nil {
+		r.visible = make(map[uint][]string)
+	}
+	r.visible[userId] = append(r.visible[userId], urls...)
+}
+
+func (r *fakeFileRepository) Usage(userId uint, _ ...*gorm.DB) (int64, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files, size int64
+	first := make(map[string]uint)
+	for _, f := range r.files {
+		if _, ok := first[f.Sha256]; !ok {
+			first[f.Sha256] = f.ID
+		}
+		if f.UserID == userId && first[f.Sha256] == f.ID {
+			files++
+			size += int64(f.Size)
+		}
+	}
+	return files, size, nil
+}
+
+func (r *fakeFileRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.ID == id {
+			setFileFields(f, updates)
+		}
+	}
+	return nil
+}
+
+func (r *fakeFileRepository) UpdateBySha256(sha256 string, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.Sha256 == sha256 {
+			setFileFields(f, updates)
+		}
+	}
+	return nil
+}
+
+func setFileFields(f *model.File, updates map[string]interface{}) {
+	if orphanedAt, ok := updates["orphaned_at"].(time.Time); ok {
+		f.OrphanedAt = &orphanedAt
+	}
+	if status, ok := updates["media_status"].(model.MediaStatus); ok {
+		f.MediaStatus = status
+	}
+	if duration, ok := updates["duration"].(*float64); ok {
+		f.Duration = duration
+	}
+	if variants, ok := updates["variants"].(*model.FileVariantList); ok {
+		f.Variants = variants
+	}
+	if waveform, ok := updates["waveform"].(*model.WaveformList); ok {
+		f.Waveform = waveform
+	}
+}
+
+func (r *fakeFileRepository) AdjustRefs(sha256s []string, delta int, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sha := range sha256s {
+		for _, f := range r.files {
+			if f.Sha256 != sha {
+				continue
+			}
+			f.RefCount = max(f.RefCount+delta, 0)
+			if f.RefCount == 0 {
+				now := time.Now()
+				f.OrphanedAt = &now
+			} else {
+				f.OrphanedAt = nil
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeFileRepository) ListOrphaned(before time.Time, limit int, _ ...*gorm.DB) ([]model.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files []model.File
+	for _, f := range r.files {
+		if f.RefCount <= 0 && f.OrphanedAt != nil && f.OrphanedAt.Before(before) && len(files) < limit {
+			files = append(files, *f)
+		}
+	}
+	return files, nil
+}
+
+func (r *fakeFileRepository) DeleteOrphaned(id uint, before time.Time, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, f := range r.files {
+		if f.ID == id && f.RefCount <= 0 && f.OrphanedAt != nil && f.OrphanedAt.Before(before) {
+			r.files = append(r.files[:i], r.files[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeFileRepository) get(id uint) *model.File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.files {
+		if f.ID == id {
+			copied := *f
+			return &copied
+		}
+	}
+	return nil
+}
+
+type fakeUploadSessionRepository struct {
+	mu       sync.Mutex
+	nextId   uint
+	sessions map[uint]*model.UploadSession
+}
+
+func newFakeUploadSessionRepository() *fakeUploadSessionRepository {
+	return &fakeUploadSessionRepository{sessions: make(map[uint]*model.UploadSession)}
+}
+
+func (r *fakeUploadSessionRepository) Create(session *model.UploadSession, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	session.ID = r.nextId
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeUploadSessionRepository) GetByUploadId(uploadId string, _ ...*gorm.DB) (*model.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.UploadId == uploadId {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUploadSessionRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil
+	}
+	for k, v := range updates {
+		switch k {
+		case "status":
+			s.Status = v.(model.UploadStatus)
+		case "file_id":
+			fileId := v.(uint)
+			s.FileID = &fileId
+		case "proof_length":
+			s.ProofLength = int64(v.(int))
+		}
+	}
+	return nil
+}
+
+func (r *fakeUploadSessionRepository) ListExpired(now time.Time, limit int, _ ...*gorm.DB) ([]model.UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []model.UploadSession
+	for _, s := range r.sessions {
+		if s.Status == model.UploadUploading && s.ExpireAt.Before(now) && len(sessions) < limit {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+var fakeSchemaCache sync.Map
+
+// applyUpdates 按 gorm 列名把 UpdateFields 的更新写入内存中的实体
+func applyUpdates(dest interface{}, updates map[string]interface{}) error {
+	s, err := schema.Parse(dest, &fakeSchemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+	value := reflect.ValueOf(dest)
+	for column, v := range updates {
+		field := s.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("未知字段: %s", column)
+		}
+		if err := field.Set(context.Background(), value, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deepCopy 通过 JSON 复制实体，避免服务层修改指针字段时影响仓库中保存的数据
+func deepCopy[T any](src *T) *T {
+	data, _ := json.Marshal(src)
+	var dst T
+	_ = json.Unmarshal(data, &dst)
+	return &dst
+}
+
+type fakeMessageRepository struct {
+	mu        sync.Mutex
+	nextId    uint
+	messages  map[uint]*model.Message
+	deletions *fakeMessageDeletionRepository // 查询历史消息时排除用户删除和清空的消息
+}
+
+func newFakeMessageRepository() *fakeMessageRepository {
+	return &fakeMessageRepository{messages: make(map[uint]*model.Message)}
+}
+
+func (r *fakeMessageRepository) Save(message *model.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	message.ID = r.nextId
+	message.CreatedAt = time.Now()
+	message.UpdatedAt = message.CreatedAt
+	r.messages[message.ID] = deepCopy(message)
+	return nil
+}
+
+func (r *fakeMessageRepository) GetById(id uint) (*model.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.messages[id]; ok {
+		return deepCopy(m), nil
+	}
+	return nil, nil
+}
+
+func (r *fakeMessageRepository) GetByIdList(ids []uint) ([]*model.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []*model.Message
+	for _, id := range ids {
+		if m, ok := r.messages[id]; ok {
+			messages = append(messages, deepCopy(m))
+		}
+	}
+	return messages, nil
+}
+
+func (r *fakeMessageRepository) UpdateFields(id uint, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.messages[id]; ok {
+		return applyUpdates(m, fields)
+	}
+	return nil
+}
+
+func (r *fakeMessageRepository) Delete(id uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *fakeMessageRepository) QueryHistoryMessages(userId uint, req *request.QueryMessagesRequest) ([]*model.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []*model.Message
+	now := time.Now()
+	after := req.Direction == request.QueryAfter
+	for _, m := range r.messages {
+		if *m.TargetType != *req.TargetType {
+			continue
+		}
+		if (after && m.ID <= req.Cursor) || (!after && req.Cursor > 0 && m.ID >= req.Cursor) {
+			continue
+		}
+		if (len(req.MessageTypes) > 0 && !utils.Contains(req.MessageTypes, *m.Type)) ||
+			(req.SenderId > 0 && uint(m.SenderId) != req.SenderId) || (req.HasAttachment && !hasAttachment(m)) {
+			continue
+		}
+		if m.ExpireAt != nil && !m.ExpireAt.After(now) {
+			continue
+		}
+		if r.deletions != nil && r.deletions.hidden(userId, *req.TargetType, req.TargetId, m.ID) {
+			continue
+		}
+		switch *m.TargetType {
+		case model.PrivateTarget:
+			sender, receiver := uint(m.SenderId), uint(*m.ReceiverId)
+			if !(sender == userId && receiver == req.TargetId) && !(sender == req.TargetId && receiver == userId) {
+				continue
+			}
+		case model.GroupTarget:
+			if uint(*m.GroupId) != req.TargetId {
+				continue
+			}
+		}
+		messages = append(messages, deepCopy(m))
+	}
+	sort.Slice(messages, func(i, j int) bool { return (messages[i].ID > messages[j].ID) != after })
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+	if len(messages) > limit+1 {
+		messages = messages[:limit+1]
+	}
+	return messages, nil
+}
+
+func hasAttachment(m *model.Message) bool {
+	if m.Content == nil {
+		return false
+	}
+	for _, p := range *m.Content {
+		for _, contentType := range model.AttachmentTypes {
+			if p.Type == contentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *fakeMessageRepository) QueryThreadReplies(userId uint, rootId uint, targetType model.TargetType, targetId uint, cursor uint, limit int) ([]*model.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []*model.Message
+	now := time.Now()
+	for _, m := range r.messages {
+		if m.RootId == nil || uint(*m.RootId) != rootId || m.ID <= cursor {
+			continue
+		}
+		if m.ExpireAt != nil && !m.ExpireAt.After(now) {
+			continue
+		}
+		if r.deletions != nil && r.deletions.hidden(userId, targetType, targetId, m.ID) {
+			continue
+		}
+		messages = append(messages, deepCopy(m))
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	if len(messages) > limit+1 {
+		messages = messages[:limit+1]
+	}
+	return messages, nil
+}
+
+type fakeGroupRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	groups map[uint]*model.Group
+}
+
+func newFakeGroupRepository() *fakeGroupRepository {
+	return &fakeGroupRepository{groups: make(map[uint]*model.Group)}
+}
+
+func (r *fakeGroupRepository) ExistsByCode(code string, _ ...*gorm.DB) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, g := range r.groups {
+		if g.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeGroupRepository) Save(group *model.Group, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if group.ID == 0 {
+		r.nextId++
+		group.ID = r.nextId
+	}
+	copied := *group
+	r.groups[group.ID] = &copied
+	return nil
+}
+
+func (r *fakeGroupRepository) Page(request.GroupSearchRequest, ...*gorm.DB) (*pagination.PageResult[model.Group], error) {
+	return &pagination.PageResult[model.Group]{}, nil
+}
+
+func (r *fakeGroupRepository) GetByID(groupID uint, _ ...*gorm.DB) (*model.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.groups[groupID]; ok {
+		copied := *g
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeGroupRepository) Delete(groupId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.groups, groupId)
+	return nil
+}
+
+func (r *fakeGroupRepository) Update(groupId uint, m map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.groups[groupId]; ok {
+		return applyUpdates(g, m)
+	}
+	return nil
+}
+
+type fakeGroupMemberRepository struct {
+	mu      sync.Mutex
+	members []*model.GroupMember
+}
+
+func (r *fakeGroupMemberRepository) find(groupId, userId uint) *model.GroupMember {
+	for _, m := range r.members {
+		if m.GroupId == groupId && m.MemberId == userId {
+			return m
+		}
+	}
+	return nil
+}
+
+func (r *fakeGroupMemberRepository) SaveBatch(list []*model.GroupMember, _ *gorm.DB) error {
+	for _, m := range list {
+		_ = r.Save(m)
+	}
+	return nil
+}
+
+func (r *fakeGroupMemberRepository) Save(member *model.GroupMember, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *member
+	r.members = append(r.members, &copied)
+	return nil
+}
+
+func (r *fakeGroupMemberRepository) ExistsByGroupIdAndUserId(groupId uint, memberId uint, _ ...*gorm.DB) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.find(groupId, memberId) != nil
+}
+
+func (r *fakeGroupMemberRepository) RejoinGroupIfDeleted(uint, uint, ...*gorm.DB) bool {
+	return false
+}
+
+func (r *fakeGroupMemberRepository) DeleteByGroupIdAndUserId(groupId uint, memberId uint, _ ...*gorm.DB) error {
+	return r.RemoveMember(groupId, memberId)
+}
+
+func (r *fakeGroupMemberRepository) GetMemberListByGroupId(groupId uint, _ ...*gorm.DB) ([]response.MemberVo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []response.MemberVo
+	for _, m := range r.members {
+		if m.GroupId == groupId {
+			list = append(list, response.MemberVo{GroupId: m.GroupId, UserId: m.MemberId, Nickname: m.GNickName, Role: m.Role, MuteEnd: m.MuteEnd})
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeGroupMemberRepository) IsOwner(groupId uint, memberId uint, _ ...*gorm.DB) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.find(groupId, memberId)
+	return m != nil && m.IsOwner()
+}
+
+func (r *fakeGroupMemberRepository) IsOwnerOrAdmin(groupId uint, memberId uint, _ ...*gorm.DB) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.find(groupId, memberId)
+	return m != nil && (m.IsOwner() || m.IsAdmin())
+}
+
+func (r *fakeGroupMemberRepository) GetRelatedMemberByUserId(id uint, _ ...*gorm.DB) ([]response.MemberVo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []response.MemberVo
+	for _, m := range r.members {
+		if m.MemberId == id {
+			list = append(list, response.MemberVo{GroupId: m.GroupId, UserId: m.MemberId, Nickname: m.GNickName, Role: m.Role})
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeGroupMemberRepository) GetGroupMember(groupId, userId uint, _ ...*gorm.DB) (*model.GroupMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m := r.find(groupId, userId); m != nil {
+		copied := *m
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeGroupMemberRepository) RemoveMember(groupId, userId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, m := range r.members {
+		if m.GroupId == groupId && m.MemberId == userId {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *fakeGroupMemberRepository) DeleteByGroupID(groupID uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.members[:0]
+	for _, m := range r.members {
+		if m.GroupId != groupID {
+			kept = append(kept, m)
+		}
+	}
+	r.members = kept
+	return nil
+}
+
+func (r *fakeGroupMemberRepository) Update(groupID, memberID uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m := r.find(groupID, memberID); m != nil {
+		return applyUpdates(m, updates)
+	}
+	return nil
+}
+
+type fakeMentionRepository struct {
+	mu       sync.Mutex
+	nextId   uint
+	mentions []*model.Mention
+}
+
+func (r *fakeMentionRepository) CreateBatch(mentions []*model.Mention, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range mentions {
+		r.nextId++
+		m.ID = r.nextId
+		m.CreatedAt = time.Now()
+		copied := *m
+		r.mentions = append(r.mentions, &copied)
+	}
+	return nil
+}
+
+func (r *fakeMentionRepository) Page(userId uint, req request.MentionQueryRequest, _ ...*gorm.DB) (*pagination.PageResult[model.Mention], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := &pagination.PageResult[model.Mention]{Records: []model.Mention{}, Page: req.Page, PageSize: req.PageSize}
+	for i := len(r.mentions) - 1; i >= 0; i-- {
+		m := r.mentions[i]
+		if m.UserId != userId || (req.GroupId != 0 && m.GroupId != req.GroupId) || (req.UnreadOnly && m.ReadAt != nil) {
+			continue
+		}
+		result.Records = append(result.Records, *m)
+	}
+	result.Total = int64(len(result.Records))
+	return result, nil
+}
+
+func (r *fakeMentionRepository) MarkRead(userId uint, req request.MentionReadRequest, _ ...*gorm.DB) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	now := time.Now()
+	for _, m := range r.mentions {
+		if m.UserId != userId || m.ReadAt != nil || (req.GroupId != 0 && m.GroupId != req.GroupId) {
+			continue
+		}
+		if len(req.MentionIds) > 0 && !utils.Contains(req.MentionIds, m.ID) {
+			continue
+		}
+		m.ReadAt = &now
+		count++
+	}
+	return count, nil
+}
+
+func (r *fakeMentionRepository) MarkReadByMessageId(userId uint, messageId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, m := range r.mentions {
+		if m.UserId == userId && m.MessageId == messageId && m.ReadAt == nil {
+			m.ReadAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeMentionRepository) DeleteByMessageId(messageId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.mentions[:0]
+	for _, m := range r.mentions {
+		if m.MessageId != messageId {
+			kept = append(kept, m)
+		}
+	}
+	r.mentions = kept
+	return nil
+}
+
+type fakeReactionRepository struct {
+	mu        sync.Mutex
+	nextId    uint
+	reactions []model.Reaction
+}
+
+func (r *fakeReactionRepository) Create(reaction *model.Reaction, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.reactions {
+		if m.MessageId == reaction.MessageId && m.UserId == reaction.UserId && m.Emoji == reaction.Emoji {
+			return false, nil
+		}
+	}
+	r.nextId++
+	reaction.ID = r.nextId
+	r.reactions = append(r.reactions, *reaction)
+	return true, nil
+}
+
+func (r *fakeReactionRepository) Delete(messageId uint, userId uint, emoji string, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, m := range r.reactions {
+		if m.MessageId == messageId && m.UserId == userId && m.Emoji == emoji {
+			r.reactions = append(r.reactions[:i], r.reactions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeReactionRepository) ListByMessageIds(messageIds []uint, _ ...*gorm.DB) ([]model.Reaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reactions := make([]model.Reaction, 0)
+	for _, m := range r.reactions {
+		if utils.Contains(messageIds, m.MessageId) {
+			reactions = append(reactions, m)
+		}
+	}
+	return reactions, nil
+}
+
+type fakeThreadRepository struct {
+	mu           sync.Mutex
+	threads      map[uint]*model.Thread
+	participants []*model.ThreadParticipant
+}
+
+func newFakeThreadRepository() *fakeThreadRepository {
+	return &fakeThreadRepository{threads: make(map[uint]*model.Thread)}
+}
+
+func (r *fakeThreadRepository) AddReply(rootId uint, replyId uint, replyAt time.Time, _ ...*gorm.DB) (*model.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	thread, ok := r.threads[rootId]
+	if !ok {
+		thread = &model.Thread{RootId: rootId}
+		r.threads[rootId] = thread
+	}
+	thread.ReplyCount++
+	thread.LastReplyId = replyId
+	thread.LastReplyAt = &replyAt
+	copied := *thread
+	return &copied, nil
+}
+
+func (r *fakeThreadRepository) find(rootId, userId uint) *model.ThreadParticipant {
+	for _, p := range r.participants {
+		if p.RootId == rootId && p.UserId == userId {
+			return p
+		}
+	}
+	return nil
+}
+
+func (r *fakeThreadRepository) Join(rootId uint, userId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(rootId, userId) == nil {
+		r.participants = append(r.participants, &model.ThreadParticipant{RootId: rootId, UserId: userId})
+	}
+	return nil
+}
+
+func (r *fakeThreadRepository) RecordReply(rootId uint, userId uint, replyCount int, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p := r.find(rootId, userId); p != nil {
+		p.ReadCount++
+	} else {
+		r.participants = append(r.participants, &model.ThreadParticipant{RootId: rootId, UserId: userId, ReadCount: replyCount})
+	}
+	return nil
+}
+
+func (r *fakeThreadRepository) MarkRead(rootId uint, userId uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, thread := r.find(rootId, userId), r.threads[rootId]; p != nil && thread != nil {
+		p.ReadCount = thread.ReplyCount
+	}
+	return nil
+}
+
+func (r *fakeThreadRepository) GetByRootIds(rootIds []uint, _ ...*gorm.DB) ([]model.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	threads := make([]model.Thread, 0)
+	for _, id := range rootIds {
+		if thread, ok := r.threads[id]; ok {
+			threads = append(threads, *thread)
+		}
+	}
+	return threads, nil
+}
+
+func (r *fakeThreadRepository) GetParticipants(rootIds []uint, _ ...*gorm.DB) ([]model.ThreadParticipant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	participants := make([]model.ThreadParticipant, 0)
+	for _, p := range r.participants {
+		if utils.Contains(rootIds, p.RootId) {
+			participants = append(participants, *p)
+		}
+	}
+	return participants, nil
+}
+
+func (r *fakeThreadRepository) PageJoined(userId uint, req request.ThreadQueryRequest, _ ...*gorm.DB) (*pagination.PageResult[model.Thread], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := &pagination.PageResult[model.Thread]{Records: []model.Thread{}, Page: req.Page, PageSize: req.PageSize}
+	for _, p := range r.participants {
+		thread := r.threads[p.RootId]
+		if p.UserId != userId || (req.UnreadOnly && thread.ReplyCount <= p.ReadCount) {
+			continue
+		}
+		result.Records = append(result.Records, *thread)
+	}
+	sort.Slice(result.Records, func(i, j int) bool { return result.Records[i].LastReplyAt.After(*result.Records[j].LastReplyAt) })
+	result.Total = int64(len(result.Records))
+	return result, nil
+}
+
+type fakePinRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	pins   []model.Pin
+	locks  map[string]*sync.Mutex // 每个会话一把锁，模拟 FOR UPDATE
+}
+
+func (r *fakePinRepository) Create(pin *model.Pin, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.pins {
+		if p.MessageId == pin.MessageId {
+			return false, nil
+		}
+	}
+	r.nextId++
+	pin.ID = r.nextId
+	pin.CreatedAt = time.Now()
+	r.pins = append(r.pins, *pin)
+	return true, nil
+}
+
+func (r *fakePinRepository) Delete(messageId uint, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.pins {
+		if p.MessageId == messageId {
+			r.pins = append(r.pins[:i], r.pins[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakePinRepository) CountByConversationForUpdate(conversation string, tx *gorm.DB) (int64, error) {
+	r.mu.Lock()
+	if r.locks == nil {
+		r.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := r.locks[conversation]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[conversation] = lock
+	}
+	r.mu.Unlock()
+	if err := fakeTxLock(tx, lock); err != nil {
+		return 0, err
+	}
+	pins, _ := r.ListByConversation(conversation)
+	// 模拟事务内的查询耗时，放大并发置顶时先查后写的竞争窗口
+	time.Sleep(time.Millisecond)
+	return int64(len(pins)), nil
+}
+
+func (r *fakePinRepository) ListByConversation(conversation string, _ ...*gorm.DB) ([]model.Pin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pins := make([]model.Pin, 0)
+	for i := len(r.pins) - 1; i >= 0; i-- {
+		if r.pins[i].Conversation == conversation {
+			pins = append(pins, r.pins[i])
+		}
+	}
+	return pins, nil
+}
+
+func (r *fakePinRepository) PinnedMessageIds(messageIds []uint, _ ...*gorm.DB) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]uint, 0)
+	for _, p := range r.pins {
+		if utils.Contains(messageIds, p.MessageId) {
+			ids = append(ids, p.MessageId)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeMessageRepository) ListExpired(now time.Time, limit int) ([]*model.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []*model.Message
+	for _, m := range r.messages {
+		if m.ExpireAt != nil && !m.ExpireAt.After(now) {
+			messages = append(messages, deepCopy(m))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ExpireAt.Before(*messages[j].ExpireAt) })
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+type fakeScheduledMessageRepository struct {
+	mu     sync.Mutex
+	nextId uint
+	list   []*model.ScheduledMessage
+}
+
+func (r *fakeScheduledMessageRepository) Create(scheduled *model.ScheduledMessage, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	scheduled.ID = r.nextId
+	scheduled.CreatedAt = time.Now()
+	r.list = append(r.list, deepCopy(scheduled))
+	return nil
+}
+
+func (r *fakeScheduledMessageRepository) ListDue(now time.Time, staleBefore time.Time, limit int, _ ...*gorm.DB) ([]model.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]model.ScheduledMessage, 0)
+	for _, s := range r.list {
+		due := s.Status == model.SchedulePending && !s.SendAt.After(now)
+		if (due || claimExpired(s, staleBefore)) && len(list) < limit {
+			list = append(list, *deepCopy(s))
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeScheduledMessageRepository) Claim(id uint, now time.Time, staleBefore time.Time, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.list {
+		if s.ID == id && (s.Status == model.SchedulePending || claimExpired(s, staleBefore)) {
+			s.Status, s.ClaimedAt = model.ScheduleSending, &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// claimExpired 发送中且领取时间早于 staleBefore
+func claimExpired(s *model.ScheduledMessage, staleBefore time.Time) bool {
+	return s.Status == model.ScheduleSending && s.ClaimedAt != nil && s.ClaimedAt.Before(staleBefore)
+}
+
+func (r *fakeScheduledMessageRepository) Transit(id uint, from model.ScheduleStatus, to model.ScheduleStatus, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.list {
+		if s.ID == id && s.Status == from {
+			s.Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeScheduledMessageRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.list {
+		if s.ID == id {
+			return applyUpdates(s, updates)
+		}
+	}
+	return nil
+}
+
+func (r *fakeScheduledMessageRepository) GetById(id uint, _ ...*gorm.DB) (*model.ScheduledMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.list {
+		if s.ID == id {
+			return deepCopy(s), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeScheduledMessageRepository) Page(senderId uint, req request.ScheduleQueryRequest, _ ...*gorm.DB) (*pagination.PageResult[model.ScheduledMessage], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]model.ScheduledMessage, 0)
+	for i := len(r.list) - 1; i >= 0; i-- {
+		s := r.list[i]
+		if s.SenderId == senderId && (req.Status == nil || s.Status == *req.Status) {
+			records = append(records, *deepCopy(s))
+		}
+	}
+	return &pagination.PageResult[model.ScheduledMessage]{Records: records, Total: int64(len(records)), Page: 1, PageSize: len(records)}, nil
+}
+
+type fakeStickerRepository struct {
+	mu        sync.Mutex
+	nextId    uint
+	packs     []*model.StickerPack
+	stickers  []*model.Sticker
+	favorites []model.StickerFavorite
+}
+
+func (r *fakeStickerRepository) id() uint {
+	r.nextId++
+	return r.nextId
+}
+
+func (r *fakeStickerRepository) CreatePack(pack *model.StickerPack, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pack.ID = r.id()
+	pack.CreatedAt = time.Now()
+	r.packs = append(r.packs, deepCopy(pack))
+	return nil
+}
+
+func (r *fakeStickerRepository) GetPackById(id uint, _ ...*gorm.DB) (*model.StickerPack, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.packs {
+		if p.ID == id {
+			return deepCopy(p), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeStickerRepository) UpdatePack(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.packs {
+		if p.ID == id {
+			return applyUpdates(p, updates)
+		}
+	}
+	return nil
+}
+
+func (r *fakeStickerRepository) AddStickerCount(packId uint, delta int, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.packs {
+		if p.ID == packId {
+			p.StickerCount += delta
+		}
+	}
+	return nil
+}
+
+func (r *fakeStickerRepository) DeletePack(id uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.packs {
+		if p.ID == id {
+			r.packs = append(r.packs[:i], r.packs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *fakeStickerRepository) PagePacks(userId uint, req request.StickerPackQueryRequest, _ ...*gorm.DB) (*pagination.PageResult[model.StickerPack], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matched := func(p *model.StickerPack) bool {
+		if req.Keyword == "" || strings.Contains(p.Name, req.Keyword) || strings.Contains(p.Description, req.Keyword) {
+			return true
+		}
+		for _, s := range r.stickers {
+			if s.PackId != nil && *s.PackId == p.ID && (strings.Contains(s.Name, req.Keyword) || strings.Contains(s.Keywords, req.Keyword)) {
+				return true
+			}
+		}
+		return false
+	}
+	records := make([]model.StickerPack, 0)
+	for i := len(r.packs) - 1; i >= 0; i-- {
+		p := r.packs[i]
+		if (req.Mine && p.CreatorId != userId) || (!req.Mine && p.StickerCount == 0) || !matched(p) {
+			continue
+		}
+		records = append(records, *deepCopy(p))
+	}
+	return &pagination.PageResult[model.StickerPack]{Records: records, Total: int64(len(records)), Page: 1, PageSize: len(records)}, nil
+}
+
+func (r *fakeStickerRepository) Create(sticker *model.Sticker, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.stickers {
+		if sticker.GroupId != nil && s.GroupId != nil && *s.GroupId == *sticker.GroupId && s.Name == sticker.Name {
+			return false, nil
+		}
+	}
+	sticker.ID = r.id()
+	sticker.CreatedAt = time.Now()
+	r.stickers = append(r.stickers, deepCopy(sticker))
+	return true, nil
+}
+
+func (r *fakeStickerRepository) GetById(id uint, _ ...*gorm.DB) (*model.Sticker, error) {
+	list, _ := r.GetByIds([]uint{id})
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return &list[0], nil
+}
+
+func (r *fakeStickerRepository) GetByIds(ids []uint, _ ...*gorm.DB) ([]model.Sticker, error) {
+	return r.filter(func(s *model.Sticker) bool { return utils.Contains(ids, s.ID) }), nil
+}
+
+func (r *fakeStickerRepository) filter(match func(s *model.Sticker) bool) []model.Sticker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]model.Sticker, 0)
+	for _, s := range r.stickers {
+		if match(s) {
+			list = append(list, *deepCopy(s))
+		}
+	}
+	return list
+}
+
+func (r *fakeStickerRepository) remove(match func(s *model.Sticker) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.stickers[:0]
+	for _, s := range r.stickers {
+		if !match(s) {
+			kept = append(kept, s)
+		}
+	}
+	r.stickers = kept
+}
+
+func (r *fakeStickerRepository) Delete(id uint, _ ...*gorm.DB) error {
+	r.remove(func(s *model.Sticker) bool { return s.ID == id })
+	return nil
+}
+
+func (r *fakeStickerRepository) DeleteByPackId(packId uint, _ ...*gorm.DB) error {
+	r.remove(func(s *model.Sticker) bool { return s.PackId != nil && *s.PackId == packId })
+	return nil
+}
+
+func (r *fakeStickerRepository) ListByPackId(packId uint, _ ...*gorm.DB) ([]model.Sticker, error) {
+	return r.filter(func(s *model.Sticker) bool { return s.PackId != nil && *s.PackId == packId }), nil
+}
+
+func (r *fakeStickerRepository) ListByGroupId(groupId uint, _ ...*gorm.DB) ([]model.Sticker, error) {
+	return r.filter(func(s *model.Sticker) bool { return s.GroupId != nil && *s.GroupId == groupId }), nil
+}
+
+func (r *fakeStickerRepository) CountByGroupId(groupId uint, _ ...*gorm.DB) (int64, error) {
+	list, _ := r.ListByGroupId(groupId)
+	return int64(len(list)), nil
+}
+
+func (r *fakeStickerRepository) AddFavorite(userId uint, stickerId uint, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.favorites {
+		if f.UserId == userId && f.StickerId == stickerId {
+			return false, nil
+		}
+	}
+	favorite := model.StickerFavorite{UserId: userId, StickerId: stickerId}
+	favorite.ID = r.id()
+	r.favorites = append(r.favorites, favorite)
+	return true, nil
+}
+
+func (r *fakeStickerRepository) RemoveFavorite(userId uint, stickerId uint, _ ...*gorm.DB) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, f := range r.favorites {
+		if f.UserId == userId && f.StickerId == stickerId {
+			r.favorites = append(r.favorites[:i], r.favorites[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeStickerRepository) RemoveFavoritesBySticker(stickerIds []uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.favorites[:0]
+	for _, f := range r.favorites {
+		if !utils.Contains(stickerIds, f.StickerId) {
+			kept = append(kept, f)
+		}
+	}
+	r.favorites = kept
+	return nil
+}
+
+func (r *fakeStickerRepository) CountFavorites(userId uint, _ ...*gorm.DB) (int64, error) {
+	list, _ := r.ListFavorites(userId)
+	return int64(len(list)), nil
+}
+
+func (r *fakeStickerRepository) ListFavorites(userId uint, _ ...*gorm.DB) ([]model.Sticker, error) {
+	r.mu.Lock()
+	var ids []uint
+	for i := len(r.favorites) - 1; i >= 0; i-- {
+		if r.favorites[i].UserId == userId {
+			ids = append(ids, r.favorites[i].StickerId)
+		}
+	}
+	r.mu.Unlock()
+	list := make([]model.Sticker, 0, len(ids))
+	for _, id := range ids {
+		if s, _ := r.GetById(id); s != nil {
+			list = append(list, *s)
+		}
+	}
+	return list, nil
+}
+
+type fakeMessageDeletionRepository struct {
+	mu        sync.Mutex
+	deletions []model.MessageDeletion
+	clears    []model.ConversationClear
+}
+
+func (r *fakeMessageDeletionRepository) Create(userId uint, messageIds []uint, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, messageId := range messageIds {
+		exists := false
+		for _, d := range r.deletions {
+			if d.UserId == userId && d.MessageId == messageId {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			r.deletions = append(r.deletions, model.MessageDeletion{UserId: userId, MessageId: messageId})
+		}
+	}
+	return nil
+}
+
+func (r *fakeMessageDeletionRepository) SaveClear(clear *model.ConversationClear, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, c := range r.clears {
+		if c.UserId == clear.UserId && c.TargetType == clear.TargetType && c.TargetId == clear.TargetId {
+			r.clears[i].ClearedId = clear.ClearedId
+			return nil
+		}
+	}
+	r.clears = append(r.clears, *clear)
+	return nil
+}
+
+// hidden 消息是否被用户删除，或在用户清空聊天记录的位置之前
+func (r *fakeMessageDeletionRepository) hidden(userId uint, targetType model.TargetType, targetId uint, messageId uint) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deletions {
+		if d.UserId == userId && d.MessageId == messageId {
+			return true
+		}
+	}
+	for _, c := range r.clears {
+		if c.UserId == userId && c.TargetType == targetType && c.TargetId == targetId && messageId <= c.ClearedId {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTxPool 只支持开启和提交事务的连接，服务层事务中的读写都由内存仓库完成
+type fakeTxPool struct{}
+
+func (fakeTxPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("fake tx pool: prepare unsupported")
+}
+
+func (fakeTxPool) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	// 嵌套事务使用的保存点语句直接视为成功
+	upper := strings.ToUpper(query)
+	if strings.HasPrefix(upper, "SAVEPOINT") || strings.HasPrefix(upper, "RELEASE") || strings.HasPrefix(upper, "ROLLBACK TO") {
+		return driver.RowsAffected(0), nil
+	}
+	return nil, fmt.Errorf("fake tx pool: unexpected sql %q", query)
+}
+
+func (fakeTxPool) QueryContext(_ context.Context, query string, _ ...interface{}) (*sql.Rows, error) {
+	return nil, fmt.Errorf("fake tx pool: unexpected sql %q", query)
+}
+
+func (fakeTxPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p fakeTxPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{fakeTxPool: p}, nil
+}
+
+// fakeTx 提交或回滚时依次执行登记的回调，用于释放内存仓库模拟的行锁
+type fakeTx struct {
+	fakeTxPool
+	mu    sync.Mutex
+	onEnd []func()
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.end()
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.end()
+	return nil
+}
+
+func (tx *fakeTx) end() {
+	tx.mu.Lock()
+	callbacks := tx.onEnd
+	tx.onEnd = nil
+	tx.mu.Unlock()
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// fakeTxLock 模拟 SELECT ... FOR UPDATE：在事务中加锁，直到事务提交或回滚才释放
+func fakeTxLock(gormDB *gorm.DB, lock *sync.Mutex) error {
+	tx, ok := gormDB.Statement.ConnPool.(*fakeTx)
+	if !ok {
+		return errors.New("fake tx pool: 行锁只能在事务中使用")
+	}
+	lock.Lock()
+	tx.mu.Lock()
+	tx.onEnd = append(tx.onEnd, lock.Unlock)
+	tx.mu.Unlock()
+	return nil
+}
+
+// fakeTxMysql 把 db.Mysql 替换为只能开启事务的连接，测试结束后恢复
+func fakeTxMysql(t *testing.T) {
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      fakeTxPool{},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := db.Mysql
+	db.Mysql = gormDB
+	t.Cleanup(func() { db.Mysql = origin })
+}
+
+// fakePollRepository 按行锁和 uk_poll_user 唯一约束模拟投票表
+type fakePollRepository struct {
+	mu      sync.Mutex
+	nextId  uint
+	polls   map[uint]*model.Poll
+	options map[uint]*model.PollOption
+	votes   []*model.PollVote
+	locks   map[uint]*sync.Mutex
+}
+
+func newFakePollRepository() *fakePollRepository {
+	return &fakePollRepository{
+		polls:   make(map[uint]*model.Poll),
+		options: make(map[uint]*model.PollOption),
+		locks:   make(map[uint]*sync.Mutex),
+	}
+}
+
+func (r *fakePollRepository) Create(poll *model.Poll, options []*model.PollOption, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	poll.ID = r.nextId
+	r.polls[poll.ID] = deepCopy(poll)
+	r.locks[poll.ID] = &sync.Mutex{}
+	for _, option := range options {
+		r.nextId++
+		option.ID = r.nextId
+		option.PollId = poll.ID
+		copied := *option
+		r.options[option.ID] = &copied
+	}
+	return nil
+}
+
+func (r *fakePollRepository) GetById(id uint, _ ...*gorm.DB) (*model.Poll, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if poll, ok := r.polls[id]; ok {
+		return deepCopy(poll), nil
+	}
+	return nil, nil
+}
+
+func (r *fakePollRepository) GetByIdForUpdate(id uint, tx *gorm.DB) (*model.Poll, error) {
+	r.mu.Lock()
+	lock, ok := r.locks[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	if err := fakeTxLock(tx, lock); err != nil {
+		return nil, err
+	}
+	return r.GetById(id)
+}
+
+func (r *fakePollRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[id]
+	if !ok {
+		return nil
+	}
+	values := make(map[string]interface{}, len(updates))
+	for column, v := range updates {
+		// 服务层只用 voter_count + 1 表达式累加投票人数
+		if _, ok := v.(clause.Expr); ok && column == "voter_count" {
+			poll.VoterCount++
+			continue
+		}
+		values[column] = v
+	}
+	return applyUpdates(poll, values)
+}
+
+func (r *fakePollRepository) GetOptions(pollId uint, _ ...*gorm.DB) ([]model.PollOption, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var options []model.PollOption
+	for _, option := range r.options {
+		if option.PollId == pollId {
+			options = append(options, *option)
+		}
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].ID < options[j].ID })
+	return options, nil
+}
+
+func (r *fakePollRepository) AddVoteCount(optionIds []uint, delta int, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range optionIds {
+		if option, ok := r.options[id]; ok {
+			option.VoteCount += delta
+		}
+	}
+	return nil
+}
+
+func (r *fakePollRepository) GetVote(pollId uint, userId uint, tx ...*gorm.DB) (*model.PollVote, error) {
+	vote := r.findVote(pollId, userId)
+	if len(tx) > 0 {
+		// 模拟事务内的查询耗时，放大并发投票时先查后写的竞争窗口
+		time.Sleep(time.Millisecond)
+	}
+	return vote, nil
+}
+
+func (r *fakePollRepository) findVote(pollId uint, userId uint) *model.PollVote {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, vote := range r.votes {
+		if vote.PollId == pollId && vote.UserId == userId {
+			return deepCopy(vote)
+		}
+	}
+	return nil
+}
+
+func (r *fakePollRepository) GetVotes(pollId uint, _ ...*gorm.DB) ([]model.PollVote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var votes []model.PollVote
+	for _, vote := range r.votes {
+		if vote.PollId == pollId {
+			votes = append(votes, *deepCopy(vote))
+		}
+	}
+	return votes, nil
+}
+
+func (r *fakePollRepository) SaveVote(vote *model.PollVote, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.votes {
+		if existing.PollId != vote.PollId || existing.UserId != vote.UserId {
+			continue
+		}
+		if vote.ID == 0 {
+			return fmt.Errorf("Duplicate entry '%d-%d' for key 'uk_poll_user'", vote.PollId, vote.UserId)
+		}
+		existing.OptionIds = deepCopy(vote).OptionIds
+		return nil
+	}
+	r.nextId++
+	vote.ID = r.nextId
+	r.votes = append(r.votes, deepCopy(vote))
+	return nil
+}
+
+// voteCounts 返回各选项的票数，用于核对与选票是否一致
+func (r *fakePollRepository) voteCounts(pollId uint) map[uint]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[uint]int)
+	for _, option := range r.options {
+		if option.PollId == pollId {
+			counts[option.ID] = option.VoteCount
+		}
+	}
+	return counts
+}
+
+type fakeReportRepository struct {
+	mu      sync.Mutex
+	reports []*model.Report
+}
+
+func (r *fakeReportRepository) Create(report *model.Report, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report.ID = uint(len(r.reports) + 1)
+	report.CreatedAt = time.Now()
+	r.reports = append(r.reports, deepCopy(report))
+	return nil
+}
+
+func (r *fakeReportRepository) GetById(id uint, _ ...*gorm.DB) (*model.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.reports) {
+		return nil, nil
+	}
+	return deepCopy(r.reports[id-1]), nil
+}
+
+func (r *fakeReportRepository) UpdateFields(id uint, updates map[string]interface{}, _ ...*gorm.DB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.reports) {
+		return nil
+	}
+	return applyUpdates(r.reports[id-1], updates)
+}
+
+func (r *fakeReportRepository) ExistsOpen(reporterId uint, targetType model.ReportTargetType, targetId uint, _ ...*gorm.DB) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, report := range r.reports {
+		if report.ReporterId == reporterId && report.TargetType == targetType && report.TargetId == targetId && !report.Status.Closed() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeReportRepository) Page(req request.ReportQueryRequest, _ ...*gorm.DB) (*pagination.PageResult[model.Report], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := &pagination.PageResult[model.Report]{Records: []model.Report{}}
+	for i := len(r.reports) - 1; i >= 0; i-- {
+		report := r.reports[i]
+		if (req.ReporterId != 0 && report.ReporterId != req.ReporterId) ||
+			(req.TargetType != "" && report.TargetType != req.TargetType) ||
+			(req.TargetId != 0 && report.TargetId != req.TargetId) ||
+			(req.Status != nil && report.Status != *req.Status) {
+			continue
+		}
+		result.Records = append(result.Records, *deepCopy(report))
+	}
+	result.Total = int64(len(result.Records))
+	return result, nil
+}
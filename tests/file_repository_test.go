@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"go-chat/internal/repository"
+	"strings"
+	"testing"
+)
+
+func TestIsVisibleToSql(t *testing.T) {
+	recorder := dryRunMysql(t)
+	repository.InitFileRepository()
+	if _, err := repository.FileRepositoryInstance.IsVisibleTo([]string{"http://localhost/object/a.png"}, 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.sqls) != 4 {
+		t.Fatalf("应依次查询用户头像、群头像、表情和消息: %v", recorder.sqls)
+	}
+	// 头像只认上传者本人设置的用户头像和群成员上传的群头像
+	avatar := recorder.sqls[0] + recorder.sqls[1]
+	for _, want := range []string{
+		"files.user_id = users.id",
+		"group_members.member_id = files.user_id",
+		"group_members.group_id = `groups`.id",
+	} {
+		if !strings.Contains(avatar, want) {
+			t.Fatalf("头像授权缺少条件 %q: %s", want, avatar)
+		}
+	}
+	// 群自定义表情只对群成员可见
+	if sticker := recorder.sqls[2]; !strings.Contains(sticker, "pack_id IS NOT NULL OR group_id IN (SELECT group_id FROM group_members WHERE member_id = 7") {
+		t.Fatalf("表情授权条件不正确: %s", sticker)
+	}
+}
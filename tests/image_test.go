@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"go-chat/configs"
+	"go-chat/internal/manager"
+	"go-chat/internal/service"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exifJpeg 生成一张带 EXIF 方向标记(右转 90 度)的 JPEG，左半边红色、右半边蓝色
+func exifJpeg(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+	// APP1: Exif 头 + 大端 TIFF 头 + IFD0 中一个 Orientation=6 的条目
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	segment := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestImagePipeline(t *testing.T) {
+	configs.AppConfig = &configs.Config{
+		Storage: configs.StorageConfig{GcGracePeriod: "1ms"},
+		Image:   configs.ImageConfig{ThumbnailSizes: []int{160, 480}, WebP: true},
+	}
+	storage := manager.NewMemoryStorage("http://localhost/object")
+	fileRepo := &fakeFileRepository{}
+	service.InitFileService(fileRepo, newFakeUploadSessionRepository(), storage, nil, nil)
+	fileService := service.FileServiceInstance
+	ctx := context.Background()
+
+	url, err := fileService.Upload(1, formFile(t, "photo.jpg", exifJpeg(t, 320, 200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := fileRepo.get(1)
+	if file.Width == nil || *file.Width != 200 || *file.Height != 320 {
+		t.Fatalf("应按 EXIF 方向摆正: %v x %v", file.Width, file.Height)
+	}
+
+	// 原图去除 EXIF，仍是可解码的 JPEG
+	reader, _, err := storage.Get(ctx, file.ObjectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := io.ReadAll(reader)
+	if bytes.Contains(stored, []byte("Exif")) {
+		t.Fatal("原图应去除 EXIF")
+	}
+	if cfg, err := jpeg.DecodeConfig(bytes.NewReader(stored)); err != nil || cfg.Width != 200 || cfg.Height != 320 {
+		t.Fatalf("去除元数据后的原图不正确: %+v %v", cfg, err)
+	}
+
+	// 只生成小于原图的缩略图，外加一份原尺寸 WebP
+	if file.Variants == nil || len(*file.Variants) != 2 {
+		t.Fatalf("衍生文件数量不正确: %+v", file.Variants)
+	}
+	thumb, webp := (*file.Variants)[0], (*file.Variants)[1]
+	if thumb.Name != "thumb_160" || thumb.Width != 100 || thumb.Height != 160 || thumb.Mime != "image/webp" {
+		t.Fatalf("缩略图不正确: %+v", thumb)
+	}
+	if webp.Name != "webp" || webp.Width != 200 || webp.Height != 320 {
+		t.Fatalf("WebP 不正确: %+v", webp)
+	}
+	if info, _ := storage.Stat(ctx, thumb.ObjectKey); info == nil || info.Size != int64(thumb.Size) {
+		t.Fatal("缩略图应写入存储")
+	}
+
+	// 缩略图与原图共用权限和引用计数
+	if err := fileService.AuthorizeObject(1, thumb.ObjectKey); err != nil {
+		t.Fatalf("上传者应能访问缩略图: %v", err)
+	}
+	if err := fileService.AuthorizeObject(2, thumb.ObjectKey); err == nil {
+		t.Fatal("无权用户不能访问缩略图")
+	}
+	fileRepo.visible = map[uint][]string{2: {thumb.Url}}
+	if err := fileService.AuthorizeObject(2, file.ObjectKey); err != nil {
+		t.Fatalf("能看到缩略图的用户应能访问原图: %v", err)
+	}
+	_ = fileService.AddRefs(url, thumb.Url)
+	if f := fileRepo.get(1); f.RefCount != 1 {
+		t.Fatalf("同一文件的原图和缩略图只计一次引用: %d", f.RefCount)
+	}
+	_ = fileService.ReleaseRefs(thumb.Url)
+	time.Sleep(5 * time.Millisecond)
+	if count, _ := fileService.CollectGarbage(); count != 1 {
+		t.Fatal("孤立图片应被回收")
+	}
+	for _, key := range []string{file.ObjectKey, thumb.ObjectKey, webp.ObjectKey} {
+		if info, _ := storage.Stat(ctx, key); info != nil {
+			t.Fatalf("回收后应删除全部对象: %s", key)
+		}
+	}
+
+	// 无法解码的图片按原样保存
+	broken := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, []byte(strings.Repeat("x", 64))...)
+	if _, err := fileService.Upload(1, formFile(t, "broken.jpg", broken)); err != nil {
+		t.Fatalf("无法解码的图片应按原样保存: %v", err)
+	}
+}
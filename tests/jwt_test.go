@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"go-chat/configs"
+	"go-chat/internal/utils/jwtUtil"
+	"testing"
+	"time"
+)
+
+// 吊销后同一秒内重新登录签发的 token 不能被当作旧 token
+func TestTokenIssuedTimeMillisecond(t *testing.T) {
+	configs.AppConfig = &configs.Config{
+		Jwt: configs.JWTConfig{SecretKey: "test", ExpirationTime: "1h", Issuer: "go-chat", Audience: "go-chat"},
+	}
+	parse := func() *jwtUtil.Claims {
+		token, err := jwtUtil.GenerateJWT(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := jwtUtil.ParseJWT(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claims
+	}
+	old := parse()
+	time.Sleep(2 * time.Millisecond)
+	revokedAt := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	renewed := parse()
+	if old.IssuedTime().After(revokedAt) || !renewed.IssuedTime().After(revokedAt) {
+		t.Fatalf("签发时间应精确到毫秒: %v %v %v", old.IssuedTime(), revokedAt, renewed.IssuedTime())
+	}
+
+	// 没有毫秒级签发时间的旧 token 使用 iat
+	old.IssuedAtMilli = 0
+	if !old.IssuedTime().Equal(old.IssuedAt.Time) {
+		t.Fatalf("旧 token 应使用 iat: %v", old.IssuedTime())
+	}
+}
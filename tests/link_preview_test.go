@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"go-chat/configs"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/service"
+	"go-chat/internal/utils/linkUtil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type linkPreviewRecorder struct {
+	fakeWsHandler
+	notices chan model.LinkPreviewNotice
+}
+
+func (r linkPreviewRecorder) LinkPreviewNotice(_ []int64, notice model.LinkPreviewNotice) {
+	r.notices <- notice
+}
+
+func TestLinkPreview(t *testing.T) {
+	configs.AppConfig = &configs.Config{}
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>备用标题</title>
+<meta property="og:title" content="示例 &amp; 文章">
+<meta name="twitter:title" content="推特标题">
+<meta name="description" content="  文章   摘要 ">
+<meta property="og:image" content="/cover.png">
+<meta property="og:site_name" content="Example">
+</head><body><meta property="og:description" content="正文中的标签不解析"></body></html>`)
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>只有标题</title></head></html>`)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><!--"+strings.Repeat("x", 4096)+`--><title>超出限制</title></head></html>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<title>太慢</title>`)
+	})
+	mux.HandleFunc("/file.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		fmt.Fprint(w, "PK")
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+
+	// 默认禁止访问回环地址，重定向到内网同样被拦截
+	if _, err := manager.NewHttpLinkFetcher(manager.LinkFetcherOptions{}).Fetch(ctx, server.URL+"/article"); err == nil {
+		t.Fatal("不应访问回环地址")
+	}
+	if _, err := manager.NewHttpLinkFetcher(manager.LinkFetcherOptions{}).Fetch(ctx, "file:///etc/passwd"); err == nil {
+		t.Fatal("只支持 http(s) 链接")
+	}
+
+	fetcher := manager.NewHttpLinkFetcher(manager.LinkFetcherOptions{
+		Timeout: 100 * time.Millisecond, MaxBytes: 1024, AllowPrivate: true,
+	})
+	preview, err := fetcher.Fetch(ctx, server.URL+"/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Url != server.URL+"/redirect" || preview.Title != "示例 & 文章" || preview.Description != "文章 摘要" ||
+		preview.Image != server.URL+"/cover.png" || preview.SiteName != "Example" {
+		t.Fatalf("预览解析错误: %+v", preview)
+	}
+	if preview, err = fetcher.Fetch(ctx, server.URL+"/plain"); err != nil || preview.Title != "只有标题" {
+		t.Fatalf("缺少 og:title 时应使用 <title>: %+v %v", preview, err)
+	}
+	if preview, err = fetcher.Fetch(ctx, server.URL+"/large"); err != nil || !preview.Empty() {
+		t.Fatalf("超出大小限制的内容不应解析: %+v %v", preview, err)
+	}
+	if _, err = fetcher.Fetch(ctx, server.URL+"/slow"); err == nil {
+		t.Fatal("抓取应超时")
+	}
+	if _, err = fetcher.Fetch(ctx, server.URL+"/file.zip"); err == nil {
+		t.Fatal("不应解析非网页内容")
+	}
+
+	links := linkUtil.Extract(model.MessagePartList{
+		part(model.Text, "看看 www.example.com/a，还有 https://example.com/b#top。"),
+		part(model.Link, "https://example.com/b"),
+		part(model.Link, "javascript:alert(1)"),
+	}, 0)
+	if len(links) != 2 || links[0] != "http://www.example.com/a" || links[1] != "https://example.com/b" {
+		t.Fatalf("链接提取错误: %v", links)
+	}
+
+	// 发送后异步写回消息并推送，同一链接再次出现时使用缓存
+	hits.Store(0)
+	messages := newFakeMessageRepository()
+	recorder := linkPreviewRecorder{notices: make(chan model.LinkPreviewNotice, 2)}
+	service.InitLinkPreviewService(messages, &fakeGroupMemberRepository{}, fetcher, manager.NewMemoryLinkPreviewCache(), recorder)
+	linkService := service.LinkPreviewServiceInstance
+	private, text, receiverId := model.PrivateTarget, model.TextContent, int64(2)
+	for i := 0; i < 2; i++ {
+		msg := &model.Message{
+			SenderId: 1, ReceiverId: &receiverId, TargetType: &private, Type: &text,
+			Content: &model.MessagePartList{part(model.Text, "链接 "+server.URL+"/article 和 "+server.URL+"/file.zip")},
+		}
+		if err := messages.Save(msg); err != nil {
+			t.Fatal(err)
+		}
+		linkService.Unfurl(msg)
+		select {
+		case notice := <-recorder.notices:
+			if notice.MessageId != msg.ID || len(notice.Previews) != 1 || notice.Previews[0].Title != "示例 & 文章" {
+				t.Fatalf("推送内容错误: %+v", notice)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("链接预览生成后应推送")
+		}
+		saved, _ := messages.GetById(msg.ID)
+		if saved.LinkPreviews == nil || len(*saved.LinkPreviews) != 1 {
+			t.Fatalf("预览应写回消息: %+v", saved.LinkPreviews)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("同一链接应只抓取一次，实际 %d 次", hits.Load())
+	}
+}
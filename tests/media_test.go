@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-chat/configs"
+	"go-chat/internal/consumer"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/service"
+	"image"
+	"image/jpeg"
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeMediaProcessor 按文件内容返回固定结果，内容包含 broken 时探测失败
+type fakeMediaProcessor struct{}
+
+func (fakeMediaProcessor) Probe(_ context.Context, path string) (*model.MediaInfo, error) {
+	if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("broken")) {
+		return nil, errors.New("invalid data")
+	}
+	return &model.MediaInfo{Duration: 12.5, Width: 64, Height: 36}, nil
+}
+
+func (fakeMediaProcessor) Poster(context.Context, string, float64) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 36)), nil)
+	return buf.Bytes(), err
+}
+
+func (fakeMediaProcessor) Waveform(_ context.Context, _ string, samples int) ([]int, error) {
+	return make([]int, samples), nil
+}
+
+type mediaNoticeRecorder struct {
+	fakeWsHandler
+	notices chan model.MediaNotice
+}
+
+func (r mediaNoticeRecorder) MediaNotice(_ int64, notice model.MediaNotice) {
+	r.notices <- notice
+}
+
+func TestMediaJobs(t *testing.T) {
+	configs.AppConfig = &configs.Config{Media: configs.MediaConfig{WaveformSamples: 8}}
+	storage := manager.NewMemoryStorage("http://localhost/object")
+	fileRepo := &fakeFileRepository{}
+	queue := manager.NewMemoryJobQueue(1, 10)
+	recorder := mediaNoticeRecorder{notices: make(chan model.MediaNotice, 3)}
+	service.InitFileService(fileRepo, newFakeUploadSessionRepository(), storage, queue, nil)
+	service.InitMediaService(fileRepo, storage, fakeMediaProcessor{}, recorder)
+	fileService := service.FileServiceInstance
+
+	mp4 := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom video")
+	mp3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x00 audio")
+	uploads := map[string][]byte{"a.mp4": mp4, "b.mp3": mp3, "c.mp3": append(mp3, "broken"...)}
+	for _, name := range []string{"a.mp4", "b.mp3", "c.mp3"} {
+		if _, err := fileService.Upload(1, formFile(t, name, uploads[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 任务在上传后异步处理，消费开始前状态为处理中；处理完成前秒传的记录复制处理中的状态
+	if f := fileRepo.get(1); f.MediaStatus != model.MediaProcessing || f.Duration != nil {
+		t.Fatalf("上传后应等待处理: %+v", f)
+	}
+	copied, err := fileService.Upload(2, formFile(t, "copy.mp4", mp4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.Consume(consumer.HandleMediaJobConsumer)
+	notices := make(map[uint]model.MediaNotice)
+	for i := 0; i < 3; i++ {
+		select {
+		case notice := <-recorder.notices:
+			notices[notice.FileId] = notice
+		case <-time.After(time.Second):
+			t.Fatal("处理完成后应通知上传者")
+		}
+	}
+
+	video := fileRepo.get(1)
+	if notices[1].Status != model.MediaReady || video.MediaStatus != model.MediaReady || *video.Duration != 12.5 {
+		t.Fatalf("视频处理结果不正确: %+v", video)
+	}
+	if video.Variants == nil || len(*video.Variants) != 1 || (*video.Variants)[0].Name != "poster" || (*video.Variants)[0].Width != 64 {
+		t.Fatalf("应生成视频封面: %+v", video.Variants)
+	}
+	if f := fileRepo.get(4); f.Url != copied || f.UserID != 2 || f.MediaStatus != model.MediaReady || f.Duration == nil || f.Variants == nil {
+		t.Fatalf("秒传的记录应同步处理结果: %+v", f)
+	}
+	if info, _ := storage.Stat(context.Background(), (*video.Variants)[0].ObjectKey); info == nil {
+		t.Fatal("视频封面应写入存储")
+	}
+	if err := fileService.AuthorizeObject(1, (*video.Variants)[0].ObjectKey); err != nil {
+		t.Fatalf("上传者应能访问视频封面: %v", err)
+	}
+	if audio := fileRepo.get(2); audio.MediaStatus != model.MediaReady || audio.Waveform == nil || len(*audio.Waveform) != 8 {
+		t.Fatalf("应生成音频波形: %+v", audio)
+	}
+	if broken := fileRepo.get(3); notices[3].Status != model.MediaFailed || broken.MediaStatus != model.MediaFailed {
+		t.Fatalf("探测失败应标记为处理失败: %+v", broken)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"go-chat/internal/db"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/repository"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sqlRecorder 记录 DryRun 模式下生成的 SQL，不连接数据库
+type sqlRecorder struct {
+	logger.Interface
+	sqls []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.sqls = append(r.sqls, sql)
+}
+
+// dryRunMysql 把 db.Mysql 替换为只生成 SQL 的连接，测试结束后恢复
+func dryRunMysql(t *testing.T) *sqlRecorder {
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "go-chat:go-chat@tcp(127.0.0.1:3306)/go-chat?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := db.Mysql
+	db.Mysql = gormDB
+	t.Cleanup(func() { db.Mysql = origin })
+	return recorder
+}
+
+func TestQueryHistoryMessagesSql(t *testing.T) {
+	recorder := dryRunMysql(t)
+	repository.InitMessageRepository()
+	private := model.PrivateTarget
+	_, err := repository.MessageRepositoryInstance.QueryHistoryMessages(1, &request.QueryMessagesRequest{
+		TargetType: &private, TargetId: 2, Cursor: 100, Direction: request.QueryAfter, Limit: 10,
+		SenderId: 1, HasAttachment: true, MessageTypes: []model.MessageType{model.TextContent, model.ImageContent},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.sqls) != 1 {
+		t.Fatalf("应生成一条 SQL: %v", recorder.sqls)
+	}
+	sql := recorder.sqls[0]
+	// 双方的两个方向必须整体加括号，后面的条件才能同时作用于两个方向
+	pair := "((sender_id = 1 AND receiver_id = 2) OR (sender_id = 2 AND receiver_id = 1))"
+	if !strings.Contains(sql, "WHERE target_type = 0 AND "+pair+" AND ") {
+		t.Fatalf("私聊双方的条件应整体成组: %s", sql)
+	}
+	attachment := "(JSON_SEARCH(content, 'one', 'image', NULL, '$[*].type') IS NOT NULL OR " +
+		"JSON_SEARCH(content, 'one', 'voice', NULL, '$[*].type') IS NOT NULL)"
+	for _, predicate := range []string{" AND id > 100 AND ", " AND type IN (0,1) AND ", " AND sender_id = 1 AND ",
+		" AND " + attachment + " AND ", "ORDER BY id ASC LIMIT 11"} {
+		if !strings.Contains(sql, predicate) {
+			t.Fatalf("缺少条件 %q: %s", predicate, sql)
+		}
+	}
+}
+
+// 删除、清空和限时消息的条件必须同时作用于自己发出和收到的私聊消息
+func TestHiddenMessagesSql(t *testing.T) {
+	recorder := dryRunMysql(t)
+	repository.InitMessageRepository()
+	private := model.PrivateTarget
+	if _, err := repository.MessageRepositoryInstance.QueryHistoryMessages(1, &request.QueryMessagesRequest{TargetType: &private, TargetId: 2}); err != nil {
+		t.Fatal(err)
+	}
+	sql := recorder.sqls[0]
+	pair := "((sender_id = 1 AND receiver_id = 2) OR (sender_id = 2 AND receiver_id = 1))"
+	rest, ok := strings.CutPrefix(sql, "SELECT * FROM `messages` WHERE target_type = 0 AND "+pair+" AND ")
+	if !ok {
+		t.Fatalf("私聊双方的条件应整体成组: %s", sql)
+	}
+	for _, predicate := range []string{
+		"(expire_at IS NULL OR expire_at > ",
+		"(NOT EXISTS (SELECT 1 FROM message_deletions d WHERE d.message_id = messages.id AND d.user_id = 1 AND d.deleted_at IS NULL))",
+		"(id > COALESCE((SELECT c.cleared_id FROM conversation_clears c WHERE c.user_id = 1 AND c.target_type = 0 AND c.target_id = 2 AND c.deleted_at IS NULL), 0))",
+	} {
+		if !strings.Contains(rest, predicate) {
+			t.Fatalf("缺少条件 %q: %s", predicate, sql)
+		}
+	}
+}
+
+// 话题回复与历史消息使用相同的可见性条件
+func TestThreadRepliesSql(t *testing.T) {
+	recorder := dryRunMysql(t)
+	repository.InitMessageRepository()
+	if _, err := repository.MessageRepositoryInstance.QueryThreadReplies(1, 50, model.GroupTarget, 3, 60, 20); err != nil {
+		t.Fatal(err)
+	}
+	sql := recorder.sqls[0]
+	for _, predicate := range []string{
+		"WHERE (root_id = 50 AND id > 60) AND ",
+		"(expire_at IS NULL OR expire_at > ",
+		"(NOT EXISTS (SELECT 1 FROM message_deletions d WHERE d.message_id = messages.id AND d.user_id = 1 AND d.deleted_at IS NULL))",
+		"(id > COALESCE((SELECT c.cleared_id FROM conversation_clears c WHERE c.user_id = 1 AND c.target_type = 1 AND c.target_id = 3 AND c.deleted_at IS NULL), 0))",
+		"ORDER BY id ASC LIMIT 21",
+	} {
+		if !strings.Contains(sql, predicate) {
+			t.Fatalf("缺少条件 %q: %s", predicate, sql)
+		}
+	}
+}
+
+func TestCountPinsForUpdateSql(t *testing.T) {
+	recorder := dryRunMysql(t)
+	repository.InitPinRepository()
+	if _, err := repository.PinRepositoryInstance.CountByConversationForUpdate("g1", db.Mysql); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.sqls) != 1 || !strings.HasSuffix(recorder.sqls[0], "FOR UPDATE") {
+		t.Fatalf("统计置顶数应加锁: %v", recorder.sqls)
+	}
+}
@@ -0,0 +1,1270 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"go-chat/configs"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/service"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// messageFixture MessageService 只能初始化一次，消息相关测试共用同一组内存仓库
+type messageFixture struct {
+	users       *fakeUserRepository
+	messages    *fakeMessageRepository
+	groups      *fakeGroupRepository
+	members     *fakeGroupMemberRepository
+	files       *fakeFileRepository
+	mentions    *fakeMentionRepository
+	reactions   *fakeReactionRepository
+	threads     *fakeThreadRepository
+	pins        *fakePinRepository
+	scheduled   *fakeScheduledMessageRepository
+	stickers    *fakeStickerRepository
+	deletions   *fakeMessageDeletionRepository
+	ws          *messageWsRecorder
+	fileService *service.FileService
+	service     *service.MessageService
+}
+
+var (
+	sharedMessageFixture *messageFixture
+	messageFixtureOnce   sync.Once
+)
+
+func newMessageFixture(t *testing.T) *messageFixture {
+	messageFixtureOnce.Do(func() {
+		f := &messageFixture{
+			users:     newFakeUserRepository(),
+			messages:  newFakeMessageRepository(),
+			groups:    newFakeGroupRepository(),
+			members:   &fakeGroupMemberRepository{},
+			files:     &fakeFileRepository{},
+			mentions:  &fakeMentionRepository{},
+			reactions: &fakeReactionRepository{},
+			threads:   newFakeThreadRepository(),
+			pins:      &fakePinRepository{},
+			scheduled: &fakeScheduledMessageRepository{},
+			stickers:  &fakeStickerRepository{},
+			deletions: &fakeMessageDeletionRepository{},
+			ws:        &messageWsRecorder{},
+		}
+		f.messages.deletions = f.deletions
+		f.files.stickers, f.files.members = f.stickers, f.members
+		service.InitFileService(f.files, newFakeUploadSessionRepository(), manager.NewMemoryStorage("http://localhost/object"), nil, nil)
+		f.fileService = service.FileServiceInstance
+		service.InitMessageService(f.messages, f.users, f.groups, f.members, nil, nil, f.fileService, f.ws, f.mentions, f.reactions, f.threads, f.pins, f.scheduled, nil, f.stickers, f.deletions)
+		f.service = service.MessageServiceInstance
+		sharedMessageFixture = f
+	})
+	if configs.AppConfig == nil {
+		configs.AppConfig = &configs.Config{}
+	}
+	return sharedMessageFixture
+}
+
+// messageWsRecorder 记录推送给各用户的 @ 提醒、表情回应、话题回复、限时消息删除和消息撤回
+type messageWsRecorder struct {
+	fakeWsHandler
+	mu        sync.Mutex
+	mentions  map[int64][]model.MentionNotice
+	reactions map[int64][]model.ReactionNotice
+	threads   map[int64][]model.ThreadNotice
+	expired   map[int64][]model.MessageExpiredNotice
+	revoked   map[int64][]model.MessageRevokedNotice
+}
+
+func (r *messageWsRecorder) MentionNotice(userId int64, notice model.MentionNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mentions == nil {
+		r.mentions = make(map[int64][]model.MentionNotice)
+	}
+	r.mentions[userId] = append(r.mentions[userId], notice)
+}
+
+func (r *messageWsRecorder) mentionsOf(userId uint) []model.MentionNotice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mentions[int64(userId)]
+}
+
+func (r *messageWsRecorder) ReactionNotice(userIds []int64, notice model.ReactionNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reactions == nil {
+		r.reactions = make(map[int64][]model.ReactionNotice)
+	}
+	for _, userId := range userIds {
+		r.reactions[userId] = append(r.reactions[userId], notice)
+	}
+}
+
+func (r *messageWsRecorder) reactionsOf(userId uint) []model.ReactionNotice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reactions[int64(userId)]
+}
+
+func (r *messageWsRecorder) ThreadNotice(userId int64, notice model.ThreadNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.threads == nil {
+		r.threads = make(map[int64][]model.ThreadNotice)
+	}
+	r.threads[userId] = append(r.threads[userId], notice)
+}
+
+func (r *messageWsRecorder) threadsOf(userId uint) []model.ThreadNotice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.threads[int64(userId)]
+}
+
+func (r *messageWsRecorder) MessageExpiredNotice(userIds []int64, notice model.MessageExpiredNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.expired == nil {
+		r.expired = make(map[int64][]model.MessageExpiredNotice)
+	}
+	for _, userId := range userIds {
+		r.expired[userId] = append(r.expired[userId], notice)
+	}
+}
+
+func (r *messageWsRecorder) expiredOf(userId uint) []model.MessageExpiredNotice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.expired[int64(userId)]
+}
+
+func (r *messageWsRecorder) MessageRevokedNotice(userIds []int64, notice model.MessageRevokedNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.revoked == nil {
+		r.revoked = make(map[int64][]model.MessageRevokedNotice)
+	}
+	for _, userId := range userIds {
+		r.revoked[userId] = append(r.revoked[userId], notice)
+	}
+}
+
+func (r *messageWsRecorder) revokedOf(userId uint) []model.MessageRevokedNotice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revoked[int64(userId)]
+}
+
+// user 创建一个用户并返回其 id
+func (f *messageFixture) user(t *testing.T, name string) uint {
+	nickname := name
+	user := &model.User{Username: name, Nickname: &nickname}
+	if err := f.users.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+// group 创建一个群，第一个成员为群主
+func (f *messageFixture) group(t *testing.T, name string, members ...uint) uint {
+	group := &model.Group{Name: name, OwnerId: members[0], Status: model.Enable}
+	if err := f.groups.Save(group); err != nil {
+		t.Fatal(err)
+	}
+	for i, member := range members {
+		role := model.Member
+		if i == 0 {
+			role = model.Owner
+		}
+		_ = f.members.Save(&model.GroupMember{GroupId: group.ID, MemberId: member, Role: role})
+	}
+	return group.ID
+}
+
+// groupMessage 构造一条群聊消息
+func (f *messageFixture) groupMessage(sender, groupId uint, parts ...*model.MessagePart) *model.Message {
+	targetType := model.GroupTarget
+	id := int64(groupId)
+	messageType := model.TextContent
+	content := model.MessagePartList(parts)
+	message := &model.Message{SenderId: int64(sender), GroupId: &id, TargetType: &targetType, Type: &messageType, Content: &content}
+	message.InitFields()
+	return message
+}
+
+// private 构造一条私聊消息
+func (f *messageFixture) private(sender, receiver uint, messageType model.MessageType, parts ...*model.MessagePart) *model.Message {
+	targetType := model.PrivateTarget
+	receiverId := int64(receiver)
+	content := model.MessagePartList(parts)
+	message := &model.Message{SenderId: int64(sender), ReceiverId: &receiverId, TargetType: &targetType, Type: &messageType, Content: &content}
+	message.InitFields()
+	return message
+}
+
+func part(contentType model.ContentType, content string) *model.MessagePart {
+	return &model.MessagePart{Type: contentType, Content: &content}
+}
+
+func TestVoiceMessage(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{Message: configs.MessageConfig{VoiceMaxDuration: 30}}
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+
+	mp3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x00 voice")
+	url, err := f.fileService.Upload(alice, formFile(t, "voice.mp3", mp3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	longUrl, _ := f.fileService.Upload(alice, formFile(t, "long.mp3", append(mp3, " long"...)))
+	textUrl, _ := f.fileService.Upload(alice, formFile(t, "a.txt", []byte("hello voice")))
+	voice, _ := f.fileService.FileByUrl(url)
+	long, _ := f.fileService.FileByUrl(longUrl)
+
+	// 音频处理完成前不能发送
+	if _, err := f.service.SendMessage(f.private(alice, bob, model.VoiceContent, part(model.Voice, url))); err == nil {
+		t.Fatal("处理中的语音不能发送")
+	}
+	voiceDuration, longDuration := 3.5, 31.0
+	_ = f.files.UpdateFields(voice.ID, map[string]interface{}{"media_status": model.MediaReady, "duration": &voiceDuration, "waveform": &model.WaveformList{1, 5, 9}})
+	_ = f.files.UpdateFields(long.ID, map[string]interface{}{"media_status": model.MediaReady, "duration": &longDuration})
+
+	// 客户端传入的时长被服务端的值覆盖
+	msg := f.private(alice, bob, model.VoiceContent, part(model.Voice, url))
+	fakeDuration := 1.0
+	(*msg.Content)[0].Duration = &fakeDuration
+	vo, err := f.service.SendMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := (*vo.Content)[0]
+	if *sent.Duration != 3.5 || sent.Waveform == nil || len(*sent.Waveform) != 3 {
+		t.Fatalf("时长和波形应以服务端为准: %+v", sent)
+	}
+
+	rejects := map[string]*model.Message{
+		"超过时长上限": f.private(alice, bob, model.VoiceContent, part(model.Voice, longUrl)),
+		"非音频文件":  f.private(alice, bob, model.VoiceContent, part(model.Voice, textUrl)),
+		"混合其他内容": f.private(alice, bob, model.VoiceContent, part(model.Voice, url), part(model.Text, "hi")),
+		"类型不一致":  f.private(alice, bob, model.TextContent, part(model.Voice, url)),
+	}
+	for name, m := range rejects {
+		if _, err := f.service.SendMessage(m); err == nil {
+			t.Fatalf("%s 应被拒绝", name)
+		}
+	}
+
+	// 接收者播放后标记为已播放并已读，发送者播放不记录，无关用户不能上报
+	if err := f.service.PlayVoice(alice, vo.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.PlayVoice(carol, vo.ID); err == nil {
+		t.Fatal("无关用户不能上报播放")
+	}
+	if err := f.service.PlayVoice(bob, vo.ID); err != nil {
+		t.Fatal(err)
+	}
+	targetType := model.PrivateTarget
+	query := func(userId, targetId uint) *model.Message {
+		resp, err := f.service.QueryMessages(userId, &request.QueryMessagesRequest{TargetType: &targetType, TargetId: targetId, Limit: 10})
+		if err != nil || len(resp.List) != 1 {
+			t.Fatalf("查询消息失败: %v", err)
+		}
+		if userId == bob && (!resp.List[0].IsPlayed || !resp.List[0].IsRead) {
+			t.Fatalf("接收者播放后应标记已播放和已读: %+v", resp.List[0])
+		}
+		if userId == alice && resp.List[0].IsPlayed {
+			t.Fatal("发送者播放不应记录")
+		}
+		m, _ := f.messages.GetById(resp.List[0].ID)
+		return m
+	}
+	query(alice, bob)
+	if m := query(bob, alice); len(*m.PlayedIdList) != 1 {
+		t.Fatalf("播放记录不正确: %v", *m.PlayedIdList)
+	}
+}
+
+func TestForwardMessage(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol, dave := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol"), f.user(t, "dave")
+	groupId := f.group(t, "周末爬山", alice, carol)
+	send := func(m *model.Message) uint {
+		vo, err := f.service.SendMessage(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vo.ID
+	}
+	imageUrl, _ := f.fileService.Upload(bob, formFile(t, "a.txt", []byte("forward image")))
+	first := send(f.private(alice, bob, model.TextContent, part(model.Text, "在吗")))
+	second := send(f.private(bob, alice, model.ImageContent, part(model.Image, imageUrl)))
+	f.files.show(alice, imageUrl)
+	groupMsg := send(f.groupMessage(carol, groupId, part(model.Text, "集合")))
+	private, group := model.PrivateTarget, model.GroupTarget
+	toCarol := request.ForwardTarget{TargetType: &private, TargetId: carol}
+	toGroup := request.ForwardTarget{TargetType: &group, TargetId: groupId}
+
+	// 权限：只能转发自己看得到的消息，只能转发到自己所在的群
+	if _, err := f.service.Forward(dave, request.ForwardMessageReq{MessageIds: []uint{first}, Targets: []request.ForwardTarget{toCarol}}); err == nil {
+		t.Fatal("看不到的消息不能转发")
+	}
+	if _, err := f.service.Forward(bob, request.ForwardMessageReq{MessageIds: []uint{first}, Targets: []request.ForwardTarget{toGroup}}); err == nil {
+		t.Fatal("不能转发到未加入的群")
+	}
+	if _, err := f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{first, groupMsg}, Merged: true, Targets: []request.ForwardTarget{toCarol}}); err == nil {
+		t.Fatal("合并转发的消息必须来自同一会话")
+	}
+
+	// 聊天记录只能由转发生成，客户端不能自行构造记录内容
+	extra, _ := model.NewExtraData(&model.ForwardRecord{Title: "聊天记录", Items: []model.ForwardItem{
+		{Type: model.ImageContent, Content: &model.MessagePartList{part(model.Image, imageUrl)}},
+	}})
+	forged := f.private(dave, carol, model.ForwardedCotent, part(model.Text, "聊天记录"))
+	forged.ExtraData = extra
+	if _, err := f.service.SendMessage(forged); err == nil {
+		t.Fatal("不能直接发送聊天记录")
+	}
+
+	// 逐条转发按时间顺序复制内容
+	list, err := f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{second, first}, Targets: []request.ForwardTarget{toCarol, toGroup}})
+	if err != nil || len(list) != 4 {
+		t.Fatalf("逐条转发失败: %v", err)
+	}
+	if list[0].SenderId != int64(alice) || *(*list[0].Content)[0].Content != "在吗" || *list[1].Type != model.ImageContent || *list[3].GroupId != int64(groupId) {
+		t.Fatalf("逐条转发内容不正确: %+v", list)
+	}
+
+	// 合并转发生成一条聊天记录，带发送者昵称快照，收到的人可以查看
+	list, err = f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{first, second}, Merged: true, Targets: []request.ForwardTarget{toCarol}})
+	if err != nil || len(list) != 1 || *list[0].Type != model.ForwardedCotent {
+		t.Fatalf("合并转发失败: %v", err)
+	}
+	record, err := f.service.ForwardRecord(carol, list[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Title != "alice和bob的聊天记录" || len(record.Items) != 2 || record.Items[1].SenderNickname != "bob" {
+		t.Fatalf("聊天记录不正确: %+v", record)
+	}
+	if len(record.Summary) != 2 || record.Summary[1] != "bob: [图片]" {
+		t.Fatalf("聊天记录摘要不正确: %v", record.Summary)
+	}
+	if _, err := f.service.ForwardRecord(dave, list[0].ID); err == nil {
+		t.Fatal("无关用户不能查看聊天记录")
+	}
+
+	// 聊天记录可以再次合并转发，嵌套的记录原样保留
+	f.files.show(carol, imageUrl)
+	list, err = f.service.Forward(carol, request.ForwardMessageReq{MessageIds: []uint{list[0].ID}, Merged: true, Targets: []request.ForwardTarget{toGroup}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, _ := f.service.ForwardRecord(alice, list[0].ID)
+	var inner model.ForwardRecord
+	if err := nested.Items[0].ExtraData.Decode(&inner); err != nil || len(inner.Items) != 2 || nested.Summary[0] != "alice: [聊天记录]" {
+		t.Fatalf("嵌套聊天记录不正确: %+v %v", nested, err)
+	}
+}
+
+func TestForwardMentionAndSticker(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	groupId := f.group(t, "转发群", alice, bob)
+	otherGroup := f.group(t, "另一个群", alice, carol)
+	packId := uint(1)
+	sticker := &model.Sticker{PackId: &packId, Name: "ok", Url: "http://localhost/object/sticker/ok.png"}
+	groupSticker := &model.Sticker{GroupId: &groupId, Name: "群表情", Url: "http://localhost/object/sticker/group.png"}
+	_, _ = f.stickers.Create(sticker)
+	_, _ = f.stickers.Create(groupSticker)
+	mention := &model.MessagePart{Type: model.MentionUser, UserId: &bob}
+	sent, err := f.service.SendMessage(f.groupMessage(alice, groupId, mention, part(model.Text, " 看这个"),
+		&model.MessagePart{Type: model.StickerPart, StickerId: &sticker.ID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, group := model.PrivateTarget, model.GroupTarget
+	forward := func(target request.ForwardTarget) *response.MessageVo {
+		list, err := f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{sent.ID}, Targets: []request.ForwardTarget{target}})
+		if err != nil || len(list) != 1 {
+			t.Fatalf("转发失败: %v", err)
+		}
+		return list[0]
+	}
+
+	// 转发到原群保留 @ 并重新提醒，表情按 sticker_id 重新填充图片
+	before := len(f.ws.mentionsOf(bob))
+	same := *forward(request.ForwardTarget{TargetType: &group, TargetId: groupId}).Content
+	if same[0].Type != model.MentionUser || same[0].UserId == nil || *same[0].UserId != bob {
+		t.Fatalf("转发到原群应保留 @: %+v", same[0])
+	}
+	if same[2].StickerId == nil || *same[2].StickerId != sticker.ID || *same[2].Content != sticker.Url {
+		t.Fatalf("转发的表情应保留 sticker_id: %+v", same[2])
+	}
+	if len(f.ws.mentionsOf(bob)) != before+1 {
+		t.Fatal("转发到原群应提醒被 @ 的成员")
+	}
+
+	// 转发到私聊或其他群时 @ 变为文字，不再提醒
+	for _, target := range []request.ForwardTarget{{TargetType: &private, TargetId: carol}, {TargetType: &group, TargetId: otherGroup}} {
+		content := *forward(target).Content
+		if content[0].Type != model.Text || content[0].UserId != nil || *content[0].Content != *(*sent.Content)[0].Content {
+			t.Fatalf("@ 应转为文字: %+v", content[0])
+		}
+		if content[2].Type != model.StickerPart || *content[2].StickerId != sticker.ID {
+			t.Fatalf("转发的表情不正确: %+v", content[2])
+		}
+	}
+	if len(f.ws.mentionsOf(bob)) != before+1 {
+		t.Fatal("@ 转为文字后不应再提醒")
+	}
+
+	// 群自定义表情仍只能在所属的群中使用
+	own, err := f.service.SendMessage(f.groupMessage(alice, groupId, &model.MessagePart{Type: model.StickerPart, StickerId: &groupSticker.ID}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{own.ID}, Targets: []request.ForwardTarget{{TargetType: &group, TargetId: otherGroup}}}); err == nil {
+		t.Fatal("群自定义表情不能转发到其他群")
+	}
+}
+
+func TestMessageFileAccess(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	secret, _ := f.fileService.Upload(alice, formFile(t, "secret.txt", []byte("alice secret")))
+	image := func(url string) *model.Message {
+		return f.private(bob, carol, model.ImageContent, part(model.Image, url))
+	}
+
+	// 不能把看不到的文件地址放进消息，否则会话中的其他人就能借此读取
+	if _, err := f.service.SendMessage(image(secret)); !errors.Is(err, interfacesservice.ErrFileForbidden) {
+		t.Fatalf("不能发送无权访问的文件: %v", err)
+	}
+	if _, err := f.service.SendMessage(image("http://localhost/object/upload/part-1")); !errors.Is(err, interfacesservice.ErrFileForbidden) {
+		t.Fatalf("不能发送不是文件记录的对象: %v", err)
+	}
+	if _, err := f.service.SendMessage(image("https://example.com/a.png")); err != nil {
+		t.Fatalf("外部地址不校验: %v", err)
+	}
+
+	// 能看到引用该文件的消息后可以引用，但只有上传者能用作头像
+	if err := f.fileService.CheckOwned(carol, secret); !errors.Is(err, interfacesservice.ErrFileForbidden) {
+		t.Fatalf("没有上传过的文件不能用作头像: %v", err)
+	}
+	f.files.show(carol, secret)
+	if err := f.fileService.CheckUsable(carol, secret); err != nil {
+		t.Fatalf("能看到消息的用户可以引用文件: %v", err)
+	}
+	if err := f.fileService.CheckOwned(carol, secret); !errors.Is(err, interfacesservice.ErrFileForbidden) {
+		t.Fatalf("只是能看到的文件不能用作头像: %v", err)
+	}
+	if err := f.fileService.CheckOwned(alice, secret, "https://example.com/avatar.png"); err != nil {
+		t.Fatalf("自己上传的文件可以用作头像: %v", err)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol, dave := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol"), f.user(t, "dave")
+	groupId := f.group(t, "读书会", alice, bob, carol)
+	_ = f.members.Update(groupId, bob, map[string]interface{}{"g_nick_name": "小b"})
+	mention := func(userId uint) *model.MessagePart {
+		return &model.MessagePart{Type: model.MentionUser, UserId: &userId}
+	}
+	mentionAll := &model.MessagePart{Type: model.MentionAll}
+
+	// 只能在群聊中 @ 群成员，@所有人 仅群主和管理员可用
+	rejects := map[string]*model.Message{
+		"私聊中@":     f.private(alice, bob, model.TextContent, mention(bob)),
+		"@非群成员":    f.groupMessage(alice, groupId, mention(dave)),
+		"未指定成员":    f.groupMessage(alice, groupId, &model.MessagePart{Type: model.MentionUser}),
+		"普通成员@所有人": f.groupMessage(bob, groupId, mentionAll),
+	}
+	for name, m := range rejects {
+		if _, err := f.service.SendMessage(m); err == nil {
+			t.Fatalf("%s 应被拒绝", name)
+		}
+	}
+
+	// 显示文字由服务端填充，优先使用群昵称；@自己不提醒
+	vo, err := f.service.SendMessage(f.groupMessage(carol, groupId, mention(bob), part(model.Text, " 看"), mention(alice), mention(carol)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *(*vo.Content)[0].Content != "@小b" || *(*vo.Content)[2].Content != "@alice" {
+		t.Fatalf("@ 的显示文字不正确: %+v", *vo.Content)
+	}
+	if notices := f.ws.mentionsOf(bob); len(notices) != 1 || notices[0].MessageId != vo.ID || notices[0].IsAll || notices[0].Preview != "@小b 看@alice@carol" {
+		t.Fatalf("被 @ 的成员应收到提醒: %+v", notices)
+	}
+	if len(f.ws.mentionsOf(carol)) != 0 {
+		t.Fatal("@自己不应提醒")
+	}
+
+	// @所有人 提醒除发送者外的全部成员，同时被单独 @ 的按单独 @ 处理
+	all, err := f.service.SendMessage(f.groupMessage(alice, groupId, mentionAll, mention(bob), part(model.Text, " 开会")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notices := f.ws.mentionsOf(carol); len(notices) != 1 || !notices[0].IsAll || *(*all.Content)[0].Content != "@所有人" {
+		t.Fatalf("@所有人 应提醒全部成员: %+v", notices)
+	}
+	if notices := f.ws.mentionsOf(bob); len(notices) != 2 || notices[1].IsAll {
+		t.Fatalf("单独被 @ 的成员提醒不正确: %+v", notices)
+	}
+	if len(f.ws.mentionsOf(alice)) != 1 {
+		t.Fatal("@所有人 不应提醒发送者")
+	}
+
+	// “@我的”列表和已读
+	page, err := f.service.MentionList(bob, request.MentionQueryRequest{UnreadOnly: true})
+	if err != nil || page.Total != 2 || page.Records[0].Message == nil || page.Records[0].Message.ID != all.ID {
+		t.Fatalf("@我的 列表不正确: %+v %v", page, err)
+	}
+	if err := f.service.ReadMessage(vo.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ = f.service.MentionList(bob, request.MentionQueryRequest{UnreadOnly: true}); page.Total != 1 {
+		t.Fatal("看过消息后对应的 @ 应标记已读")
+	}
+	if count, _ := f.service.ReadMentions(bob, request.MentionReadRequest{GroupId: groupId}); count != 1 {
+		t.Fatalf("应标记整个群的 @ 已读: %d", count)
+	}
+
+	// 撤回后不再出现在“@我的”中
+	if err := f.service.Revoke(alice, all.ID); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ = f.service.MentionList(carol, request.MentionQueryRequest{}); page.Total != 0 {
+		t.Fatal("撤回的消息不应出现在 @我的 中")
+	}
+}
+
+func TestReactions(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{Message: configs.MessageConfig{ReactionMaxPerUser: 2}}
+	alice, bob, carol, dave := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol"), f.user(t, "dave")
+	groupId := f.group(t, "羽毛球", alice, bob, carol)
+	vo, err := f.service.SendMessage(f.groupMessage(alice, groupId, part(model.Text, "周六打球")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	react := func(userId uint, emoji string) ([]response.ReactionVo, error) {
+		return f.service.AddReaction(userId, request.ReactionReq{MessageId: vo.ID, Emoji: emoji})
+	}
+
+	if _, err := react(dave, "👍"); err == nil {
+		t.Fatal("看不到消息的用户不能回应")
+	}
+	for _, emoji := range []string{"ok", "赞", "👍 "} {
+		if _, err := react(bob, emoji); err == nil {
+			t.Fatalf("%q 不是合法的表情", emoji)
+		}
+	}
+
+	// 重复回应同一表情不重复计数，超过每人上限拒绝
+	_, _ = react(bob, "👍")
+	_, _ = react(bob, "👍")
+	_, _ = react(carol, "👍")
+	if _, err := react(bob, "🎉"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := react(bob, "😂"); err == nil {
+		t.Fatal("超过每人回应上限应被拒绝")
+	}
+	if notices := f.ws.reactionsOf(alice); len(notices) != 3 || notices[1].Count != 2 || notices[1].Nickname != "carol" {
+		t.Fatalf("回应变化应推送给会话中的其他人: %+v", notices)
+	}
+	if notices := f.ws.reactionsOf(bob); len(notices) != 1 || notices[0].UserId != carol {
+		t.Fatal("不应推送给回应者自己")
+	}
+
+	// 查询历史消息时带上汇总后的回应
+	group := model.GroupTarget
+	resp, err := f.service.QueryMessages(carol, &request.QueryMessagesRequest{TargetType: &group, TargetId: groupId, Limit: 10})
+	if err != nil || len(resp.List) != 1 {
+		t.Fatalf("查询消息失败: %v", err)
+	}
+	reactions := resp.List[0].Reactions
+	if len(reactions) != 2 || reactions[0].Emoji != "👍" || reactions[0].Count != 2 || !reactions[0].Reacted || reactions[1].Reacted {
+		t.Fatalf("回应汇总不正确: %+v", reactions)
+	}
+	if users := reactions[0].Users; users[0].UserId != bob || users[1].Nickname != "carol" {
+		t.Fatalf("回应者列表不正确: %+v", users)
+	}
+
+	// 取消回应
+	reactions, err = f.service.RemoveReaction(bob, request.ReactionReq{MessageId: vo.ID, Emoji: "🎉"})
+	if err != nil || len(reactions) != 1 {
+		t.Fatalf("取消回应失败: %+v %v", reactions, err)
+	}
+	if notices := f.ws.reactionsOf(alice); notices[len(notices)-1].Action != model.ReactionRemove || notices[len(notices)-1].Count != 0 {
+		t.Fatalf("取消回应应推送: %+v", notices)
+	}
+	if _, err := react(bob, "😂"); err != nil {
+		t.Fatalf("取消后应能回应其他表情: %v", err)
+	}
+}
+
+func TestThreads(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol, dave := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol"), f.user(t, "dave")
+	groupId := f.group(t, "旅行计划", alice, bob, carol, dave)
+	_ = f.members.Update(groupId, bob, map[string]interface{}{"g_nick_name": "导游"})
+	otherGroup := f.group(t, "其他群", alice)
+	send := func(m *model.Message) *response.MessageVo {
+		vo, err := f.service.SendMessage(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vo
+	}
+	reply := func(sender uint, replyId uint, text string) *model.Message {
+		m := f.groupMessage(sender, groupId, part(model.Text, text))
+		id := int64(replyId)
+		m.ReplyId = &id
+		return m
+	}
+
+	root := send(f.groupMessage(bob, groupId, part(model.Text, "去哪里玩？")))
+	if _, err := f.service.SendMessage(func() *model.Message {
+		m := f.groupMessage(alice, otherGroup, part(model.Text, "串群"))
+		id := int64(root.ID)
+		m.ReplyId = &id
+		return m
+	}()); err == nil {
+		t.Fatal("不能回复其他会话的消息")
+	}
+
+	// 回复的回复归入同一个话题
+	first := send(reply(carol, root.ID, "海边"))
+	second := send(reply(alice, first.ID, "同意"))
+	if first.RootId == nil || *first.RootId != int64(root.ID) || *second.RootId != int64(root.ID) {
+		t.Fatalf("回复应归入根消息的话题: %v %v", first.RootId, second.RootId)
+	}
+	// 根消息发送者和之前的回复者收到通知，未读数按各自已读的回复计算
+	if notices := f.ws.threadsOf(bob); len(notices) != 2 || notices[1].Unread != 2 || notices[1].ReplyCount != 2 {
+		t.Fatalf("根消息发送者应收到话题通知: %+v", notices)
+	}
+	if notices := f.ws.threadsOf(carol); len(notices) != 1 || notices[0].Unread != 1 || notices[0].SenderNickname != "alice" {
+		t.Fatalf("参与者应收到话题通知: %+v", notices)
+	}
+	if len(f.ws.threadsOf(dave)) != 0 || len(f.ws.threadsOf(alice)) != 0 {
+		t.Fatal("未参与的人和回复者自己不应收到通知")
+	}
+
+	// 历史消息中引用的消息显示其自己的发送者，根消息带话题摘要
+	group := model.GroupTarget
+	resp, err := f.service.QueryMessages(bob, &request.QueryMessagesRequest{TargetType: &group, TargetId: groupId, Limit: 10})
+	if err != nil || len(resp.List) != 3 {
+		t.Fatalf("查询消息失败: %v", err)
+	}
+	latest, oldest := resp.List[0], resp.List[2]
+	if latest.Reply == nil || latest.Reply.ID != first.ID || *latest.Reply.SenderNickName != "carol" || *latest.SenderNickName != "alice" {
+		t.Fatalf("引用的消息发送者不正确: %+v", latest.Reply)
+	}
+	thread := oldest.Thread
+	if thread == nil || thread.ReplyCount != 2 || thread.ParticipantCount != 3 || thread.Participants[0].UserId != bob || thread.Unread != 2 || !thread.Joined {
+		t.Fatalf("话题摘要不正确: %+v", thread)
+	}
+	if *oldest.SenderNickName != "导游" {
+		t.Fatalf("群聊应显示群昵称: %s", *oldest.SenderNickName)
+	}
+
+	// 分页查看回复，传入回复 id 时定位到根消息
+	replies, err := f.service.ThreadReplies(dave, request.ThreadRepliesRequest{RootId: first.ID, Limit: 1})
+	if err != nil || replies.Root.ID != root.ID || len(replies.List) != 1 || replies.List[0].ID != first.ID || !replies.HasMore {
+		t.Fatalf("话题回复分页不正确: %+v %v", replies, err)
+	}
+	replies, _ = f.service.ThreadReplies(dave, request.ThreadRepliesRequest{RootId: root.ID, Cursor: uint(replies.Cursor), Limit: 1})
+	if len(replies.List) != 1 || replies.List[0].ID != second.ID || replies.HasMore {
+		t.Fatalf("话题回复第二页不正确: %+v", replies)
+	}
+	outsider := f.user(t, "erin")
+	if _, err := f.service.ThreadReplies(outsider, request.ThreadRepliesRequest{RootId: root.ID}); err == nil {
+		t.Fatal("非群成员不能查看话题")
+	}
+
+	// 参与的话题和已读
+	page, err := f.service.ThreadList(bob, request.ThreadQueryRequest{UnreadOnly: true})
+	if err != nil || page.Total != 1 || page.Records[0].Root == nil || page.Records[0].Root.ID != root.ID || page.Records[0].Unread != 2 {
+		t.Fatalf("参与的话题不正确: %+v %v", page, err)
+	}
+	if err := f.service.ReadThread(outsider, request.ThreadReadRequest{RootId: root.ID}); err == nil {
+		t.Fatal("非群成员不能标记话题已读")
+	}
+	if err := f.service.ReadThread(bob, request.ThreadReadRequest{RootId: 99999}); err == nil {
+		t.Fatal("不存在的话题不能标记已读")
+	}
+	// 传入回复 id 时标记其所在的话题
+	if err := f.service.ReadThread(bob, request.ThreadReadRequest{RootId: first.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ = f.service.ThreadList(bob, request.ThreadQueryRequest{UnreadOnly: true}); page.Total != 0 {
+		t.Fatal("标记已读后不应有未读话题")
+	}
+
+	// 自己删除的回复、清空聊天记录之前的回复和到期的限时回复不再返回
+	expired := send(reply(carol, root.ID, "限时回复"))
+	past := time.Now().Add(-time.Second)
+	_ = f.messages.UpdateFields(expired.ID, map[string]interface{}{"expire_at": &past})
+	// 仓库在消息测试间共用，避免影响限时消息的删除统计
+	t.Cleanup(func() { _ = f.messages.Delete(expired.ID) })
+	if err := f.service.DeleteMessages(dave, request.MessageDeleteReq{MessageIds: []uint{first.ID}}); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(userId uint) []uint {
+		replies, err := f.service.ThreadReplies(userId, request.ThreadRepliesRequest{RootId: root.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := make([]uint, 0, len(replies.List))
+		for _, vo := range replies.List {
+			list = append(list, vo.ID)
+		}
+		return list
+	}
+	if got := ids(dave); !reflect.DeepEqual(got, []uint{second.ID}) {
+		t.Fatalf("删除的回复和到期的回复不应返回: %v", got)
+	}
+	if got := ids(carol); !reflect.DeepEqual(got, []uint{first.ID, second.ID}) {
+		t.Fatalf("删除只对自己生效: %v", got)
+	}
+	if err := f.service.ClearConversation(carol, request.ConversationClearReq{TargetId: groupId, TargetType: &group}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(carol); len(got) != 0 {
+		t.Fatalf("清空聊天记录之前的回复不应返回: %v", got)
+	}
+}
+
+func TestPins(t *testing.T) {
+	f := newMessageFixture(t)
+	fakeTxMysql(t)
+	configs.AppConfig = &configs.Config{Message: configs.MessageConfig{PinMaxCount: 2}}
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	groupId := f.group(t, "读书会", alice, bob, carol)
+	send := func(m *model.Message) *response.MessageVo {
+		vo, err := f.service.SendMessage(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vo
+	}
+
+	// 私聊双方都可以置顶，置顶后生成系统消息
+	first := send(f.private(alice, bob, model.TextContent, part(model.Text, "明天十点见")))
+	second := send(f.private(bob, alice, model.TextContent, part(model.Text, "好的")))
+	notice, err := f.service.PinMessage(bob, request.PinMessageReq{MessageId: first.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extra model.PinExtra
+	if *notice.Type != model.SystemContent || notice.ExtraData.(model.ExtraData).Decode(&extra) != nil ||
+		extra.Action != model.PinAdd || extra.MessageId != first.ID || extra.OperatorId != bob {
+		t.Fatalf("置顶应生成系统消息: %+v %+v", notice, extra)
+	}
+	if *(*notice.Content)[0].Content != "bob 置顶了一条消息：明天十点见" {
+		t.Fatalf("系统消息文本不正确: %s", *(*notice.Content)[0].Content)
+	}
+	if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: first.ID}); err == nil {
+		t.Fatal("重复置顶应失败")
+	}
+	if _, err := f.service.PinMessage(carol, request.PinMessageReq{MessageId: second.ID}); err == nil {
+		t.Fatal("会话外的人不能置顶")
+	}
+	if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: notice.ID}); err == nil {
+		t.Fatal("系统消息不能置顶")
+	}
+	toBob := model.PrivateTarget
+	for _, merged := range []bool{false, true} {
+		if _, err := f.service.Forward(alice, request.ForwardMessageReq{MessageIds: []uint{notice.ID}, Merged: merged,
+			Targets: []request.ForwardTarget{{TargetType: &toBob, TargetId: bob}}}); err == nil {
+			t.Fatal("系统消息不能转发")
+		}
+	}
+	if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: second.ID}); err != nil {
+		t.Fatal(err)
+	}
+	third := send(f.private(alice, bob, model.TextContent, part(model.Text, "带伞")))
+	if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: third.ID}); err == nil {
+		t.Fatal("超过置顶上限应失败")
+	}
+
+	// 历史消息和置顶列表
+	private := model.PrivateTarget
+	resp, err := f.service.QueryMessages(alice, &request.QueryMessagesRequest{TargetType: &private, TargetId: bob, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := 0
+	for _, vo := range resp.List {
+		if vo.Pinned {
+			pinned++
+		}
+	}
+	if pinned != 2 {
+		t.Fatalf("历史消息应带置顶状态: %d", pinned)
+	}
+	list, err := f.service.PinnedList(bob, request.PinListReq{TargetId: alice, TargetType: &private})
+	if err != nil || len(list) != 2 || list[0].MessageId != second.ID || list[1].PinnedByNickname != "bob" || list[1].Message == nil || !list[1].Message.Pinned {
+		t.Fatalf("置顶列表不正确: %+v %v", list, err)
+	}
+
+	// 取消置顶和撤回都会移除置顶
+	if notice, err = f.service.UnpinMessage(alice, request.PinMessageReq{MessageId: second.ID}); err != nil || *(*notice.Content)[0].Content != "alice 取消置顶了一条消息：好的" {
+		t.Fatalf("取消置顶失败: %v", err)
+	}
+	if _, err := f.service.UnpinMessage(alice, request.PinMessageReq{MessageId: second.ID}); err == nil {
+		t.Fatal("未置顶的消息不能取消置顶")
+	}
+	if err := f.service.Revoke(alice, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := f.service.PinnedList(alice, request.PinListReq{TargetId: bob, TargetType: &private}); len(list) != 0 {
+		t.Fatalf("撤回的消息不应保留置顶: %+v", list)
+	}
+
+	// 群聊仅群主和管理员可以置顶
+	group := model.GroupTarget
+	topic := send(f.groupMessage(carol, groupId, part(model.Text, "本月读《三体》")))
+	if _, err := f.service.PinMessage(carol, request.PinMessageReq{MessageId: topic.ID}); err == nil {
+		t.Fatal("普通成员不能置顶群消息")
+	}
+	if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: topic.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := f.service.PinnedList(carol, request.PinListReq{TargetId: groupId, TargetType: &group}); err != nil || len(list) != 1 || list[0].Message.ID != topic.ID {
+		t.Fatalf("群置顶列表不正确: %+v %v", list, err)
+	}
+	if _, err := f.service.PinnedList(f.user(t, "dave"), request.PinListReq{TargetId: groupId, TargetType: &group}); err == nil {
+		t.Fatal("非群成员不能查看置顶")
+	}
+
+	// 并发置顶不会超过上限：群里已有一条置顶，上限为 2，只有一条能成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 5; i++ {
+		message := send(f.groupMessage(carol, groupId, part(model.Text, fmt.Sprintf("候选书目 %d", i))))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := f.service.PinMessage(alice, request.PinMessageReq{MessageId: message.ID}); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if list, _ := f.service.PinnedList(alice, request.PinListReq{TargetId: groupId, TargetType: &group}); succeeded != 1 || len(list) != 2 {
+		t.Fatalf("并发置顶超过上限: 成功 %d 条, 共 %d 条", succeeded, len(list))
+	}
+}
+
+func TestScheduledMessages(t *testing.T) {
+	f := newMessageFixture(t)
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	groupId := f.group(t, "周末", alice, carol)
+	private, group := model.PrivateTarget, model.GroupTarget
+	text := model.TextContent
+	schedule := func(sender uint, targetType *model.TargetType, targetId uint, content string, sendAt time.Time) (*model.ScheduledMessage, error) {
+		return f.service.ScheduleMessage(sender, request.ScheduleMessageReq{
+			TargetType: targetType,
+			TargetId:   targetId,
+			Type:       &text,
+			Content:    model.MessagePartList{part(model.Text, content)},
+			SendAt:     sendAt,
+		})
+	}
+
+	for name, sendAt := range map[string]time.Time{
+		"发送时间已过": time.Now().Add(-time.Minute),
+		"超过提前天数": time.Now().AddDate(0, 0, 31),
+	} {
+		if _, err := schedule(alice, &private, bob, "hi", sendAt); err == nil {
+			t.Fatalf("%s: 应创建失败", name)
+		}
+	}
+	if _, err := schedule(bob, &group, groupId, "hi", time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("非群成员不能定时发送群消息")
+	}
+
+	birthday, err := schedule(alice, &private, bob, "生日快乐", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, _ := schedule(alice, &private, bob, "取消的消息", time.Now().Add(time.Hour))
+	leaving, _ := schedule(carol, &group, groupId, "退群后发送", time.Now().Add(time.Hour))
+	if err := f.service.CancelScheduled(bob, request.ScheduleCancelReq{Id: canceled.ID}); err == nil {
+		t.Fatal("不能取消他人的定时消息")
+	}
+	if err := f.service.CancelScheduled(alice, request.ScheduleCancelReq{Id: canceled.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.CancelScheduled(alice, request.ScheduleCancelReq{Id: canceled.ID}); err == nil {
+		t.Fatal("不能重复取消")
+	}
+
+	// 未到发送时间不发送；到时后发送，发送时重新校验群成员身份
+	if count, err := f.service.DispatchScheduled(); err != nil || count != 0 {
+		t.Fatalf("未到时间不应发送: %d %v", count, err)
+	}
+	past := time.Now().Add(-time.Second)
+	for _, id := range []uint{birthday.ID, canceled.ID, leaving.ID} {
+		_ = f.scheduled.UpdateFields(id, map[string]interface{}{"send_at": past})
+	}
+	_ = f.members.DeleteByGroupIdAndUserId(groupId, carol)
+	if count, err := f.service.DispatchScheduled(); err != nil || count != 2 {
+		t.Fatalf("应处理两条到期的定时消息: %d %v", count, err)
+	}
+	sent, _ := f.scheduled.GetById(birthday.ID)
+	if sent.Status != model.ScheduleSent || sent.MessageId == nil {
+		t.Fatalf("定时消息应已发送: %+v", sent)
+	}
+	if message, _ := f.service.GetMessageById(*sent.MessageId); message == nil || *(*message.Content)[0].Content != "生日快乐" || uint(*message.ReceiverId) != bob {
+		t.Fatalf("发出的消息不正确: %+v", message)
+	}
+	if failed, _ := f.scheduled.GetById(leaving.ID); failed.Status != model.ScheduleFailed || failed.FailReason == "" {
+		t.Fatalf("退群后定时消息应发送失败: %+v", failed)
+	}
+	if count, _ := f.service.DispatchScheduled(); count != 0 {
+		t.Fatal("已发送的定时消息不应重复发送")
+	}
+
+	// 发送中断（如服务重启）的消息超过租约后重新领取，租约内的不重复发送
+	interrupted, _ := schedule(alice, &private, bob, "中断后重发", time.Now().Add(time.Hour))
+	sending, _ := schedule(alice, &private, bob, "正在发送", time.Now().Add(time.Hour))
+	for id, claimedAt := range map[uint]time.Time{interrupted.ID: time.Now().Add(-time.Hour), sending.ID: time.Now()} {
+		_ = f.scheduled.UpdateFields(id, map[string]interface{}{"send_at": past, "status": model.ScheduleSending, "claimed_at": claimedAt})
+	}
+	if count, err := f.service.DispatchScheduled(); err != nil || count != 1 {
+		t.Fatalf("应重新领取超过租约的定时消息: %d %v", count, err)
+	}
+	if resent, _ := f.scheduled.GetById(interrupted.ID); resent.Status != model.ScheduleSent || resent.MessageId == nil {
+		t.Fatalf("中断的定时消息应重新发送: %+v", resent)
+	}
+	if pending, _ := f.scheduled.GetById(sending.ID); pending.Status != model.ScheduleSending || pending.MessageId != nil {
+		t.Fatalf("租约内的定时消息不应被重新领取: %+v", pending)
+	}
+	if err := f.service.CancelScheduled(alice, request.ScheduleCancelReq{Id: birthday.ID}); err == nil {
+		t.Fatal("已发送的定时消息不能取消")
+	}
+
+	status := model.ScheduleCanceled
+	page, err := f.service.ScheduledList(alice, request.ScheduleQueryRequest{Status: &status})
+	if err != nil || len(page.Records) != 1 || page.Records[0].ID != canceled.ID {
+		t.Fatalf("按状态查询定时消息不正确: %+v %v", page, err)
+	}
+}
+
+func TestEphemeralMessages(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{Message: configs.MessageConfig{TtlMax: 3600}}
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	groupId := f.group(t, "限时群", alice, bob, carol)
+	private := model.PrivateTarget
+	ephemeral := func(m *model.Message, ttl int, burn bool) *model.Message {
+		m.Ttl, m.BurnAfterRead = ttl, burn
+		return m
+	}
+
+	for name, m := range map[string]*model.Message{
+		"超过最长时长":    ephemeral(f.private(alice, bob, model.TextContent, part(model.Text, "hi")), 7200, false),
+		"阅后即焚未设时长":  ephemeral(f.private(alice, bob, model.TextContent, part(model.Text, "hi")), 0, true),
+		"群聊不支持阅后即焚": ephemeral(f.groupMessage(alice, groupId, part(model.Text, "hi")), 10, true),
+	} {
+		if _, err := f.service.SendMessage(m); err == nil {
+			t.Fatalf("%s: 应发送失败", name)
+		}
+	}
+
+	// 限时消息发送时开始计时，客户端传入的销毁时间被忽略
+	timed := f.groupMessage(alice, groupId, part(model.Text, "十分钟后删除"))
+	forged := time.Now().Add(-time.Hour)
+	timed.ExpireAt = &forged
+	vo, err := f.service.SendMessage(ephemeral(timed, 600, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vo.ExpireAt == nil || vo.ExpireAt.Before(time.Now().Add(590*time.Second)) {
+		t.Fatalf("销毁时间应由服务端计算: %v", vo.ExpireAt)
+	}
+	if _, err := f.service.Forward(bob, request.ForwardMessageReq{MessageIds: []uint{vo.ID}, Targets: []request.ForwardTarget{{TargetType: &private, TargetId: alice}}}); err == nil {
+		t.Fatal("限时消息不能转发")
+	}
+
+	// 阅后即焚在接收者读取后开始计时，发送者读取不计时
+	burn, err := f.service.SendMessage(ephemeral(f.private(alice, bob, model.TextContent, part(model.Text, "看完就删")), 30, true))
+	if err != nil || burn.ExpireAt != nil {
+		t.Fatalf("阅后即焚的消息读取前不应计时: %v", err)
+	}
+	_ = f.service.ReadMessage(burn.ID, alice)
+	if message, _ := f.messages.GetById(burn.ID); message.ExpireAt != nil {
+		t.Fatal("发送者读取不应开始计时")
+	}
+	_ = f.service.ReadMessage(burn.ID, bob)
+	message, _ := f.messages.GetById(burn.ID)
+	if message.ExpireAt == nil {
+		t.Fatal("接收者读取后应开始计时")
+	}
+	firstExpire := *message.ExpireAt
+	_ = f.service.ReadMessage(burn.ID, bob)
+	if message, _ := f.messages.GetById(burn.ID); !message.ExpireAt.Equal(firstExpire) {
+		t.Fatal("重复读取不应重新计时")
+	}
+
+	// 到期后从历史消息中消失，定时任务删除并通知会话成员
+	past := time.Now().Add(-time.Second)
+	_ = f.messages.UpdateFields(burn.ID, map[string]interface{}{"expire_at": &past})
+	_ = f.messages.UpdateFields(vo.ID, map[string]interface{}{"expire_at": &past})
+	resp, err := f.service.QueryMessages(bob, &request.QueryMessagesRequest{TargetType: &private, TargetId: alice, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range resp.List {
+		if item.ID == burn.ID {
+			t.Fatal("到期的消息不应出现在历史消息中")
+		}
+	}
+	if count, err := f.service.ExpireMessages(); err != nil || count != 2 {
+		t.Fatalf("应删除两条到期的消息: %d %v", count, err)
+	}
+	if message, _ := f.messages.GetById(burn.ID); message != nil {
+		t.Fatal("到期的消息应被删除")
+	}
+	if notices := f.ws.expiredOf(alice); len(notices) != 2 {
+		t.Fatalf("发送者应收到删除通知: %+v", notices)
+	}
+	if notices := f.ws.expiredOf(carol); len(notices) != 1 || notices[0].MessageId != vo.ID || *notices[0].GroupId != int64(groupId) {
+		t.Fatalf("群成员应收到群消息的删除通知: %+v", notices)
+	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{Message: configs.MessageConfig{RevokeWindow: 60}}
+	alice, bob, carol := f.user(t, "alice"), f.user(t, "bob"), f.user(t, "carol")
+	groupId := f.group(t, "删除群", alice, bob, carol)
+	private, group := model.PrivateTarget, model.GroupTarget
+	send := func(m *model.Message) uint {
+		vo, err := f.service.SendMessage(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vo.ID
+	}
+	history := func(userId uint, targetType model.TargetType, targetId uint) map[uint]*response.MessageVo {
+		resp, err := f.service.QueryMessages(userId, &request.QueryMessagesRequest{TargetType: &targetType, TargetId: targetId, Limit: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := make(map[uint]*response.MessageVo, len(resp.List))
+		for _, item := range resp.List {
+			list[item.ID] = item
+		}
+		return list
+	}
+	aged := func(messageId uint) {
+		_ = f.messages.UpdateFields(messageId, map[string]interface{}{"created_at": time.Now().Add(-time.Hour)})
+	}
+
+	first := send(f.private(alice, bob, model.TextContent, part(model.Text, "第一条")))
+	second := send(f.private(bob, alice, model.TextContent, part(model.Text, "第二条")))
+	other := send(f.private(alice, carol, model.TextContent, part(model.Text, "和 carol 的私聊")))
+
+	// 仅自己删除只对自己隐藏
+	if err := f.service.DeleteMessages(bob, request.MessageDeleteReq{MessageIds: []uint{other}}); err == nil {
+		t.Fatal("不能删除看不到的消息")
+	}
+	if err := f.service.DeleteMessages(bob, request.MessageDeleteReq{MessageIds: []uint{first}}); err != nil {
+		t.Fatal(err)
+	}
+	if list := history(bob, private, alice); list[first] != nil || list[second] == nil {
+		t.Fatalf("删除的消息应只对自己隐藏: %v", list)
+	}
+	if list := history(alice, private, bob); list[first] == nil {
+		t.Fatal("对方仍应看到消息")
+	}
+	// 自己发出的消息同样可以仅自己删除
+	own := send(f.private(alice, bob, model.TextContent, part(model.Text, "自己发的")))
+	if err := f.service.DeleteMessages(alice, request.MessageDeleteReq{MessageIds: []uint{own}}); err != nil {
+		t.Fatal(err)
+	}
+	if list := history(alice, private, bob); list[own] != nil || list[first] == nil {
+		t.Fatalf("自己删除的消息不应再出现: %v", list)
+	}
+	if list := history(bob, private, alice); list[own] == nil {
+		t.Fatal("对方仍应看到消息")
+	}
+
+	// 对所有人删除：发送者只能在时限内撤回自己的消息，任一消息不满足条件时都不删除
+	if err := f.service.DeleteMessages(bob, request.MessageDeleteReq{MessageIds: []uint{second, first}, ForEveryone: true}); err == nil {
+		t.Fatal("不能撤回他人的私聊消息")
+	}
+	if message, _ := f.messages.GetById(second); *message.Status != model.Enable {
+		t.Fatal("校验失败时不应撤回任何消息")
+	}
+	aged(first)
+	if err := f.service.Revoke(alice, first); err == nil {
+		t.Fatal("超过撤回时限不能撤回")
+	}
+	if err := f.service.DeleteMessages(bob, request.MessageDeleteReq{MessageIds: []uint{second}, ForEveryone: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.Revoke(bob, second); err == nil {
+		t.Fatal("不能重复撤回")
+	}
+	vo := history(alice, private, bob)[second]
+	if vo == nil || *vo.Status != model.Disable || vo.Content != nil || *vo.RevokedBy != int64(bob) {
+		t.Fatalf("撤回的消息应保留占位但不返回内容: %+v", vo)
+	}
+	if message, _ := f.messages.GetById(second); message.Content == nil || message.RevokedAt == nil {
+		t.Fatal("撤回的消息内容应保留在库中")
+	}
+	if notices := f.ws.revokedOf(alice); len(notices) != 1 || notices[0].MessageId != second {
+		t.Fatalf("会话成员应收到撤回通知: %+v", notices)
+	}
+
+	// 群主和管理员可以随时撤回群内的消息
+	old := send(f.groupMessage(bob, groupId, part(model.Text, "很久以前")))
+	aged(old)
+	if err := f.service.Revoke(carol, old); err == nil {
+		t.Fatal("普通成员不能撤回他人消息")
+	}
+	if err := f.service.Revoke(bob, old); err == nil {
+		t.Fatal("超过撤回时限不能撤回")
+	}
+	if err := f.service.Revoke(alice, old); err != nil {
+		t.Fatal(err)
+	}
+	if notices := f.ws.revokedOf(carol); len(notices) != 1 || notices[0].OperatorId != alice || notices[0].SenderId != int64(bob) {
+		t.Fatalf("群成员应收到撤回通知: %+v", notices)
+	}
+
+	// 清空聊天记录只对自己生效，之后的新消息照常显示
+	mine := send(f.private(carol, bob, model.TextContent, part(model.Text, "清空前自己发的")))
+	if err := f.service.ClearConversation(carol, request.ConversationClearReq{TargetType: &private, TargetId: bob}); err != nil {
+		t.Fatal(err)
+	}
+	if list := history(carol, private, bob); len(list) != 0 {
+		t.Fatalf("清空后自己发出的消息也不应再出现: %v", list)
+	}
+	if list := history(bob, private, carol); list[mine] == nil {
+		t.Fatal("对方仍应看到消息")
+	}
+	before := send(f.groupMessage(carol, groupId, part(model.Text, "清空前")))
+	if err := f.service.ClearConversation(bob, request.ConversationClearReq{TargetType: &group, TargetId: groupId}); err != nil {
+		t.Fatal(err)
+	}
+	if list := history(bob, group, groupId); len(list) != 0 {
+		t.Fatalf("清空后不应再看到之前的消息: %v", list)
+	}
+	if list := history(carol, group, groupId); list[before] == nil {
+		t.Fatal("其他成员仍应看到消息")
+	}
+	after := send(f.groupMessage(carol, groupId, part(model.Text, "清空后")))
+	if list := history(bob, group, groupId); len(list) != 1 || list[after] == nil {
+		t.Fatalf("清空后的新消息应正常显示: %v", list)
+	}
+}
+
+func TestQueryMessages(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{}
+	alice, bob := f.user(t, "alice"), f.user(t, "bob")
+	groupId := f.group(t, "翻页群", alice, bob)
+	group := model.GroupTarget
+	imageUrl, _ := f.fileService.Upload(alice, formFile(t, "query.txt", []byte("query image")))
+	ids := make([]uint, 0, 8)
+	for i := 0; i < 8; i++ {
+		sender := []uint{alice, bob}[i%2]
+		m := f.groupMessage(sender, groupId, part(model.Text, fmt.Sprintf("第 %d 条", i+1)))
+		switch i {
+		case 2:
+			m = f.groupMessage(sender, groupId, part(model.Image, imageUrl))
+			*m.Type = model.ImageContent
+		case 5:
+			f.files.show(bob, imageUrl)
+			m = f.groupMessage(sender, groupId, part(model.Text, "看图"), part(model.Image, imageUrl))
+		}
+		vo, err := f.service.SendMessage(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, vo.ID)
+	}
+	query := func(req request.QueryMessagesRequest) *response.QueryMessagesResponse {
+		req.TargetType, req.TargetId = &group, groupId
+		resp, err := f.service.QueryMessages(alice, &req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	expect := func(name string, resp *response.QueryMessagesResponse, hasMore, hasNewer bool, want ...uint) {
+		got := make([]uint, 0, len(resp.List))
+		for _, item := range resp.List {
+			got = append(got, item.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) || resp.HasMore != hasMore || resp.HasNewer != hasNewer {
+			t.Fatalf("%s: 期望 %v more=%v newer=%v，实际 %v more=%v newer=%v", name, want, hasMore, hasNewer, got, resp.HasMore, resp.HasNewer)
+		}
+	}
+
+	expect("未指定条数时使用默认值", query(request.QueryMessagesRequest{}), false, false,
+		ids[7], ids[6], ids[5], ids[4], ids[3], ids[2], ids[1], ids[0])
+
+	// 向前翻页
+	page := query(request.QueryMessagesRequest{Limit: 3})
+	expect("最新一页", page, true, false, ids[7], ids[6], ids[5])
+	page = query(request.QueryMessagesRequest{Limit: 3, Cursor: uint(page.Cursor)})
+	expect("更早一页", page, true, true, ids[4], ids[3], ids[2])
+	if page.Cursor != int64(ids[2]) || page.NewerCursor != int64(ids[4]) {
+		t.Fatalf("游标错误: %d %d", page.Cursor, page.NewerCursor)
+	}
+
+	// 向后翻页，返回的消息同样按从新到旧排列
+	page = query(request.QueryMessagesRequest{Direction: request.QueryAfter, Limit: 3})
+	expect("最早一页", page, false, true, ids[2], ids[1], ids[0])
+	page = query(request.QueryMessagesRequest{Direction: request.QueryAfter, Limit: 3, Cursor: uint(page.NewerCursor)})
+	expect("更新一页", page, true, true, ids[5], ids[4], ids[3])
+	page = query(request.QueryMessagesRequest{Direction: request.QueryAfter, Limit: 3, Cursor: ids[7]})
+	expect("没有更新的消息", page, true, false)
+	if page.NewerCursor != int64(ids[7]) {
+		t.Fatal("没有新消息时应保持原游标")
+	}
+
+	// 跳转到某条消息
+	expect("跳转", query(request.QueryMessagesRequest{Direction: request.QueryAround, Cursor: ids[4], Limit: 4}), true, true,
+		ids[5], ids[4], ids[3], ids[2])
+	expect("跳转到最新的消息", query(request.QueryMessagesRequest{Direction: request.QueryAround, Cursor: ids[7], Limit: 5}), true, false,
+		ids[7], ids[6], ids[5])
+	expect("跳转时只取一条", query(request.QueryMessagesRequest{Direction: request.QueryAround, Cursor: ids[0], Limit: 1}), false, true,
+		ids[0])
+	for name, req := range map[string]request.QueryMessagesRequest{
+		"跳转未指定消息": {Direction: request.QueryAround},
+		"非法的翻页方向": {Direction: "sideways"},
+	} {
+		req.TargetType, req.TargetId = &group, groupId
+		if _, err := f.service.QueryMessages(alice, &req); err == nil {
+			t.Fatalf("%s: 应查询失败", name)
+		}
+	}
+
+	// 过滤条件
+	expect("按多个类型过滤", query(request.QueryMessagesRequest{MessageTypes: []model.MessageType{model.ImageContent, model.VoiceContent}}), false, false,
+		ids[2])
+	expect("按发送者过滤", query(request.QueryMessagesRequest{SenderId: bob, Limit: 2}), true, false, ids[7], ids[5])
+	expect("只查有附件的消息", query(request.QueryMessagesRequest{HasAttachment: true}), false, false, ids[5], ids[2])
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"go-chat/configs"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/acUtil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func textMessage(senderId int64, text string) *model.Message {
+	content := model.MessagePartList{{Type: model.Text, Content: &text}}
+	return &model.Message{SenderId: senderId, Content: &content}
+}
+
+func TestAhoCorasickMask(t *testing.T) {
+	automaton := acUtil.New([]string{"he", "she", "his", "hers", "坏词"})
+	masked, matches := automaton.Mask("uSHErs 说了坏词", '*')
+	if masked != "u***** 说了**" {
+		t.Fatalf("打码结果不正确: %q", masked)
+	}
+	// she / he / hers 重叠命中 + 坏词，打码不区分大小写
+	if len(matches) != 4 {
+		t.Fatalf("命中数量不正确: %+v", matches)
+	}
+	if !automaton.Contains("hello world") || automaton.Contains("abc") {
+		t.Fatal("Contains 结果不正确")
+	}
+}
+
+func TestModerationPipeline(t *testing.T) {
+	wordFile := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordFile, []byte("# 注释\n坏词\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configs.AppConfig = &configs.Config{Moderation: configs.ModerationConfig{
+		Enabled:            true,
+		Words:              []string{"badword"},
+		WordFiles:          []string{wordFile},
+		LinkBlocklist:      []string{"evil.com"},
+		FloodMaxDuplicates: 2,
+	}}
+	words := manager.NewSensitiveWordChecker()
+	moderator := manager.NewModerationManager(words, manager.NewLinkChecker(), manager.NewFloodChecker())
+	if err := moderator.Reload(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 敏感词默认打码放行
+	msg := textMessage(1, "this is a BadWord and 坏词")
+	result := moderator.Moderate(msg)
+	if result.Action != model.ModerationMask || *(*msg.Content)[0].Content != "this is a ******* and **" {
+		t.Fatalf("敏感词应被打码: %v %q", result.Action, *(*msg.Content)[0].Content)
+	}
+	if *(*result.OriginalContent)[0].Content != "this is a BadWord and 坏词" {
+		t.Fatal("应保留原始内容")
+	}
+
+	// 黑名单域名（含子域名）拒绝
+	result = moderator.Moderate(textMessage(2, "看这个 https://www.evil.com/x"))
+	if result.Action != model.ModerationReject {
+		t.Fatalf("黑名单链接应被拒绝: %+v", result)
+	}
+	if moderator.Moderate(textMessage(2, "https://notevil.com")).Action != model.ModerationPass {
+		t.Fatal("相似域名不应命中")
+	}
+
+	// 重复内容刷屏
+	for i := 0; i < 2; i++ {
+		if moderator.Moderate(textMessage(3, "刷屏")).Action != model.ModerationPass {
+			t.Fatalf("第 %d 条不应命中刷屏", i+1)
+		}
+	}
+	if moderator.Moderate(textMessage(3, "刷屏")).Action != model.ModerationReject {
+		t.Fatal("重复内容超过阈值应被拒绝")
+	}
+
+	// 词表文件修改后热加载，动作改为标记审核
+	time.Sleep(10 * time.Millisecond)
+	if err := os.WriteFile(wordFile, []byte("新词\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configs.AppConfig.Moderation.WordAction = "flag"
+	if err := moderator.Reload(false); err != nil {
+		t.Fatal(err)
+	}
+	if moderator.Moderate(textMessage(4, "坏词")).Action != model.ModerationPass {
+		t.Fatal("旧词应已移除")
+	}
+	result = moderator.Moderate(textMessage(4, "出现了新词"))
+	if result.Action != model.ModerationFlag || len(result.Hits) != 1 || result.Hits[0].Checker != "sensitive_word" {
+		t.Fatalf("新词应被标记审核: %+v", result)
+	}
+
+	// 关闭审核后全部放行
+	configs.AppConfig.Moderation.Enabled = false
+	if moderator.Moderate(textMessage(5, "badword")).Action != model.ModerationPass {
+		t.Fatal("关闭审核后应放行")
+	}
+}
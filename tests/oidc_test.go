@@ -0,0 +1,342 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"go-chat/configs"
+	apiv1 "go-chat/internal/api/v1"
+	controllers "go-chat/internal/controller"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/service"
+	"go-chat/internal/utils/jwtUtil"
+	"go-chat/internal/utils/oidcUtil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIdp 模拟 OIDC 身份提供方：授权接口直接“同意”并回跳，token 接口校验 PKCE 后签发 RS256 id_token
+type mockIdp struct {
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	clientId     string
+	clientSecret string
+	subject      string
+	email        string
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge   string
+	nonce       string
+	redirectUri string
+}
+
+func newMockIdp(t *testing.T) *mockIdp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdp{
+		key:          key,
+		clientId:     "go-chat",
+		clientSecret: "secret",
+		subject:      "employee-42",
+		email:        "alice@example.com",
+		codes:        make(map[string]mockAuthorization),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != idp.clientId || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		code, _ := oidcUtil.RandomString(16)
+		idp.mu.Lock()
+		idp.codes[code] = mockAuthorization{
+			challenge:   q.Get("code_challenge"),
+			nonce:       q.Get("nonce"),
+			redirectUri: q.Get("redirect_uri"),
+		}
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != idp.clientId || secret != idp.clientSecret {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		idp.mu.Lock()
+		auth, exists := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+		if !exists || auth.redirectUri != r.PostForm.Get("redirect_uri") ||
+			oidcUtil.CodeChallengeS256(r.PostForm.Get("code_verifier")) != auth.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idp.mu.Lock()
+		subject, email := idp.subject, idp.email
+		idp.mu.Unlock()
+		claims := oidcUtil.IdTokenClaims{
+			Nonce:             auth.nonce,
+			Email:             email,
+			EmailVerified:     true,
+			Name:              "Alice",
+			PreferredUsername: "alice",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    idp.server.URL,
+				Subject:   subject,
+				Audience:  jwt.ClaimStrings{idp.clientId},
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			},
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test-key"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-" + subject,
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+			"expires_in":   300,
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// setAccount 切换之后授权的外部账号及其邮箱
+func (idp *mockIdp) setAccount(subject, email string) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.subject = subject
+	idp.email = email
+}
+
+// oidcAuthorize 在提供方完成授权，返回回跳 go-chat 的回调地址
+func oidcAuthorize(t *testing.T, authUrl string) string {
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback.RequestURI()
+}
+
+// oidcCallback 以携带 cookies 的浏览器打开回调地址
+func oidcCallback(t *testing.T, router *gin.Engine, callbackUri string, cookies []*http.Cookie) model.Response {
+	req := httptest.NewRequest(http.MethodGet, callbackUri, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var body model.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("回调响应解析失败: %v", err)
+	}
+	return body
+}
+
+// oidcLogin 完整走一遍 go-chat 登录 -> 提供方授权 -> go-chat 回调，返回回调响应、回调地址和浏览器 cookies
+func oidcLogin(t *testing.T, router *gin.Engine) (model.Response, string, []*http.Cookie) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/oidc/mock/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login 应重定向到提供方, got %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	callbackUri := oidcAuthorize(t, w.Header().Get("Location"))
+	return oidcCallback(t, router, callbackUri, cookies), callbackUri, cookies
+}
+
+func TestOidcLoginFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	idp := newMockIdp(t)
+	configs.AppConfig = &configs.Config{
+		Api: configs.ApiConfig{Prefix: "/api/v1"},
+		Jwt: configs.JWTConfig{SecretKey: "test", ExpirationTime: "1h", Issuer: "go-chat", Audience: "go-chat"},
+		Oidc: configs.OidcConfig{Providers: []configs.OidcProviderConfig{{
+			Name:          "mock",
+			Issuer:        idp.server.URL,
+			ClientId:      idp.clientId,
+			ClientSecret:  idp.clientSecret,
+			RedirectUrl:   "http://go-chat.test/api/v1/user/oidc/mock/callback",
+			AutoProvision: true,
+			LinkByEmail:   true,
+		}}},
+	}
+
+	userRepository := newFakeUserRepository()
+	identityRepository := &fakeUserIdentityRepository{}
+	userService := service.InitUserService(fakeWsHandler{}, userRepository, nil)
+	service.InitOidcService(userService, userRepository, identityRepository, manager.NewMemoryOidcStateStore())
+	controllers.InitOidcController(service.OidcServiceInstance)
+	router := gin.New()
+	apiv1.OidcApi(router)
+
+	// 首次登录：自动创建用户并绑定外部身份
+	body, callbackUri, cookies := oidcLogin(t, router)
+	if body.Code != http.StatusOK {
+		t.Fatalf("首次登录失败: %+v", body)
+	}
+	claims, err := jwtUtil.ParseJWT(body.Data.(string))
+	if err != nil {
+		t.Fatalf("签发的 jwt 无效: %v", err)
+	}
+	user, _ := userRepository.GetById(claims.ID)
+	if user == nil || user.Username != "alice" || user.Email == nil || *user.Email != idp.email || !user.EmailVerified {
+		t.Fatalf("自动创建的用户不正确: %+v", user)
+	}
+	identity, _ := identityRepository.GetByProviderAndSubject("mock", idp.subject)
+	if identity == nil || identity.UserId != user.ID {
+		t.Fatalf("外部身份未绑定: %+v", identity)
+	}
+
+	// state 只能使用一次
+	if replay := oidcCallback(t, router, callbackUri, cookies); replay.Code == http.StatusOK {
+		t.Fatal("重放的回调不应登录成功")
+	}
+
+	// 再次登录：复用已绑定的用户，不重复创建
+	body, _, _ = oidcLogin(t, router)
+	if body.Code != http.StatusOK {
+		t.Fatalf("再次登录失败: %+v", body)
+	}
+	claims, _ = jwtUtil.ParseJWT(body.Data.(string))
+	if claims.ID != user.ID || userRepository.count() != 1 {
+		t.Fatalf("再次登录应复用用户 %d, got %d (共 %d 个用户)", user.ID, claims.ID, userRepository.count())
+	}
+
+	// 登录 CSRF：攻击者在自己的浏览器发起登录并完成授权，把回调地址发给受害者，受害者的浏览器没有对应 cookie
+	var w *httptest.ResponseRecorder
+	_, _, victimCookies := oidcLogin(t, router)
+	for name, browser := range map[string][]*http.Cookie{"没有 cookie": nil, "其他浏览器的 cookie": victimCookies} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/oidc/mock/login", nil))
+		forged := oidcAuthorize(t, w.Header().Get("Location"))
+		if resp := oidcCallback(t, router, forged, browser); resp.Code == http.StatusOK {
+			t.Fatalf("%s: 不应使用他人发起的登录状态", name)
+		}
+	}
+
+	// 绑定 CSRF：攻击者为自己的账号发起绑定，受害者在自己的浏览器完成授权，外部身份不能绑到攻击者账号上
+	origin := manager.SessionManagerInstance
+	manager.SessionManagerInstance = &fakeSessionManager{}
+	t.Cleanup(func() { manager.SessionManagerInstance = origin })
+	mallory := &model.User{Username: "mallory", Status: model.Enable}
+	_ = userRepository.Save(mallory)
+	malloryToken, _ := jwtUtil.GenerateJWT(mallory.ID)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/user/oidc/mock/bind", nil)
+	req.Header.Set("Authorization", "Bearer "+malloryToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var bind model.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &bind); err != nil || bind.Code != http.StatusOK {
+		t.Fatalf("发起绑定失败: %s", w.Body.String())
+	}
+	malloryCookies := w.Result().Cookies()
+	idp.setAccount("employee-7", idp.email)
+	forged := oidcAuthorize(t, bind.Data.(string))
+	if resp := oidcCallback(t, router, forged, victimCookies); resp.Code == http.StatusOK {
+		t.Fatal("受害者的浏览器不应完成他人发起的绑定")
+	}
+	if identity, _ := identityRepository.GetByProviderAndSubject("mock", "employee-7"); identity != nil {
+		t.Fatalf("外部身份不应被绑定: %+v", identity)
+	}
+
+	// 在发起绑定的浏览器中完成授权才会绑定到当前用户，浏览器再次发起时沿用已有的 cookie
+	for _, cookie := range malloryCookies {
+		req.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if len(w.Result().Cookies()) != 0 {
+		t.Fatal("已有浏览器标识时不应重新下发 cookie")
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &bind)
+	body = oidcCallback(t, router, oidcAuthorize(t, bind.Data.(string)), malloryCookies)
+	if body.Code != http.StatusOK {
+		t.Fatalf("绑定失败: %+v", body)
+	}
+	identity, _ = identityRepository.GetByProviderAndSubject("mock", "employee-7")
+	if identity == nil || identity.UserId != mallory.ID {
+		t.Fatalf("外部身份应绑定到发起绑定的用户: %+v", identity)
+	}
+
+	// 按邮箱自动关联：只关联邮箱已验证且唯一的本地用户，否则拒绝并提示登录后绑定，也不自动创建用户
+	email := func(address string) *string { return &address }
+	carol := &model.User{Username: "carol", Email: email("carol@example.com"), EmailVerified: true, Status: model.Enable}
+	dave := &model.User{Username: "dave", Email: email("dave@example.com"), Status: model.Enable}
+	erin := &model.User{Username: "erin", Email: email("erin@example.com"), EmailVerified: true, Status: model.Enable}
+	erinCopy := &model.User{Username: "erin2", Email: email("erin@example.com"), EmailVerified: true, Status: model.Enable}
+	for _, u := range []*model.User{carol, dave, erin, erinCopy} {
+		_ = userRepository.Save(u)
+	}
+	users := userRepository.count()
+	for subject, address := range map[string]string{"employee-9": "dave@example.com", "employee-10": "erin@example.com"} {
+		idp.setAccount(subject, address)
+		if body, _, _ = oidcLogin(t, router); body.Code == http.StatusOK {
+			t.Fatalf("%s 不应自动关联本地用户", address)
+		}
+		if identity, _ := identityRepository.GetByProviderAndSubject("mock", subject); identity != nil || userRepository.count() != users {
+			t.Fatalf("%s 不应绑定外部身份或创建用户: %+v", address, identity)
+		}
+	}
+	idp.setAccount("employee-8", "carol@example.com")
+	body, _, _ = oidcLogin(t, router)
+	if body.Code != http.StatusOK {
+		t.Fatalf("已验证邮箱应自动关联: %+v", body)
+	}
+	claims, _ = jwtUtil.ParseJWT(body.Data.(string))
+	if claims.ID != carol.ID || userRepository.count() != users {
+		t.Fatalf("应关联到邮箱已验证的用户 %d, got %d", carol.ID, claims.ID)
+	}
+}
@@ -0,0 +1,309 @@
+package tests
+
+import (
+	"fmt"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/service"
+	"go-chat/internal/utils/pollUtil"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPollChoices(t *testing.T) {
+	options := []uint{11, 12, 13, 14}
+	for name, c := range map[string]struct {
+		choices    []uint
+		maxChoices int
+	}{
+		"未选择":     {nil, 1},
+		"选项不属于投票": {[]uint{11, 99}, 4},
+		"单选选了多项":  {[]uint{11, 12}, 1},
+		"超过可选项数":  {[]uint{11, 12, 13}, 2},
+	} {
+		if _, err := pollUtil.NormalizeChoices(c.choices, options, c.maxChoices); err == nil {
+			t.Fatalf("%s: 应校验失败", name)
+		}
+	}
+	// 重复的选项只计一次，结果按 id 排序
+	choices, err := pollUtil.NormalizeChoices([]uint{13, 11, 13}, options, 2)
+	if err != nil || !reflect.DeepEqual(choices, []uint{11, 13}) {
+		t.Fatalf("选项去重排序不正确: %v %v", choices, err)
+	}
+
+	// 修改投票只增减有变化的选项
+	removed, added := pollUtil.Diff([]uint{11, 13}, []uint{13, 14})
+	if !reflect.DeepEqual(removed, []uint{11}) || !reflect.DeepEqual(added, []uint{14}) {
+		t.Fatalf("修改投票的差异不正确: %v %v", removed, added)
+	}
+	if removed, added = pollUtil.Diff(nil, []uint{12}); removed != nil || !reflect.DeepEqual(added, []uint{12}) {
+		t.Fatalf("首次投票只加票: %v %v", removed, added)
+	}
+
+	// 手动结束或过了截止时间都视为已结束
+	now := time.Now()
+	deadline := now.Add(time.Minute)
+	poll := &model.Poll{Status: model.PollOpen, Deadline: &deadline}
+	if poll.Closed(now) || !poll.Closed(deadline) {
+		t.Fatal("截止时间判断不正确")
+	}
+	poll.Status = model.PollClosed
+	if !poll.Closed(now) {
+		t.Fatal("手动结束的投票应已结束")
+	}
+
+	// 选票按 JSON 保存和读取
+	list := model.PollChoiceList{11, 13}
+	value, _ := list.Value()
+	var scanned model.PollChoiceList
+	if err := scanned.Scan(value); err != nil || !reflect.DeepEqual(scanned, list) {
+		t.Fatalf("选票读写不一致: %v %v", scanned, err)
+	}
+}
+
+// pollFixture PollService 只能初始化一次，复用消息测试的用户、群和消息服务
+type pollFixture struct {
+	*messageFixture
+	polls   *fakePollRepository
+	service *service.PollService
+}
+
+var (
+	pollFixtureOnce   sync.Once
+	sharedPollFixture *pollFixture
+)
+
+func newPollFixture(t *testing.T) *pollFixture {
+	m := newMessageFixture(t)
+	pollFixtureOnce.Do(func() {
+		f := &pollFixture{messageFixture: m, polls: newFakePollRepository()}
+		service.InitPollService(f.polls, m.users, m.groups, m.members, m.service, fakeWsHandler{})
+		f.service = service.PollServiceInstance
+		sharedPollFixture = f
+	})
+	fakeTxMysql(t)
+	return sharedPollFixture
+}
+
+// create 发起投票并返回投票 id 和选项 id
+func (f *pollFixture) create(t *testing.T, creator uint, req request.PollCreateRequest) (uint, []uint) {
+	if len(req.Options) == 0 {
+		req.Options = []string{"A", "B", "C"}
+	}
+	vo, err := f.service.Create(creator, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := vo.ExtraData.(model.ExtraData)
+	var extra model.PollExtra
+	if err := data.Decode(&extra); err != nil {
+		t.Fatal(err)
+	}
+	return extra.PollId, f.optionsOf(extra.PollId)
+}
+
+func (f *pollFixture) optionsOf(pollId uint) []uint {
+	options, _ := f.polls.GetOptions(pollId)
+	ids := make([]uint, 0, len(options))
+	for _, option := range options {
+		ids = append(ids, option.ID)
+	}
+	return ids
+}
+
+// counts 按选项顺序返回票数
+func (f *pollFixture) counts(pollId uint, optionIds []uint) []int {
+	counts := f.polls.voteCounts(pollId)
+	list := make([]int, 0, len(optionIds))
+	for _, id := range optionIds {
+		list = append(list, counts[id])
+	}
+	return list
+}
+
+func (f *pollFixture) voterCount(pollId uint) int {
+	poll, _ := f.polls.GetById(pollId)
+	return poll.VoterCount
+}
+
+func TestPollVoteChange(t *testing.T) {
+	f := newPollFixture(t)
+	owner, member := f.user(t, "poll-change-owner"), f.user(t, "poll-change-member")
+	groupId := f.group(t, "poll-change", owner, member)
+	pollId, options := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "午饭"})
+
+	vo, err := f.service.Vote(member, request.PollVoteRequest{PollId: pollId, OptionIds: []uint{options[0]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vo.MyChoices, []uint{options[0]}) || vo.VoterCount != 1 {
+		t.Fatalf("投票结果不正确: %+v", vo)
+	}
+	// 修改投票：旧选项减票、新选项加票，投票人数不变
+	if vo, err = f.service.Vote(member, request.PollVoteRequest{PollId: pollId, OptionIds: []uint{options[1]}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vo.MyChoices, []uint{options[1]}) {
+		t.Fatalf("修改后的选择不正确: %v", vo.MyChoices)
+	}
+	if counts := f.counts(pollId, options); !reflect.DeepEqual(counts, []int{0, 1, 0}) || f.voterCount(pollId) != 1 {
+		t.Fatalf("修改投票后票数不正确: %v 人数 %d", counts, f.voterCount(pollId))
+	}
+	// 重复提交相同选择不改变票数
+	if _, err = f.service.Vote(member, request.PollVoteRequest{PollId: pollId, OptionIds: []uint{options[1]}}); err != nil {
+		t.Fatal(err)
+	}
+	if counts := f.counts(pollId, options); !reflect.DeepEqual(counts, []int{0, 1, 0}) || f.voterCount(pollId) != 1 {
+		t.Fatalf("重复投票不应改变票数: %v 人数 %d", counts, f.voterCount(pollId))
+	}
+	// 实名投票返回投票人
+	detail, err := f.service.Detail(owner, pollId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voters := detail.Options[1].Voters; len(voters) != 1 || voters[0].UserId != member {
+		t.Fatalf("实名投票应返回投票人: %+v", detail.Options)
+	}
+}
+
+func TestPollVoteChoiceLimit(t *testing.T) {
+	f := newPollFixture(t)
+	owner := f.user(t, "poll-limit-owner")
+	groupId := f.group(t, "poll-limit", owner)
+	single, singleOptions := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "单选"})
+	multi, multiOptions := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "多选", Multiple: true, MaxChoices: 2})
+
+	for name, req := range map[string]request.PollVoteRequest{
+		"单选选了多项":   {PollId: single, OptionIds: singleOptions[:2]},
+		"超过可选项数":   {PollId: multi, OptionIds: multiOptions},
+		"选项属于其他投票": {PollId: multi, OptionIds: []uint{singleOptions[0]}},
+		"未选择":      {PollId: multi},
+	} {
+		if _, err := f.service.Vote(owner, req); err == nil {
+			t.Fatalf("%s: 应投票失败", name)
+		}
+	}
+	if f.voterCount(single) != 0 || f.voterCount(multi) != 0 {
+		t.Fatal("投票失败不应计入投票人数")
+	}
+
+	if _, err := f.service.Vote(owner, request.PollVoteRequest{PollId: multi, OptionIds: multiOptions[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Vote(owner, request.PollVoteRequest{PollId: multi, OptionIds: multiOptions[1:]}); err != nil {
+		t.Fatal(err)
+	}
+	if counts := f.counts(multi, multiOptions); !reflect.DeepEqual(counts, []int{0, 1, 1}) || f.voterCount(multi) != 1 {
+		t.Fatalf("多选修改后票数不正确: %v 人数 %d", counts, f.voterCount(multi))
+	}
+}
+
+func TestPollVoteClosed(t *testing.T) {
+	f := newPollFixture(t)
+	owner, member := f.user(t, "poll-closed-owner"), f.user(t, "poll-closed-member")
+	groupId := f.group(t, "poll-closed", owner, member)
+
+	// 发起人手动结束后不能再投票
+	closed, options := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "手动结束"})
+	if _, err := f.service.Close(member, request.PollCloseRequest{PollId: closed}); err == nil {
+		t.Fatal("普通成员不能结束投票")
+	}
+	vo, err := f.service.Close(owner, request.PollCloseRequest{PollId: closed})
+	if err != nil || !vo.Closed {
+		t.Fatalf("结束投票失败: %v", err)
+	}
+	if _, err := f.service.Vote(member, request.PollVoteRequest{PollId: closed, OptionIds: options[:1]}); err == nil {
+		t.Fatal("已结束的投票不能投票")
+	}
+
+	// 过了截止时间不能再投票
+	deadline := time.Now().Add(time.Hour)
+	expired, options := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "截止", Deadline: &deadline})
+	if _, err := f.service.Vote(member, request.PollVoteRequest{PollId: expired, OptionIds: options[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.polls.UpdateFields(expired, map[string]interface{}{"deadline": time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Vote(member, request.PollVoteRequest{PollId: expired, OptionIds: options[1:2]}); err == nil {
+		t.Fatal("过了截止时间不能修改投票")
+	}
+	if counts := f.counts(expired, options); !reflect.DeepEqual(counts, []int{1, 0, 0}) {
+		t.Fatalf("截止后票数不应变化: %v", counts)
+	}
+	if counts := f.counts(closed, f.optionsOf(closed)); !reflect.DeepEqual(counts, []int{0, 0, 0}) || f.voterCount(closed) != 0 {
+		t.Fatalf("已结束的投票票数不应变化: %v", counts)
+	}
+}
+
+func TestPollVoteNonMember(t *testing.T) {
+	f := newPollFixture(t)
+	owner, outsider := f.user(t, "poll-outsider-owner"), f.user(t, "poll-outsider")
+	groupId := f.group(t, "poll-outsider", owner)
+	pollId, options := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "外人"})
+
+	if _, err := f.service.Vote(outsider, request.PollVoteRequest{PollId: pollId, OptionIds: options[:1]}); err == nil {
+		t.Fatal("非群成员不能投票")
+	}
+	if _, err := f.service.Detail(outsider, pollId); err == nil {
+		t.Fatal("非群成员不能查看投票")
+	}
+	if vote, _ := f.polls.GetVote(pollId, outsider); vote != nil || f.voterCount(pollId) != 0 {
+		t.Fatal("非群成员的选票不应保存")
+	}
+}
+
+// 同一成员并发投票时，行锁保证只有第一次新建选票，其余都按修改处理，不会触发 uk_poll_user 冲突
+func TestPollVoteConcurrent(t *testing.T) {
+	f := newPollFixture(t)
+	owner := f.user(t, "poll-concurrent-owner")
+	members := []uint{owner}
+	for i := 0; i < 4; i++ {
+		members = append(members, f.user(t, fmt.Sprintf("poll-concurrent-%d", i)))
+	}
+	groupId := f.group(t, "poll-concurrent", members...)
+	pollId, options := f.create(t, owner, request.PollCreateRequest{GroupId: groupId, Question: "并发"})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(members)*10)
+	for _, member := range members {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(member uint, option uint) {
+				defer wg.Done()
+				if _, err := f.service.Vote(member, request.PollVoteRequest{PollId: pollId, OptionIds: []uint{option}}); err != nil {
+					errs <- err
+				}
+			}(member, options[i%len(options)])
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("并发投票失败: %v", err)
+	}
+
+	if f.voterCount(pollId) != len(members) {
+		t.Fatalf("投票人数应为 %d，实际 %d", len(members), f.voterCount(pollId))
+	}
+	// 每个成员只有一张选票，选项票数之和等于选票数
+	votes, _ := f.polls.GetVotes(pollId)
+	total := 0
+	for _, count := range f.counts(pollId, options) {
+		total += count
+	}
+	if len(votes) != len(members) || total != len(members) {
+		t.Fatalf("选票数 %d、总票数 %d 应都为 %d", len(votes), total, len(members))
+	}
+	expected := make(map[uint]int)
+	for _, vote := range votes {
+		expected[(*vote.OptionIds)[0]]++
+	}
+	for id, count := range f.polls.voteCounts(pollId) {
+		if expected[id] != count {
+			t.Fatalf("选项(%d)票数 %d 与选票 %d 不一致", id, count, expected[id])
+		}
+	}
+}
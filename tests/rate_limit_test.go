@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-chat/configs"
+	"go-chat/internal/manager"
+	"go-chat/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiterSlidingWindow(t *testing.T) {
+	limiter := manager.NewMemoryRateLimiter(2)
+	window := 100 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		result, _ := limiter.Allow("a", 3, window)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("第 %d 次应放行: %+v", i+1, result)
+		}
+	}
+	result, _ := limiter.Allow("a", 3, window)
+	if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > window {
+		t.Fatalf("超过限制应拒绝并给出重试时间: %+v", result)
+	}
+
+	// key 数量超过上限时淘汰最久未使用的
+	_, _ = limiter.Allow("b", 3, window)
+	_, _ = limiter.Allow("c", 3, window)
+	if limiter.Len() != 2 {
+		t.Fatalf("key 数量应被限制为 2, got %d", limiter.Len())
+	}
+	if result, _ = limiter.Allow("a", 3, window); !result.Allowed {
+		t.Fatal("被淘汰的 key 应重新计数")
+	}
+
+	// 窗口滑过后恢复，过期的 key 被清理
+	time.Sleep(window + 20*time.Millisecond)
+	if result, _ = limiter.Allow("c", 3, window); !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("窗口过后应恢复: %+v", result)
+	}
+	if limiter.Len() != 1 {
+		t.Fatalf("过期的 key 应被清理, got %d", limiter.Len())
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configs.AppConfig = &configs.Config{
+		Jwt: configs.JWTConfig{SecretKey: "test", ExpirationTime: "1h", Issuer: "go-chat", Audience: "go-chat"},
+		Rate: configs.RateConfig{Rules: []configs.RateLimitRule{
+			{Path: "/api/v1/user/login", Method: "POST", Limit: 2, Window: "1m", By: "ip"},
+			{Event: "chat", Limit: 1, Window: "1m"},
+		}},
+	}
+	manager.RateLimitManagerInstance = manager.NewRateLimitManager(manager.NewMemoryRateLimiter(0))
+	router := gin.New()
+	router.Use(middleware.RateLimitMiddleware())
+	router.POST("/api/v1/user/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/user/info", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	login := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/user/login", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		w := login("10.0.0.1")
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != fmt.Sprint(1-i) {
+			t.Fatalf("第 %d 次登录应放行: %d %v", i+1, w.Code, w.Header())
+		}
+	}
+	w := login("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Fatalf("超过限制应返回 429 和 Retry-After: %d %v", w.Code, w.Header())
+	}
+	if w = login("10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatal("不同 ip 应分别计数")
+	}
+
+	// 未配置规则的路由不限流，也不返回限流头
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/info", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("未命中规则的路由不应限流: %d %v", w.Code, w.Header())
+	}
+
+	// ws 事件按用户限流
+	if result := manager.RateLimitManagerInstance.AllowEvent("chat", 1); result == nil || !result.Allowed {
+		t.Fatal("第一条聊天消息应放行")
+	}
+	if result := manager.RateLimitManagerInstance.AllowEvent("chat", 1); result.Allowed {
+		t.Fatal("第二条聊天消息应被限流")
+	}
+	if result := manager.RateLimitManagerInstance.AllowEvent("heartbeat", 1); result != nil {
+		t.Fatal("未配置规则的事件不应限流")
+	}
+}
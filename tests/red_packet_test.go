@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"errors"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	"go-chat/internal/utils/redPacketUtil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedPacketSplit(t *testing.T) {
+	for _, c := range []struct {
+		total int64
+		count int
+	}{{100, 1}, {100, 100}, {101, 2}, {20000, 7}, {3, 3}} {
+		for i := 0; i < 200; i++ {
+			amounts := redPacketUtil.SplitRandom(c.total, c.count)
+			var sum int64
+			for _, amount := range amounts {
+				if amount < 1 {
+					t.Fatalf("每份至少 1 分: %v", amounts)
+				}
+				sum += amount
+			}
+			if len(amounts) != c.count || sum != c.total {
+				t.Fatalf("拆分后总额或个数不正确: %d/%d %v", c.total, c.count, amounts)
+			}
+		}
+	}
+	if amounts := redPacketUtil.SplitFixed(50, 3); len(amounts) != 3 || amounts[2] != 50 {
+		t.Fatalf("普通红包每份金额相同: %v", amounts)
+	}
+
+	// 红包消息的扩展字段按 JSON 原样保存和读取
+	extra, _ := model.NewExtraData(model.RedPacketExtra{RedPacketId: 7, Mode: model.RedPacketRandom})
+	value, _ := extra.Value()
+	var scanned model.ExtraData
+	_ = scanned.Scan([]byte(value.(string)))
+	var decoded model.RedPacketExtra
+	if err := scanned.Decode(&decoded); err != nil || decoded.RedPacketId != 7 || decoded.Mode != model.RedPacketRandom {
+		t.Fatalf("扩展字段读写不一致: %+v %v", decoded, err)
+	}
+}
+
+func TestMemoryRedPacketStoreConcurrentClaim(t *testing.T) {
+	store := manager.NewMemoryRedPacketStore()
+	amounts := redPacketUtil.SplitRandom(1000, 10)
+	_ = store.Put(1, amounts, time.Minute)
+
+	// 50 个用户各抢 3 次，只有 10 人抢到，每人最多一份，总额不超发
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		claimed = make(map[uint]int64)
+		sum     int64
+	)
+	for user := uint(1); user <= 50; user++ {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(user uint) {
+				defer wg.Done()
+				amount, err := store.Take(1, user)
+				if err != nil {
+					if !errors.Is(err, interfacemanager.ErrRedPacketClaimed) && !errors.Is(err, interfacemanager.ErrRedPacketEmpty) {
+						t.Errorf("未知错误: %v", err)
+					}
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if _, ok := claimed[user]; ok {
+					t.Errorf("用户 %d 重复领取", user)
+				}
+				claimed[user] = amount
+				sum += amount
+			}(user)
+		}
+	}
+	wg.Wait()
+	if len(claimed) != 10 || sum != 1000 {
+		t.Fatalf("领取结果不正确: %d 人 共 %d 分", len(claimed), sum)
+	}
+
+	// 落库失败归还后可重新领取
+	var user uint
+	for user = range claimed {
+		break
+	}
+	_ = store.Restore(1, user, claimed[user])
+	if amount, err := store.Take(1, user); err != nil || amount != claimed[user] {
+		t.Fatalf("归还后应能重新领取: %d %v", amount, err)
+	}
+	_ = store.Remove(1)
+	if _, err := store.Take(1, 99); !errors.Is(err, interfacemanager.ErrRedPacketEmpty) {
+		t.Fatalf("清理后不能再领取: %v", err)
+	}
+}
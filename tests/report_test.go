@@ -0,0 +1,282 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go-chat/internal/controller"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reportWsRecorder 记录推送给举报人的结案通知
+type reportWsRecorder struct {
+	fakeWsHandler
+	mu      sync.Mutex
+	notices map[int64][]model.ReportNotice
+}
+
+func (r *reportWsRecorder) ReportNotice(userId int64, notice model.ReportNotice) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.notices == nil {
+		r.notices = make(map[int64][]model.ReportNotice)
+	}
+	r.notices[userId] = append(r.notices[userId], notice)
+}
+
+// noticesOf 结案通知是异步推送的，等待一小段时间再读取
+func (r *reportWsRecorder) noticesOf(userId uint, want int) []model.ReportNotice {
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		notices := append([]model.ReportNotice(nil), r.notices[int64(userId)]...)
+		r.mu.Unlock()
+		if len(notices) >= want || time.Now().After(deadline) {
+			return notices
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// reportFixture ReportService 只能初始化一次，复用消息测试的用户、群和消息仓库
+type reportFixture struct {
+	*messageFixture
+	reports *fakeReportRepository
+	ws      *reportWsRecorder
+	service *service.ReportService
+}
+
+var (
+	reportFixtureOnce   sync.Once
+	sharedReportFixture *reportFixture
+)
+
+func newReportFixture(t *testing.T) *reportFixture {
+	m := newMessageFixture(t)
+	reportFixtureOnce.Do(func() {
+		f := &reportFixture{messageFixture: m, reports: &fakeReportRepository{}, ws: &reportWsRecorder{}}
+		service.InitReportService(f.reports, m.messages, m.users, m.groups, m.members, f.ws)
+		f.service = service.ReportServiceInstance
+		controller.InitReportController(f.service)
+		sharedReportFixture = f
+	})
+	return sharedReportFixture
+}
+
+func TestReportMessageSnapshot(t *testing.T) {
+	f := newReportFixture(t)
+	sender, reporter, outsider := f.user(t, "report-sender"), f.user(t, "report-reporter"), f.user(t, "report-outsider")
+	message := f.private(sender, reporter, model.TextContent, part(model.Text, "加我领红包"))
+	if err := f.messages.Save(message); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.service.Create(outsider, request.ReportCreateRequest{TargetType: model.ReportMessage, TargetId: message.ID, Category: model.ReportSpam}); err == nil {
+		t.Fatal("不能举报自己看不到的消息")
+	}
+	if _, err := f.service.Create(sender, request.ReportCreateRequest{TargetType: model.ReportMessage, TargetId: message.ID, Category: model.ReportSpam}); err == nil {
+		t.Fatal("不能举报自己的消息")
+	}
+	report, err := f.service.Create(reporter, request.ReportCreateRequest{TargetType: model.ReportMessage, TargetId: message.ID, Category: model.ReportFraud, Comment: "  诈骗  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != model.ReportPending || report.Comment == nil || *report.Comment != "诈骗" {
+		t.Fatalf("举报初始状态不正确: %+v", report)
+	}
+
+	// 消息被撤回、内容被改写后，快照仍保留举报时的内容
+	if err := f.messageFixture.service.Revoke(sender, message.ID); err != nil {
+		t.Fatal(err)
+	}
+	changed := model.MessagePartList{part(model.Text, "已修改")}
+	if err := f.messages.UpdateFields(message.ID, map[string]interface{}{"content": &changed}); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := f.reports.GetById(report.ID)
+	var snapshot model.ReportSnapshot
+	if saved.Snapshot == nil || json.Unmarshal([]byte(*saved.Snapshot), &snapshot) != nil || snapshot.Message == nil {
+		t.Fatalf("举报应保存消息快照: %v", saved.Snapshot)
+	}
+	content := *snapshot.Message.Content
+	if snapshot.Message.Id != message.ID || snapshot.Message.SenderId != int64(sender) ||
+		len(content) != 1 || content[0].Content == nil || *content[0].Content != "加我领红包" {
+		t.Fatalf("快照应为举报时的消息内容: %+v", snapshot.Message)
+	}
+}
+
+func TestReportDuplicate(t *testing.T) {
+	f := newReportFixture(t)
+	reporter, target := f.user(t, "report-dup-reporter"), f.user(t, "report-dup-target")
+	req := request.ReportCreateRequest{TargetType: model.ReportUser, TargetId: target, Category: model.ReportAbuse}
+
+	first, err := f.service.Create(reporter, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Create(reporter, req); err == nil {
+		t.Fatal("未结案前不能重复举报同一对象")
+	}
+	if err := f.service.Handle(1, request.ReportHandleRequest{ReportId: first.ID, Status: model.ReportProcessing}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Create(reporter, req); err == nil {
+		t.Fatal("处理中的举报也不能重复提交")
+	}
+	// 其他人举报同一对象不受影响
+	other := f.user(t, "report-dup-other")
+	if _, err := f.service.Create(other, req); err != nil {
+		t.Fatal(err)
+	}
+	// 结案后可以再次举报
+	if err := f.service.Handle(1, request.ReportHandleRequest{ReportId: first.ID, Status: model.ReportRejected}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.Create(reporter, req); err != nil {
+		t.Fatalf("结案后应可再次举报: %v", err)
+	}
+	mine, _ := f.service.MyReports(reporter, request.ReportQueryRequest{ReporterId: other})
+	if mine.Total != 2 {
+		t.Fatalf("我的举报只返回自己提交的: %d", mine.Total)
+	}
+}
+
+func TestReportHandle(t *testing.T) {
+	f := newReportFixture(t)
+	reporter, target, handler := f.user(t, "report-handle-reporter"), f.user(t, "report-handle-target"), f.user(t, "report-handle-admin")
+	report, err := f.service.Create(reporter, request.ReportCreateRequest{TargetType: model.ReportUser, TargetId: target, Category: model.ReportPorn})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, req := range map[string]request.ReportHandleRequest{
+		"改回待处理": {ReportId: report.ID, Status: model.ReportPending},
+		"未知状态":  {ReportId: report.ID, Status: 9},
+		"举报不存在": {ReportId: 9999, Status: model.ReportResolved},
+	} {
+		if err := f.service.Handle(handler, req); err == nil {
+			t.Fatalf("%s: 应处理失败", name)
+		}
+	}
+
+	// 待处理 -> 处理中：记录处理人，不通知举报人
+	if err := f.service.Handle(handler, request.ReportHandleRequest{ReportId: report.ID, Status: model.ReportProcessing}); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := f.reports.GetById(report.ID)
+	if saved.Status != model.ReportProcessing || saved.HandlerId == nil || *saved.HandlerId != handler || saved.HandledAt != nil {
+		t.Fatalf("处理中状态不正确: %+v", saved)
+	}
+
+	// 处理中 -> 已处理：记录结果和结案时间并通知举报人
+	if err := f.service.Handle(handler, request.ReportHandleRequest{ReportId: report.ID, Status: model.ReportResolved, Result: "已封禁"}); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ = f.reports.GetById(report.ID)
+	if saved.Status != model.ReportResolved || saved.HandledAt == nil || saved.Result == nil || *saved.Result != "已封禁" {
+		t.Fatalf("结案状态不正确: %+v", saved)
+	}
+	notices := f.ws.noticesOf(reporter, 1)
+	if len(notices) != 1 || notices[0].ReportId != report.ID || notices[0].Status != model.ReportResolved {
+		t.Fatalf("结案后应通知举报人一次: %+v", notices)
+	}
+
+	// 已结案的举报不能再改状态
+	for _, status := range []model.ReportStatus{model.ReportProcessing, model.ReportRejected, model.ReportResolved} {
+		if err := f.service.Handle(handler, request.ReportHandleRequest{ReportId: report.ID, Status: status}); err == nil {
+			t.Fatalf("已结案的举报不能改为 %d", status)
+		}
+	}
+	if saved, _ = f.reports.GetById(report.ID); saved.Status != model.ReportResolved {
+		t.Fatalf("结案后状态不应变化: %d", saved.Status)
+	}
+
+	// 待处理可以直接驳回
+	rejected, err := f.service.Create(reporter, request.ReportCreateRequest{TargetType: model.ReportUser, TargetId: handler, Category: model.ReportOther})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.Handle(handler, request.ReportHandleRequest{ReportId: rejected.ID, Status: model.ReportRejected}); err != nil {
+		t.Fatal(err)
+	}
+	if notices = f.ws.noticesOf(reporter, 2); len(notices) != 2 || notices[1].Status != model.ReportRejected || notices[1].Result != nil {
+		t.Fatalf("驳回后应通知举报人: %+v", notices)
+	}
+}
+
+func TestReportController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newReportFixture(t)
+	reporter, target, handler := f.user(t, "report-api-reporter"), f.user(t, "report-api-target"), f.user(t, "report-api-admin")
+	post := func(userId uint, path string, handle gin.HandlerFunc, body interface{}) model.Response {
+		router := gin.New()
+		router.POST(path, func(c *gin.Context) {
+			c.Set("id", userId)
+		}, handle)
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+		var resp model.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s 响应不是 JSON: %s", path, w.Body.String())
+		}
+		return resp
+	}
+	con := controller.ReportControllerInstance
+
+	// 缺少必填参数、类别不合法都返回错误
+	if resp := post(reporter, "/report/create", con.Create, map[string]interface{}{"target_type": "user"}); resp.Code == http.StatusOK {
+		t.Fatal("缺少参数应返回错误")
+	}
+	if resp := post(reporter, "/report/create", con.Create, map[string]interface{}{"target_type": "user", "target_id": target, "category": "unknown"}); resp.Code == http.StatusOK {
+		t.Fatal("不支持的类别应返回错误")
+	}
+
+	// 举报人取自登录用户，不能冒充他人
+	resp := post(reporter, "/report/create", con.Create, map[string]interface{}{"target_type": "user", "target_id": target, "category": "spam"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("提交举报失败: %+v", resp)
+	}
+	data, _ := json.Marshal(resp.Data)
+	var report model.Report
+	_ = json.Unmarshal(data, &report)
+	if report.ID == 0 || report.ReporterId != reporter || report.Snapshot == nil {
+		t.Fatalf("返回的举报不正确: %+v", report)
+	}
+	if resp := post(reporter, "/report/create", con.Create, map[string]interface{}{"target_type": "user", "target_id": target, "category": "spam"}); resp.Code == http.StatusOK {
+		t.Fatal("重复举报应返回错误")
+	}
+
+	// 管理员处理：非法状态返回错误，结案后不能再处理
+	if resp := post(handler, "/admin/report/handle", con.Handle, map[string]interface{}{"report_id": report.ID, "status": model.ReportPending}); resp.Code == http.StatusOK {
+		t.Fatal("非法的处理状态应返回错误")
+	}
+	if resp := post(handler, "/admin/report/handle", con.Handle, map[string]interface{}{"report_id": report.ID, "status": model.ReportResolved, "result": "已处理"}); resp.Code != http.StatusOK {
+		t.Fatalf("处理举报失败: %+v", resp)
+	}
+	if resp := post(handler, "/admin/report/handle", con.Handle, map[string]interface{}{"report_id": report.ID, "status": model.ReportRejected}); resp.Code == http.StatusOK {
+		t.Fatal("已结案的举报不能再处理")
+	}
+	saved, _ := f.reports.GetById(report.ID)
+	if saved.HandlerId == nil || *saved.HandlerId != handler {
+		t.Fatalf("处理人应取自登录用户: %+v", saved.HandlerId)
+	}
+
+	// 我的举报只返回当前用户提交的
+	resp = post(reporter, "/report/mine", con.Mine, map[string]interface{}{"reporter_id": target})
+	data, _ = json.Marshal(resp.Data)
+	var mine struct {
+		Records []model.Report `json:"records"`
+	}
+	_ = json.Unmarshal(data, &mine)
+	if resp.Code != http.StatusOK || len(mine.Records) != 1 || mine.Records[0].ID != report.ID {
+		t.Fatalf("我的举报不正确: %+v", resp)
+	}
+}
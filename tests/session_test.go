@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-chat/configs"
+	"go-chat/internal/manager"
+	"go-chat/internal/middleware"
+	"go-chat/internal/utils/jwtUtil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebSocketHandshakeAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configs.AppConfig = &configs.Config{
+		Jwt: configs.JWTConfig{SecretKey: "test", ExpirationTime: "1h", Issuer: "go-chat", Audience: "go-chat"},
+	}
+	sessions := &fakeSessionManager{}
+	origin := manager.SessionManagerInstance
+	manager.SessionManagerInstance = sessions
+	t.Cleanup(func() { manager.SessionManagerInstance = origin })
+	token, _ := jwtUtil.GenerateJWT(7)
+	handshake := func(query string, header string) (int64, error) {
+		req := httptest.NewRequest(http.MethodGet, "/ws"+query, nil)
+		if header != "" {
+			req.Header.Set("Authorization", "Bearer "+header)
+		}
+		return manager.WebSocketUserId(req)
+	}
+
+	// 只带 id 不能建立连接，用户身份以 token 为准
+	if _, err := handshake("?id=7", ""); err == nil {
+		t.Fatal("没有 token 不能连接")
+	}
+	if _, err := handshake("?token=bad", ""); !errors.Is(err, manager.ErrTokenInvalid) {
+		t.Fatalf("无效 token 不能连接: %v", err)
+	}
+	if _, err := handshake("?id=8&token="+token, ""); err == nil {
+		t.Fatal("id 与 token 不一致时不能连接")
+	}
+	if id, err := handshake("?id=7&token="+token, ""); err != nil || id != 7 {
+		t.Fatalf("query 中的 token 应能连接: %d %v", id, err)
+	}
+	if id, err := handshake("", token); err != nil || id != 7 {
+		t.Fatalf("请求头中的 token 应能连接: %d %v", id, err)
+	}
+
+	// 强制下线后旧 token 不能重新连接，也不能再调用接口；重新登录后恢复
+	time.Sleep(2 * time.Millisecond)
+	_ = sessions.RevokeUserTokens(7)
+	if _, err := handshake("?token="+token, ""); !errors.Is(err, manager.ErrTokenRevoked) {
+		t.Fatalf("被吊销的 token 不能重新连接: %v", err)
+	}
+	router := gin.New()
+	router.GET("/me", middleware.AuthMiddleware(), func(c *gin.Context) { c.String(http.StatusOK, "%d", c.GetUint("id")) })
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := get(token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("被吊销的 token 不能调用接口: %d", w.Code)
+	}
+	time.Sleep(2 * time.Millisecond)
+	renewed, _ := jwtUtil.GenerateJWT(7)
+	if id, err := handshake("?token="+renewed, ""); err != nil || id != 7 {
+		t.Fatalf("重新登录后应能连接: %d %v", id, err)
+	}
+	if w := get(renewed); w.Code != http.StatusOK || w.Body.String() != "7" {
+		t.Fatalf("重新登录后应能调用接口: %d %s", w.Code, w.Body.String())
+	}
+}
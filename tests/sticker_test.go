@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"go-chat/configs"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/service"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// stickerPng 生成一张纯色 PNG，颜色不同的图片不会被秒传合并
+func stickerPng(t *testing.T, c color.RGBA) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func stickerPart(id uint) *model.MessagePart {
+	return &model.MessagePart{Type: model.StickerPart, StickerId: &id}
+}
+
+func TestStickers(t *testing.T) {
+	f := newMessageFixture(t)
+	configs.AppConfig = &configs.Config{}
+	service.InitStickerService(f.stickers, f.members, f.fileService)
+	stickers := service.StickerServiceInstance
+	alice, bob, carol := f.user(t, "sticker-alice"), f.user(t, "sticker-bob"), f.user(t, "sticker-carol")
+	groupId := f.group(t, "sticker-group", alice, bob)
+
+	pack, err := stickers.CreatePack(alice, request.StickerPackCreateRequest{Name: " 猫猫 ", Description: "各种猫"})
+	if err != nil || pack.Name != "猫猫" {
+		t.Fatalf("创建表情包失败: %+v %v", pack, err)
+	}
+	// 空表情包只有创建者能看到
+	if result, _ := stickers.PackList(bob, request.StickerPackQueryRequest{}); len(result.Records) != 0 {
+		t.Fatalf("空表情包不应出现在列表中: %+v", result.Records)
+	}
+	if result, _ := stickers.PackList(alice, request.StickerPackQueryRequest{Mine: true}); len(result.Records) != 1 {
+		t.Fatalf("应能看到自己的空表情包: %+v", result.Records)
+	}
+
+	red := formFile(t, "red.png", stickerPng(t, color.RGBA{R: 255, A: 255}))
+	if _, err := stickers.AddSticker(bob, request.StickerAddRequest{PackId: pack.ID, Name: "开心"}, red); err == nil {
+		t.Fatal("不能向别人的表情包添加表情")
+	}
+	if _, err := stickers.AddSticker(alice, request.StickerAddRequest{PackId: pack.ID, Name: "文本"},
+		formFile(t, "a.txt", []byte("not an image"))); err == nil {
+		t.Fatal("表情只能是图片")
+	}
+	happy, err := stickers.AddSticker(alice, request.StickerAddRequest{PackId: pack.ID, Name: "开心", Keywords: " happy   smile "}, red)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if happy.Url == "" || happy.Keywords != "happy smile" || happy.Width != 32 || happy.Height != 24 {
+		t.Fatalf("表情信息错误: %+v", happy)
+	}
+	detail, err := stickers.PackDetail(bob, pack.ID)
+	if err != nil || detail.StickerCount != 1 || detail.Cover != happy.Url || len(detail.Stickers) != 1 {
+		t.Fatalf("第一个表情应作为封面: %+v %v", detail, err)
+	}
+	if result, _ := stickers.SearchPacks(bob, request.StickerPackQueryRequest{Keyword: "smile"}); len(result.Records) != 1 {
+		t.Fatal("应能按包内表情的关键字搜索")
+	}
+	if result, _ := stickers.SearchPacks(bob, request.StickerPackQueryRequest{Keyword: "dog"}); len(result.Records) != 0 {
+		t.Fatal("关键字不匹配时不应返回")
+	}
+	if _, err := stickers.SearchPacks(bob, request.StickerPackQueryRequest{Keyword: " "}); err == nil {
+		t.Fatal("搜索关键字不能为空")
+	}
+
+	// 群自定义表情由群主和管理员管理，短代码群内唯一
+	blue := formFile(t, "blue.png", stickerPng(t, color.RGBA{B: 255, A: 255}))
+	if _, err := stickers.AddSticker(bob, request.StickerAddRequest{GroupId: groupId, Name: "party"}, blue); err == nil {
+		t.Fatal("普通成员不能添加群表情")
+	}
+	if _, err := stickers.AddSticker(alice, request.StickerAddRequest{GroupId: groupId, Name: "派对"}, blue); err == nil {
+		t.Fatal("群表情名称只能是短代码")
+	}
+	party, err := stickers.AddSticker(alice, request.StickerAddRequest{GroupId: groupId, Name: "party"}, blue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stickers.AddSticker(alice, request.StickerAddRequest{GroupId: groupId, Name: "party"}, blue); err == nil {
+		t.Fatal("群内表情名称不能重复")
+	}
+	if _, err := stickers.GroupStickers(carol, groupId); err == nil {
+		t.Fatal("非群成员不能查看群表情")
+	}
+	if list, _ := stickers.GroupStickers(bob, groupId); len(list) != 1 || list[0].ID != party.ID {
+		t.Fatalf("群表情列表错误: %+v", list)
+	}
+
+	// 表情包的图片所有人都能读取，群表情的图片只有群成员能读取
+	objectKey := func(url string) string { return strings.TrimPrefix(url, "http://localhost/object/") }
+	if err := f.fileService.AuthorizeObject(carol, objectKey(happy.Url)); err != nil {
+		t.Fatalf("其他用户应能读取表情包的图片: %v", err)
+	}
+	if err := f.fileService.AuthorizeObject(bob, objectKey(party.Url)); err != nil {
+		t.Fatalf("群成员应能读取群表情的图片: %v", err)
+	}
+	if err := f.fileService.AuthorizeObject(carol, objectKey(party.Url)); !errors.Is(err, interfacesservice.ErrFileForbidden) {
+		t.Fatalf("非群成员不能读取群表情的图片: %v", err)
+	}
+
+	// 发送时按表情ID填充图片地址，群表情只能在本群使用
+	vo, err := f.service.SendMessage(f.groupMessage(bob, groupId, stickerPart(party.ID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := (*vo.Content)[0]; content.Content == nil || *content.Content != party.Url {
+		t.Fatalf("表情内容应为图片地址: %+v", content)
+	}
+	if _, err := f.service.SendMessage(f.private(alice, bob, model.TextContent, stickerPart(party.ID))); err == nil {
+		t.Fatal("群表情不能在私聊中使用")
+	}
+	forged := stickerPart(happy.ID)
+	forged.Content = &party.Url
+	vo, err = f.service.SendMessage(f.private(alice, carol, model.TextContent, part(model.Text, "看"), forged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *(*vo.Content)[1].Content != happy.Url {
+		t.Fatal("表情内容应由服务端填充")
+	}
+	if _, err := f.service.SendMessage(f.private(alice, bob, model.TextContent, stickerPart(9999))); err == nil {
+		t.Fatal("不存在的表情不能发送")
+	}
+	if _, err := f.service.SendMessage(f.private(alice, bob, model.ImageContent, stickerPart(happy.ID))); err == nil {
+		t.Fatal("表情只能在文本消息中发送")
+	}
+
+	// 收藏
+	if err := stickers.AddFavorite(carol, request.StickerIdRequest{StickerId: party.ID}); err == nil {
+		t.Fatal("非群成员不能收藏群表情")
+	}
+	if err := stickers.AddFavorite(bob, request.StickerIdRequest{StickerId: happy.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stickers.AddFavorite(bob, request.StickerIdRequest{StickerId: happy.ID}); err == nil {
+		t.Fatal("不能重复收藏")
+	}
+	if err := stickers.AddFavorite(bob, request.StickerIdRequest{StickerId: party.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := stickers.Favorites(bob); len(list) != 2 || list[0].ID != party.ID {
+		t.Fatalf("收藏列表应按收藏时间倒序: %+v", list)
+	}
+
+	// 删除表情时清理收藏，删除的是封面时重新选择封面
+	if err := stickers.RemoveSticker(bob, request.StickerIdRequest{StickerId: happy.ID}); err == nil {
+		t.Fatal("不能删除别人表情包中的表情")
+	}
+	if err := stickers.RemoveSticker(alice, request.StickerIdRequest{StickerId: happy.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := stickers.Favorites(bob); len(list) != 1 || list[0].ID != party.ID {
+		t.Fatalf("被删除的表情应从收藏中移除: %+v", list)
+	}
+	if detail, _ := stickers.PackDetail(alice, pack.ID); detail.StickerCount != 0 || detail.Cover != "" {
+		t.Fatalf("表情包应更新数量和封面: %+v", detail)
+	}
+	if err := stickers.RemoveFavorite(bob, request.StickerIdRequest{StickerId: party.ID}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"go-chat/configs"
+	interfacemanager "go-chat/internal/interfaces/manager"
+	"go-chat/internal/manager"
+	"go-chat/internal/utils/signUtil"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageDrivers(t *testing.T) {
+	configs.AppConfig = &configs.Config{Storage: configs.StorageConfig{SignSecret: "test"}}
+	local, err := manager.NewLocalStorage(t.TempDir(), "http://localhost/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drivers := map[string]interfacemanager.Storage{
+		"local":  local,
+		"memory": manager.NewMemoryStorage("http://localhost/object"),
+	}
+	ctx := context.Background()
+	for name, storage := range drivers {
+		info, err := storage.Put(ctx, "image/a.txt", strings.NewReader("hello"), 5, "text/plain")
+		if err != nil || info.Size != 5 || info.ETag == "" {
+			t.Fatalf("%s: 写入失败: %+v %v", name, info, err)
+		}
+		if _, err := storage.Put(ctx, "image/b.txt", strings.NewReader("hello"), 3, "text/plain"); err == nil {
+			t.Fatalf("%s: 大小不一致应写入失败", name)
+		}
+		if _, err := storage.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Fatalf("%s: 越界路径应被拒绝", name)
+		}
+		reader, info, err := storage.Get(ctx, "image/a.txt")
+		if err != nil {
+			t.Fatalf("%s: 读取失败: %v", name, err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != "hello" || info.ContentType != "text/plain" {
+			t.Fatalf("%s: 读取内容不正确: %q %+v", name, data, info)
+		}
+		if storage.URL("image/a.txt") != "http://localhost/object/image/a.txt" {
+			t.Fatalf("%s: 访问地址不正确: %s", name, storage.URL("image/a.txt"))
+		}
+
+		// 预签名地址校验方法、路径和有效期
+		presigned, err := storage.Presign(ctx, http.MethodPut, "image/c.txt", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, _ := url.Parse(presigned)
+		q := u.Query()
+		if !signUtil.Verify(http.MethodPut, "image/c.txt", q.Get("expires"), q.Get("signature")) {
+			t.Fatalf("%s: 预签名应校验通过: %s", name, presigned)
+		}
+		if signUtil.Verify(http.MethodPut, "image/d.txt", q.Get("expires"), q.Get("signature")) ||
+			signUtil.Verify(http.MethodGet, "image/c.txt", q.Get("expires"), q.Get("signature")) {
+			t.Fatalf("%s: 篡改路径或方法后应校验失败", name)
+		}
+		expired, _ := storage.Presign(ctx, http.MethodPut, "image/c.txt", -time.Minute)
+		u, _ = url.Parse(expired)
+		if signUtil.Verify(http.MethodPut, "image/c.txt", u.Query().Get("expires"), u.Query().Get("signature")) {
+			t.Fatalf("%s: 过期签名应校验失败", name)
+		}
+
+		if err := storage.Delete(ctx, "image/a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := storage.Stat(ctx, "image/a.txt"); info != nil || err != nil {
+			t.Fatalf("%s: 删除后应不存在: %+v %v", name, info, err)
+		}
+		if _, _, err := storage.Get(ctx, "image/a.txt"); err != interfacemanager.ErrObjectNotFound {
+			t.Fatalf("%s: 读取不存在的对象应返回 ErrObjectNotFound: %v", name, err)
+		}
+	}
+}
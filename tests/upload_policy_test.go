@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-chat/configs"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/manager"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	"go-chat/internal/service"
+	"io"
+	"strings"
+	"testing"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeVirusScanner 内容包含 EICAR 测试串时视为病毒
+type fakeVirusScanner struct {
+	scanned int
+}
+
+func (s *fakeVirusScanner) Scan(_ context.Context, reader io.Reader, _ string) (string, error) {
+	s.scanned++
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+		return "Eicar-Test-Signature", nil
+	}
+	return "", nil
+}
+
+func TestUploadPolicy(t *testing.T) {
+	configs.AppConfig = &configs.Config{Upload: configs.UploadConfig{
+		MaxSize:      100,
+		TypeMaxSize:  map[string]int64{"document": 32},
+		AllowedMimes: []string{"image/*", "text/plain"},
+		UserQuota:    40,
+	}}
+	storage := manager.NewMemoryStorage("http://localhost/object")
+	fileRepo := &fakeFileRepository{}
+	sessionRepo := newFakeUploadSessionRepository()
+	scanner := &fakeVirusScanner{}
+	service.InitFileService(fileRepo, sessionRepo, storage, nil, scanner)
+	fileService := service.FileServiceInstance
+
+	upload := func(userId uint, name string, data []byte) error {
+		_, err := fileService.Upload(userId, formFile(t, name, data))
+		return err
+	}
+	// 可执行文件改名为 .jpg、内容不是图片的 .png 都按扩展名伪造拒绝
+	if err := upload(1, "cat.jpg", append([]byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), make([]byte, 50)...)); !errors.Is(err, interfacesservice.ErrFileTypeNotAllowed) {
+		t.Fatalf("伪造扩展名应被拒绝: %v", err)
+	}
+	if err := upload(1, "cat.png", []byte("hello")); !errors.Is(err, interfacesservice.ErrFileTypeNotAllowed) {
+		t.Fatalf("内容与扩展名不符应被拒绝: %v", err)
+	}
+	// 白名单之外的类型
+	if err := upload(1, "a.pdf", []byte("%PDF-1.4\n%")); !errors.Is(err, interfacesservice.ErrFileTypeNotAllowed) {
+		t.Fatalf("不在白名单中的类型应被拒绝: %v", err)
+	}
+	// 总上限和按类别的上限
+	if err := upload(1, "big.txt", []byte(strings.Repeat("a", 101))); !errors.Is(err, interfacesservice.ErrFileTooLarge) {
+		t.Fatalf("超过总上限应被拒绝: %v", err)
+	}
+	if err := upload(1, "doc.txt", []byte(strings.Repeat("a", 33))); !errors.Is(err, interfacesservice.ErrFileTooLarge) {
+		t.Fatalf("超过类别上限应被拒绝: %v", err)
+	}
+	// 病毒扫描
+	if err := upload(1, "virus.txt", []byte(eicar[:30])); err != nil {
+		t.Fatalf("正常文件应能上传: %v", err)
+	}
+	scanner.scanned = 0
+	configs.AppConfig.Upload.TypeMaxSize = nil
+	configs.AppConfig.Upload.UserQuota = 0
+	if err := upload(2, "virus.txt", []byte(eicar)); !errors.Is(err, interfacesservice.ErrFileInfected) || scanner.scanned != 1 {
+		t.Fatalf("病毒文件应被拒绝: %v", err)
+	}
+	if len(fileRepo.files) != 1 {
+		t.Fatal("未通过扫描的文件不应保存")
+	}
+
+	// 配额：秒传他人的文件不占用配额
+	configs.AppConfig.Upload.UserQuota = 40
+	if err := upload(1, "b.txt", []byte(strings.Repeat("b", 20))); !errors.Is(err, interfacesservice.ErrQuotaExceeded) {
+		t.Fatalf("超过配额应被拒绝: %v", err)
+	}
+	if err := upload(2, "a.txt", []byte(eicar[:30])); err != nil {
+		t.Fatalf("秒传应不受配额限制: %v", err)
+	}
+	usage, err := fileService.Usage(1)
+	if err != nil || usage.Files != 1 || usage.Used != 30 || usage.Quota != 40 || usage.Remaining != 10 {
+		t.Fatalf("用量不正确: %+v %v", usage, err)
+	}
+	if usage, _ = fileService.Usage(2); usage.Used != 0 || len(fileRepo.files) != 2 {
+		t.Fatalf("秒传不应计入用量: %+v", usage)
+	}
+
+	// 分片上传：创建会话时检查大小和配额，完成时扫描，发现病毒取消会话
+	if _, err := fileService.InitUpload(1, request.UploadInitRequest{Name: "c.txt", Size: 20, Sha256: strings.Repeat("0", 64)}); !errors.Is(err, interfacesservice.ErrQuotaExceeded) {
+		t.Fatalf("创建会话时应检查配额: %v", err)
+	}
+	configs.AppConfig.Upload.UserQuota = 0
+	sum := sha256.Sum256([]byte(eicar))
+	session, err := fileService.InitUpload(3, request.UploadInitRequest{Name: "d.txt", Size: int64(len(eicar)), Sha256: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = storage.Put(context.Background(), "uploads/"+session.UploadId+"/00001", strings.NewReader(eicar), int64(len(eicar)), "")
+	if _, err := fileService.CompleteUpload(3, request.UploadCompleteRequest{UploadId: session.UploadId}); !errors.Is(err, interfacesservice.ErrFileInfected) {
+		t.Fatalf("分片上传的病毒文件应被拒绝: %v", err)
+	}
+	if s, _ := sessionRepo.GetByUploadId(session.UploadId); s.Status != model.UploadAborted || len(fileRepo.files) != 2 {
+		t.Fatalf("发现病毒后应取消会话: %+v", s)
+	}
+}
@@ -0,0 +1,307 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-chat/configs"
+	"go-chat/internal/controller"
+	interfacesservice "go-chat/internal/interfaces/service"
+	"go-chat/internal/manager"
+	"go-chat/internal/middleware"
+	"go-chat/internal/model"
+	request "go-chat/internal/model/request"
+	response "go-chat/internal/model/response"
+	"go-chat/internal/service"
+	"go-chat/internal/utils/jwtUtil"
+	"image/color"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunkedUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configs.AppConfig = &configs.Config{Storage: configs.StorageConfig{SignSecret: "test", PartSize: 1 << 20}}
+	storage := manager.NewMemoryStorage("http://localhost/object")
+	fileRepo := &fakeFileRepository{}
+	sessionRepo := newFakeUploadSessionRepository()
+	service.InitFileService(fileRepo, sessionRepo, storage, nil, nil)
+	fileService := service.FileServiceInstance
+	controller.InitFileController(fileService)
+	router := gin.New()
+	router.PUT("/object/*key", middleware.PresignMiddleware(), controller.FileControllerInstance.PutObject)
+
+	data := bytes.Repeat([]byte("0123456789"), 250*1024) // 2.5MB，3 个分片
+	sum := sha256.Sum256(data)
+	session, err := fileService.InitUpload(1, request.UploadInitRequest{Name: "a.bin", Size: int64(len(data)), Sha256: hex.EncodeToString(sum[:])})
+	if err != nil || session.PartCount != 3 || session.Parts[2].Size != int64(len(data))-2<<20 {
+		t.Fatalf("创建会话失败: %+v %v", session, err)
+	}
+	putPart := func(rawUrl string, partNumber int) int {
+		u, _ := url.Parse(rawUrl)
+		start := int64(partNumber-1) * session.PartSize
+		end := min(start+session.PartSize, int64(len(data)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, u.RequestURI(), bytes.NewReader(data[start:end])))
+		return w.Code
+	}
+	if code := putPart(session.Parts[0].Url, 1); code != http.StatusOK {
+		t.Fatalf("上传分片 1 失败: %d", code)
+	}
+	if code := putPart(session.Parts[0].Url+"x", 1); code != http.StatusForbidden {
+		t.Fatalf("篡改签名应被拒绝: %d", code)
+	}
+	putPart(session.Parts[2].Url, 3)
+	if _, err := fileService.GetUploadSession(2, session.UploadId); err == nil {
+		t.Fatal("不能查询其他用户的上传会话")
+	}
+
+	// 断点续传：只返回未上传分片的地址
+	resumed, err := fileService.GetUploadSession(1, session.UploadId)
+	if err != nil || !resumed.Parts[0].Uploaded || resumed.Parts[1].Uploaded || resumed.Parts[1].Url == "" || resumed.Parts[0].Url != "" {
+		t.Fatalf("上传进度不正确: %+v %v", resumed, err)
+	}
+	if _, err := fileService.CompleteUpload(1, request.UploadCompleteRequest{UploadId: session.UploadId}); err == nil {
+		t.Fatal("缺少分片时不能完成上传")
+	}
+	putPart(resumed.Parts[1].Url, 2)
+	file, err := fileService.CompleteUpload(1, request.UploadCompleteRequest{UploadId: session.UploadId})
+	if err != nil || file.ID == 0 || file.UserID != 1 || file.Size != uint64(len(data)) {
+		t.Fatalf("完成上传失败: %+v %v", file, err)
+	}
+	reader, _, err := storage.Get(context.Background(), file.ObjectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, _ := io.ReadAll(reader)
+	if !bytes.Equal(merged, data) {
+		t.Fatal("合并后的文件内容不正确")
+	}
+	if info, _ := storage.Stat(context.Background(), "uploads/"+session.UploadId+"/00001"); info != nil {
+		t.Fatal("完成后应删除分片")
+	}
+	if _, err := fileService.CompleteUpload(1, request.UploadCompleteRequest{UploadId: session.UploadId}); err == nil {
+		t.Fatal("不能重复完成上传")
+	}
+
+	// SHA-256 不一致时不生成文件
+	bad, _ := fileService.InitUpload(1, request.UploadInitRequest{Name: "b.bin", Size: 3, Sha256: hex.EncodeToString(sum[:])})
+	_, _ = storage.Put(context.Background(), "uploads/"+bad.UploadId+"/00001", bytes.NewReader([]byte("abc")), 3, "")
+	if _, err := fileService.CompleteUpload(1, request.UploadCompleteRequest{UploadId: bad.UploadId}); err == nil || len(fileRepo.files) != 1 {
+		t.Fatalf("校验失败时不应生成文件: %v", err)
+	}
+	if err := fileService.AbortUpload(1, bad.UploadId); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := sessionRepo.GetByUploadId(bad.UploadId); s.Status != model.UploadAborted {
+		t.Fatal("取消后状态应为已取消")
+	}
+
+	// 他人上传过的内容：只知道哈希不能秒传，错误的校验数据会作废本次校验
+	init := func(userId uint) *response.UploadSessionVo {
+		vo, err := fileService.InitUpload(userId, request.UploadInitRequest{Name: "copy.bin", Size: int64(len(data)), Sha256: hex.EncodeToString(sum[:])})
+		if err != nil || vo.File != nil || vo.Status != model.UploadUploading || vo.Proof == nil || vo.Proof.Length != 32 {
+			t.Fatalf("他人上传过的内容需要校验: %+v %v", vo, err)
+		}
+		return vo
+	}
+	proof := func(vo *response.UploadSessionVo) []byte {
+		return data[vo.Proof.Offset : vo.Proof.Offset+vo.Proof.Length]
+	}
+	guess := init(2)
+	right := hex.EncodeToString(proof(guess))
+	if _, err := fileService.CompleteUpload(2, request.UploadCompleteRequest{UploadId: guess.UploadId, Proof: strings.Repeat("00", 32)}); !errors.Is(err, interfacesservice.ErrUploadProof) {
+		t.Fatalf("校验数据错误时不能秒传: %v", err)
+	}
+	if _, err := fileService.CompleteUpload(2, request.UploadCompleteRequest{UploadId: guess.UploadId, Proof: right}); !errors.Is(err, interfacesservice.ErrUploadProof) {
+		t.Fatalf("校验失败后不能再次尝试: %v", err)
+	}
+	if vo, _ := fileService.GetUploadSession(2, guess.UploadId); vo.Proof != nil {
+		t.Fatal("校验失败后不应再返回校验区间")
+	}
+
+	// 上传的分片内容不一致时不能复用，也不能覆盖已有的对象
+	for i := 1; i <= guess.PartCount; i++ {
+		length := guess.Parts[i-1].Size
+		_, _ = storage.Put(context.Background(), fmt.Sprintf("uploads/%s/%05d", guess.UploadId, i), bytes.NewReader(make([]byte, length)), length, "")
+	}
+	if _, err := fileService.CompleteUpload(2, request.UploadCompleteRequest{UploadId: guess.UploadId}); err == nil {
+		t.Fatal("分片校验失败时不能复用")
+	}
+	reader, _, _ = storage.Get(context.Background(), file.ObjectKey)
+	if merged, _ = io.ReadAll(reader); !bytes.Equal(merged, data) {
+		t.Fatal("校验失败不应改动已有的对象")
+	}
+
+	// 提交正确的校验数据后秒传，生成自己的文件记录，存储对象共用
+	instant := init(3)
+	claimed, err := fileService.CompleteUpload(3, request.UploadCompleteRequest{UploadId: instant.UploadId, Proof: hex.EncodeToString(proof(instant))})
+	if err != nil || claimed.UserID != 3 || claimed.ID == file.ID || claimed.ObjectKey != file.ObjectKey || len(fileRepo.files) != 2 {
+		t.Fatalf("秒传失败: %+v %v", claimed, err)
+	}
+	if owned, _ := fileService.InitUpload(3, request.UploadInitRequest{Name: "again.bin", Size: int64(len(data)), Sha256: hex.EncodeToString(sum[:])}); owned.File == nil || owned.File.ID != claimed.ID {
+		t.Fatalf("自己上传过的内容应直接完成: %+v", owned)
+	}
+}
+
+func formFile(t *testing.T, name string, data []byte) *multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", name)
+	_, _ = part.Write(data)
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	return req.MultipartForm.File["file"][0]
+}
+
+func TestFileDedupAndGarbageCollect(t *testing.T) {
+	configs.AppConfig = &configs.Config{Storage: configs.StorageConfig{GcGracePeriod: "1ms"}}
+	storage := manager.NewMemoryStorage("http://localhost/object")
+	fileRepo := &fakeFileRepository{}
+	service.InitFileService(fileRepo, newFakeUploadSessionRepository(), storage, nil, nil)
+	fileService := service.FileServiceInstance
+
+	data := []byte("same meme")
+	url1, err := fileService.Upload(1, formFile(t, "a.txt", data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 相同内容只保存一份对象，每个上传者有自己的文件记录
+	url2, err := fileService.Upload(2, formFile(t, "b.txt", data))
+	if err != nil || url1 != url2 || len(fileRepo.files) != 2 || fileRepo.get(2).UserID != 2 {
+		t.Fatalf("相同内容应只保存一份: %s %s %v", url1, url2, err)
+	}
+	if again, _ := fileService.Upload(2, formFile(t, "b.txt", data)); again != url1 || len(fileRepo.files) != 2 {
+		t.Fatal("重复上传不应生成新记录")
+	}
+
+	// 引用计数：重复地址只计一次，非本存储地址忽略
+	_ = fileService.AddRefs(url1, url1, "https://example.com/a.png")
+	if f := fileRepo.get(1); f.RefCount != 1 || f.OrphanedAt != nil {
+		t.Fatalf("引用数不正确: %+v", f)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if count, _ := fileService.CollectGarbage(); count != 0 {
+		t.Fatal("有引用的文件不应被回收")
+	}
+	_ = fileService.ReleaseRefs(url1)
+	if f := fileRepo.get(1); f.RefCount != 0 || f.OrphanedAt == nil {
+		t.Fatalf("引用归零后应记录孤立时间: %+v", f)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if count, err := fileService.CollectGarbage(); count != 2 || err != nil {
+		t.Fatalf("超过宽限期的孤立文件应被回收: %d %v", count, err)
+	}
+	if info, _ := storage.Stat(context.Background(), strings.TrimPrefix(url1, "http://localhost/object/")); info != nil {
+		t.Fatal("回收后应删除对象")
+	}
+}
+
+func TestFileDownloadAccessAndRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configs.AppConfig = &configs.Config{
+		Jwt: configs.JWTConfig{SecretKey: "test", ExpirationTime: "1h", Issuer: "go-chat", Audience: "go-chat"},
+	}
+	storage := manager.NewMemoryStorage("/object")
+	fileRepo := &fakeFileRepository{}
+	service.InitFileService(fileRepo, newFakeUploadSessionRepository(), storage, nil, nil)
+	controller.InitFileController(service.FileServiceInstance)
+	router := gin.New()
+	router.GET("/object/*key", middleware.ObjectAccessMiddleware(), controller.FileControllerInstance.GetObject)
+
+	fileUrl, err := service.FileServiceInstance.Upload(1, formFile(t, "a.txt", []byte("0123456789")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(rawUrl string, userId uint, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, rawUrl, nil)
+		if userId != 0 {
+			token, _ := jwtUtil.GenerateJWT(userId)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(fileUrl, 0, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("未登录不能下载: %d", w.Code)
+	}
+	if w := get(fileUrl, 2, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("看不到消息的用户不能下载: %d", w.Code)
+	}
+	w := get(fileUrl, 1, nil)
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" || w.Header().Get("ETag") == "" || w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("上传者应能下载: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w = get(fileUrl, 1, map[string]string{"If-None-Match": w.Header().Get("ETag")}); w.Code != http.StatusNotModified {
+		t.Fatalf("ETag 未变化应返回 304: %d", w.Code)
+	}
+
+	// 图片、音频、视频以外的文件作为附件下载，所有响应都禁止浏览器猜测类型
+	for name, data := range map[string][]byte{
+		"a.html": []byte("<html><script>alert(1)</script></html>"),
+		"a.svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+		"a.png":  stickerPng(t, color.RGBA{G: 255, A: 255}),
+	} {
+		objectUrl, err := service.FileServiceInstance.Upload(1, formFile(t, name, data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w = get(objectUrl, 1, nil)
+		disposition := w.Header().Get("Content-Disposition")
+		if w.Code != http.StatusOK || w.Header().Get("X-Content-Type-Options") != "nosniff" ||
+			(name == "a.png") != (disposition == "") || (disposition != "" && !strings.HasPrefix(disposition, "attachment")) {
+			t.Fatalf("%s 的响应头不正确: %d %v", name, w.Code, w.Header())
+		}
+	}
+	if w = get(fileUrl, 2, nil); w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("无权访问时也应禁止浏览器猜测类型")
+	}
+
+	// 能看到引用消息的用户可以下载，并支持 Range
+	fileRepo.visible = map[uint][]string{2: {fileUrl}}
+	w = get(fileUrl, 2, map[string]string{"Range": "bytes=2-5"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" || w.Header().Get("Content-Range") != "bytes 2-5/10" {
+		t.Fatalf("Range 下载不正确: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w = get(fileUrl, 2, map[string]string{"Range": "bytes=-3"}); w.Body.String() != "789" {
+		t.Fatalf("后缀 Range 不正确: %q", w.Body.String())
+	}
+	if w = get(fileUrl, 2, map[string]string{"Range": "bytes=20-"}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("超出范围应返回 416: %d", w.Code)
+	}
+
+	// 签名地址无需登录，无权访问的文件不签名
+	signed, err := service.FileServiceInstance.SignUrls(3, []string{fileUrl})
+	if err != nil || len(signed) != 0 {
+		t.Fatalf("无权访问的文件不应签名: %v %v", signed, err)
+	}
+	signed, _ = service.FileServiceInstance.SignUrls(2, []string{fileUrl, "https://example.com/a.png"})
+	if len(signed) != 1 {
+		t.Fatalf("只应签名本存储的文件: %v", signed)
+	}
+	if w = get(signed[fileUrl], 0, nil); w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Fatalf("签名地址应能下载: %d", w.Code)
+	}
+	if w = get(signed[fileUrl]+"0", 0, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("篡改签名应被拒绝: %d", w.Code)
+	}
+}